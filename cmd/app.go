@@ -29,6 +29,9 @@ func NewApp() *cli.App {
 		StartServerCommand(),
 		LoginCommand(),
 		PublishCommand(),
+		YankCommand(),
+		VerifyCommand(),
+		AuditCommand(),
 	}
 
 	return app
@@ -0,0 +1,69 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/fatih/color"
+	"github.com/urfave/cli/v2"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+
+	"github.com/cosmos/atlas/config"
+	"github.com/cosmos/atlas/server/models"
+)
+
+// AuditCommand returns a CLI command handler responsible for operating on the
+// Atlas server's audit log.
+func AuditCommand() *cli.Command {
+	return &cli.Command{
+		Name:  "audit",
+		Usage: "Operate on the Atlas server's audit log",
+		Subcommands: []*cli.Command{
+			AuditVerifyCommand(),
+		},
+	}
+}
+
+// AuditVerifyCommand returns a CLI command handler responsible for verifying
+// the hash chain of the Atlas server's audit log, connecting to the server's
+// database as configured for the server.
+func AuditVerifyCommand() *cli.Command {
+	return &cli.Command{
+		Name: "verify",
+		Usage: `Verify the hash chain of the audit log, i.e. that no audit event has been
+modified, removed or inserted out of band. The database is read using the
+server's configuration.`,
+		Flags: []cli.Flag{
+			&cli.StringFlag{
+				Name:    config.ConfigPath,
+				Aliases: []string{"c"},
+				Usage:   "Server configuration file.",
+			},
+		},
+		Action: func(ctx *cli.Context) error {
+			konfig, err := ParseServerConfig(ctx)
+			if err != nil {
+				return err
+			}
+
+			dbURL := konfig.String(config.DatabaseURL)
+			if dbURL == "" {
+				return errors.New("no database URL configured")
+			}
+
+			db, err := gorm.Open(postgres.Open(dbURL), &gorm.Config{Logger: logger.Default.LogMode(logger.Silent)})
+			if err != nil {
+				return fmt.Errorf("failed to connect to database: %w", err)
+			}
+
+			if err := models.VerifyAuditEvents(db); err != nil {
+				return fmt.Errorf("failed to verify audit log: %w", err)
+			}
+
+			_, _ = color.New(color.FgGreen).Fprintln(ctx.App.Writer, "audit log successfully verified!")
+			return nil
+		},
+	}
+}
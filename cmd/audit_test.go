@@ -0,0 +1,24 @@
+package cmd_test
+
+import (
+	"context"
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/cosmos/atlas/cmd"
+)
+
+func TestAuditVerifyCommand_NoDatabase(t *testing.T) {
+	configPath := filepath.Join(t.TempDir(), "config.toml")
+	require.NoError(t, ioutil.WriteFile(configPath, []byte(`listen.addr = "localhost:8080"`), 0600))
+
+	app := cmd.NewApp()
+	mockIn, _ := cmd.ApplyMockIO(app)
+	ctx := cmd.ContextWithReader(context.Background(), mockIn)
+
+	err := cmd.ExecTestCmd(ctx, app, []string{"atlas", "audit", "verify", "-c", configPath})
+	require.EqualError(t, err, "no database URL configured")
+}
@@ -0,0 +1,124 @@
+package cmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"os"
+
+	"github.com/cosmos/atlas/server/models"
+	v1 "github.com/cosmos/atlas/server/router/v1"
+)
+
+// Environment variables provided by the GitHub Actions runtime to workflows
+// granted the 'id-token: write' permission, which are used to request an OIDC
+// token for the workflow run.
+const (
+	envActionsIDTokenRequestURL   = "ACTIONS_ID_TOKEN_REQUEST_URL"
+	envActionsIDTokenRequestToken = "ACTIONS_ID_TOKEN_REQUEST_TOKEN"
+)
+
+// requestGitHubActionsIDToken requests an OIDC token for the given audience from
+// the GitHub Actions runtime. An error is returned if not running in a GitHub
+// Actions workflow granted the 'id-token: write' permission.
+func requestGitHubActionsIDToken(audience string) (string, error) {
+	requestURL := os.Getenv(envActionsIDTokenRequestURL)
+	requestToken := os.Getenv(envActionsIDTokenRequestToken)
+
+	if requestURL == "" || requestToken == "" {
+		return "", errors.New("failed to request OIDC token: not running in a GitHub Actions workflow with the 'id-token: write' permission")
+	}
+
+	u, err := url.Parse(requestURL)
+	if err != nil {
+		return "", fmt.Errorf("failed to parse OIDC token request URL: %w", err)
+	}
+
+	query := u.Query()
+	query.Set("audience", audience)
+	u.RawQuery = query.Encode()
+
+	request, err := http.NewRequest("GET", u.String(), nil)
+	if err != nil {
+		return "", fmt.Errorf("failed to create request: %w", err)
+	}
+
+	request.Header.Set("Authorization", fmt.Sprintf("Bearer %s", requestToken))
+
+	resp, err := client.Do(request)
+	if err != nil {
+		return "", fmt.Errorf("failed to request OIDC token: %w", err)
+	}
+
+	defer func() {
+		_ = resp.Body.Close()
+	}()
+
+	if resp.StatusCode != http.StatusOK {
+		body, err := ioutil.ReadAll(resp.Body)
+		if err != nil {
+			return "", fmt.Errorf("failed to read response body: %w", err)
+		}
+
+		return "", fmt.Errorf("failed to request OIDC token: %w", errors.New(string(body)))
+	}
+
+	var result struct {
+		Value string `json:"value"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return "", fmt.Errorf("failed to decode response: %w", err)
+	}
+
+	return result.Value, nil
+}
+
+// exchangeOIDCToken exchanges an OIDC token of a trusted workflow run for a
+// short-lived API token at the given registry.
+func exchangeOIDCToken(registry, oidcToken string) (string, error) {
+	bodyBz, err := json.Marshal(v1.OIDCTokenExchange{Token: oidcToken})
+	if err != nil {
+		return "", fmt.Errorf("failed to encode request: %w", err)
+	}
+
+	request, err := http.NewRequest("PUT", fmt.Sprintf("%s/api/v1/oidc/token", registry), bytes.NewBuffer(bodyBz))
+	if err != nil {
+		return "", fmt.Errorf("failed to create request: %w", err)
+	}
+
+	request.Header.Set("Content-Type", "application/json")
+
+	resp, err := client.Do(request)
+	if err != nil {
+		return "", fmt.Errorf("failed to exchange OIDC token: %w", err)
+	}
+
+	defer func() {
+		_ = resp.Body.Close()
+	}()
+
+	if resp.StatusCode != http.StatusOK {
+		body, err := ioutil.ReadAll(resp.Body)
+		if err != nil {
+			return "", fmt.Errorf("failed to read response body: %w", err)
+		}
+
+		return "", fmt.Errorf("failed to exchange OIDC token: %w", errors.New(string(body)))
+	}
+
+	var token models.UserTokenJSON
+	if err := json.NewDecoder(resp.Body).Decode(&token); err != nil {
+		return "", fmt.Errorf("failed to decode response: %w", err)
+	}
+
+	tokenStr, ok := token.Token.(string)
+	if !ok || tokenStr == "" {
+		return "", errors.New("failed to exchange OIDC token: no API token returned")
+	}
+
+	return tokenStr, nil
+}
@@ -9,23 +9,33 @@ import (
 	"net/http"
 	"os"
 	"path"
+	"path/filepath"
+	"strings"
 	"time"
 
 	"github.com/BurntSushi/toml"
 	"github.com/fatih/color"
-	"github.com/go-playground/validator/v10"
 	"github.com/urfave/cli/v2"
 
 	"github.com/cosmos/atlas/server/httputil"
+	"github.com/cosmos/atlas/server/models"
 	v1 "github.com/cosmos/atlas/server/router/v1"
 )
 
+const manifestFileName = "atlas.toml"
+
 var (
-	validate = validator.New()
+	validate = v1.NewValidator()
 
 	client = http.Client{
 		Timeout: 15 * time.Second,
 	}
+
+	// publishClient defines the client used to publish modules, which allows for
+	// the registry fetching the source of every published module version.
+	publishClient = http.Client{
+		Timeout: 5 * time.Minute,
+	}
 )
 
 // PublishCommand returns a CLI command handler responsible for publishing
@@ -44,8 +54,8 @@ func PublishCommand() *cli.Command {
 			&cli.StringFlag{
 				Name:    "manifest",
 				Aliases: []string{"m"},
-				Value:   path.Join(mustGetwd(), "atlas.toml"),
-				Usage:   "The path to the Cosmos SDK module manifest",
+				Value:   path.Join(mustGetwd(), manifestFileName),
+				Usage:   "The path to the Cosmos SDK module manifest or a directory of manifests",
 			},
 			&cli.StringFlag{
 				Name:    "registry",
@@ -58,51 +68,71 @@ func PublishCommand() *cli.Command {
 				Value: false,
 				Usage: "Verify the module manifest without publishing",
 			},
+			&cli.BoolFlag{
+				Name:  "oidc",
+				Value: false,
+				Usage: "Publish with a short-lived token exchanged for the OIDC token of the GitHub Actions workflow run instead of the stored credentials",
+			},
+			&cli.StringFlag{
+				Name:    "oidc-token",
+				EnvVars: []string{"ATLAS_OIDC_TOKEN"},
+				Usage:   "The OIDC token to exchange, which implies --oidc and is requested from GitHub Actions if not provided",
+			},
+			&cli.StringFlag{
+				Name:  "oidc-audience",
+				Value: v1.DefaultOIDCAudience,
+				Usage: "The audience of the OIDC token requested from GitHub Actions",
+			},
 		},
 		Action: func(ctx *cli.Context) error {
-			var manifest v1.Manifest
-
-			// fetch and decode the manifest
-			manifestPath := ctx.String("manifest")
-			if _, err := toml.DecodeFile(manifestPath, &manifest); err != nil {
-				return fmt.Errorf("failed to read manifest: %w", err)
+			// fetch and decode the manifest(s)
+			manifests, err := readManifests(ctx.String("manifest"))
+			if err != nil {
+				return err
 			}
 
 			// verify the contents if requested
 			if ctx.Bool("dry-run") {
-				if err := validate.Struct(manifest); err != nil {
-					return fmt.Errorf("failed to verify manifest: %w", httputil.TransformValidationError(err))
+				for _, manifest := range manifests {
+					if err := validate.Struct(manifest); err != nil {
+						return fmt.Errorf("failed to verify manifest for '%s': %w", manifest.Module.Name, httputil.TransformValidationError(err))
+					}
 				}
 
 				_, _ = color.New(color.FgGreen).Fprintln(ctx.App.Writer, "manifest successfully verified!")
 				return nil
 			}
 
-			// fetch the user token from configuration
-			dir := path.Join(ctx.String("dir"), ".atlas")
-			credsPath := path.Join(dir, "credentials")
-
-			credentials, err := parseCredentials(credsPath)
+			token, err := publishToken(ctx)
 			if err != nil {
 				return err
 			}
 
-			// make the API request
-			bodyBz, err := json.Marshal(manifest)
+			// make the API request, where multiple modules are published atomically
+			var (
+				body interface{} = manifests[0]
+				path             = fmt.Sprintf("%s/api/v1/modules", ctx.String("registry"))
+			)
+
+			if len(manifests) > 1 {
+				body = v1.BatchManifest{Manifests: manifests}
+				path = fmt.Sprintf("%s/api/v1/modules/batch", ctx.String("registry"))
+			}
+
+			bodyBz, err := json.Marshal(body)
 			if err != nil {
 				return fmt.Errorf("failed to encode manifest: %w", err)
 			}
 
-			path := fmt.Sprintf("%s/api/v1/modules", ctx.String("registry"))
 			request, err := http.NewRequest("PUT", path, bytes.NewBuffer(bodyBz))
 			if err != nil {
 				return fmt.Errorf("failed to create request: %w", err)
 			}
 
 			request.Header.Set("Content-Type", "application/json")
-			request.Header.Set("Authorization", fmt.Sprintf("Bearer %s", credentials.Registry.Token))
+			request.Header.Set("Authorization", fmt.Sprintf("Bearer %s", token))
 
-			resp, err := client.Do(request)
+			resp, err := publishClient.Do(request)
 			if err != nil {
 				return fmt.Errorf("failed to publish module: %w", err)
 			}
@@ -120,8 +150,235 @@ func PublishCommand() *cli.Command {
 				return fmt.Errorf("failed to publish module: %w", errors.New(string(body)))
 			}
 
-			_, _ = color.New(color.FgGreen).Fprintln(ctx.App.Writer, "module successfully published!")
+			var results []models.ModulePublishJSON
+			if len(manifests) > 1 {
+				err = json.NewDecoder(resp.Body).Decode(&results)
+			} else {
+				results = make([]models.ModulePublishJSON, 1)
+				err = json.NewDecoder(resp.Body).Decode(&results[0])
+			}
+
+			if err != nil {
+				return fmt.Errorf("failed to decode response: %w", err)
+			}
+
+			for _, result := range results {
+				_, _ = color.New(color.FgGreen).Fprintf(ctx.App.Writer, "module %s successfully published!\n", result.Name)
+
+				// warn about dependent modules whose constraints the version does not satisfy
+				for _, e := range result.DependencyImpact {
+					_, _ = color.New(color.FgYellow).Fprintf(
+						ctx.App.Writer, "warning: %s/%s@%s requires %s/%s %s\n",
+						e.Dependent.Team, e.Dependent.Name, e.Dependent.Version, e.Dependency.Team, e.Dependency.Name, e.Constraint,
+					)
+				}
+			}
+
 			return nil
 		},
 	}
 }
+
+// publishToken returns the API token to publish with. If requested, an OIDC
+// token, either given or requested from GitHub Actions, is exchanged for a
+// short-lived API token of a trusted workflow. Otherwise, the user token is
+// read from the stored credentials.
+func publishToken(ctx *cli.Context) (string, error) {
+	oidcToken := ctx.String("oidc-token")
+
+	if oidcToken == "" && ctx.Bool("oidc") {
+		var err error
+
+		oidcToken, err = requestGitHubActionsIDToken(ctx.String("oidc-audience"))
+		if err != nil {
+			return "", err
+		}
+	}
+
+	if oidcToken != "" {
+		return exchangeOIDCToken(ctx.String("registry"), oidcToken)
+	}
+
+	// fetch the user token from configuration
+	dir := path.Join(ctx.String("dir"), ".atlas")
+	credsPath := path.Join(dir, "credentials")
+
+	credentials, err := parseCredentials(credsPath)
+	if err != nil {
+		return "", err
+	}
+
+	return credentials.Registry.Token, nil
+}
+
+// readManifests reads and expands the manifest(s) at the given path, including
+// the README of each module version. If the path is a directory, it is walked
+// for all manifests named atlas.toml. An error is returned if any manifest or
+// README cannot be read or if no manifest is found.
+func readManifests(manifestPath string) ([]v1.Manifest, error) {
+	info, err := os.Stat(manifestPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read manifest: %w", err)
+	}
+
+	paths := []string{manifestPath}
+	if info.IsDir() {
+		paths = nil
+
+		err := filepath.Walk(manifestPath, func(p string, fi os.FileInfo, err error) error {
+			if err != nil {
+				return err
+			}
+
+			if !fi.IsDir() && fi.Name() == manifestFileName {
+				paths = append(paths, p)
+			}
+
+			return nil
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to find manifests: %w", err)
+		}
+
+		if len(paths) == 0 {
+			return nil, fmt.Errorf("failed to find manifests: no %s found in '%s'", manifestFileName, manifestPath)
+		}
+	}
+
+	var manifests []v1.Manifest
+	for _, p := range paths {
+		var manifest v1.Manifest
+		if _, err := toml.DecodeFile(p, &manifest); err != nil {
+			return nil, fmt.Errorf("failed to read manifest '%s': %w", p, err)
+		}
+
+		// read the README to publish along with the version, if any
+		readme, err := readReadme(p, manifest.Version.ReadmePath)
+		if err != nil {
+			return nil, err
+		}
+
+		manifest.Version.Readme = readme
+
+		expanded, err := manifest.Expand()
+		if err != nil {
+			return nil, fmt.Errorf("failed to read manifest '%s': %w", p, err)
+		}
+
+		// A module's path defaults to the manifest's directory relative to the
+		// repository root. In a multi-module manifest, e.g. of a monorepo, each
+		// module publishes the README in its directory, falling back to the
+		// manifest's README if it has none.
+		root := repoRoot(filepath.Dir(p))
+		for i := range expanded {
+			m := &expanded[i]
+			if m.Module.Path == "" {
+				m.Module.Path = manifestDirPath(root, p)
+			}
+
+			if len(manifest.Modules) == 0 || m.Module.Path == "" {
+				continue
+			}
+
+			readme, err := readModuleReadme(root, m.Module.Path)
+			if err != nil {
+				return nil, err
+			}
+
+			if readme != "" {
+				m.Version.Readme = readme
+			}
+		}
+
+		manifests = append(manifests, expanded...)
+	}
+
+	return manifests, nil
+}
+
+// readReadme returns the contents of the Markdown README to publish along with
+// a module version, where readmePath is relative to the manifest's directory. If
+// no path is provided, the README.md in the manifest's directory is used if it
+// exists.
+func readReadme(manifestPath, readmePath string) (string, error) {
+	dir := filepath.Dir(manifestPath)
+
+	if readmePath == "" {
+		readmePath = "README.md"
+		if _, err := os.Stat(filepath.Join(dir, readmePath)); os.IsNotExist(err) {
+			return "", nil
+		}
+	}
+
+	if !filepath.IsAbs(readmePath) {
+		readmePath = filepath.Join(dir, readmePath)
+	}
+
+	bz, err := ioutil.ReadFile(readmePath)
+	if err != nil {
+		return "", fmt.Errorf("failed to read README: %w", err)
+	}
+
+	return string(bz), nil
+}
+
+// readModuleReadme returns the contents of the README.md in a module's
+// directory, where modulePath is relative to the repository root. An empty
+// README is returned if the module has none.
+func readModuleReadme(root, modulePath string) (string, error) {
+	readmePath := filepath.Join(root, filepath.FromSlash(modulePath), "README.md")
+
+	bz, err := ioutil.ReadFile(readmePath)
+	if os.IsNotExist(err) {
+		return "", nil
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to read README of '%s': %w", modulePath, err)
+	}
+
+	return string(bz), nil
+}
+
+// manifestDirPath returns the slash separated path of a manifest's directory
+// relative to the repository root, which is empty if the manifest is in the
+// repository root or outside of it.
+func manifestDirPath(root, manifestPath string) string {
+	dir, err := filepath.Abs(filepath.Dir(manifestPath))
+	if err != nil {
+		return ""
+	}
+
+	absRoot, err := filepath.Abs(root)
+	if err != nil {
+		return ""
+	}
+
+	rel, err := filepath.Rel(absRoot, dir)
+	if err != nil || rel == "." || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return ""
+	}
+
+	return filepath.ToSlash(rel)
+}
+
+// repoRoot returns the root directory of the repository containing dir, i.e. the
+// closest ancestor containing a .git entry. If none exists, dir is returned.
+func repoRoot(dir string) string {
+	abs, err := filepath.Abs(dir)
+	if err != nil {
+		return dir
+	}
+
+	for d := abs; ; {
+		if _, err := os.Stat(filepath.Join(d, ".git")); err == nil {
+			return d
+		}
+
+		parent := filepath.Dir(d)
+		if parent == d {
+			return dir
+		}
+
+		d = parent
+	}
+}
@@ -2,8 +2,13 @@ package cmd_test
 
 import (
 	"context"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
 	"os"
 	"path"
+	"strings"
 	"testing"
 
 	"github.com/BurntSushi/toml"
@@ -105,3 +110,242 @@ func TestPublishCommand_DryRun_Invalid(t *testing.T) {
 	require.Error(t, cmd.ExecTestCmd(ctx, app, []string{"atlas", "publish", "-m", manifestPath, "--dry-run"}))
 	require.Contains(t, mockOut.String(), "failed to verify manifest", mockOut.String())
 }
+
+func TestPublishCommand_DryRun_MissingReadme(t *testing.T) {
+	app := cmd.NewApp()
+	mockIn, mockOut := cmd.ApplyMockIO(app)
+	ctx := cmd.ContextWithReader(context.Background(), mockIn)
+
+	manifest := v1.Manifest{
+		Module: v1.ModuleManifest{
+			Name: "x/test",
+		},
+		Authors: []v1.AuthorsManifest{
+			{Name: "test_author1", Email: "testauthor1@testmodule.com"},
+		},
+		Version: v1.VersionManifest{
+			Repo:       "https://github.com/cosmos/cosmos-sdk/releases/tag/v0.39.1",
+			Version:    "v1.0.0",
+			ReadmePath: "docs/README.md",
+		},
+	}
+
+	// create temp directory and write a manifest referencing a missing README
+	tmpDir := t.TempDir()
+	manifestPath := path.Join(tmpDir, "manifest.toml")
+
+	file, err := os.Create(manifestPath)
+	require.NoError(t, err)
+
+	defer func() {
+		_ = file.Close()
+	}()
+
+	encoder := toml.NewEncoder(file)
+	require.NoError(t, encoder.Encode(manifest))
+
+	// execute command and verify output
+	require.Error(t, cmd.ExecTestCmd(ctx, app, []string{"atlas", "publish", "-m", manifestPath, "--dry-run"}))
+	require.Contains(t, mockOut.String(), "failed to read README", mockOut.String())
+
+	// write the README and ensure the manifest is valid
+	require.NoError(t, os.Mkdir(path.Join(tmpDir, "docs"), 0755))
+	require.NoError(t, ioutil.WriteFile(path.Join(tmpDir, "docs", "README.md"), []byte("# x/test"), 0644))
+
+	require.NoError(t, cmd.ExecTestCmd(ctx, app, []string{"atlas", "publish", "-m", manifestPath, "--dry-run"}))
+	require.Contains(t, mockOut.String(), "manifest successfully verified!", mockOut.String())
+}
+
+func TestPublishCommand_DryRun_MultiModule(t *testing.T) {
+	app := cmd.NewApp()
+	mockIn, mockOut := cmd.ApplyMockIO(app)
+	ctx := cmd.ContextWithReader(context.Background(), mockIn)
+
+	multiManifest := `
+[[modules]]
+name = "x/foo"
+
+[[modules]]
+name = "x/bar"
+
+[[authors]]
+name = "test_author1"
+
+[version]
+repo = "https://github.com/cosmos/cosmos-sdk/releases/tag/v0.39.1"
+version = "v1.0.0"
+`
+
+	singleManifest := `
+[module]
+name = "x/baz"
+
+[[authors]]
+name = "test_author1"
+
+[version]
+repo = "https://github.com/cosmos/cosmos-sdk/releases/tag/v0.39.1"
+version = "v1.0.0"
+`
+
+	// create a directory tree of manifests
+	tmpDir := t.TempDir()
+	require.NoError(t, os.MkdirAll(path.Join(tmpDir, "x", "baz"), 0755))
+	require.NoError(t, ioutil.WriteFile(path.Join(tmpDir, "atlas.toml"), []byte(multiManifest), 0644))
+	require.NoError(t, ioutil.WriteFile(path.Join(tmpDir, "x", "baz", "atlas.toml"), []byte(singleManifest), 0644))
+
+	// execute command against a multi-module manifest and verify output
+	require.NoError(t, cmd.ExecTestCmd(ctx, app, []string{"atlas", "publish", "-m", path.Join(tmpDir, "atlas.toml"), "--dry-run"}))
+	require.Contains(t, mockOut.String(), "manifest successfully verified!", mockOut.String())
+
+	// execute command against the directory and verify output
+	require.NoError(t, cmd.ExecTestCmd(ctx, app, []string{"atlas", "publish", "-m", tmpDir, "--dry-run"}))
+	require.Contains(t, mockOut.String(), "manifest successfully verified!", mockOut.String())
+
+	// ensure an invalid module in the directory results in an error
+	invalidManifest := strings.Replace(singleManifest, `name = "x/baz"`, `description = "missing name"`, 1)
+	require.NoError(t, ioutil.WriteFile(path.Join(tmpDir, "x", "baz", "atlas.toml"), []byte(invalidManifest), 0644))
+
+	require.Error(t, cmd.ExecTestCmd(ctx, app, []string{"atlas", "publish", "-m", tmpDir, "--dry-run"}))
+	require.Contains(t, mockOut.String(), "failed to verify manifest", mockOut.String())
+}
+
+func TestPublishCommand_OIDC(t *testing.T) {
+	manifest := `
+[module]
+name = "x/test"
+
+[[authors]]
+name = "test_author1"
+
+[version]
+repo = "https://github.com/cosmos/cosmos-sdk/releases/tag/v0.39.1"
+version = "v1.0.0"
+`
+
+	var published bool
+
+	mux := http.NewServeMux()
+
+	// the GitHub Actions OIDC token endpoint
+	mux.HandleFunc("/token", func(w http.ResponseWriter, req *http.Request) {
+		require.Equal(t, "Bearer request_token", req.Header.Get("Authorization"))
+		require.Equal(t, "atlas", req.URL.Query().Get("audience"))
+
+		_ = json.NewEncoder(w).Encode(map[string]string{"value": "oidc_token"})
+	})
+
+	mux.HandleFunc("/api/v1/oidc/token", func(w http.ResponseWriter, req *http.Request) {
+		var body v1.OIDCTokenExchange
+		require.NoError(t, json.NewDecoder(req.Body).Decode(&body))
+
+		if body.Token != "oidc_token" {
+			http.Error(w, "untrusted workflow", http.StatusForbidden)
+			return
+		}
+
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{"token": "api_token", "trusted_publishing": true})
+	})
+
+	mux.HandleFunc("/api/v1/modules", func(w http.ResponseWriter, req *http.Request) {
+		require.Equal(t, http.MethodPut, req.Method)
+		require.Equal(t, "Bearer api_token", req.Header.Get("Authorization"))
+
+		published = true
+		_ = json.NewEncoder(w).Encode(map[string]string{"name": "x/test"})
+	})
+
+	srv := httptest.NewServer(mux)
+	defer srv.Close()
+
+	tmpDir := t.TempDir()
+	manifestPath := path.Join(tmpDir, "atlas.toml")
+	require.NoError(t, ioutil.WriteFile(manifestPath, []byte(manifest), 0644))
+
+	publish := func(args ...string) error {
+		app := cmd.NewApp()
+		mockIn, _ := cmd.ApplyMockIO(app)
+		ctx := cmd.ContextWithReader(context.Background(), mockIn)
+
+		published = false
+		return cmd.ExecTestCmd(ctx, app, append([]string{"atlas", "publish", "-d", tmpDir, "-r", srv.URL, "-m", manifestPath}, args...))
+	}
+
+	// an OIDC token cannot be requested outside of GitHub Actions
+	require.Error(t, publish("--oidc"))
+	require.False(t, published)
+
+	// a given OIDC token is exchanged
+	require.Error(t, publish("--oidc-token", "other_token"))
+	require.False(t, published)
+
+	require.NoError(t, publish("--oidc-token", "oidc_token"))
+	require.True(t, published)
+
+	// an OIDC token is requested from GitHub Actions and exchanged
+	require.NoError(t, os.Setenv("ACTIONS_ID_TOKEN_REQUEST_URL", srv.URL+"/token?api-version=2.0"))
+	require.NoError(t, os.Setenv("ACTIONS_ID_TOKEN_REQUEST_TOKEN", "request_token"))
+
+	defer func() {
+		_ = os.Unsetenv("ACTIONS_ID_TOKEN_REQUEST_URL")
+		_ = os.Unsetenv("ACTIONS_ID_TOKEN_REQUEST_TOKEN")
+	}()
+
+	require.NoError(t, publish("--oidc"))
+	require.True(t, published)
+}
+
+func TestPublishCommand_MultiModuleReadme(t *testing.T) {
+	manifest := `
+[[modules]]
+name = "x/foo"
+path = "x/foo"
+
+[[modules]]
+name = "x/bar"
+path = "x/bar"
+
+[[authors]]
+name = "test_author1"
+
+[version]
+repo = "https://github.com/cosmos/cosmos-sdk/releases/tag/v0.39.1"
+version = "v1.0.0"
+`
+
+	var batch v1.BatchManifest
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/api/v1/oidc/token", func(w http.ResponseWriter, req *http.Request) {
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{"token": "api_token", "trusted_publishing": true})
+	})
+	mux.HandleFunc("/api/v1/modules/batch", func(w http.ResponseWriter, req *http.Request) {
+		require.NoError(t, json.NewDecoder(req.Body).Decode(&batch))
+		_ = json.NewEncoder(w).Encode([]map[string]string{{"name": "x/foo"}, {"name": "x/bar"}})
+	})
+
+	srv := httptest.NewServer(mux)
+	defer srv.Close()
+
+	// create a repository where only x/foo has its own README
+	tmpDir := t.TempDir()
+	require.NoError(t, os.MkdirAll(path.Join(tmpDir, ".git"), 0755))
+	require.NoError(t, os.MkdirAll(path.Join(tmpDir, "x", "foo"), 0755))
+	require.NoError(t, ioutil.WriteFile(path.Join(tmpDir, "atlas.toml"), []byte(manifest), 0644))
+	require.NoError(t, ioutil.WriteFile(path.Join(tmpDir, "README.md"), []byte("# root"), 0644))
+	require.NoError(t, ioutil.WriteFile(path.Join(tmpDir, "x", "foo", "README.md"), []byte("# x/foo"), 0644))
+
+	app := cmd.NewApp()
+	mockIn, _ := cmd.ApplyMockIO(app)
+	ctx := cmd.ContextWithReader(context.Background(), mockIn)
+
+	require.NoError(t, cmd.ExecTestCmd(ctx, app, []string{
+		"atlas", "publish", "-d", tmpDir, "-r", srv.URL, "-m", path.Join(tmpDir, "atlas.toml"), "--oidc-token", "oidc_token",
+	}))
+
+	require.Len(t, batch.Manifests, 2)
+	require.Equal(t, "x/foo", batch.Manifests[0].Module.Name)
+	require.Equal(t, "# x/foo", batch.Manifests[0].Version.Readme)
+	require.Equal(t, "x/bar", batch.Manifests[1].Module.Name)
+	require.Equal(t, "# root", batch.Manifests[1].Version.Readme)
+}
@@ -0,0 +1,103 @@
+package cmd
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strconv"
+
+	"github.com/fatih/color"
+	"github.com/urfave/cli/v2"
+
+	"github.com/cosmos/atlas/server/checksum"
+	"github.com/cosmos/atlas/server/models"
+)
+
+// VerifyCommand returns a CLI command handler responsible for verifying that a
+// local checkout of a Cosmos SDK module matches the source of a published
+// module version in the Atlas registry.
+func VerifyCommand() *cli.Command {
+	return &cli.Command{
+		Name: "verify",
+		Usage: `Verify a local checkout of a Cosmos SDK module against the source checksum of a
+published module version. The checkout must be at the version's commit. Only the module's
+directory is verified, where untracked files and files excluded from repository archives
+are ignored.`,
+		ArgsUsage: "[module-id] [version]",
+		Flags: []cli.Flag{
+			&cli.StringFlag{
+				Name:    "path",
+				Aliases: []string{"p"},
+				Value:   mustGetwd(),
+				Usage:   "The root directory of the local repository checkout",
+			},
+			&cli.StringFlag{
+				Name:    "registry",
+				Aliases: []string{"r"},
+				Value:   "https://api.atlas.cosmos.network",
+				Usage:   "The Atlas registry API address",
+			},
+		},
+		Action: func(ctx *cli.Context) error {
+			if ctx.NArg() != 2 {
+				return errors.New("expected a module ID and version")
+			}
+
+			moduleID, err := strconv.ParseUint(ctx.Args().Get(0), 10, 64)
+			if err != nil {
+				return fmt.Errorf("invalid module ID: %w", err)
+			}
+
+			version := ctx.Args().Get(1)
+
+			// make the API request
+			path := fmt.Sprintf("%s/api/v1/modules/%d/versions/%s", ctx.String("registry"), moduleID, url.PathEscape(version))
+
+			resp, err := client.Get(path)
+			if err != nil {
+				return fmt.Errorf("failed to fetch module version: %w", err)
+			}
+
+			defer func() {
+				_ = resp.Body.Close()
+			}()
+
+			if resp.StatusCode != http.StatusOK {
+				body, err := ioutil.ReadAll(resp.Body)
+				if err != nil {
+					return fmt.Errorf("failed to read response body: %w", err)
+				}
+
+				return fmt.Errorf("failed to fetch module version: %w", errors.New(string(body)))
+			}
+
+			var mv models.ModuleVersionJSON
+			if err := json.NewDecoder(resp.Body).Decode(&mv); err != nil {
+				return fmt.Errorf("failed to decode response: %w", err)
+			}
+
+			expected, ok := mv.SourceChecksum.(string)
+			if !ok || expected == "" {
+				return fmt.Errorf("module version %s has no source checksum", version)
+			}
+
+			// compute the checksum over the module's directory in the local checkout
+			sourcePath, _ := mv.SourcePath.(string)
+
+			got, err := checksum.HashDir(ctx.String("path"), sourcePath)
+			if err != nil {
+				return err
+			}
+
+			if got != expected {
+				return fmt.Errorf("checksum mismatch for module version %s; got: %s, want: %s", version, got, expected)
+			}
+
+			_, _ = color.New(color.FgGreen).Fprintf(ctx.App.Writer, "module version %s successfully verified!\n", version)
+			return nil
+		},
+	}
+}
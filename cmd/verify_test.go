@@ -0,0 +1,87 @@
+package cmd_test
+
+import (
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/cosmos/atlas/cmd"
+	"github.com/cosmos/atlas/server/checksum"
+	"github.com/cosmos/atlas/server/models"
+)
+
+func TestVerifyCommand(t *testing.T) {
+	// create a local checkout
+	checkout := t.TempDir()
+	require.NoError(t, ioutil.WriteFile(path.Join(checkout, "README.md"), []byte("# cosmos-sdk"), 0644))
+	require.NoError(t, os.MkdirAll(path.Join(checkout, "x", "bank"), 0755))
+	require.NoError(t, ioutil.WriteFile(path.Join(checkout, "x", "bank", "module.go"), []byte("package bank"), 0644))
+
+	sum, err := checksum.HashDir(checkout, "")
+	require.NoError(t, err)
+
+	moduleSum, err := checksum.HashDir(checkout, "x/bank")
+	require.NoError(t, err)
+
+	versions := map[string]models.ModuleVersionJSON{
+		"/api/v1/modules/1/versions/v1.0.0": {Version: "v1.0.0", SourceChecksum: sum},
+		"/api/v1/modules/1/versions/v1.1.0": {Version: "v1.1.0", SourceChecksum: checksum.Prefix + "AAAA"},
+		"/api/v1/modules/1/versions/v0.9.0": {Version: "v0.9.0"},
+		"/api/v1/modules/1/versions/v1.2.0": {Version: "v1.2.0", SourceChecksum: moduleSum, SourcePath: "x/bank"},
+		"/api/v1/modules/1/versions/v1.3.0": {Version: "v1.3.0", SourceChecksum: sum, SourcePath: "x/bank"},
+	}
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		require.Equal(t, http.MethodGet, req.Method)
+
+		mv, ok := versions[req.URL.Path]
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+
+		_ = json.NewEncoder(w).Encode(mv)
+	}))
+	defer srv.Close()
+
+	testCases := []struct {
+		name      string
+		args      []string
+		expectErr bool
+	}{
+		{"missing version", []string{"1"}, true},
+		{"invalid module ID", []string{"x/bank", "v1.0.0"}, true},
+		{"unknown version", []string{"1", "v2.0.0"}, true},
+		{"no checksum", []string{"1", "v0.9.0"}, true},
+		{"checksum mismatch", []string{"1", "v1.1.0"}, true},
+		{"checksum match", []string{"1", "v1.0.0"}, false},
+		{"module checksum match", []string{"1", "v1.2.0"}, false},
+		{"module checksum mismatch", []string{"1", "v1.3.0"}, true},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+
+		t.Run(tc.name, func(t *testing.T) {
+			app := cmd.NewApp()
+			mockIn, mockOut := cmd.ApplyMockIO(app)
+			ctx := cmd.ContextWithReader(context.Background(), mockIn)
+
+			args := append([]string{"atlas", "verify", "-p", checkout, "-r", srv.URL}, tc.args...)
+			err := cmd.ExecTestCmd(ctx, app, args)
+			if tc.expectErr {
+				require.Error(t, err)
+			} else {
+				require.NoError(t, err)
+				require.Contains(t, mockOut.String(), "successfully verified", mockOut.String())
+			}
+		})
+	}
+}
@@ -0,0 +1,126 @@
+package cmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"strconv"
+
+	"github.com/fatih/color"
+	"github.com/urfave/cli/v2"
+
+	v1 "github.com/cosmos/atlas/server/router/v1"
+)
+
+// YankCommand returns a CLI command handler responsible for yanking, or
+// un-yanking, a published Cosmos SDK module version in the Atlas registry.
+func YankCommand() *cli.Command {
+	return &cli.Command{
+		Name: "yank",
+		Usage: `Yank a published Cosmos SDK module version. A yanked version remains accessible
+by its exact version but is no longer considered the latest version of the module.`,
+		ArgsUsage: "[module-id] [version]",
+		Flags: []cli.Flag{
+			&cli.StringFlag{
+				Name:    "dir",
+				Aliases: []string{"d"},
+				Value:   os.Getenv("HOME"),
+				Usage:   "The root directory for Atlas configuration",
+			},
+			&cli.StringFlag{
+				Name:    "registry",
+				Aliases: []string{"r"},
+				Value:   "https://api.atlas.cosmos.network",
+				Usage:   "The Atlas registry API address",
+			},
+			&cli.StringFlag{
+				Name:  "reason",
+				Usage: "The reason for yanking the module version (required unless --undo is set)",
+			},
+			&cli.BoolFlag{
+				Name:  "undo",
+				Value: false,
+				Usage: "Un-yank a previously yanked module version",
+			},
+		},
+		Action: func(ctx *cli.Context) error {
+			if ctx.NArg() != 2 {
+				return errors.New("expected a module ID and version")
+			}
+
+			moduleID, err := strconv.ParseUint(ctx.Args().Get(0), 10, 64)
+			if err != nil {
+				return fmt.Errorf("invalid module ID: %w", err)
+			}
+
+			version := ctx.Args().Get(1)
+			undo := ctx.Bool("undo")
+
+			action := "yank"
+			body := []byte{}
+
+			if undo {
+				action = "unyank"
+			} else {
+				request := v1.ModuleVersionYank{Reason: ctx.String("reason")}
+				if err := validate.Struct(request); err != nil {
+					return errors.New("a reason is required to yank a module version")
+				}
+
+				body, err = json.Marshal(request)
+				if err != nil {
+					return fmt.Errorf("failed to encode request: %w", err)
+				}
+			}
+
+			// fetch the user token from configuration
+			dir := path.Join(ctx.String("dir"), ".atlas")
+			credsPath := path.Join(dir, "credentials")
+
+			credentials, err := parseCredentials(credsPath)
+			if err != nil {
+				return err
+			}
+
+			// make the API request
+			path := fmt.Sprintf(
+				"%s/api/v1/modules/%d/versions/%s/%s",
+				ctx.String("registry"), moduleID, url.PathEscape(version), action,
+			)
+			request, err := http.NewRequest("PUT", path, bytes.NewBuffer(body))
+			if err != nil {
+				return fmt.Errorf("failed to create request: %w", err)
+			}
+
+			request.Header.Set("Content-Type", "application/json")
+			request.Header.Set("Authorization", fmt.Sprintf("Bearer %s", credentials.Registry.Token))
+
+			resp, err := client.Do(request)
+			if err != nil {
+				return fmt.Errorf("failed to %s module version: %w", action, err)
+			}
+
+			defer func() {
+				_ = resp.Body.Close()
+			}()
+
+			if resp.StatusCode != http.StatusOK {
+				body, err := ioutil.ReadAll(resp.Body)
+				if err != nil {
+					return fmt.Errorf("failed to read response body: %w", err)
+				}
+
+				return fmt.Errorf("failed to %s module version: %w", action, errors.New(string(body)))
+			}
+
+			_, _ = color.New(color.FgGreen).Fprintf(ctx.App.Writer, "module version %s successfully %sed!\n", version, action)
+			return nil
+		},
+	}
+}
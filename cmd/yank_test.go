@@ -0,0 +1,98 @@
+package cmd_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/cosmos/atlas/cmd"
+	v1 "github.com/cosmos/atlas/server/router/v1"
+)
+
+func TestYankCommand(t *testing.T) {
+	fakeToken := "fake_token"
+
+	var (
+		gotPath string
+		gotBody v1.ModuleVersionYank
+	)
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		require.Equal(t, http.MethodPut, req.Method)
+		require.Equal(t, "Bearer "+fakeToken, req.Header.Get("Authorization"))
+
+		gotPath = req.URL.Path
+		gotBody = v1.ModuleVersionYank{}
+		_ = json.NewDecoder(req.Body).Decode(&gotBody)
+
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer srv.Close()
+
+	tmpDir := t.TempDir()
+
+	testCases := []struct {
+		name         string
+		args         []string
+		expectErr    bool
+		expectPath   string
+		expectReason string
+	}{
+		{
+			name:      "missing version",
+			args:      []string{"--reason", "broken", "1"},
+			expectErr: true,
+		},
+		{
+			name:      "invalid module ID",
+			args:      []string{"--reason", "broken", "x/bank", "v1.0.0"},
+			expectErr: true,
+		},
+		{
+			name:      "missing reason",
+			args:      []string{"1", "v1.0.0"},
+			expectErr: true,
+		},
+		{
+			name:         "yank",
+			args:         []string{"--reason", "broken", "1", "v1.0.0"},
+			expectPath:   "/api/v1/modules/1/versions/v1.0.0/yank",
+			expectReason: "broken",
+		},
+		{
+			name:       "un-yank",
+			args:       []string{"--undo", "1", "v1.0.0"},
+			expectPath: "/api/v1/modules/1/versions/v1.0.0/unyank",
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+
+		t.Run(tc.name, func(t *testing.T) {
+			gotPath = ""
+
+			app := cmd.NewApp()
+			mockIn, mockOut := cmd.ApplyMockIO(app)
+			ctx := cmd.ContextWithReader(context.Background(), mockIn)
+
+			require.NoError(t, cmd.ExecTestCmd(ctx, app, []string{"atlas", "login", "-d", tmpDir, fakeToken}))
+
+			args := append([]string{"atlas", "yank", "-d", tmpDir, "-r", srv.URL}, tc.args...)
+			err := cmd.ExecTestCmd(ctx, app, args)
+			if tc.expectErr {
+				require.Error(t, err)
+				require.Empty(t, gotPath)
+			} else {
+				require.NoError(t, err)
+				require.Equal(t, tc.expectPath, gotPath)
+				require.Equal(t, tc.expectReason, gotBody.Reason)
+				require.Contains(t, mockOut.String(), "successfully", mockOut.String())
+			}
+		})
+	}
+}
@@ -9,26 +9,48 @@ import (
 // passed as CLI flags. All keys are dot-delimitated except for environment
 // variables which are snake-cased and must be prefixed with ATLAS_*.
 const (
-	ConfigPath          = "config"
-	LogLevel            = "log.level"
-	LogFormat           = "log.format"
-	ListenAddr          = "listen.addr"
-	Dev                 = "dev"
-	DatabaseURL         = "database.url"
-	HTTPReadTimeout     = "http.read.timeout"
-	HTTPWriteTimeout    = "http.write.timeout"
-	GHClientID          = "gh.client.id"
-	GHClientSecret      = "gh.client.secret"
-	SessionKey          = "session.key"
-	AllowedOrigins      = "allowed.origins"
-	SendGridAPIKey      = "sendgrid.api.key"
-	DomainName          = "domain.name"
-	SyslogAddr          = "syslog.addr"
-	IPStackKey          = "ipstack.key"
-	NodeCrawlInterval   = "node.crawl.interval"
-	NodeRecheckInterval = "node.recheck.interval"
-	NodeReseedSize      = "node.reseed.size"
-	NodeSeeds           = "node.seeds"
+	ConfigPath              = "config"
+	LogLevel                = "log.level"
+	LogFormat               = "log.format"
+	ListenAddr              = "listen.addr"
+	Dev                     = "dev"
+	DatabaseURL             = "database.url"
+	HTTPReadTimeout         = "http.read.timeout"
+	HTTPWriteTimeout        = "http.write.timeout"
+	GHClientID              = "gh.client.id"
+	GHClientSecret          = "gh.client.secret"
+	GHToken                 = "gh.token"
+	GitLabURL               = "gitlab.url"
+	GitLabToken             = "gitlab.token"
+	GiteaURL                = "gitea.url"
+	GiteaToken              = "gitea.token"
+	RepoCacheTTL            = "repo.cache.ttl"
+	RepoCacheMaxStale       = "repo.cache.max.stale"
+	PublishOrgMembers       = "publish.org.members"
+	PublishTeams            = "publish.teams"
+	PublishAccounts         = "publish.accounts"
+	SessionKey              = "session.key"
+	AllowedOrigins          = "allowed.origins"
+	SendGridAPIKey          = "sendgrid.api.key"
+	DomainName              = "domain.name"
+	InviteTTL               = "invite.ttl"
+	OIDCIssuer              = "oidc.issuer"
+	OIDCJWKSURL             = "oidc.jwks.url"
+	OIDCAudience            = "oidc.audience"
+	OIDCTokenTTL            = "oidc.token.ttl"
+	SyslogAddr              = "syslog.addr"
+	IPStackKey              = "ipstack.key"
+	NodeCrawlInterval       = "node.crawl.interval"
+	NodeRecheckInterval     = "node.recheck.interval"
+	NodeReseedSize          = "node.reseed.size"
+	NodeSeeds               = "node.seeds"
+	NodeGeolocationProvider = "node.geolocation.provider"
+	NodeGeolocationMMDBPath = "node.geolocation.mmdb.path"
+	NodeCrawlWorkers        = "node.crawl.workers"
+	NodeCrawlRateLimit      = "node.crawl.rate.limit"
+	NodeCrawlHostInterval   = "node.crawl.host.interval"
+	NodeCrawlBackoffBase    = "node.crawl.backoff.base"
+	NodeCrawlBackoffMax     = "node.crawl.backoff.max"
 )
 
 // Config defines a configuration abstraction so we don't rely on any specific
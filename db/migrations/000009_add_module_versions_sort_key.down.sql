@@ -0,0 +1,4 @@
+BEGIN;
+DROP INDEX IF EXISTS idx_module_versions_module_id_sort_key;
+ALTER TABLE module_versions DROP COLUMN IF EXISTS sort_key;
+COMMIT;
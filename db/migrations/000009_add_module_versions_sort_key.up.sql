@@ -0,0 +1,39 @@
+BEGIN;
+-- add the Semantic Versioning sort key column, where a "C" collation ensures
+-- the key is compared byte-wise
+ALTER TABLE module_versions
+ADD COLUMN IF NOT EXISTS sort_key VARCHAR COLLATE "C";
+-- backfill sort keys for existing versions, i.e. [v]MAJOR.MINOR.PATCH with an
+-- optional pre-release and build metadata, where any other version is left as
+-- NULL; the keys must match those produced by semver.Version.SortKey, where a
+-- release is suffixed with '~' and a pre-release with '-' followed by its
+-- identifiers separated by '!', each prefixed with '0' and zero-padded if it is
+-- numeric and fits in a uint64 or prefixed with '1' otherwise
+UPDATE module_versions mv
+SET sort_key = lpad(parsed.m [1], 20, '0') || '.' || lpad(parsed.m [2], 20, '0') || '.' || lpad(parsed.m [3], 20, '0') || CASE
+    WHEN parsed.m [4] IS NULL THEN '~'
+    ELSE '-' || (
+      SELECT string_agg(
+          CASE
+            WHEN ids.id !~ '^[0-9]+$' THEN '1' || ids.id
+            WHEN ids.id::NUMERIC > 18446744073709551615 THEN '1' || ids.id
+            ELSE '0' || lpad(ids.id, 20, '0')
+          END,
+          '!'
+          ORDER BY ids.ord
+        )
+      FROM unnest(string_to_array(parsed.m [4], '.')) WITH ORDINALITY AS ids(id, ord)
+    )
+  END
+FROM (
+    SELECT id,
+      regexp_match(
+        version,
+        '^v?(0|[1-9][0-9]*)\.(0|[1-9][0-9]*)\.(0|[1-9][0-9]*)(?:-((?:0|[1-9][0-9]*|[0-9]*[A-Za-z-][0-9A-Za-z-]*)(?:\.(?:0|[1-9][0-9]*|[0-9]*[A-Za-z-][0-9A-Za-z-]*))*))?(?:\+[0-9A-Za-z-]+(?:\.[0-9A-Za-z-]+)*)?$'
+      ) AS m
+    FROM module_versions
+  ) AS parsed
+WHERE mv.id = parsed.id
+  AND parsed.m IS NOT NULL;
+CREATE INDEX IF NOT EXISTS idx_module_versions_module_id_sort_key ON module_versions(module_id, sort_key);
+COMMIT;
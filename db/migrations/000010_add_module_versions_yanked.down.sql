@@ -0,0 +1,4 @@
+BEGIN;
+ALTER TABLE module_versions DROP COLUMN IF EXISTS yank_reason;
+ALTER TABLE module_versions DROP COLUMN IF EXISTS yanked;
+COMMIT;
@@ -0,0 +1,7 @@
+BEGIN;
+-- yanked versions remain accessible but are never resolved as the latest version
+ALTER TABLE module_versions
+ADD COLUMN IF NOT EXISTS yanked BOOLEAN NOT NULL DEFAULT FALSE;
+ALTER TABLE module_versions
+ADD COLUMN IF NOT EXISTS yank_reason VARCHAR;
+COMMIT;
@@ -0,0 +1,3 @@
+BEGIN;
+DROP TABLE IF EXISTS sdk_compat_ranges;
+COMMIT;
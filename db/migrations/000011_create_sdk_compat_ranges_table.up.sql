@@ -0,0 +1,42 @@
+BEGIN;
+-- 
+-- Create the sdk_compat_ranges table, where each row defines a range of
+-- compatible Cosmos SDK versions [min_key, max_key) by their sort keys
+-- 
+CREATE TABLE IF NOT EXISTS sdk_compat_ranges (
+    id SERIAL PRIMARY KEY,
+    module_version_id INT NOT NULL,
+    min_key VARCHAR COLLATE "C",
+    max_key VARCHAR COLLATE "C",
+    created_at TIMESTAMPTZ NOT NULL,
+    updated_at TIMESTAMPTZ NOT NULL,
+    deleted_at TIMESTAMPTZ,
+    FOREIGN KEY (module_version_id) REFERENCES module_versions(id) ON DELETE CASCADE
+);
+CREATE INDEX IF NOT EXISTS idx_sdk_compat_ranges_module_version_id ON sdk_compat_ranges(module_version_id);
+CREATE INDEX IF NOT EXISTS idx_sdk_compat_ranges_deleted_at ON sdk_compat_ranges(deleted_at timestamptz_ops);
+-- backfill ranges for existing versions with a SDK compatibility of the form
+-- [v]MAJOR.MINOR[.x], where any other value is left without ranges until the
+-- version is published with a valid constraint
+INSERT INTO sdk_compat_ranges (
+    module_version_id,
+    min_key,
+    max_key,
+    created_at,
+    updated_at
+  )
+SELECT parsed.id,
+  lpad(parsed.m [1], 20, '0') || '.' || lpad(parsed.m [2], 20, '0') || '.' || lpad('0', 20, '0') || '~',
+  lpad(parsed.m [1], 20, '0') || '.' || lpad((parsed.m [2]::NUMERIC + 1)::TEXT, 20, '0') || '.' || lpad('0', 20, '0') || '-0' || lpad('0', 20, '0'),
+  NOW(),
+  NOW()
+FROM (
+    SELECT id,
+      regexp_match(
+        sdk_compat,
+        '^v?(0|[1-9][0-9]*)\.(0|[1-9][0-9]*)(\.[xX*])?$'
+      ) AS m
+    FROM module_versions
+  ) AS parsed
+WHERE parsed.m IS NOT NULL;
+COMMIT;
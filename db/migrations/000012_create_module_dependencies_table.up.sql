@@ -0,0 +1,19 @@
+BEGIN;
+-- 
+-- Create the module_dependencies table
+-- 
+CREATE TABLE IF NOT EXISTS module_dependencies (
+    id SERIAL PRIMARY KEY,
+    module_version_id INT NOT NULL,
+    dependency_id INT NOT NULL,
+    version_constraint VARCHAR NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL,
+    updated_at TIMESTAMPTZ NOT NULL,
+    deleted_at TIMESTAMPTZ,
+    FOREIGN KEY (module_version_id) REFERENCES module_versions(id) ON DELETE CASCADE,
+    FOREIGN KEY (dependency_id) REFERENCES modules(id) ON DELETE CASCADE
+);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_module_dependencies_module_version_id_dependency_id ON module_dependencies(module_version_id, dependency_id);
+CREATE INDEX IF NOT EXISTS idx_module_dependencies_dependency_id ON module_dependencies(dependency_id);
+CREATE INDEX IF NOT EXISTS idx_module_dependencies_deleted_at ON module_dependencies(deleted_at timestamptz_ops);
+COMMIT;
@@ -0,0 +1,3 @@
+BEGIN;
+DROP TABLE IF EXISTS dependency_impacts;
+COMMIT;
@@ -0,0 +1,19 @@
+BEGIN;
+-- 
+-- Create the dependency_impacts table
+-- 
+CREATE TABLE IF NOT EXISTS dependency_impacts (
+    id SERIAL PRIMARY KEY,
+    module_version_id INT NOT NULL,
+    dependent_module_id INT NOT NULL,
+    dependent_version VARCHAR NOT NULL,
+    version_constraint VARCHAR NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL,
+    updated_at TIMESTAMPTZ NOT NULL,
+    deleted_at TIMESTAMPTZ,
+    FOREIGN KEY (module_version_id) REFERENCES module_versions(id) ON DELETE CASCADE,
+    FOREIGN KEY (dependent_module_id) REFERENCES modules(id) ON DELETE CASCADE
+);
+CREATE INDEX IF NOT EXISTS idx_dependency_impacts_module_version_id ON dependency_impacts(module_version_id);
+CREATE INDEX IF NOT EXISTS idx_dependency_impacts_deleted_at ON dependency_impacts(deleted_at timestamptz_ops);
+COMMIT;
@@ -0,0 +1,16 @@
+BEGIN;
+-- 
+-- Create the module_version_readmes table
+-- 
+CREATE TABLE IF NOT EXISTS module_version_readmes (
+    id SERIAL PRIMARY KEY,
+    module_version_id INT NOT NULL,
+    markdown TEXT NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL,
+    updated_at TIMESTAMPTZ NOT NULL,
+    deleted_at TIMESTAMPTZ,
+    FOREIGN KEY (module_version_id) REFERENCES module_versions(id) ON DELETE CASCADE
+);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_module_version_readmes_module_version_id ON module_version_readmes(module_version_id);
+CREATE INDEX IF NOT EXISTS idx_module_version_readmes_deleted_at ON module_version_readmes(deleted_at timestamptz_ops);
+COMMIT;
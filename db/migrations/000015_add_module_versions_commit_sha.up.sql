@@ -0,0 +1,5 @@
+BEGIN;
+-- the commit the version's tag points to, for versions published after tags are verified
+ALTER TABLE module_versions
+ADD COLUMN IF NOT EXISTS commit_sha VARCHAR;
+COMMIT;
@@ -0,0 +1,4 @@
+BEGIN;
+ALTER TABLE module_versions DROP COLUMN IF EXISTS source_path;
+ALTER TABLE module_versions DROP COLUMN IF EXISTS source_checksum;
+COMMIT;
@@ -0,0 +1,9 @@
+BEGIN;
+-- the content hash of the source tree at the version's commit
+ALTER TABLE module_versions
+ADD COLUMN IF NOT EXISTS source_checksum VARCHAR;
+-- the module's directory relative to the repository root, which the source
+-- checksum covers, where NULL denotes the repository root
+ALTER TABLE module_versions
+ADD COLUMN IF NOT EXISTS source_path VARCHAR;
+COMMIT;
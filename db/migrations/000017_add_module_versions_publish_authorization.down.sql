@@ -0,0 +1,3 @@
+BEGIN;
+ALTER TABLE module_versions DROP COLUMN IF EXISTS publish_authorization;
+COMMIT;
@@ -0,0 +1,8 @@
+BEGIN;
+-- the mode by which the publisher was authorized to publish the version
+ALTER TABLE module_versions
+ADD COLUMN IF NOT EXISTS publish_authorization VARCHAR;
+
+-- versions published prior to this migration could only be published by contributors
+UPDATE module_versions SET publish_authorization = 'contributor' WHERE publish_authorization IS NULL;
+COMMIT;
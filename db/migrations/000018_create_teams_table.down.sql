@@ -0,0 +1,6 @@
+BEGIN;
+DROP TABLE IF EXISTS module_owner_teams;
+DROP TABLE IF EXISTS team_member_invites;
+DROP TABLE IF EXISTS team_members;
+DROP TABLE IF EXISTS teams;
+COMMIT;
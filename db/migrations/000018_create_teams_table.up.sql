@@ -0,0 +1,56 @@
+BEGIN;
+-- 
+-- Create the teams table
+-- 
+CREATE TABLE IF NOT EXISTS teams (
+    id SERIAL PRIMARY KEY,
+    name VARCHAR NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL,
+    updated_at TIMESTAMPTZ NOT NULL,
+    deleted_at TIMESTAMPTZ
+);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_teams_name ON teams(name);
+CREATE INDEX IF NOT EXISTS idx_teams_deleted_at ON teams(deleted_at timestamptz_ops);
+-- 
+-- Create the team_members table
+-- 
+CREATE TABLE IF NOT EXISTS team_members (
+    team_id INT NOT NULL,
+    user_id INT NOT NULL,
+    role VARCHAR NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL,
+    updated_at TIMESTAMPTZ NOT NULL,
+    PRIMARY KEY (team_id, user_id),
+    FOREIGN KEY (team_id) REFERENCES teams(id) ON DELETE CASCADE,
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+);
+CREATE INDEX IF NOT EXISTS idx_team_members_user_id ON team_members(user_id);
+-- 
+-- Create the team_member_invites table
+-- 
+CREATE TABLE IF NOT EXISTS team_member_invites (
+    team_id INT NOT NULL,
+    invited_user_id INT NOT NULL,
+    invited_by_user_id INT NOT NULL,
+    role VARCHAR NOT NULL,
+    token uuid UNIQUE NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL,
+    updated_at TIMESTAMPTZ NOT NULL,
+    FOREIGN KEY (team_id) REFERENCES teams(id) ON DELETE CASCADE,
+    FOREIGN KEY (invited_user_id) REFERENCES users(id) ON DELETE CASCADE,
+    FOREIGN KEY (invited_by_user_id) REFERENCES users(id) ON DELETE CASCADE
+);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_team_member_invites ON team_member_invites(team_id, invited_user_id);
+CREATE INDEX IF NOT EXISTS idx_team_member_invites_invited_user_id ON team_member_invites(invited_user_id);
+-- 
+-- Create the module_owner_teams table
+-- 
+CREATE TABLE IF NOT EXISTS module_owner_teams (
+    team_id INT NOT NULL,
+    module_id INT NOT NULL,
+    PRIMARY KEY (team_id, module_id),
+    FOREIGN KEY (team_id) REFERENCES teams(id) ON DELETE CASCADE,
+    FOREIGN KEY (module_id) REFERENCES modules(id) ON DELETE CASCADE
+);
+CREATE INDEX IF NOT EXISTS idx_module_owner_teams_module_id ON module_owner_teams(module_id);
+COMMIT;
@@ -0,0 +1,16 @@
+BEGIN;
+-- 
+-- Create the module_owner_events table
+-- 
+CREATE TABLE IF NOT EXISTS module_owner_events (
+    id SERIAL PRIMARY KEY,
+    module_id INT NOT NULL,
+    action VARCHAR NOT NULL,
+    actor VARCHAR NOT NULL,
+    user_name VARCHAR,
+    team_name VARCHAR,
+    created_at TIMESTAMPTZ NOT NULL,
+    FOREIGN KEY (module_id) REFERENCES modules(id) ON DELETE CASCADE
+);
+CREATE INDEX IF NOT EXISTS idx_module_owner_events_module_id ON module_owner_events(module_id);
+COMMIT;
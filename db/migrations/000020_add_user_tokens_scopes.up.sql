@@ -0,0 +1,9 @@
+BEGIN;
+-- the scopes granted to the token and its optional expiry
+ALTER TABLE user_tokens
+ADD COLUMN IF NOT EXISTS scopes VARCHAR[] NOT NULL DEFAULT '{}',
+ADD COLUMN IF NOT EXISTS expires_at TIMESTAMPTZ;
+
+-- tokens created prior to this migration were authorized for every request
+UPDATE user_tokens SET scopes = '{read,publish,invite,owners:manage,teams:manage,tokens:manage,user:write}';
+COMMIT;
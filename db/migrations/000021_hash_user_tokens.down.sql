@@ -0,0 +1,15 @@
+BEGIN;
+-- Note: hashed tokens cannot be restored, so all existing tokens are revoked and
+-- assigned a new random token.
+ALTER TABLE user_tokens
+ADD COLUMN IF NOT EXISTS token uuid UNIQUE;
+UPDATE user_tokens SET token = uuid_generate_v4(), revoked = true;
+ALTER TABLE user_tokens
+ALTER COLUMN token SET NOT NULL;
+
+DROP INDEX IF EXISTS idx_user_tokens_prefix;
+ALTER TABLE user_tokens
+DROP COLUMN IF EXISTS prefix,
+DROP COLUMN IF EXISTS salt,
+DROP COLUMN IF EXISTS hash;
+COMMIT;
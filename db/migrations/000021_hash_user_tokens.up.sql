@@ -0,0 +1,20 @@
+BEGIN;
+-- tokens are identified by their prefix and stored as salted SHA-256 hashes
+ALTER TABLE user_tokens
+ADD COLUMN IF NOT EXISTS prefix VARCHAR,
+ADD COLUMN IF NOT EXISTS salt VARCHAR,
+ADD COLUMN IF NOT EXISTS hash VARCHAR;
+
+-- hash the tokens created prior to this migration, which remain valid
+UPDATE user_tokens SET
+    prefix = substr(token::text, 1, 8),
+    salt = replace(uuid_generate_v4()::text, '-', '');
+UPDATE user_tokens SET hash = encode(sha256(convert_to(salt || token::text, 'UTF8')), 'hex');
+
+ALTER TABLE user_tokens
+ALTER COLUMN prefix SET NOT NULL,
+ALTER COLUMN salt SET NOT NULL,
+ALTER COLUMN hash SET NOT NULL,
+DROP COLUMN IF EXISTS token;
+CREATE INDEX IF NOT EXISTS idx_user_tokens_prefix ON user_tokens(prefix);
+COMMIT;
@@ -0,0 +1,4 @@
+BEGIN;
+ALTER TABLE user_tokens DROP COLUMN IF EXISTS last_used_at;
+DROP TABLE IF EXISTS user_token_usages;
+COMMIT;
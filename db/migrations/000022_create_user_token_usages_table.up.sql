@@ -0,0 +1,19 @@
+BEGIN;
+-- 
+-- Create the user_token_usages table
+-- 
+CREATE TABLE IF NOT EXISTS user_token_usages (
+    id SERIAL PRIMARY KEY,
+    user_token_id INT NOT NULL,
+    ip VARCHAR NOT NULL,
+    user_agent VARCHAR NOT NULL,
+    method VARCHAR NOT NULL,
+    path VARCHAR NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL,
+    FOREIGN KEY (user_token_id) REFERENCES user_tokens(id) ON DELETE CASCADE
+);
+CREATE INDEX IF NOT EXISTS idx_user_token_usages_user_token_id ON user_token_usages(user_token_id);
+
+ALTER TABLE user_tokens
+ADD COLUMN IF NOT EXISTS last_used_at TIMESTAMPTZ;
+COMMIT;
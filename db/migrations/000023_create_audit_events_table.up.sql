@@ -0,0 +1,29 @@
+BEGIN;
+-- 
+-- Create the audit_events table
+-- 
+CREATE TABLE IF NOT EXISTS audit_events (
+    id SERIAL PRIMARY KEY,
+    action VARCHAR NOT NULL,
+    actor_id INT NOT NULL,
+    module_id INT,
+    data TEXT NOT NULL,
+    prev_hash VARCHAR NOT NULL,
+    hash VARCHAR NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL
+);
+CREATE INDEX IF NOT EXISTS idx_audit_events_actor_id ON audit_events(actor_id);
+CREATE INDEX IF NOT EXISTS idx_audit_events_module_id ON audit_events(module_id);
+
+-- audit events are append-only, so the table intentionally has no foreign keys
+-- and rejects updates and deletes
+CREATE OR REPLACE FUNCTION audit_events_append_only() RETURNS TRIGGER AS $$
+BEGIN
+    RAISE EXCEPTION 'audit_events is append-only';
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER audit_events_append_only
+BEFORE UPDATE OR DELETE ON audit_events
+FOR EACH ROW EXECUTE PROCEDURE audit_events_append_only();
+COMMIT;
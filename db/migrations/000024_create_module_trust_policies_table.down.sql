@@ -0,0 +1,6 @@
+BEGIN;
+ALTER TABLE user_tokens
+DROP COLUMN IF EXISTS trusted_publishing;
+
+DROP TABLE IF EXISTS module_trust_policies;
+COMMIT;
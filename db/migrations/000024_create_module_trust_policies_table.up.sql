@@ -0,0 +1,21 @@
+BEGIN;
+-- 
+-- Create the module_trust_policies table
+-- 
+CREATE TABLE IF NOT EXISTS module_trust_policies (
+    id SERIAL PRIMARY KEY,
+    module_id INT NOT NULL,
+    user_id INT NOT NULL,
+    repository VARCHAR NOT NULL,
+    workflow VARCHAR NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL,
+    FOREIGN KEY (module_id) REFERENCES modules(id) ON DELETE CASCADE,
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_module_trust_policies_module_workflow ON module_trust_policies(module_id, repository, workflow);
+CREATE INDEX IF NOT EXISTS idx_module_trust_policies_workflow ON module_trust_policies(repository, workflow);
+
+-- tokens issued in exchange for an OIDC token of a trusted workflow
+ALTER TABLE user_tokens
+ADD COLUMN IF NOT EXISTS trusted_publishing BOOLEAN NOT NULL DEFAULT false;
+COMMIT;
@@ -0,0 +1,7 @@
+BEGIN;
+ALTER TABLE nodes
+DROP COLUMN IF EXISTS online,
+DROP COLUMN IF EXISTS last_seen_at;
+
+DROP TABLE IF EXISTS node_observations;
+COMMIT;
@@ -0,0 +1,23 @@
+BEGIN;
+-- 
+-- Create the node_observations table
+-- 
+CREATE TABLE IF NOT EXISTS node_observations (
+    id SERIAL PRIMARY KEY,
+    node_id INT NOT NULL,
+    reachable BOOLEAN NOT NULL,
+    latency_ms BIGINT NOT NULL DEFAULT 0,
+    height BIGINT,
+    version VARCHAR,
+    created_at TIMESTAMPTZ NOT NULL,
+    FOREIGN KEY (node_id) REFERENCES nodes(id) ON DELETE CASCADE
+);
+CREATE INDEX IF NOT EXISTS idx_node_observations_node_created_at ON node_observations(node_id, created_at);
+
+-- nodes are marked offline instead of being deleted when unreachable
+ALTER TABLE nodes
+ADD COLUMN IF NOT EXISTS online BOOLEAN NOT NULL DEFAULT true,
+ADD COLUMN IF NOT EXISTS last_seen_at TIMESTAMPTZ;
+
+UPDATE nodes SET last_seen_at = updated_at;
+COMMIT;
@@ -0,0 +1,7 @@
+BEGIN;
+ALTER TABLE nodes
+DROP COLUMN IF EXISTS latest_block_height,
+DROP COLUMN IF EXISTS latest_block_time,
+DROP COLUMN IF EXISTS catching_up,
+DROP COLUMN IF EXISTS voting_power;
+COMMIT;
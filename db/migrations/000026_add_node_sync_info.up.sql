@@ -0,0 +1,7 @@
+BEGIN;
+ALTER TABLE nodes
+ADD COLUMN IF NOT EXISTS latest_block_height BIGINT NOT NULL DEFAULT 0,
+ADD COLUMN IF NOT EXISTS latest_block_time TIMESTAMPTZ,
+ADD COLUMN IF NOT EXISTS catching_up BOOLEAN NOT NULL DEFAULT false,
+ADD COLUMN IF NOT EXISTS voting_power BIGINT NOT NULL DEFAULT 0;
+COMMIT;
@@ -0,0 +1,7 @@
+BEGIN;
+DELETE FROM nodes
+WHERE location_id IS NULL;
+
+ALTER TABLE nodes
+ALTER COLUMN location_id SET NOT NULL;
+COMMIT;
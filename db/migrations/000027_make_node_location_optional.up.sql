@@ -0,0 +1,5 @@
+BEGIN;
+-- a node's location is unknown if it could not be geolocated
+ALTER TABLE nodes
+ALTER COLUMN location_id DROP NOT NULL;
+COMMIT;
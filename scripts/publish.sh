@@ -4,11 +4,13 @@ token=$1
 path_manifest=$2
 dry_run=$3
 
-atlas login $token
-
 if [[ "$dry_run" == "true" ]]; then
 	atlas publish -m $path_manifest --dry-run
   echo "dry-run"
-else
+elif [[ -n "$token" ]]; then
+	atlas login $token
 	atlas publish -m $path_manifest
+else
+	# exchange the workflow's OIDC token for a short-lived token
+	atlas publish -m $path_manifest --oidc
 fi
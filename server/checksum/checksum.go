@@ -0,0 +1,415 @@
+// Package checksum implements a content hash of a source tree, such that the
+// hash of a module version's source computed from a repository archive can be
+// reproduced from a local checkout.
+//
+// The hash is computed over every regular file in the tree, excluding the .git
+// directory. A summary is built containing a line per file, sorted by its slash
+// separated path relative to the root of the tree, of the form
+// "<sha256 of contents in hex>  <path>\n". The hash is the SHA-256 of the summary
+// encoded as "h1:" followed by its standard base64 encoding, similar to the hash
+// used by Go modules.
+//
+// The tree may be a subdirectory of a repository, e.g. a module's directory in a
+// monorepo, in which case paths are relative to the subdirectory. A local
+// checkout of a Git repository is hashed using the same set of files a
+// repository archive contains, i.e. its tracked files except those with the
+// export-ignore attribute, such that untracked and ignored files are excluded.
+package checksum
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// Prefix defines the prefix of every checksum which identifies the algorithm.
+const Prefix = "h1:"
+
+// gitDir defines the name of the Git directory which is excluded from a hash.
+const gitDir = ".git"
+
+// Tree defines the hex encoded SHA-256 of the contents of every regular file in
+// a source tree, keyed by its slash separated path relative to the root of the
+// tree.
+type Tree map[string]string
+
+// HashDir returns the checksum of the source tree rooted at the slash separated
+// subdirectory subdir of dir, where an empty subdir denotes dir itself. If dir
+// is the root of a Git repository, i.e. contains a .git directory, only the
+// files a repository archive contains are hashed, which requires git. Symbolic
+// links and empty directories are ignored.
+func HashDir(dir, subdir string) (string, error) {
+	subdir, err := CleanSubdir(subdir)
+	if err != nil {
+		return "", err
+	}
+
+	var tree Tree
+	if _, err := os.Stat(filepath.Join(dir, gitDir)); err == nil {
+		tree, err = readGitDir(dir, subdir)
+		if err != nil {
+			return "", fmt.Errorf("failed to hash directory: %w", err)
+		}
+	} else {
+		tree, err = readDir(filepath.Join(dir, filepath.FromSlash(subdir)))
+		if err != nil {
+			return "", fmt.Errorf("failed to hash directory: %w", err)
+		}
+
+		tree = tree.prefixed(subdir)
+	}
+
+	return tree.Checksum(subdir)
+}
+
+// HashTarGz returns the checksum of the source tree rooted at the slash
+// separated subdirectory subdir of a gzipped tarball, as read by ReadTarGz,
+// where an empty subdir denotes the root of the tarball.
+func HashTarGz(r io.Reader, subdir string) (string, error) {
+	tree, err := ReadTarGz(r)
+	if err != nil {
+		return "", err
+	}
+
+	return tree.Checksum(subdir)
+}
+
+// ReadTarGz returns the Tree contained in a gzipped tarball, where the
+// tarball's single top-level directory is considered the root of the tree, as
+// is the case for repository archives. Entries other than regular files are
+// ignored.
+func ReadTarGz(r io.Reader) (Tree, error) {
+	gzr, err := gzip.NewReader(r)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read archive: %w", err)
+	}
+
+	defer gzr.Close()
+
+	tree := make(Tree)
+	tr := tar.NewReader(gzr)
+
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to read archive: %w", err)
+		}
+
+		if hdr.Typeflag != tar.TypeReg && hdr.Typeflag != tar.TypeRegA {
+			continue
+		}
+
+		// strip the top-level directory
+		name := path.Clean(hdr.Name)
+		i := strings.Index(name, "/")
+		if i < 0 {
+			continue
+		}
+
+		name = name[i+1:]
+		if inGitDir(name) {
+			continue
+		}
+
+		sum, err := hashFile(tr)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read archive: %w", err)
+		}
+
+		tree[name] = sum
+	}
+
+	return tree, nil
+}
+
+// Checksum returns the checksum of the subtree rooted at the slash separated
+// subdirectory subdir, where an empty subdir denotes the entire tree. An error
+// is returned if the subtree contains no files.
+func (t Tree) Checksum(subdir string) (string, error) {
+	subdir, err := CleanSubdir(subdir)
+	if err != nil {
+		return "", err
+	}
+
+	sums := t
+	if subdir != "" {
+		sums = make(Tree)
+
+		prefix := subdir + "/"
+		for p, sum := range t {
+			if strings.HasPrefix(p, prefix) {
+				sums[strings.TrimPrefix(p, prefix)] = sum
+			}
+		}
+	}
+
+	return summarize(sums)
+}
+
+// CleanSubdir returns the canonical form of a slash separated subdirectory of a
+// tree, where the root of the tree is denoted by an empty subdirectory. An error
+// is returned if the subdirectory is absolute or not contained in the tree.
+func CleanSubdir(subdir string) (string, error) {
+	if subdir == "" {
+		return "", nil
+	}
+
+	if path.IsAbs(subdir) || strings.Contains(subdir, "\\") {
+		return "", fmt.Errorf("invalid subdirectory '%s': must be a relative slash separated path", subdir)
+	}
+
+	clean := path.Clean(subdir)
+	if clean == ".." || strings.HasPrefix(clean, "../") {
+		return "", fmt.Errorf("invalid subdirectory '%s': must be contained in the tree", subdir)
+	}
+
+	if clean == "." {
+		return "", nil
+	}
+
+	return clean, nil
+}
+
+// readDir returns the Tree of every regular file in dir, excluding any Git
+// directory, keyed by its path relative to dir.
+func readDir(dir string) (Tree, error) {
+	tree := make(Tree)
+
+	err := filepath.Walk(dir, func(p string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+
+		if info.Name() == gitDir && p != dir {
+			if info.IsDir() {
+				return filepath.SkipDir
+			}
+
+			return nil
+		}
+
+		if !info.Mode().IsRegular() {
+			return nil
+		}
+
+		rel, err := filepath.Rel(dir, p)
+		if err != nil {
+			return err
+		}
+
+		sum, err := hashPath(p)
+		if err != nil {
+			return err
+		}
+
+		tree[filepath.ToSlash(rel)] = sum
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return tree, nil
+}
+
+// readGitDir returns the Tree of the files a repository archive of the Git
+// repository rooted at dir contains within the given subdirectory, i.e. the
+// tracked files except those with the export-ignore attribute, keyed by their
+// path relative to dir. The files are read from the working tree, such that any
+// local modification changes the Tree, where a tracked file missing from the
+// working tree results in an error.
+func readGitDir(dir, subdir string) (Tree, error) {
+	args := []string{"ls-files", "-z", "--cached"}
+	if subdir != "" {
+		args = append(args, "--", subdir)
+	}
+
+	out, err := git(dir, nil, args...)
+	if err != nil {
+		return nil, err
+	}
+
+	files := splitNul(out)
+	if len(files) == 0 {
+		return Tree{}, nil
+	}
+
+	// An archive excludes a path if it, or any directory containing it, has the
+	// export-ignore attribute, so the attribute is checked for every directory.
+	seen := make(map[string]bool)
+	paths := make([]string, 0, len(files))
+	for _, f := range files {
+		for p := f; p != "." && !seen[p]; p = path.Dir(p) {
+			seen[p] = true
+			paths = append(paths, p)
+		}
+	}
+
+	// check-attr outputs a <path> NUL <attribute> NUL <value> NUL triple per path
+	out, err = git(dir, strings.NewReader(strings.Join(paths, "\x00")+"\x00"), "check-attr", "-z", "--stdin", "export-ignore")
+	if err != nil {
+		return nil, err
+	}
+
+	ignored := make(map[string]bool)
+	attrs := splitNul(out)
+	for i := 0; i+2 < len(attrs); i += 3 {
+		if v := attrs[i+2]; v == "set" || v == "true" {
+			ignored[attrs[i]] = true
+		}
+	}
+
+	isIgnored := func(f string) bool {
+		for p := f; p != "."; p = path.Dir(p) {
+			if ignored[p] {
+				return true
+			}
+		}
+
+		return false
+	}
+
+	tree := make(Tree)
+	for _, f := range files {
+		if isIgnored(f) || inGitDir(f) {
+			continue
+		}
+
+		p := filepath.Join(dir, filepath.FromSlash(f))
+
+		info, err := os.Lstat(p)
+		if err != nil {
+			return nil, err
+		}
+
+		// skip symbolic links and submodules, which archives contain as
+		// non-regular entries
+		if !info.Mode().IsRegular() {
+			continue
+		}
+
+		sum, err := hashPath(p)
+		if err != nil {
+			return nil, err
+		}
+
+		tree[f] = sum
+	}
+
+	return tree, nil
+}
+
+// git executes a git command in dir and returns its standard output.
+func git(dir string, stdin io.Reader, args ...string) (string, error) {
+	var stdout, stderr bytes.Buffer
+
+	cmd := exec.Command("git", append([]string{"-C", dir, "-c", "core.quotePath=false"}, args...)...)
+	cmd.Stdin = stdin
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+
+	if err := cmd.Run(); err != nil {
+		return "", fmt.Errorf("failed to execute git %s: %w: %s", args[0], err, strings.TrimSpace(stderr.String()))
+	}
+
+	return stdout.String(), nil
+}
+
+// splitNul splits NUL terminated output into its fields.
+func splitNul(s string) []string {
+	s = strings.TrimSuffix(s, "\x00")
+	if s == "" {
+		return nil
+	}
+
+	return strings.Split(s, "\x00")
+}
+
+// prefixed returns the Tree with every path prefixed by the given slash
+// separated subdirectory.
+func (t Tree) prefixed(subdir string) Tree {
+	if subdir == "" {
+		return t
+	}
+
+	res := make(Tree, len(t))
+	for p, sum := range t {
+		res[subdir+"/"+p] = sum
+	}
+
+	return res
+}
+
+// inGitDir returns true if a slash separated path is, or is contained in, a Git
+// directory.
+func inGitDir(p string) bool {
+	for _, elem := range strings.Split(p, "/") {
+		if elem == gitDir {
+			return true
+		}
+	}
+
+	return false
+}
+
+// hashPath returns the hex encoded SHA-256 of the contents of the file at p.
+func hashPath(p string) (string, error) {
+	f, err := os.Open(p)
+	if err != nil {
+		return "", err
+	}
+
+	defer f.Close()
+
+	return hashFile(f)
+}
+
+// hashFile returns the hex encoded SHA-256 of a file's contents.
+func hashFile(r io.Reader) (string, error) {
+	h := sha256.New()
+	if _, err := io.Copy(h, r); err != nil {
+		return "", err
+	}
+
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// summarize returns the checksum of a set of file hashes keyed by path.
+func summarize(sums map[string]string) (string, error) {
+	if len(sums) == 0 {
+		return "", errors.New("failed to compute checksum: no files found")
+	}
+
+	paths := make([]string, 0, len(sums))
+	for p := range sums {
+		if strings.Contains(p, "\n") {
+			return "", fmt.Errorf("failed to compute checksum: invalid file name %q", p)
+		}
+
+		paths = append(paths, p)
+	}
+
+	sort.Strings(paths)
+
+	h := sha256.New()
+	for _, p := range paths {
+		fmt.Fprintf(h, "%s  %s\n", sums[p], p)
+	}
+
+	return Prefix + base64.StdEncoding.EncodeToString(h.Sum(nil)), nil
+}
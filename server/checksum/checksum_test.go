@@ -0,0 +1,207 @@
+package checksum_test
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/cosmos/atlas/server/checksum"
+)
+
+func writeDir(t *testing.T, files map[string]string) string {
+	t.Helper()
+
+	dir := t.TempDir()
+	for name, contents := range files {
+		p := filepath.Join(dir, filepath.FromSlash(name))
+		require.NoError(t, os.MkdirAll(filepath.Dir(p), 0755))
+		require.NoError(t, ioutil.WriteFile(p, []byte(contents), 0644))
+	}
+
+	return dir
+}
+
+func writeTarGz(t *testing.T, root string, files map[string]string) []byte {
+	t.Helper()
+
+	names := make([]string, 0, len(files))
+	for name := range files {
+		names = append(names, name)
+	}
+
+	sort.Strings(names)
+
+	var buf bytes.Buffer
+	gzw := gzip.NewWriter(&buf)
+	tw := tar.NewWriter(gzw)
+
+	require.NoError(t, tw.WriteHeader(&tar.Header{Name: root + "/", Typeflag: tar.TypeDir, Mode: 0755}))
+
+	for _, name := range names {
+		contents := files[name]
+		require.NoError(t, tw.WriteHeader(&tar.Header{
+			Name:     root + "/" + name,
+			Typeflag: tar.TypeReg,
+			Mode:     0644,
+			Size:     int64(len(contents)),
+		}))
+
+		_, err := tw.Write([]byte(contents))
+		require.NoError(t, err)
+	}
+
+	require.NoError(t, tw.Close())
+	require.NoError(t, gzw.Close())
+
+	return buf.Bytes()
+}
+
+func TestHash(t *testing.T) {
+	files := map[string]string{
+		"README.md":          "# x/bank",
+		"x/bank/module.go":   "package bank",
+		"x/bank/spec/01.md":  "# State",
+		"x/bank/keeper/k.go": "package keeper",
+	}
+
+	archiveSum, err := checksum.HashTarGz(bytes.NewReader(writeTarGz(t, "cosmos-cosmos-sdk-6d2f2ea", files)), "")
+	require.NoError(t, err)
+	require.True(t, strings.HasPrefix(archiveSum, checksum.Prefix))
+
+	// a local directory, including a nested Git directory, yields the same
+	// checksum
+	checkout := map[string]string{"x/.git/HEAD": "ref: refs/heads/master"}
+	for name, contents := range files {
+		checkout[name] = contents
+	}
+
+	dirSum, err := checksum.HashDir(writeDir(t, checkout), "")
+	require.NoError(t, err)
+	require.Equal(t, archiveSum, dirSum)
+
+	// a modified file yields a different checksum
+	files["x/bank/module.go"] = "package bank // modified"
+
+	modifiedSum, err := checksum.HashDir(writeDir(t, files), "")
+	require.NoError(t, err)
+	require.NotEqual(t, archiveSum, modifiedSum)
+
+	// an added file yields a different checksum
+	delete(files, "x/bank/module.go")
+	files["x/bank/module_test.go"] = "package bank"
+
+	addedSum, err := checksum.HashDir(writeDir(t, files), "")
+	require.NoError(t, err)
+	require.NotEqual(t, archiveSum, addedSum)
+
+	// an empty tree has no checksum
+	_, err = checksum.HashDir(t.TempDir(), "")
+	require.Error(t, err)
+}
+
+func TestHash_Subdir(t *testing.T) {
+	files := map[string]string{
+		"README.md":         "# cosmos-sdk",
+		"x/bank/module.go":  "package bank",
+		"x/bank/README.md":  "# x/bank",
+		"x/mint/module.go":  "package mint",
+		"x/bankx/module.go": "package bankx",
+	}
+
+	tree, err := checksum.ReadTarGz(bytes.NewReader(writeTarGz(t, "cosmos-cosmos-sdk-6d2f2ea", files)))
+	require.NoError(t, err)
+
+	bankSum, err := tree.Checksum("x/bank")
+	require.NoError(t, err)
+
+	// the subtree's checksum equals the checksum of the module's files alone
+	moduleSum, err := checksum.HashDir(writeDir(t, map[string]string{
+		"module.go": "package bank",
+		"README.md": "# x/bank",
+	}), "")
+	require.NoError(t, err)
+	require.Equal(t, moduleSum, bankSum)
+
+	// every module has its own checksum
+	mintSum, err := tree.Checksum("./x/mint/")
+	require.NoError(t, err)
+	require.NotEqual(t, bankSum, mintSum)
+
+	rootSum, err := tree.Checksum("")
+	require.NoError(t, err)
+	require.NotEqual(t, bankSum, rootSum)
+
+	dirSum, err := checksum.HashDir(writeDir(t, files), "x/bank")
+	require.NoError(t, err)
+	require.Equal(t, bankSum, dirSum)
+
+	// a missing or invalid subdirectory has no checksum
+	for _, subdir := range []string{"x/staking", "../x/bank", "/x/bank"} {
+		_, err = tree.Checksum(subdir)
+		require.Error(t, err, subdir)
+	}
+}
+
+func TestHashDir_Git(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git is not installed")
+	}
+
+	files := map[string]string{
+		"README.md":          "# cosmos-sdk",
+		".gitignore":         "*.log\n",
+		".gitattributes":     "/x/bank/spec export-ignore\n*.pdf export-ignore\n",
+		"x/bank/module.go":   "package bank",
+		"x/bank/spec/01.md":  "# State",
+		"x/bank/paper.pdf":   "%PDF",
+		"x/bank/keeper/k.go": "package keeper",
+	}
+
+	dir := writeDir(t, files)
+	for _, args := range [][]string{
+		{"init", "-q"},
+		{"add", "-A"},
+		{"-c", "user.name=test", "-c", "user.email=test@test.com", "commit", "-q", "-m", "init"},
+	} {
+		cmd := exec.Command("git", append([]string{"-C", dir}, args...)...)
+		out, err := cmd.CombinedOutput()
+		require.NoError(t, err, string(out))
+	}
+
+	// the archive excludes the files with the export-ignore attribute
+	archive := map[string]string{
+		"x/bank/module.go":   "package bank",
+		"x/bank/keeper/k.go": "package keeper",
+	}
+
+	archiveSum, err := checksum.HashTarGz(bytes.NewReader(writeTarGz(t, "cosmos-cosmos-sdk-6d2f2ea", archive)), "x/bank")
+	require.NoError(t, err)
+
+	dirSum, err := checksum.HashDir(dir, "x/bank")
+	require.NoError(t, err)
+	require.Equal(t, archiveSum, dirSum)
+
+	// untracked and ignored files are excluded
+	require.NoError(t, ioutil.WriteFile(filepath.Join(dir, "x", "bank", "debug.log"), []byte("debug"), 0644))
+	require.NoError(t, ioutil.WriteFile(filepath.Join(dir, "x", "bank", "notes.txt"), []byte("notes"), 0644))
+
+	dirSum, err = checksum.HashDir(dir, "x/bank")
+	require.NoError(t, err)
+	require.Equal(t, archiveSum, dirSum)
+
+	// a modified tracked file yields a different checksum
+	require.NoError(t, ioutil.WriteFile(filepath.Join(dir, "x", "bank", "module.go"), []byte("package bank // modified"), 0644))
+
+	dirSum, err = checksum.HashDir(dir, "x/bank")
+	require.NoError(t, err)
+	require.NotEqual(t, archiveSum, dirSum)
+}
@@ -0,0 +1,172 @@
+package crawl
+
+import (
+	"errors"
+	"fmt"
+	"net"
+
+	"github.com/harwoeck/ipstack"
+	"github.com/oschwald/maxminddb-golang"
+
+	"github.com/cosmos/atlas/config"
+	"github.com/cosmos/atlas/server/models"
+)
+
+// Supported geolocation providers.
+const (
+	GeolocationProviderIPStack = "ipstack"
+	GeolocationProviderMMDB    = "mmdb"
+	GeolocationProviderNone    = "none"
+)
+
+const (
+	ipClientHTTPS    = false
+	ipClientTimeoutS = 5
+
+	// mmdbLanguage defines the language of the place names read from an mmdb
+	// database.
+	mmdbLanguage = "en"
+)
+
+// ErrLocationNotFound defines a sentinel error when no geographical location is
+// known for an address.
+var ErrLocationNotFound = errors.New("location not found")
+
+var (
+	_ Geolocator = (*IPStackGeolocator)(nil)
+	_ Geolocator = (*MMDBGeolocator)(nil)
+	_ Geolocator = NoopGeolocator{}
+)
+
+type (
+	// Geolocator defines the interface of a provider which resolves the
+	// geographical location of a node's address, i.e. an IP or hostname. A
+	// Location without coordinates is returned if the provider does not resolve
+	// locations.
+	Geolocator interface {
+		Geolocate(addr string) (models.Location, error)
+	}
+
+	// IPStackGeolocator implements a Geolocator which queries the ipstack API.
+	IPStackGeolocator struct {
+		client *ipstack.Client
+	}
+
+	// MMDBGeolocator implements a Geolocator which reads a local MaxMind DB
+	// (mmdb) city database, e.g. MaxMind's GeoLite2 City or DB-IP's IP to City
+	// Lite database. Hostnames are resolved to their first IP address.
+	MMDBGeolocator struct {
+		reader *maxminddb.Reader
+	}
+
+	// NoopGeolocator implements a Geolocator which does not resolve locations.
+	NoopGeolocator struct{}
+
+	// mmdbCity defines the fields of an mmdb city database record which are
+	// relevant to a Location.
+	mmdbCity struct {
+		City struct {
+			Names map[string]string `maxminddb:"names"`
+		} `maxminddb:"city"`
+		Country struct {
+			Names map[string]string `maxminddb:"names"`
+		} `maxminddb:"country"`
+		Subdivisions []struct {
+			Names map[string]string `maxminddb:"names"`
+		} `maxminddb:"subdivisions"`
+		Location struct {
+			Latitude  *float64 `maxminddb:"latitude"`
+			Longitude *float64 `maxminddb:"longitude"`
+		} `maxminddb:"location"`
+	}
+)
+
+// NewGeolocator returns the Geolocator of the configured geolocation provider,
+// which defaults to ipstack. An error is returned if the provider is unknown or
+// fails to be created.
+func NewGeolocator(cfg config.Config) (Geolocator, error) {
+	switch provider := cfg.String(config.NodeGeolocationProvider); provider {
+	case "", GeolocationProviderIPStack:
+		return NewIPStackGeolocator(cfg.String(config.IPStackKey)), nil
+
+	case GeolocationProviderMMDB:
+		return NewMMDBGeolocator(cfg.String(config.NodeGeolocationMMDBPath))
+
+	case GeolocationProviderNone:
+		return NoopGeolocator{}, nil
+
+	default:
+		return nil, fmt.Errorf("unknown geolocation provider '%s'", provider)
+	}
+}
+
+// NewIPStackGeolocator returns an IPStackGeolocator using the given ipstack API
+// key.
+func NewIPStackGeolocator(key string) *IPStackGeolocator {
+	return &IPStackGeolocator{client: ipstack.NewClient(key, ipClientHTTPS, ipClientTimeoutS)}
+}
+
+// Geolocate implements the Geolocator interface by querying the ipstack API.
+func (g *IPStackGeolocator) Geolocate(addr string) (models.Location, error) {
+	resp, err := g.client.Check(addr)
+	if err != nil {
+		return models.Location{}, err
+	}
+
+	return locationFromIPResp(resp), nil
+}
+
+// NewMMDBGeolocator returns an MMDBGeolocator reading the mmdb database at the
+// given path. An error is returned if the database cannot be opened.
+func NewMMDBGeolocator(path string) (*MMDBGeolocator, error) {
+	if path == "" {
+		return nil, errors.New("failed to open mmdb database: no path provided")
+	}
+
+	reader, err := maxminddb.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open mmdb database: %w", err)
+	}
+
+	return &MMDBGeolocator{reader: reader}, nil
+}
+
+// Geolocate implements the Geolocator interface by looking up the address in
+// the mmdb database. An error wrapping ErrLocationNotFound is returned if the
+// database has no coordinates for the address.
+func (g *MMDBGeolocator) Geolocate(addr string) (models.Location, error) {
+	ip := net.ParseIP(addr)
+	if ip == nil {
+		ips, err := net.LookupIP(addr)
+		if err != nil {
+			return models.Location{}, fmt.Errorf("failed to resolve '%s': %w", addr, err)
+		}
+		if len(ips) == 0 {
+			return models.Location{}, fmt.Errorf("failed to resolve '%s': no addresses found", addr)
+		}
+
+		ip = ips[0]
+	}
+
+	var record mmdbCity
+	if err := g.reader.Lookup(ip, &record); err != nil {
+		return models.Location{}, fmt.Errorf("failed to look up '%s': %w", addr, err)
+	}
+
+	if record.Location.Latitude == nil || record.Location.Longitude == nil {
+		return models.Location{}, fmt.Errorf("failed to look up '%s': %w", addr, ErrLocationNotFound)
+	}
+
+	return locationFromMMDBCity(record), nil
+}
+
+// Close closes the mmdb database.
+func (g *MMDBGeolocator) Close() error {
+	return g.reader.Close()
+}
+
+// Geolocate implements the Geolocator interface by returning a Location without
+// coordinates.
+func (NoopGeolocator) Geolocate(_ string) (models.Location, error) {
+	return models.Location{}, nil
+}
@@ -0,0 +1,57 @@
+package crawl_test
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/knadh/koanf"
+	"github.com/knadh/koanf/providers/confmap"
+	"github.com/stretchr/testify/require"
+
+	"github.com/cosmos/atlas/config"
+	"github.com/cosmos/atlas/server/crawl"
+)
+
+func TestNewGeolocator(t *testing.T) {
+	testCases := []struct {
+		name      string
+		provider  string
+		mmdbPath  string
+		expected  crawl.Geolocator
+		expectErr bool
+	}{
+		{"default", "", "", &crawl.IPStackGeolocator{}, false},
+		{"ipstack", crawl.GeolocationProviderIPStack, "", &crawl.IPStackGeolocator{}, false},
+		{"none", crawl.GeolocationProviderNone, "", crawl.NoopGeolocator{}, false},
+		{"mmdb without path", crawl.GeolocationProviderMMDB, "", nil, true},
+		{"mmdb with missing database", crawl.GeolocationProviderMMDB, filepath.Join(t.TempDir(), "city.mmdb"), nil, true},
+		{"unknown", "maxmind", "", nil, true},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+
+		t.Run(tc.name, func(t *testing.T) {
+			cfg := koanf.New(".")
+			require.NoError(t, cfg.Load(confmap.Provider(map[string]interface{}{
+				config.NodeGeolocationProvider: tc.provider,
+				config.NodeGeolocationMMDBPath: tc.mmdbPath,
+			}, "."), nil))
+
+			geolocator, err := crawl.NewGeolocator(cfg)
+			if tc.expectErr {
+				require.Error(t, err)
+				return
+			}
+
+			require.NoError(t, err)
+			require.IsType(t, tc.expected, geolocator)
+		})
+	}
+}
+
+func TestNoopGeolocator_Geolocate(t *testing.T) {
+	loc, err := crawl.NoopGeolocator{}.Geolocate("127.0.0.1")
+	require.NoError(t, err)
+	require.False(t, loc.HasCoordinates())
+}
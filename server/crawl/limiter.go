@@ -0,0 +1,137 @@
+package crawl
+
+import (
+	"sync"
+	"time"
+)
+
+// HostLimiter implements per-host rate limiting and back-off of node crawls. A
+// host may be crawled at most once per interval and a host that keeps failing
+// to be crawled is backed off exponentially, starting at the base back-off and
+// doubling with every consecutive failure up to the max back-off.
+type HostLimiter struct {
+	mtx sync.Mutex
+
+	interval    time.Duration
+	backoffBase time.Duration
+	backoffMax  time.Duration
+	hosts       map[string]*hostState
+}
+
+// hostState defines the crawl state of a single host.
+type hostState struct {
+	lastCrawl time.Time
+	failures  uint
+	retryAt   time.Time
+}
+
+// NewHostLimiter returns a HostLimiter with the given per-host crawl interval
+// and base and max back-off.
+func NewHostLimiter(interval, backoffBase, backoffMax time.Duration) *HostLimiter {
+	return &HostLimiter{
+		interval:    interval,
+		backoffBase: backoffBase,
+		backoffMax:  backoffMax,
+		hosts:       make(map[string]*hostState),
+	}
+}
+
+// Allow returns true if a host may be crawled now, i.e. if it has not been
+// crawled within the interval and it is not being backed off, in which case the
+// crawl is recorded. It returns false otherwise.
+func (hl *HostLimiter) Allow(host string) bool {
+	hl.mtx.Lock()
+	defer hl.mtx.Unlock()
+
+	now := time.Now()
+
+	state, ok := hl.hosts[host]
+	if !ok {
+		hl.hosts[host] = &hostState{lastCrawl: now}
+		return true
+	}
+
+	if now.Sub(state.lastCrawl) < hl.interval || now.Before(state.retryAt) {
+		return false
+	}
+
+	state.lastCrawl = now
+	return true
+}
+
+// BackedOff returns true if a host is being backed off after failing to be
+// crawled.
+func (hl *HostLimiter) BackedOff(host string) bool {
+	hl.mtx.Lock()
+	defer hl.mtx.Unlock()
+
+	state, ok := hl.hosts[host]
+	return ok && time.Now().Before(state.retryAt)
+}
+
+// Success records a successful crawl of a host, resetting its back-off.
+func (hl *HostLimiter) Success(host string) {
+	hl.mtx.Lock()
+	defer hl.mtx.Unlock()
+
+	if state, ok := hl.hosts[host]; ok {
+		state.failures = 0
+		state.retryAt = time.Time{}
+	}
+}
+
+// Failure records a failed crawl of a host, backing it off until the next retry.
+func (hl *HostLimiter) Failure(host string) {
+	hl.mtx.Lock()
+	defer hl.mtx.Unlock()
+
+	state, ok := hl.hosts[host]
+	if !ok {
+		state = &hostState{lastCrawl: time.Now()}
+		hl.hosts[host] = state
+	}
+
+	state.failures++
+	state.retryAt = time.Now().Add(hl.backoff(state.failures))
+}
+
+// Size returns the number of hosts whose crawl state is tracked.
+func (hl *HostLimiter) Size() int {
+	hl.mtx.Lock()
+	defer hl.mtx.Unlock()
+	return len(hl.hosts)
+}
+
+// Prune removes the crawl state of all hosts which are neither rate limited nor
+// backed off anymore, such that the state of hosts that are no longer crawled
+// does not accumulate. The state of a failing host is only removed once it has
+// not been retried for the max back-off after its back-off lapsed.
+func (hl *HostLimiter) Prune() {
+	hl.mtx.Lock()
+	defer hl.mtx.Unlock()
+
+	now := time.Now()
+	for host, state := range hl.hosts {
+		if now.Sub(state.lastCrawl) < hl.interval || now.Before(state.retryAt) {
+			continue
+		}
+
+		if state.failures == 0 || now.Sub(state.retryAt) >= hl.backoffMax {
+			delete(hl.hosts, host)
+		}
+	}
+}
+
+// backoff returns the back-off after the given number of consecutive failures.
+func (hl *HostLimiter) backoff(failures uint) time.Duration {
+	backoff := hl.backoffBase
+	for i := uint(1); i < failures && backoff < hl.backoffMax; i++ {
+		backoff *= 2
+	}
+
+	if backoff > hl.backoffMax {
+		return hl.backoffMax
+	}
+
+	return backoff
+}
@@ -0,0 +1,61 @@
+package crawl_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/cosmos/atlas/server/crawl"
+)
+
+func TestHostLimiter_Allow(t *testing.T) {
+	hl := crawl.NewHostLimiter(50*time.Millisecond, time.Hour, time.Hour)
+
+	require.True(t, hl.Allow("1.2.3.4"))
+	require.False(t, hl.Allow("1.2.3.4"))
+	require.True(t, hl.Allow("5.6.7.8"))
+	require.Equal(t, 2, hl.Size())
+
+	time.Sleep(60 * time.Millisecond)
+	require.True(t, hl.Allow("1.2.3.4"))
+}
+
+func TestHostLimiter_Backoff(t *testing.T) {
+	hl := crawl.NewHostLimiter(0, 50*time.Millisecond, 100*time.Millisecond)
+
+	require.True(t, hl.Allow("1.2.3.4"))
+	require.False(t, hl.BackedOff("1.2.3.4"))
+	hl.Failure("1.2.3.4")
+	require.False(t, hl.Allow("1.2.3.4"))
+	require.True(t, hl.BackedOff("1.2.3.4"))
+	require.False(t, hl.BackedOff("5.6.7.8"))
+
+	time.Sleep(60 * time.Millisecond)
+	require.False(t, hl.BackedOff("1.2.3.4"))
+	require.True(t, hl.Allow("1.2.3.4"))
+
+	// the back-off doubles upon a consecutive failure
+	hl.Failure("1.2.3.4")
+	time.Sleep(60 * time.Millisecond)
+	require.False(t, hl.Allow("1.2.3.4"))
+
+	time.Sleep(60 * time.Millisecond)
+	require.True(t, hl.Allow("1.2.3.4"))
+
+	// a successful crawl resets the back-off
+	hl.Success("1.2.3.4")
+	require.True(t, hl.Allow("1.2.3.4"))
+}
+
+func TestHostLimiter_Prune(t *testing.T) {
+	hl := crawl.NewHostLimiter(0, time.Hour, time.Hour)
+
+	require.True(t, hl.Allow("1.2.3.4"))
+	require.True(t, hl.Allow("5.6.7.8"))
+	hl.Failure("5.6.7.8")
+
+	hl.Prune()
+	require.Equal(t, 1, hl.Size())
+	require.False(t, hl.Allow("5.6.7.8"))
+}
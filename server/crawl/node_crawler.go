@@ -4,13 +4,16 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"io"
+	"math"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
-	"github.com/harwoeck/ipstack"
 	lru "github.com/hashicorp/golang-lru"
 	"github.com/rs/zerolog"
+	"golang.org/x/time/rate"
 	"gorm.io/gorm"
 
 	"github.com/cosmos/atlas/config"
@@ -20,18 +23,50 @@ import (
 const (
 	defaultP2PPort    = "26656"
 	locationCacheSize = 1000
-	ipClientHTTPS     = false
-	ipClientTimeoutS  = 5
+
+	// DefaultCrawlWorkers defines the default number of nodes crawled
+	// concurrently.
+	DefaultCrawlWorkers = 32
+
+	// DefaultHostCrawlInterval defines the default minimum interval between
+	// successive crawls of the same host.
+	DefaultHostCrawlInterval = time.Minute
+
+	// DefaultCrawlBackoffBase and DefaultCrawlBackoffMax define the default
+	// initial and maximum durations for which a host that fails to be crawled is
+	// backed off.
+	DefaultCrawlBackoffBase = 5 * time.Minute
+	DefaultCrawlBackoffMax  = time.Hour
 )
 
-// Crawler implements the Tendermint p2p network crawler.
+// CrawlStats defines the statistics of a single crawl, i.e. the number of nodes
+// crawled and deferred to a subsequent crawl due to per-host rate limiting or
+// back-off, the peak number of nodes queued in the node pool, the peak number of
+// busy workers and the utilization of the workers, i.e. the fraction of the
+// crawl's duration the workers spent crawling.
+type CrawlStats struct {
+	Crawled       int
+	Deferred      int
+	MaxQueueDepth int
+	Workers       int
+	PeakWorkers   int
+	Utilization   float64
+	Elapsed       time.Duration
+}
+
+// Crawler implements the Tendermint p2p network crawler. Nodes are crawled by a
+// bounded pool of workers, where the rate at which crawls are started is limited
+// globally and per host, and hosts that keep failing are backed off.
 type Crawler struct {
-	logger   zerolog.Logger
-	pool     *NodePool
-	ipClient *ipstack.Client
-	locCache *lru.ARCCache
-	seeds    []string
-	doneCh   chan struct{}
+	logger     zerolog.Logger
+	pool       *NodePool
+	geolocator Geolocator
+	locCache   *lru.ARCCache
+	seeds      []string
+	doneCh     chan struct{}
+	workers    int
+	limiter    *rate.Limiter
+	hosts      *HostLimiter
 
 	mtx sync.Mutex
 	db  *gorm.DB
@@ -56,30 +91,73 @@ func NewCrawler(logger zerolog.Logger, cfg config.Config, db *gorm.DB) (*Crawler
 		return nil, nil
 	}
 
+	geolocator, err := NewGeolocator(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	workers := cfg.Int(config.NodeCrawlWorkers)
+	if workers <= 0 {
+		workers = DefaultCrawlWorkers
+	}
+
+	// a global rate limit of zero disables it
+	limit := rate.Inf
+	if r := cfg.Int(config.NodeCrawlRateLimit); r > 0 {
+		limit = rate.Limit(r)
+	}
+
+	hostInterval := cfg.Duration(config.NodeCrawlHostInterval)
+	if hostInterval <= 0 {
+		hostInterval = DefaultHostCrawlInterval
+	}
+
+	backoffBase := cfg.Duration(config.NodeCrawlBackoffBase)
+	if backoffBase <= 0 {
+		backoffBase = DefaultCrawlBackoffBase
+	}
+
+	backoffMax := cfg.Duration(config.NodeCrawlBackoffMax)
+	if backoffMax < backoffBase {
+		backoffMax = DefaultCrawlBackoffMax
+		if backoffMax < backoffBase {
+			backoffMax = backoffBase
+		}
+	}
+
 	return &Crawler{
 		logger:          logger,
 		db:              db,
 		seeds:           strings.Split(cfg.String(config.NodeSeeds), ","),
 		crawlInterval:   cfg.Duration(config.NodeCrawlInterval),
 		recheckInterval: cfg.Duration(config.NodeRecheckInterval),
-		ipClient:        ipstack.NewClient(cfg.String(config.IPStackKey), ipClientHTTPS, ipClientTimeoutS),
+		geolocator:      geolocator,
 		locCache:        locCache,
 		pool:            NewNodePool(uint(cfg.Int(config.NodeReseedSize))),
 		doneCh:          make(chan struct{}),
+		workers:         workers,
+		limiter:         rate.NewLimiter(limit, 1),
+		hosts:           NewHostLimiter(hostInterval, backoffBase, backoffMax),
 	}, nil
 }
 
 // Stop signals to the crawler that it should halt and exit all spawned goroutines.
+// The geolocator is closed if it holds any resources.
 func (c *Crawler) Stop() {
 	close(c.doneCh)
+
+	if closer, ok := c.geolocator.(io.Closer); ok {
+		if err := closer.Close(); err != nil {
+			c.logger.Error().Err(err).Msg("failed to close geolocator")
+		}
+	}
 }
 
-// Start starts a blocking process in which a random node is selected from the
-// node pool and crawled. For each successful crawl, it'll be persisted or updated
-// and its peers will be added to the node pool if they do not already exist.
-// This process continues indefinitely until all nodes are exhausted from the pool.
-// When the pool is empty and after crawlInterval seconds since the last complete
-// crawl, a random set of nodes from the DB are added to reseed the pool.
+// Start starts a blocking process in which nodes are crawled in crawls occurring
+// every crawlInterval. For each successful crawl of a node, it'll be persisted or
+// updated and its peers will be added to the node pool if they do not already
+// exist. When a crawl completes, a random set of nodes from the DB are added to
+// reseed the pool. The process continues until the crawler is stopped.
 func (c *Crawler) Start() {
 	// seed the pool with the initial set of seeds before crawling
 	c.pool.Seed(c.seeds)
@@ -92,67 +170,173 @@ func (c *Crawler) Start() {
 	for {
 		select {
 		case <-ticker.C:
-			c.logger.Info().Msg("starting to crawl nodes")
-
-			// reset the peer buffer
-			c.mtx.Lock()
-			c.tmpPeers = make([]Peer, 0)
-			c.mtx.Unlock()
-
-			var wg sync.WaitGroup
-			nc := 0
-			start := time.Now()
-
-			// Keep picking a pseudo-random node from the pool to crawl until the pool
-			// is exhausted.
-			peer, ok := c.pool.RandomNode()
-			for ok {
-				wg.Add(1)
-				c.pool.DeleteNode(peer)
-
-				go func(p Peer) {
-					defer wg.Done()
-					c.CrawlNode(p)
-				}(peer)
-
-				// pick the next pseudo-random node
-				peer, ok = c.pool.RandomNode()
-
-				if nc%50 == 0 {
-					c.logger.Info().Int("size", c.pool.Size()).Msg("node pool size")
+			c.logger.Info().Int("workers", c.workers).Msg("starting to crawl nodes")
+
+			stats := c.crawl()
+
+			c.logger.Info().
+				Int("num_crawled", stats.Crawled).
+				Int("num_deferred", stats.Deferred).
+				Int("max_queue_depth", stats.MaxQueueDepth).
+				Int("workers", stats.Workers).
+				Int("peak_workers", stats.PeakWorkers).
+				Float64("utilization", stats.Utilization).
+				Float64("elapsed", stats.Elapsed.Seconds()).
+				Msg("node crawl complete; reseeding node pool")
+
+			c.hosts.Prune()
+			c.pool.Reseed()
+
+		case <-c.doneCh:
+			return
+		}
+	}
+}
+
+// crawl performs a single crawl in which a pseudo-random node is picked from the
+// node pool and handed to a bounded pool of workers to be crawled, until the
+// pool is exhausted or the crawler is stopped. Starting a node's crawl is
+// subject to the global rate limit, whereas nodes whose host was crawled
+// recently or is being backed off are deferred to a subsequent crawl. A node
+// whose host is being backed off is recorded as unreachable, at most once per
+// crawl, such that its uptime accounts for the crawls it is skipped. Once all
+// workers complete, the peers discovered and deferred are added to the node pool
+// and the crawl's statistics are returned.
+func (c *Crawler) crawl() CrawlStats {
+	// reset the peer buffer
+	c.mtx.Lock()
+	c.tmpPeers = make([]Peer, 0)
+	c.mtx.Unlock()
+
+	// cancel waiting on the global rate limit when the crawler is stopped
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	go func() {
+		select {
+		case <-c.doneCh:
+			cancel()
+		case <-ctx.Done():
+		}
+	}()
+
+	var (
+		wg           sync.WaitGroup
+		activeCount  int64
+		peakCount    int64
+		busyDuration int64
+	)
+
+	stats := CrawlStats{Workers: c.workers}
+	start := time.Now()
+	queue := make(chan Peer)
+
+	for i := 0; i < c.workers; i++ {
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+
+			for p := range queue {
+				active := atomic.AddInt64(&activeCount, 1)
+				for peak := atomic.LoadInt64(&peakCount); active > peak; peak = atomic.LoadInt64(&peakCount) {
+					if atomic.CompareAndSwapInt64(&peakCount, peak, active) {
+						break
+					}
 				}
 
-				nc++
+				crawlStart := time.Now()
+				c.CrawlNode(p)
+
+				atomic.AddInt64(&busyDuration, int64(time.Since(crawlStart)))
+				atomic.AddInt64(&activeCount, -1)
 			}
+		}()
+	}
+
+	// Keep picking a pseudo-random node from the pool to crawl until the pool is
+	// exhausted or the crawler is stopped.
+	nc := 0
+	backedOff := make(map[Peer]bool)
+	peer, ok := c.pool.RandomNode()
+	for ok && ctx.Err() == nil {
+		if depth := c.pool.Size(); depth > stats.MaxQueueDepth {
+			stats.MaxQueueDepth = depth
+		}
 
-			// wait for all crawlers to complete
-			wg.Wait()
+		c.pool.DeleteNode(peer)
 
-			// add all peers from the temp buffer to the node pool
-			c.mtx.Lock()
-			for _, p := range c.tmpPeers {
-				c.logger.Debug().Str("rpc_address", p.RPCAddr).Msg("adding peer to node pool")
-				c.pool.AddNode(p)
+		host := parseHostname(peer.RPCAddr)
+
+		switch {
+		case !c.hosts.Allow(host):
+			c.logger.Debug().Str("rpc_address", peer.RPCAddr).Msg("deferring rate limited node")
+			c.deferPeer(peer)
+			stats.Deferred++
+
+			if c.hosts.BackedOff(host) && !backedOff[peer] {
+				backedOff[peer] = true
+				c.markNodeOffline(models.Node{Address: host, Network: peer.Network})
 			}
-			c.mtx.Unlock()
 
-			elapsed := time.Since(start).Seconds()
+		case c.limiter.Wait(ctx) == nil:
+			select {
+			case queue <- peer:
+				stats.Crawled++
 
-			c.logger.Info().Int("num_crawled", nc).
-				Float64("elapsed", elapsed).
-				Msg("node crawl complete; reseeding node pool")
-			c.pool.Reseed()
+			case <-ctx.Done():
+			}
+		}
 
-		case <-c.doneCh:
-			return
+		if nc%50 == 0 {
+			c.logger.Info().
+				Int("queue_depth", c.pool.Size()).
+				Int64("active_workers", atomic.LoadInt64(&activeCount)).
+				Msg("node pool size")
 		}
+
+		nc++
+
+		// pick the next pseudo-random node
+		peer, ok = c.pool.RandomNode()
+	}
+
+	// wait for all workers to complete
+	close(queue)
+	wg.Wait()
+
+	// add all peers from the temp buffer to the node pool
+	c.mtx.Lock()
+	for _, p := range c.tmpPeers {
+		c.logger.Debug().Str("rpc_address", p.RPCAddr).Msg("adding peer to node pool")
+		c.pool.AddNode(p)
+	}
+	c.mtx.Unlock()
+
+	stats.Elapsed = time.Since(start)
+	stats.PeakWorkers = int(atomic.LoadInt64(&peakCount))
+
+	if stats.Elapsed > 0 {
+		utilization := float64(atomic.LoadInt64(&busyDuration)) / (float64(stats.Elapsed) * float64(c.workers))
+		stats.Utilization = math.Round(utilization*10000) / 10000
 	}
+
+	return stats
+}
+
+// deferPeer adds a peer to the temp buffer such that it is crawled in a
+// subsequent crawl.
+func (c *Crawler) deferPeer(p Peer) {
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+	c.tmpPeers = append(c.tmpPeers, p)
 }
 
 // RecheckNodes starts a blocking process where every recheckInterval duration
 // the crawler checks for all stale nodes that need to be rechecked. For each
 // stale node, the node is added back into the node pool to be re-crawled and
-// updated (or removed).
+// updated (or marked offline). Node observations past their retention are purged
+// on each recheck.
 func (c *Crawler) RecheckNodes() {
 	ticker := time.NewTicker(c.recheckInterval)
 	defer ticker.Stop()
@@ -162,6 +346,13 @@ func (c *Crawler) RecheckNodes() {
 		case <-ticker.C:
 			c.logger.Info().Msg("rechecking nodes...")
 
+			purged, err := models.PurgeNodeObservations(c.db, time.Now().Add(-models.NodeObservationRetention))
+			if err != nil {
+				c.logger.Info().Err(err).Msg("failed to purge node observations")
+			} else if purged > 0 {
+				c.logger.Debug().Int64("num_purged", purged).Msg("purged expired node observations")
+			}
+
 			nodes, err := models.GetStaleNodes(c.db, time.Now())
 			if err != nil {
 				c.logger.Info().Err(err).Msg("failed to get all stale nodes")
@@ -192,10 +383,10 @@ func (c *Crawler) RecheckNodes() {
 // CrawlNode performs the main crawling functionality for a Tendermint node. It
 // accepts a node RPC address and attempts to ping that node's P2P address by
 // using the RPC address and the default P2P port of 26656. If the P2P address
-// cannot be reached, the node is deleted if it exists in the database. Otherwise,
-// we attempt to get additional metadata aboout the node via it's RPC address
-// and its set of peers. For every peer that doesn't exist in the node pool, it
-// is added.
+// cannot be reached, the node is marked offline if it exists in the database.
+// Otherwise, we attempt to get additional metadata aboout the node via it's RPC
+// address and its set of peers. For every peer that doesn't exist in the node
+// pool, it is added. Each attempt is recorded as an observation of the node.
 func (c *Crawler) CrawlNode(p Peer) {
 	host := parseHostname(p.RPCAddr)
 	nodeP2PAddr := fmt.Sprintf("%s:%s", host, defaultP2PPort)
@@ -207,57 +398,69 @@ func (c *Crawler) CrawlNode(p Peer) {
 		Network: p.Network,
 	}
 
-	var deleteNode bool
+	// A host that fails to be crawled is backed off, whereas a successful crawl
+	// resets its back-off.
+	var markOffline bool
 	defer func() {
-		if deleteNode {
-			c.deleteNode(node)
+		if markOffline {
+			c.markNodeOffline(node)
+			c.hosts.Failure(host)
+		} else {
+			c.hosts.Success(host)
 		}
 	}()
 
 	c.logger.Debug().Str("p2p_address", nodeP2PAddr).Str("rpc_address", p.RPCAddr).Msg("pinging node...")
 
-	// Attempt to ping the node where upon failure, we remove the node from the
-	// database.
-	if ok := pingAddress(nodeP2PAddr, 5*time.Second); !ok {
+	// Attempt to ping the node where upon failure, we mark the node as offline in
+	// the database.
+	latency, ok := pingAddress(nodeP2PAddr, 5*time.Second)
+	if !ok {
 		c.logger.Info().
 			Str("p2p_address", nodeP2PAddr).
 			Str("rpc_address", p.RPCAddr).
-			Msg("failed to ping node; deleting...")
+			Msg("failed to ping node; marking offline...")
 
-		deleteNode = true
+		markOffline = true
 		return
 	}
 
-	// Grab the node's geolocation information where upon failure, we remove the
-	// node from the database.
+	obs := models.NodeObservation{
+		Reachable: true,
+		LatencyMS: latency.Milliseconds(),
+	}
+
+	// Grab the node's geolocation information where upon failure, we continue
+	// crawling the node without a location, such that any location it already
+	// has is retained.
 	loc, err := c.GetGeolocation(node.Address)
 	if err != nil {
 		c.logger.Error().
 			Err(err).
 			Str("p2p_address", nodeP2PAddr).
 			Str("rpc_address", p.RPCAddr).
-			Msg("failed to get node geolocation; deleting...")
-
-		deleteNode = true
-		return
+			Msg("failed to get node geolocation")
+	} else {
+		node.Location = loc
 	}
 
-	node.Location = loc
-
 	client, err := newRPCClient(p.RPCAddr, clientTimeout)
 	if err != nil {
 		c.logger.Error().
 			Err(err).
 			Str("p2p_address", nodeP2PAddr).
 			Str("rpc_address", p.RPCAddr).
-			Msg("failed to create RPC client")
+			Msg("failed to create RPC client; marking offline...")
 
+		markOffline = true
 		return
 	}
 
 	// Attempt to get the node's status which provides us with node metadata.
 	// Upon failure, we return and prevent further crawling if the network is
 	// unknown due to the lack of any useful information about the node.
+	// Otherwise, the node is saved without a status such that its existing
+	// metadata is retained.
 	status, err := client.Status(context.Background())
 	if err != nil {
 		c.logger.Error().
@@ -267,14 +470,21 @@ func (c *Crawler) CrawlNode(p Peer) {
 			Msg("failed to get node status")
 
 		if node.Network == "" {
-			deleteNode = true
+			markOffline = true
 			return
 		}
 	} else {
+		obs.Height = models.NewNullInt64(status.SyncInfo.LatestBlockHeight)
+		obs.Version = models.NewNullString(status.NodeInfo.Version)
+
 		node.Moniker = status.NodeInfo.Moniker
 		node.NodeID = string(status.NodeInfo.ID())
 		node.Version = status.NodeInfo.Version
 		node.TxIndex = status.NodeInfo.Other.TxIndex
+		node.LatestBlockHeight = status.SyncInfo.LatestBlockHeight
+		node.LatestBlockTime = models.NewNullTime(status.SyncInfo.LatestBlockTime)
+		node.CatchingUp = status.SyncInfo.CatchingUp
+		node.VotingPower = status.ValidatorInfo.VotingPower
 
 		if node.Network == "" {
 			node.Network = status.NodeInfo.Network
@@ -308,15 +518,15 @@ func (c *Crawler) CrawlNode(p Peer) {
 		}
 	}
 
-	c.upsertNode(node)
+	c.upsertNode(node, obs)
 }
 
 // GetGeolocation returns a Location record containing geolocation information
 // for a given node. It will first check to see if the location already exists
-// in cache. If the record does not exist in the cache, a Node record is queried
-// by the provided address. If that record does not exist, we perform a query
-// against the ipstack API and write to the cache. An error is returned if the
-// database query fails.
+// in cache. If the record does not exist in the cache, a Node record with a
+// location is queried by the provided address. If that record does not exist,
+// we query the configured geolocator and write any resolved location to the
+// cache. An error is returned if the database query or geolocation fails.
 func (c *Crawler) GetGeolocation(addr string) (models.Location, error) {
 	// return the location from cache if it exists
 	if loc, ok := c.locCache.Get(addr); ok {
@@ -325,54 +535,62 @@ func (c *Crawler) GetGeolocation(addr string) (models.Location, error) {
 
 	var loc models.Location
 
-	// Query for the Node record and if the record exists, use that Location.
-	// Otherwise, perform a query against ipstack using the provided address.
+	// Query for the Node record and if the record exists with a location, use
+	// that Location. Otherwise, query the geolocator using the provided address.
 	node, err := models.QueryNode(c.db, map[string]interface{}{"address": addr})
-	if err != nil {
-		if errors.Is(err, gorm.ErrRecordNotFound) {
-			ipResp, err := c.ipClient.Check(addr)
-			if err != nil {
-				return models.Location{}, err
-			}
+	switch {
+	case err == nil && node.LocationID != nil:
+		loc = node.Location
 
-			loc = locationFromIPResp(ipResp)
-		} else {
+	case err == nil || errors.Is(err, gorm.ErrRecordNotFound):
+		loc, err = c.geolocator.Geolocate(addr)
+		if err != nil {
 			return models.Location{}, err
 		}
-	} else {
-		loc = node.Location
+
+	default:
+		return models.Location{}, err
 	}
 
-	// write to cache
-	c.locCache.Add(addr, loc)
+	// write to cache unless the geolocator does not resolve locations
+	if loc.HasCoordinates() {
+		c.locCache.Add(addr, loc)
+	}
 
 	return loc, nil
 }
 
-// deleteNode provides a thread-safe way of deleting the given node from the
-// database. Concurrent goroutines are spawned for each node to crawl, so we
-// use the crawler's mutex to prevent any issues with concurrent database
-// operations.
-func (c *Crawler) deleteNode(n models.Node) {
+// markNodeOffline provides a thread-safe way of marking the given node as
+// offline in the database. Concurrent goroutines are spawned for each node to
+// crawl, so we use the crawler's mutex to prevent any issues with concurrent
+// database operations.
+func (c *Crawler) markNodeOffline(n models.Node) {
 	c.mtx.Lock()
 	defer c.mtx.Unlock()
 
-	if err := n.Delete(c.db); err != nil {
-		c.logger.Error().Err(err).Str("rpc_address", n.Address).Msg("failed to delete node")
+	if err := n.MarkOffline(c.db); err != nil {
+		c.logger.Error().Err(err).Str("rpc_address", n.Address).Msg("failed to mark node offline")
 	}
 }
 
 // upsertNode provides a thread-safe way of updating the given node from the
-// database. Concurrent goroutines are spawned for each node to crawl, so we
-// use the crawler's mutex to prevent any issues with concurrent database
-// operations.
-func (c *Crawler) upsertNode(n models.Node) {
+// database and recording the given observation of it. Concurrent goroutines are
+// spawned for each node to crawl, so we use the crawler's mutex to prevent any
+// issues with concurrent database operations.
+func (c *Crawler) upsertNode(n models.Node, obs models.NodeObservation) {
 	c.mtx.Lock()
 	defer c.mtx.Unlock()
 
-	if _, err := n.Upsert(c.db); err != nil {
+	record, err := n.Upsert(c.db)
+	if err != nil {
 		c.logger.Error().Err(err).Str("rpc_address", n.Address).Msg("failed to save node")
-	} else {
-		c.logger.Info().Str("rpc_address", n.Address).Msg("successfully crawled and saved node")
+		return
 	}
+
+	if _, err := record.RecordObservation(c.db, obs); err != nil {
+		c.logger.Error().Err(err).Str("rpc_address", n.Address).Msg("failed to record node observation")
+		return
+	}
+
+	c.logger.Info().Str("rpc_address", n.Address).Msg("successfully crawled and saved node")
 }
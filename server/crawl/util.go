@@ -53,12 +53,31 @@ func locationFromIPResp(r *ipstack.Response) models.Location {
 	}
 }
 
-func pingAddress(address string, timeout time.Duration) bool {
+func locationFromMMDBCity(r mmdbCity) models.Location {
+	var region string
+	if len(r.Subdivisions) > 0 {
+		region = r.Subdivisions[0].Names[mmdbLanguage]
+	}
+
+	return models.Location{
+		Country:   r.Country.Names[mmdbLanguage],
+		Region:    region,
+		City:      r.City.Names[mmdbLanguage],
+		Latitude:  fmt.Sprintf("%f", *r.Location.Latitude),
+		Longitude: fmt.Sprintf("%f", *r.Location.Longitude),
+	}
+}
+
+// pingAddress attempts to dial the given TCP address, returning the time it took
+// to establish a connection and true upon success.
+func pingAddress(address string, timeout time.Duration) (time.Duration, bool) {
+	start := time.Now()
+
 	conn, err := net.DialTimeout("tcp", address, timeout)
 	if err != nil {
-		return false
+		return 0, false
 	}
 
 	defer conn.Close()
-	return true
+	return time.Since(start), true
 }
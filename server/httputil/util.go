@@ -167,8 +167,8 @@ func TransformValidationError(err error) error {
 }
 
 // CreateHealthChecker returns a health checker instance with all checkers
-// registered.
-func CreateHealthChecker(db checkers.SQLPinger, disableLog bool) (*health.Health, error) {
+// registered, including any additional checks provided.
+func CreateHealthChecker(db checkers.SQLPinger, disableLog bool, checks ...*health.Config) (*health.Health, error) {
 	h := health.New()
 	if disableLog {
 		h.DisableLogging()
@@ -190,6 +190,10 @@ func CreateHealthChecker(db checkers.SQLPinger, disableLog bool) (*health.Health
 		return nil, fmt.Errorf("failed to add health checkers: %w", err)
 	}
 
+	if err := h.AddChecks(checks); err != nil {
+		return nil, fmt.Errorf("failed to add health checkers: %w", err)
+	}
+
 	if err := h.Start(); err != nil {
 		return nil, fmt.Errorf("failed to start health checker: %w", err)
 	}
@@ -0,0 +1,226 @@
+package models
+
+import (
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"gorm.io/gorm"
+
+	"github.com/cosmos/atlas/server/httputil"
+)
+
+// Audit event actions. Module ownership changes are recorded as the module's
+// owner event action prefixed by "module.", e.g. "module.owner_added".
+const (
+	AuditActionModulePublish           = "module.publish"
+	AuditActionModuleStar              = "module.star"
+	AuditActionModuleUnStar            = "module.unstar"
+	AuditActionModuleYank              = "module.yank"
+	AuditActionModuleUnYank            = "module.unyank"
+	AuditActionOwnerInvite             = "module.owner_invited"
+	AuditActionOwnerInviteResend       = "module.owner_invite_resent"
+	AuditActionOwnerInviteRevoke       = "module.owner_invite_revoked"
+	AuditActionOwnerInviteDecline      = "module.owner_invite_declined"
+	AuditActionTrustPolicyAdd          = "module.trust_policy_added"
+	AuditActionTrustPolicyRemove       = "module.trust_policy_removed"
+	AuditActionTeamMemberAdd           = "team.member_added"
+	AuditActionTeamMemberRoleChange    = "team.member_role_changed"
+	AuditActionTeamMemberRemove        = "team.member_removed"
+	AuditActionTeamMemberInvite        = "team.member_invited"
+	AuditActionTeamMemberInviteResend  = "team.member_invite_resent"
+	AuditActionTeamMemberInviteDecline = "team.member_invite_declined"
+	AuditActionTokenCreate             = "token.create"
+	AuditActionTokenRevoke             = "token.revoke"
+	AuditActionEmailChange             = "user.email_change"
+)
+
+// auditEventsLockKey defines the key of the transaction-level advisory lock
+// which serializes appending audit events, such that each event is chained to
+// the event appended before it.
+//
+// Note: As the audit log is a single hash chain, every audited mutation holds
+// the lock from appending its event until its transaction commits, i.e. audited
+// mutations are serialized. This is acceptable given the registry's low write
+// volume and short transactions, and keeps verification of the log a single
+// linear scan. Should write contention become an issue, the log may be split
+// into a chain per module and user, locked by key.
+const auditEventsLockKey = 0x61756469
+
+// ErrAuditChainBroken defines a sentinel error when the audit event hash chain
+// does not verify, i.e. when an audit event has been modified, removed or
+// inserted out of band.
+var ErrAuditChainBroken = errors.New("audit event hash chain is broken")
+
+type (
+	// AuditEventJSON defines the JSON-encodeable type for an AuditEvent.
+	AuditEventJSON struct {
+		ID        uint            `json:"id"`
+		CreatedAt time.Time       `json:"created_at"`
+		Action    string          `json:"action"`
+		ActorID   uint            `json:"actor_id"`
+		ModuleID  interface{}     `json:"module_id"`
+		Data      json.RawMessage `json:"data"`
+		PrevHash  string          `json:"prev_hash"`
+		Hash      string          `json:"hash"`
+	}
+
+	// AuditEvent defines an entry in the append-only audit log of registry
+	// mutations. Each event is written in the same transaction as the mutation it
+	// records and is chained to the previous event by including the previous
+	// event's hash in its own hash, such that modifying, removing or inserting
+	// events out of band is detectable.
+	AuditEvent struct {
+		ID        uint `gorm:"primaryKey"`
+		CreatedAt time.Time
+
+		Action   string
+		ActorID  uint
+		ModuleID sql.NullInt64
+		Data     string
+		PrevHash string
+		Hash     string
+	}
+)
+
+// MarshalJSON implements custom JSON marshaling for the AuditEvent model.
+func (ae AuditEvent) MarshalJSON() ([]byte, error) {
+	moduleID, _ := ae.ModuleID.Value()
+
+	return json.Marshal(AuditEventJSON{
+		ID:        ae.ID,
+		CreatedAt: ae.CreatedAt,
+		Action:    ae.Action,
+		ActorID:   ae.ActorID,
+		ModuleID:  moduleID,
+		Data:      json.RawMessage(ae.Data),
+		PrevHash:  ae.PrevHash,
+		Hash:      ae.Hash,
+	})
+}
+
+// computeHash returns the hex-encoded SHA-256 hash of the event's previous hash
+// and contents, excluding its ID which is only assigned once written.
+func (ae AuditEvent) computeHash() string {
+	// Note: Encoding a struct is deterministic and the creation time is encoded
+	// as Unix nanoseconds to be independent of the time zone.
+	bz, _ := json.Marshal(struct {
+		PrevHash  string `json:"prev_hash"`
+		CreatedAt int64  `json:"created_at"`
+		Action    string `json:"action"`
+		ActorID   uint   `json:"actor_id"`
+		ModuleID  int64  `json:"module_id"`
+		Data      string `json:"data"`
+	}{
+		PrevHash:  ae.PrevHash,
+		CreatedAt: ae.CreatedAt.UnixNano(),
+		Action:    ae.Action,
+		ActorID:   ae.ActorID,
+		ModuleID:  ae.ModuleID.Int64,
+		Data:      ae.Data,
+	})
+
+	h := sha256.Sum256(bz)
+	return hex.EncodeToString(h[:])
+}
+
+// recordAuditEvent appends an event to the audit log within the given
+// transaction, where the data, if any, is JSON encoded and a zero module ID
+// denotes an event unrelated to a module.
+func recordAuditEvent(tx *gorm.DB, action string, actorID, moduleID uint, data map[string]interface{}) error {
+	if data == nil {
+		data = map[string]interface{}{}
+	}
+
+	bz, err := json.Marshal(data)
+	if err != nil {
+		return fmt.Errorf("failed to encode audit event data: %w", err)
+	}
+
+	if err := tx.Exec("SELECT pg_advisory_xact_lock(?)", auditEventsLockKey).Error; err != nil {
+		return fmt.Errorf("failed to lock audit events: %w", err)
+	}
+
+	var prev []AuditEvent
+	if err := tx.Order("id DESC").Limit(1).Find(&prev).Error; err != nil {
+		return fmt.Errorf("failed to query for the latest audit event: %w", err)
+	}
+
+	event := AuditEvent{
+		// Postgres stores timestamps with microsecond precision, so the time is
+		// truncated such that the hash can be verified once stored.
+		CreatedAt: time.Now().UTC().Truncate(time.Microsecond),
+		Action:    action,
+		ActorID:   actorID,
+		ModuleID:  NewNullInt64(int64(moduleID)),
+		Data:      string(bz),
+	}
+
+	if len(prev) > 0 {
+		event.PrevHash = prev[0].Hash
+	}
+
+	event.Hash = event.computeHash()
+
+	if err := tx.Create(&event).Error; err != nil {
+		return fmt.Errorf("failed to record audit event: %w", err)
+	}
+
+	return nil
+}
+
+// GetAuditEvents returns a paginated set of audit events matching a query. It
+// returns an error upon failure.
+func GetAuditEvents(db *gorm.DB, query map[string]interface{}, pq httputil.PaginationQuery) ([]AuditEvent, Paginator, error) {
+	var (
+		events []AuditEvent
+		total  int64
+	)
+
+	if err := db.Where(query).Scopes(paginateScope(pq, &events)).Error; err != nil {
+		return nil, Paginator{}, fmt.Errorf("failed to query for audit events: %w", err)
+	}
+
+	if err := db.Model(&AuditEvent{}).Where(query).Count(&total).Error; err != nil {
+		return nil, Paginator{}, fmt.Errorf("failed to query for audit event count: %w", err)
+	}
+
+	return events, buildPaginator(pq, total), nil
+}
+
+// VerifyAuditEvents verifies the hash chain of the entire audit log. An error
+// wrapping ErrAuditChainBroken, referencing the first event that does not
+// verify, is returned if the chain is broken and an error is returned upon
+// query failure.
+func VerifyAuditEvents(db *gorm.DB) error {
+	const batchSize = 1000
+
+	var (
+		lastID   uint
+		prevHash string
+	)
+
+	for {
+		var events []AuditEvent
+		if err := db.Where("id > ?", lastID).Order("id ASC").Limit(batchSize).Find(&events).Error; err != nil {
+			return fmt.Errorf("failed to query for audit events: %w", err)
+		}
+
+		for _, e := range events {
+			if e.PrevHash != prevHash || e.Hash != e.computeHash() {
+				return fmt.Errorf("invalid audit event %d: %w", e.ID, ErrAuditChainBroken)
+			}
+
+			lastID = e.ID
+			prevHash = e.Hash
+		}
+
+		if len(events) < batchSize {
+			return nil
+		}
+	}
+}
@@ -0,0 +1,284 @@
+package models
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"gorm.io/gorm"
+
+	"github.com/cosmos/atlas/server/semver"
+)
+
+type (
+	// ModuleDependency defines a dependency of a ModuleVersion on another Module,
+	// where Constraint defines the Semantic Version constraint the dependency's
+	// version must satisfy.
+	ModuleDependency struct {
+		gorm.Model
+
+		ModuleVersionID uint
+		DependencyID    uint
+		Constraint      string `gorm:"column:version_constraint"`
+	}
+
+	// ModuleRefJSON defines the JSON-encodeable type for a reference to a module
+	// and optionally one of its versions.
+	ModuleRefJSON struct {
+		ModuleID uint   `json:"module_id"`
+		Team     string `json:"team"`
+		Name     string `json:"name"`
+		Version  string `json:"version,omitempty"`
+	}
+
+	// DependencyEdgeJSON defines the JSON-encodeable type for a DependencyEdge.
+	DependencyEdgeJSON struct {
+		Dependent  ModuleRefJSON `json:"dependent"`
+		Dependency ModuleRefJSON `json:"dependency"`
+		Constraint string        `json:"constraint"`
+	}
+
+	// DependencyEdge defines an edge in the module dependency graph, where the
+	// latest version of a dependent module depends on a dependency module.
+	DependencyEdge struct {
+		DependentModuleID  uint
+		DependentTeam      string
+		DependentName      string
+		DependentVersion   string
+		DependencyModuleID uint
+		DependencyTeam     string
+		DependencyName     string
+		DependencyVersion  string
+		Constraint         string
+	}
+
+	// DependencyImpact defines a dependent module whose declared version
+	// constraint, at the time a ModuleVersion was published, is not satisfied by
+	// that ModuleVersion. The set of DependencyImpact records of a ModuleVersion
+	// makes up its dependency impact report.
+	DependencyImpact struct {
+		gorm.Model
+
+		ModuleVersionID   uint
+		DependentModuleID uint
+		DependentVersion  string
+		Constraint        string `gorm:"column:version_constraint"`
+	}
+
+	// ModulePublishJSON defines the JSON-encodeable type for the result of
+	// publishing a module, which includes the published version's dependency
+	// impact report.
+	ModulePublishJSON struct {
+		ModuleJSON
+
+		DependencyImpact []DependencyEdgeJSON `json:"dependency_impact"`
+	}
+)
+
+// MarshalJSON implements custom JSON marshaling for the DependencyEdge model.
+func (de DependencyEdge) MarshalJSON() ([]byte, error) {
+	return json.Marshal(de.NewDependencyEdgeJSON())
+}
+
+func (de DependencyEdge) NewDependencyEdgeJSON() DependencyEdgeJSON {
+	return DependencyEdgeJSON{
+		Dependent: ModuleRefJSON{
+			ModuleID: de.DependentModuleID,
+			Team:     de.DependentTeam,
+			Name:     de.DependentName,
+			Version:  de.DependentVersion,
+		},
+		Dependency: ModuleRefJSON{
+			ModuleID: de.DependencyModuleID,
+			Team:     de.DependencyTeam,
+			Name:     de.DependencyName,
+			Version:  de.DependencyVersion,
+		},
+		Constraint: de.Constraint,
+	}
+}
+
+// GetModuleDependencies returns the dependencies declared by the latest version
+// of a module by ID. An error is returned upon database query failure.
+func GetModuleDependencies(db *gorm.DB, moduleID uint) ([]DependencyEdge, error) {
+	edges, err := queryDependencyEdges(db, "dm.id = ?", moduleID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query for module dependencies: %w", err)
+	}
+
+	return edges, nil
+}
+
+// GetModuleDependents returns the modules whose latest version depends on a
+// module by ID. An error is returned upon database query failure.
+func GetModuleDependents(db *gorm.DB, moduleID uint) ([]DependencyEdge, error) {
+	edges, err := queryDependencyEdges(db, "m.id = ?", moduleID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query for module dependents: %w", err)
+	}
+
+	return edges, nil
+}
+
+// GetDependencyImpactReport returns the dependency impact report of a module
+// version by ID, i.e. the dependent modules whose constraints were not satisfied
+// by the version when it was published. An error is returned upon database query
+// failure.
+func GetDependencyImpactReport(db *gorm.DB, moduleVersionID uint) ([]DependencyEdge, error) {
+	edges := []DependencyEdge{}
+
+	err := db.Raw(`SELECT
+  dm.id AS dependent_module_id,
+  dm.team AS dependent_team,
+  dm.name AS dependent_name,
+  i.dependent_version AS dependent_version,
+  m.id AS dependency_module_id,
+  m.team AS dependency_team,
+  m.name AS dependency_name,
+  mv.version AS dependency_version,
+  i.version_constraint AS "constraint"
+FROM
+  dependency_impacts i
+  INNER JOIN
+    module_versions mv
+    ON (i.module_version_id = mv.id)
+  INNER JOIN
+    modules m
+    ON (mv.module_id = m.id)
+  INNER JOIN
+    modules dm
+    ON (i.dependent_module_id = dm.id)
+WHERE
+  i.module_version_id = ?
+  AND i.deleted_at IS NULL
+  AND dm.deleted_at IS NULL
+ORDER BY
+  dm.team,
+  dm.name;
+`, moduleVersionID).Scan(&edges).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to query for dependency impact report: %w", err)
+	}
+
+	return edges, nil
+}
+
+// createDependencies creates the ModuleDependency records for a newly created
+// ModuleVersion.
+func (mv ModuleVersion) createDependencies(db *gorm.DB) error {
+	if len(mv.Dependencies) == 0 {
+		return nil
+	}
+
+	deps := make([]ModuleDependency, len(mv.Dependencies))
+	for i, d := range mv.Dependencies {
+		deps[i] = ModuleDependency{
+			ModuleVersionID: mv.ID,
+			DependencyID:    d.DependencyID,
+			Constraint:      d.Constraint,
+		}
+	}
+
+	if err := db.Create(&deps).Error; err != nil {
+		return fmt.Errorf("failed to create module dependencies: %w", err)
+	}
+
+	return nil
+}
+
+// createDependencyImpacts creates the dependency impact report for a newly
+// created ModuleVersion. It records every module whose latest version depends
+// on the ModuleVersion's module with a constraint the ModuleVersion does not
+// satisfy.
+func (mv ModuleVersion) createDependencyImpacts(db *gorm.DB) error {
+	version, err := semver.Parse(mv.Version)
+	if err != nil {
+		return fmt.Errorf("failed to create dependency impact report: %w", err)
+	}
+
+	dependents, err := GetModuleDependents(db, mv.ModuleID)
+	if err != nil {
+		return err
+	}
+
+	var impacts []DependencyImpact
+	for _, d := range dependents {
+		constraint, err := semver.ParseConstraint(d.Constraint)
+		if err != nil {
+			return fmt.Errorf("failed to create dependency impact report: %w", err)
+		}
+
+		if !constraint.Check(version) {
+			impacts = append(impacts, DependencyImpact{
+				ModuleVersionID:   mv.ID,
+				DependentModuleID: d.DependentModuleID,
+				DependentVersion:  d.DependentVersion,
+				Constraint:        d.Constraint,
+			})
+		}
+	}
+
+	if len(impacts) == 0 {
+		return nil
+	}
+
+	if err := db.Create(&impacts).Error; err != nil {
+		return fmt.Errorf("failed to create dependency impact report: %w", err)
+	}
+
+	return nil
+}
+
+// queryDependencyEdges returns all the dependency edges, originating from the
+// latest non-yanked version of every module, that match the given condition.
+// The dependent module is aliased as 'dm' and the dependency module as 'm'.
+func queryDependencyEdges(db *gorm.DB, cond string, args ...interface{}) ([]DependencyEdge, error) {
+	edges := []DependencyEdge{}
+
+	err := db.Raw(fmt.Sprintf(`WITH latest AS (
+  SELECT DISTINCT
+    ON (module_id) id,
+    module_id,
+    version
+  FROM
+    module_versions
+  WHERE
+    yanked = FALSE
+    AND deleted_at IS NULL
+  ORDER BY
+    module_id,
+    %s
+)
+SELECT
+  dm.id AS dependent_module_id,
+  dm.team AS dependent_team,
+  dm.name AS dependent_name,
+  l.version AS dependent_version,
+  m.id AS dependency_module_id,
+  m.team AS dependency_team,
+  m.name AS dependency_name,
+  d.version_constraint AS "constraint"
+FROM
+  module_dependencies d
+  INNER JOIN
+    latest l
+    ON (d.module_version_id = l.id)
+  INNER JOIN
+    modules dm
+    ON (l.module_id = dm.id)
+  INNER JOIN
+    modules m
+    ON (d.dependency_id = m.id)
+WHERE
+  d.deleted_at IS NULL
+  AND dm.deleted_at IS NULL
+  AND m.deleted_at IS NULL
+  AND %s
+ORDER BY
+  dm.team,
+  dm.name,
+  m.team,
+  m.name;
+`, versionOrderBy, cond), args...).Scan(&edges).Error
+
+	return edges, err
+}
@@ -28,3 +28,11 @@ func NewNullInt64(i int64) sql.NullInt64 {
 
 	return sql.NullInt64{Int64: i, Valid: true}
 }
+
+func NewNullTime(t time.Time) sql.NullTime {
+	if t.IsZero() {
+		return sql.NullTime{}
+	}
+
+	return sql.NullTime{Time: t, Valid: true}
+}
@@ -22,6 +22,7 @@ import (
 
 	"github.com/cosmos/atlas/server/httputil"
 	"github.com/cosmos/atlas/server/models"
+	"github.com/cosmos/atlas/server/semver"
 )
 
 type ModelsTestSuite struct {
@@ -260,7 +261,7 @@ func (mts *ModelsTestSuite) TestGetModuleByID() {
 func (mts *ModelsTestSuite) TestGetAllModules() {
 	mts.resetDB()
 
-	mods, paginator, err := models.GetAllModules(mts.gormDB, httputil.PaginationQuery{Page: 1, Limit: 10, Order: "id"})
+	mods, paginator, err := models.GetAllModules(mts.gormDB, httputil.PaginationQuery{Page: 1, Limit: 10, Order: "id"}, nil)
 	mts.Require().NoError(err)
 	mts.Require().Empty(mods)
 	mts.Require().Zero(paginator.PrevPage)
@@ -292,7 +293,7 @@ func (mts *ModelsTestSuite) TestGetAllModules() {
 	}
 
 	// first page (full) ordered by newest
-	mods, paginator, err = models.GetAllModules(mts.gormDB, httputil.PaginationQuery{Page: 1, Limit: 10, Order: "created_at,id", Reverse: true})
+	mods, paginator, err = models.GetAllModules(mts.gormDB, httputil.PaginationQuery{Page: 1, Limit: 10, Order: "created_at,id", Reverse: true}, nil)
 	mts.Require().NoError(err)
 	mts.Require().Len(mods, 10)
 	mts.Require().Zero(paginator.PrevPage)
@@ -301,21 +302,21 @@ func (mts *ModelsTestSuite) TestGetAllModules() {
 	mts.Require().Equal(uint(16), mods[len(mods)-1].ID)
 
 	// first page (full)
-	mods, paginator, err = models.GetAllModules(mts.gormDB, httputil.PaginationQuery{Page: 1, Limit: 10, Order: "id"})
+	mods, paginator, err = models.GetAllModules(mts.gormDB, httputil.PaginationQuery{Page: 1, Limit: 10, Order: "id"}, nil)
 	mts.Require().NoError(err)
 	mts.Require().Len(mods, 10)
 	mts.Require().Zero(paginator.PrevPage)
 	mts.Require().Equal(int64(2), paginator.NextPage)
 
 	// second page (full)
-	mods, paginator, err = models.GetAllModules(mts.gormDB, httputil.PaginationQuery{Page: 2, Limit: 10, Order: "id"})
+	mods, paginator, err = models.GetAllModules(mts.gormDB, httputil.PaginationQuery{Page: 2, Limit: 10, Order: "id"}, nil)
 	mts.Require().NoError(err)
 	mts.Require().Len(mods, 10)
 	mts.Require().Equal(int64(1), paginator.PrevPage)
 	mts.Require().Equal(int64(3), paginator.NextPage)
 
 	// third page (partially full)
-	mods, paginator, err = models.GetAllModules(mts.gormDB, httputil.PaginationQuery{Page: 3, Limit: 10, Order: "id"})
+	mods, paginator, err = models.GetAllModules(mts.gormDB, httputil.PaginationQuery{Page: 3, Limit: 10, Order: "id"}, nil)
 	mts.Require().NoError(err)
 	mts.Require().Len(mods, 5)
 	mts.Require().Equal(int64(2), paginator.PrevPage)
@@ -601,6 +602,41 @@ func (mts *ModelsTestSuite) TestModuleUpdateVersion() {
 	mts.Require().NoError(err)
 	mts.Require().Equal(mod.Version.Version, latest.Version)
 	mts.Require().Equal(mod.Version.SDKCompat, latest.SDKCompat)
+
+	// publish a backport and a pre-release which must not become the latest
+	for _, v := range []string{"v0.9.3", "v2.0.0-rc.1"} {
+		mod.Version = models.ModuleVersion{
+			Version:       v,
+			Repo:          "https://github.com/cosmos/cosmos-sdk/releases/tag/v0.40.0",
+			Documentation: "https://raw.githubusercontent.com/cosmos/cosmos-sdk/v0.40.0/x/bank/README.md",
+		}
+
+		record, err = mod.Upsert(mts.gormDB)
+		mts.Require().NoError(err)
+	}
+
+	mts.Require().Len(record.Versions, 5)
+
+	latest, err = record.GetLatestVersion(mts.gormDB)
+	mts.Require().NoError(err)
+	mts.Require().Equal("v2.0.0", latest.Version)
+
+	versions := make([]string, len(record.Versions))
+	for i, v := range record.Versions {
+		versions[i] = v.Version
+	}
+
+	mts.Require().Equal([]string{"v2.0.0", "v2.0.0-rc.1", "v1.0.1", "v1.0.0", "v0.9.3"}, versions)
+
+	// publish an invalid version
+	mod.Version = models.ModuleVersion{
+		Version:       "v3",
+		Repo:          "https://github.com/cosmos/cosmos-sdk/releases/tag/v0.40.0",
+		Documentation: "https://raw.githubusercontent.com/cosmos/cosmos-sdk/v0.40.0/x/bank/README.md",
+	}
+
+	_, err = mod.Upsert(mts.gormDB)
+	mts.Require().Error(err)
 }
 
 func (mts *ModelsTestSuite) TestModuleSearch() {
@@ -622,7 +658,7 @@ func (mts *ModelsTestSuite) TestModuleSearch() {
 		{Name: "userD", Email: models.NewNullString("userd@email.com")},
 	}
 
-	mods, paginator, err := models.SearchModules(mts.gormDB, "test", httputil.PaginationQuery{Page: 1, Limit: 10, Order: "id"})
+	mods, paginator, err := models.SearchModules(mts.gormDB, "test", httputil.PaginationQuery{Page: 1, Limit: 10, Order: "id"}, nil)
 	mts.Require().NoError(err)
 	mts.Require().Empty(mods)
 	mts.Require().Zero(paginator.PrevPage)
@@ -720,7 +756,7 @@ func (mts *ModelsTestSuite) TestModuleSearch() {
 		tc := tc
 
 		mts.Run(tc.name, func() {
-			modules, paginator, err := models.SearchModules(mts.gormDB, tc.query, tc.pageQuery)
+			modules, paginator, err := models.SearchModules(mts.gormDB, tc.query, tc.pageQuery, nil)
 			mts.Require().NoError(err)
 			mts.Require().Len(modules, len(tc.expectedRecords))
 			mts.Require().Equal(tc.expectedPaginator, paginator)
@@ -748,7 +784,7 @@ func (mts *ModelsTestSuite) TestUserTokens() {
 	mts.Require().NoError(err)
 	mts.Require().Equal(int64(0), record.CountTokens(mts.gormDB))
 
-	token1, err := record.CreateToken(mts.gormDB, "dev")
+	token1, err := record.CreateToken(mts.gormDB, "dev", nil, sql.NullTime{})
 	mts.Require().NoError(err)
 	mts.Require().NotEmpty(token1.Token)
 
@@ -759,7 +795,7 @@ func (mts *ModelsTestSuite) TestUserTokens() {
 		mts.Require().Equal(uint(i+1), token1.Count)
 	}
 
-	token2, err := record.CreateToken(mts.gormDB, "prod")
+	token2, err := record.CreateToken(mts.gormDB, "prod", nil, sql.NullTime{})
 	mts.Require().NoError(err)
 	mts.Require().NotEmpty(token2.Token)
 
@@ -774,16 +810,107 @@ func (mts *ModelsTestSuite) TestUserTokens() {
 	mts.Require().NoError(err)
 	mts.Require().True(token2.Revoked)
 
-	token, err := models.QueryUserToken(mts.gormDB, map[string]interface{}{"token": token1.Token.String()})
+	token, err := models.AuthenticateUserToken(mts.gormDB, token1.Token.String())
 	mts.Require().NoError(err)
-	mts.Require().Equal(token1, token)
+	mts.Require().Equal(token1.ID, token.ID)
+	mts.Require().Equal(token1.Token.String()[:models.UserTokenPrefixLen], token.Prefix)
+	mts.Require().NotContains(token.Hash, token1.Token.String())
+	mts.Require().Equal(uuid.Nil, token.Token)
+
+	// revoked and unknown tokens
+	_, err = models.AuthenticateUserToken(mts.gormDB, token2.Token.String())
+	mts.Require().ErrorIs(err, gorm.ErrRecordNotFound)
+
+	_, err = models.AuthenticateUserToken(mts.gormDB, token1.Token.String()[:models.UserTokenPrefixLen]+"-0000-0000-0000-000000000000")
+	mts.Require().ErrorIs(err, gorm.ErrRecordNotFound)
 
 	// duplicate name
-	token3, err := record.CreateToken(mts.gormDB, "prod")
+	token3, err := record.CreateToken(mts.gormDB, "prod", nil, sql.NullTime{})
 	mts.Require().Error(err)
 	mts.Require().Equal(uuid.UUID{}, token3.Token)
 }
 
+func (mts *ModelsTestSuite) TestUserTokenScopes() {
+	mts.resetDB()
+
+	record, err := models.User{Name: "foo", GithubUserID: models.NewNullInt64(12345)}.Upsert(mts.gormDB)
+	mts.Require().NoError(err)
+
+	// invalid scopes
+	_, err = record.CreateToken(mts.gormDB, "invalid", []string{"admin"}, sql.NullTime{})
+	mts.Require().Error(err)
+
+	_, err = record.CreateToken(mts.gormDB, "invalid", []string{"publish:module/foo"}, sql.NullTime{})
+	mts.Require().Error(err)
+
+	// default scopes
+	token, err := record.CreateToken(mts.gormDB, "cli", nil, sql.NullTime{})
+	mts.Require().NoError(err)
+	mts.Require().ElementsMatch(models.DefaultTokenScopes, token.Scopes)
+	mts.Require().True(token.HasScope(models.TokenScopeRead))
+	mts.Require().True(token.HasScope(models.PublishModuleScope(1)))
+	mts.Require().False(token.HasScope(models.TokenScopeTokens))
+	mts.Require().True(token.CanPublish())
+	mts.Require().False(token.Expired())
+
+	// module specific publish scope
+	token, err = record.CreateToken(mts.gormDB, "ci", []string{models.PublishModuleScope(1)}, models.NewNullTime(time.Now().Add(time.Hour)))
+	mts.Require().NoError(err)
+
+	token, err = models.AuthenticateUserToken(mts.gormDB, token.Token.String())
+	mts.Require().NoError(err)
+	mts.Require().True(token.HasScope(models.PublishModuleScope(1)))
+	mts.Require().False(token.HasScope(models.PublishModuleScope(2)))
+	mts.Require().False(token.HasScope(models.TokenScopePublish))
+	mts.Require().False(token.HasScope(models.TokenScopeRead))
+	mts.Require().True(token.CanPublish())
+	mts.Require().True(token.ExpiresAt.Valid)
+	mts.Require().False(token.Expired())
+
+	// expired token
+	token, err = record.CreateToken(mts.gormDB, "expired", []string{models.TokenScopeRead}, models.NewNullTime(time.Now().Add(-time.Hour)))
+	mts.Require().NoError(err)
+	mts.Require().True(token.Expired())
+	mts.Require().False(token.CanPublish())
+}
+
+func (mts *ModelsTestSuite) TestUserTokenUsage() {
+	mts.resetDB()
+
+	record, err := models.User{Name: "foo", GithubUserID: models.NewNullInt64(12345)}.Upsert(mts.gormDB)
+	mts.Require().NoError(err)
+
+	token, err := record.CreateToken(mts.gormDB, "cli", nil, sql.NullTime{})
+	mts.Require().NoError(err)
+	mts.Require().False(token.LastUsedAt.Valid)
+
+	for i := 0; i < 3; i++ {
+		token, err = token.RecordUsage(mts.gormDB, models.UserTokenUsage{
+			IP:        "192.0.2.1",
+			UserAgent: "atlas-cli",
+			Method:    "PUT",
+			Path:      fmt.Sprintf("/api/v1/modules/%d", i),
+		})
+		mts.Require().NoError(err)
+		mts.Require().Equal(uint(i+1), token.Count)
+		mts.Require().True(token.LastUsedAt.Valid)
+	}
+
+	token, err = models.QueryUserToken(mts.gormDB, map[string]interface{}{"id": token.ID})
+	mts.Require().NoError(err)
+	mts.Require().Equal(uint(3), token.Count)
+	mts.Require().True(token.LastUsedAt.Valid)
+
+	usage, paginator, err := token.GetUsage(mts.gormDB, httputil.PaginationQuery{Page: 1, Limit: 2, Order: "id", Reverse: true})
+	mts.Require().NoError(err)
+	mts.Require().Equal(int64(3), paginator.Total)
+	mts.Require().Equal(int64(2), paginator.NextPage)
+	mts.Require().Len(usage, 2)
+	mts.Require().Equal("/api/v1/modules/2", usage[0].Path)
+	mts.Require().Equal(token.ID, usage[0].UserTokenID)
+	mts.Require().Equal("192.0.2.1", usage[0].IP)
+}
+
 func (mts *ModelsTestSuite) TestUserUpsert() {
 	mts.resetDB()
 
@@ -1147,216 +1274,1219 @@ func (mts *ModelsTestSuite) TestModuleStar() {
 	mts.Require().False(ok)
 }
 
-func (mts *ModelsTestSuite) TestUserEmailConfirmation_Upsert() {
+func (mts *ModelsTestSuite) TestModuleTrustPolicies() {
 	mts.resetDB()
 
-	mod := models.Module{
-		Name: "x/bank",
-		Team: "cosmonauts",
-		Owners: []models.User{
-			{Name: "foo"},
-		},
-		Authors: []models.User{
-			{Name: "foo"},
-		},
-		Version: models.ModuleVersion{
-			Version:       "v1.0.0",
-			Repo:          "https://github.com/cosmos/cosmos-sdk/releases/tag/v0.39.1",
-			Documentation: "https://raw.githubusercontent.com/cosmos/cosmos-sdk/v0.39.1/x/bank/README.md",
-		},
-		Keywords: []models.Keyword{
-			{Name: "tokens"}, {Name: "transfer"},
-		},
-		BugTracker: models.BugTracker{
-			URL:     models.NewNullString("cosmonauts.com"),
-			Contact: models.NewNullString("contact@cosmonauts.com"),
-		},
+	user, err := models.User{Name: "foo", GithubUserID: models.NewNullInt64(12345)}.Upsert(mts.gormDB)
+	mts.Require().NoError(err)
+
+	modules := make([]models.Module, 2)
+	for i, name := range []string{"x/bank", "x/staking"} {
+		mod := models.Module{
+			Name:    name,
+			Team:    "cosmonauts",
+			Owners:  []models.User{user},
+			Authors: []models.User{user},
+			Version: models.ModuleVersion{
+				Version:       "v1.0.0",
+				Repo:          "https://github.com/cosmos/cosmos-sdk/releases/tag/v0.39.1",
+				Documentation: "https://raw.githubusercontent.com/cosmos/cosmos-sdk/v0.39.1/x/bank/README.md",
+				PublishedBy:   user.ID,
+			},
+		}
+
+		modules[i], err = mod.Upsert(mts.gormDB)
+		mts.Require().NoError(err)
 	}
 
-	// create module
-	mod, err := mod.Upsert(mts.gormDB)
+	// the workflow of a monorepo is trusted by both modules
+	for _, mod := range modules {
+		_, err := mod.AddTrustPolicy(mts.gormDB, user, "Cosmonauts/SDK", "release.yml")
+		mts.Require().NoError(err)
+	}
+
+	// registering an existing policy has no effect
+	policy, err := modules[0].AddTrustPolicy(mts.gormDB, user, "cosmonauts/sdk", "release.yml")
 	mts.Require().NoError(err)
 
-	user, err := models.GetUserByID(mts.gormDB, mod.Owners[0].ID)
+	policies, err := modules[0].GetTrustPolicies(mts.gormDB)
 	mts.Require().NoError(err)
-	mts.Require().False(user.EmailConfirmed)
+	mts.Require().Len(policies, 1)
+	mts.Require().Equal(policy.ID, policies[0].ID)
+	mts.Require().Equal("cosmonauts/sdk", policies[0].Repository)
 
-	uec, err := models.UserEmailConfirmation{UserID: mod.Owners[0].ID, Email: "foo@email.com"}.Upsert(mts.gormDB)
-	token1 := uec.Token
+	_, err = models.CreateTrustedPublishToken(mts.gormDB, "cosmonauts/sdk", "ci.yml", time.Now().Add(time.Minute))
+	mts.Require().ErrorIs(err, gorm.ErrRecordNotFound)
+
+	token, err := models.CreateTrustedPublishToken(mts.gormDB, "cosmonauts/SDK", "release.yml", time.Now().Add(time.Minute))
 	mts.Require().NoError(err)
-	mts.Require().NotEqual(uuid.UUID{}, token1)
-	mts.Require().Equal("foo@email.com", uec.Email)
+	mts.Require().NotEmpty(token.Token)
+	mts.Require().True(token.TrustedPublishing)
+	mts.Require().Equal(user.ID, token.UserID)
+	mts.Require().True(token.CanPublish())
+	mts.Require().False(token.HasScope(models.TokenScopePublish))
+	mts.Require().True(token.HasScope(models.PublishModuleScope(modules[0].ID)))
+	mts.Require().True(token.HasScope(models.PublishModuleScope(modules[1].ID)))
+	mts.Require().Equal(int64(0), user.CountTokens(mts.gormDB))
 
-	uec, err = models.UserEmailConfirmation{UserID: mod.Owners[0].ID, Email: "bar@email.com"}.Upsert(mts.gormDB)
-	token2 := uec.Token
+	record, err := models.AuthenticateUserToken(mts.gormDB, token.Token.String())
 	mts.Require().NoError(err)
-	mts.Require().NotEqual(uuid.UUID{}, token2)
-	mts.Require().NotEqual(token1, token2)
-	mts.Require().Equal("bar@email.com", uec.Email)
+	mts.Require().True(record.TrustedPublishing)
 
-	var count int64
-	mts.Require().NoError(mts.gormDB.Table("user_email_confirmations").Count(&count).Error)
-	mts.Require().Equal(int64(1), count)
-}
+	// removing a policy narrows the modules the workflow may publish
+	stakingPolicies, err := modules[1].GetTrustPolicies(mts.gormDB)
+	mts.Require().NoError(err)
+	mts.Require().Len(stakingPolicies, 1)
+	mts.Require().NoError(modules[1].RemoveTrustPolicy(mts.gormDB, user, stakingPolicies[0].ID))
 
-func (mts *ModelsTestSuite) TestQueryUserEmailConfirmation() {
-	mts.resetDB()
+	token, err = models.CreateTrustedPublishToken(mts.gormDB, "cosmonauts/sdk", "release.yml", time.Now().Add(time.Minute))
+	mts.Require().NoError(err)
+	mts.Require().Equal([]string{models.PublishModuleScope(modules[0].ID)}, []string(token.Scopes))
 
-	mod := models.Module{
-		Name: "x/bank",
-		Team: "cosmonauts",
-		Owners: []models.User{
-			{Name: "foo", Email: models.NewNullString("foo@cosmonauts.com")},
-		},
-		Authors: []models.User{
-			{Name: "foo", Email: models.NewNullString("foo@cosmonauts.com")},
-		},
-		Version: models.ModuleVersion{
-			Version:       "v1.0.0",
-			Repo:          "https://github.com/cosmos/cosmos-sdk/releases/tag/v0.39.1",
-			Documentation: "https://raw.githubusercontent.com/cosmos/cosmos-sdk/v0.39.1/x/bank/README.md",
-		},
-		Keywords: []models.Keyword{
-			{Name: "tokens"}, {Name: "transfer"},
-		},
-		BugTracker: models.BugTracker{
-			URL:     models.NewNullString("cosmonauts.com"),
-			Contact: models.NewNullString("contact@cosmonauts.com"),
-		},
-	}
+	mts.Require().ErrorIs(modules[1].RemoveTrustPolicy(mts.gormDB, user, policies[0].ID), gorm.ErrRecordNotFound)
 
-	// create module
-	mod, err := mod.Upsert(mts.gormDB)
+	// transferring ownership removes the policies of former owners, such that
+	// the new owner may trust the workflow
+	newOwner, err := models.User{Name: "bar", GithubUserID: models.NewNullInt64(67890)}.Upsert(mts.gormDB)
 	mts.Require().NoError(err)
 
-	user, err := models.GetUserByID(mts.gormDB, mod.Owners[0].ID)
+	_, err = modules[0].TransferToUser(mts.gormDB, user, newOwner)
 	mts.Require().NoError(err)
-	mts.Require().False(user.EmailConfirmed)
 
-	query := map[string]interface{}{"user_id": mod.Owners[0].ID}
+	policies, err = modules[0].GetTrustPolicies(mts.gormDB)
+	mts.Require().NoError(err)
+	mts.Require().Empty(policies)
 
-	uec, err := models.QueryUserEmailConfirmation(mts.gormDB, query)
-	mts.Require().Error(err)
-	mts.Require().Empty(uec)
+	_, err = models.CreateTrustedPublishToken(mts.gormDB, "cosmonauts/sdk", "release.yml", time.Now().Add(time.Minute))
+	mts.Require().ErrorIs(err, gorm.ErrRecordNotFound)
 
-	uec, err = models.UserEmailConfirmation{UserID: mod.Owners[0].ID, Email: "foo@email.com"}.Upsert(mts.gormDB)
-	token := uec.Token
+	_, err = modules[0].AddTrustPolicy(mts.gormDB, newOwner, "cosmonauts/sdk", "release.yml")
 	mts.Require().NoError(err)
-	mts.Require().NotEqual(uuid.UUID{}, token)
-	mts.Require().Equal("foo@email.com", uec.Email)
 
-	uec, err = models.QueryUserEmailConfirmation(mts.gormDB, query)
+	// removing an owner removes their policies
+	mod, err := models.GetModuleByID(mts.gormDB, modules[0].ID)
 	mts.Require().NoError(err)
-	mts.Require().Equal(mod.Owners[0].ID, uec.UserID)
-	mts.Require().Equal(token, uec.Token)
-	mts.Require().Equal("foo@email.com", uec.Email)
+
+	mod, err = mod.AddOwner(mts.gormDB, user)
+	mts.Require().NoError(err)
+	mts.Require().Len(mod.Owners, 2)
+
+	_, err = mod.RemoveOwner(mts.gormDB, user, newOwner)
+	mts.Require().NoError(err)
+
+	policies, err = modules[0].GetTrustPolicies(mts.gormDB)
+	mts.Require().NoError(err)
+	mts.Require().Empty(policies)
 }
 
-func (mts *ModelsTestSuite) TestUser_ConfirmEmail() {
+func (mts *ModelsTestSuite) TestAuditEvents() {
 	mts.resetDB()
 
+	user, err := models.User{Name: "foo", GithubUserID: models.NewNullInt64(12345)}.Upsert(mts.gormDB)
+	mts.Require().NoError(err)
+
 	mod := models.Module{
-		Name: "x/bank",
-		Team: "cosmonauts",
-		Owners: []models.User{
-			{Name: "foo", Email: models.NewNullString("foo@cosmonauts.com")},
-		},
-		Authors: []models.User{
-			{Name: "foo", Email: models.NewNullString("foo@cosmonauts.com")},
-		},
+		Name:    "x/bank",
+		Team:    "cosmonauts",
+		Owners:  []models.User{user},
+		Authors: []models.User{user},
 		Version: models.ModuleVersion{
 			Version:       "v1.0.0",
 			Repo:          "https://github.com/cosmos/cosmos-sdk/releases/tag/v0.39.1",
 			Documentation: "https://raw.githubusercontent.com/cosmos/cosmos-sdk/v0.39.1/x/bank/README.md",
-		},
-		Keywords: []models.Keyword{
-			{Name: "tokens"}, {Name: "transfer"},
-		},
-		BugTracker: models.BugTracker{
-			URL:     models.NewNullString("cosmonauts.com"),
-			Contact: models.NewNullString("contact@cosmonauts.com"),
+			PublishedBy:   user.ID,
 		},
 	}
 
-	// create module
-	mod, err := mod.Upsert(mts.gormDB)
+	mod, err = mod.Upsert(mts.gormDB)
 	mts.Require().NoError(err)
 
-	user, err := models.GetUserByID(mts.gormDB, mod.Owners[0].ID)
+	token, err := user.CreateToken(mts.gormDB, "cli", nil, sql.NullTime{})
 	mts.Require().NoError(err)
-	mts.Require().False(user.EmailConfirmed)
-	mts.Require().Equal("foo@cosmonauts.com", user.Email.String)
 
-	uec, err := models.UserEmailConfirmation{UserID: mod.Owners[0].ID, Email: "foo@email.com"}.Upsert(mts.gormDB)
-	token := uec.Token
+	_, err = token.Revoke(mts.gormDB)
 	mts.Require().NoError(err)
-	mts.Require().NotEqual(uuid.UUID{}, token)
-	mts.Require().Equal("foo@email.com", uec.Email)
 
-	user, err = user.ConfirmEmail(mts.gormDB, uec)
+	_, err = mod.Star(mts.gormDB, user.ID)
 	mts.Require().NoError(err)
-	mts.Require().True(user.EmailConfirmed)
-	mts.Require().Equal("foo@email.com", user.Email.String)
 
-	query := map[string]interface{}{"user_id": mod.Owners[0].ID}
-	uec, err = models.QueryUserEmailConfirmation(mts.gormDB, query)
-	mts.Require().Error(err)
-	mts.Require().Empty(uec)
+	mv, err := models.QueryModuleVersion(mts.gormDB, map[string]interface{}{"module_id": mod.ID, "version": "v1.0.0"})
+	mts.Require().NoError(err)
+
+	mv, err = mv.Yank(mts.gormDB, user.ID, "broken")
+	mts.Require().NoError(err)
+
+	_, err = mv.UnYank(mts.gormDB, user.ID)
+	mts.Require().NoError(err)
+
+	pq := httputil.PaginationQuery{Page: 1, Limit: 10, Order: "id"}
+
+	events, paginator, err := models.GetAuditEvents(mts.gormDB, map[string]interface{}{"actor_id": user.ID}, pq)
+	mts.Require().NoError(err)
+	mts.Require().Equal(int64(7), paginator.Total)
+
+	actions := make([]string, len(events))
+	for i, e := range events {
+		actions[i] = e.Action
+	}
+
+	mts.Require().Equal([]string{
+		models.AuditActionModulePublish,
+		"module." + models.OwnerEventOwnerAdded,
+		models.AuditActionTokenCreate,
+		models.AuditActionTokenRevoke,
+		models.AuditActionModuleStar,
+		models.AuditActionModuleYank,
+		models.AuditActionModuleUnYank,
+	}, actions)
+
+	// events are chained
+	mts.Require().Empty(events[0].PrevHash)
+	for i := 1; i < len(events); i++ {
+		mts.Require().Equal(events[i-1].Hash, events[i].PrevHash)
+	}
+
+	mts.Require().JSONEq(`{"version":"v1.0.0"}`, events[0].Data)
+	mts.Require().Equal(int64(mod.ID), events[0].ModuleID.Int64)
+	mts.Require().False(events[2].ModuleID.Valid)
+	mts.Require().JSONEq(`{"version":"v1.0.0","reason":"broken"}`, events[5].Data)
+
+	events, _, err = models.GetAuditEvents(mts.gormDB, map[string]interface{}{"module_id": mod.ID}, pq)
+	mts.Require().NoError(err)
+	mts.Require().Len(events, 5)
+
+	mts.Require().NoError(models.VerifyAuditEvents(mts.gormDB))
+
+	// audit events are append-only
+	mts.Require().Error(mts.gormDB.Exec("UPDATE audit_events SET data = '{}' WHERE id = ?", events[0].ID).Error)
+	mts.Require().Error(mts.gormDB.Exec("DELETE FROM audit_events WHERE id = ?", events[0].ID).Error)
+
+	// tampering is detected
+	mts.Require().NoError(mts.gormDB.Exec("ALTER TABLE audit_events DISABLE TRIGGER audit_events_append_only").Error)
+	mts.Require().NoError(mts.gormDB.Exec(`UPDATE audit_events SET data = '{"version":"v0.1.0"}' WHERE id = ?`, events[0].ID).Error)
+	mts.Require().NoError(mts.gormDB.Exec("ALTER TABLE audit_events ENABLE TRIGGER audit_events_append_only").Error)
+
+	mts.Require().ErrorIs(models.VerifyAuditEvents(mts.gormDB), models.ErrAuditChainBroken)
 }
 
-func (mts *ModelsTestSuite) TestModuleOwnerInvite_Upsert() {
+func (mts *ModelsTestSuite) TestModuleVersionYank() {
 	mts.resetDB()
 
 	mod := models.Module{
 		Name: "x/bank",
 		Team: "cosmonauts",
-		Owners: []models.User{
-			{Name: "foo"},
-		},
 		Authors: []models.User{
-			{Name: "bar"},
+			{Name: "admin"},
 		},
-		Version: models.ModuleVersion{
-			Version:       "v1.0.0",
+		Owners: []models.User{
+			{Name: "admin"},
+		},
+		BugTracker: models.BugTracker{},
+	}
+
+	var (
+		record models.Module
+		err    error
+	)
+
+	for _, v := range []string{"v1.0.0", "v1.1.0"} {
+		mod.Version = models.ModuleVersion{
+			Version:       v,
 			Repo:          "https://github.com/cosmos/cosmos-sdk/releases/tag/v0.39.1",
 			Documentation: "https://raw.githubusercontent.com/cosmos/cosmos-sdk/v0.39.1/x/bank/README.md",
-		},
-		Keywords: []models.Keyword{
-			{Name: "tokens"}, {Name: "transfer"},
-		},
-		BugTracker: models.BugTracker{
-			URL:     models.NewNullString("cosmonauts.com"),
-			Contact: models.NewNullString("contact@cosmonauts.com"),
-		},
+		}
+
+		record, err = mod.Upsert(mts.gormDB)
+		mts.Require().NoError(err)
 	}
 
-	// create module
-	mod, err := mod.Upsert(mts.gormDB)
+	mv, err := models.QueryModuleVersion(mts.gormDB, map[string]interface{}{"module_id": record.ID, "version": "v1.1.0"})
 	mts.Require().NoError(err)
+	mts.Require().False(mv.Yanked)
 
-	owner, err := models.GetUserByID(mts.gormDB, mod.Owners[0].ID)
+	// yank the latest version
+	mv, err = mv.Yank(mts.gormDB, mv.PublishedBy, "broken state migration")
+	mts.Require().NoError(err)
+	mts.Require().True(mv.Yanked)
+	mts.Require().Equal(models.NewNullString("broken state migration"), mv.YankReason)
+
+	latest, err := record.GetLatestVersion(mts.gormDB)
+	mts.Require().NoError(err)
+	mts.Require().Equal("v1.0.0", latest.Version)
+
+	// ensure the yanked version remains accessible and re-publishing it does
+	// not revert the yank
+	mod.Version = models.ModuleVersion{
+		Version:       "v1.1.0",
+		Repo:          "https://github.com/cosmos/cosmos-sdk/releases/tag/v0.39.1",
+		Documentation: "https://raw.githubusercontent.com/cosmos/cosmos-sdk/v0.39.1/x/bank/README.md",
+	}
+
+	record, err = mod.Upsert(mts.gormDB)
+	mts.Require().NoError(err)
+	mts.Require().Len(record.Versions, 2)
+
+	mv, err = models.QueryModuleVersion(mts.gormDB, map[string]interface{}{"module_id": record.ID, "version": "v1.1.0"})
+	mts.Require().NoError(err)
+	mts.Require().True(mv.Yanked)
+	mts.Require().Equal("broken state migration", mv.YankReason.String)
+
+	// un-yank the version
+	mv, err = mv.UnYank(mts.gormDB, mv.PublishedBy)
+	mts.Require().NoError(err)
+	mts.Require().False(mv.Yanked)
+	mts.Require().False(mv.YankReason.Valid)
+
+	latest, err = record.GetLatestVersion(mts.gormDB)
+	mts.Require().NoError(err)
+	mts.Require().Equal("v1.1.0", latest.Version)
+
+	// ensure no latest version exists when all versions are yanked
+	for _, v := range record.Versions {
+		_, err = v.Yank(mts.gormDB, v.PublishedBy, "deprecated")
+		mts.Require().NoError(err)
+	}
+
+	_, err = record.GetLatestVersion(mts.gormDB)
+	mts.Require().Error(err)
+}
+
+func (mts *ModelsTestSuite) TestModuleSDKCompat() {
+	mts.resetDB()
+
+	newModule := func(name, description string, versions map[string]string) models.Module {
+		mod := models.Module{
+			Name:        name,
+			Team:        "cosmonauts",
+			Description: description,
+			Authors: []models.User{
+				{Name: "admin"},
+			},
+			Owners: []models.User{
+				{Name: "admin"},
+			},
+			BugTracker: models.BugTracker{},
+		}
+
+		var (
+			record models.Module
+			err    error
+		)
+
+		for version, sdkCompat := range versions {
+			mod.Version = models.ModuleVersion{
+				Version:   version,
+				Repo:      "https://github.com/cosmos/cosmos-sdk",
+				SDKCompat: models.NewNullString(sdkCompat),
+			}
+
+			record, err = mod.Upsert(mts.gormDB)
+			mts.Require().NoError(err)
+		}
+
+		return record
+	}
+
+	bank := newModule("x/bank", "tokens", map[string]string{"v1.0.0": "v0.39.x", "v2.0.0": ">=0.40, <0.43"})
+	staking := newModule("x/staking", "tokens and validators", map[string]string{"v1.0.0": ">=0.42.1"})
+	gov := newModule("x/gov", "proposals", map[string]string{"v1.0.0": ""})
+
+	// an invalid SDK compatibility constraint must be rejected
+	invalid := bank
+	invalid.Version = models.ModuleVersion{
+		Version:   "v3.0.0",
+		Repo:      "https://github.com/cosmos/cosmos-sdk",
+		SDKCompat: models.NewNullString("stargate"),
+	}
+
+	_, err := invalid.Upsert(mts.gormDB)
+	mts.Require().Error(err)
+
+	pq := httputil.PaginationQuery{Page: 1, Limit: 10, Order: "id"}
+
+	testCases := []struct {
+		sdkVersion string
+		expected   []uint
+	}{
+		{"v0.39.2", []uint{bank.ID}},
+		{"v0.40.0", []uint{bank.ID}},
+		{"v0.42.3", []uint{bank.ID, staking.ID}},
+		{"v0.42.0", []uint{bank.ID}},
+		{"v0.43.0", []uint{staking.ID}},
+		{"v0.43.0-rc.1", []uint{staking.ID}},
+		{"v0.44.0", []uint{staking.ID}},
+		{"v1.0.0", []uint{staking.ID}},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+
+		mts.Run(tc.sdkVersion, func() {
+			sdkVersion := semver.MustParse(tc.sdkVersion)
+
+			mods, paginator, err := models.GetAllModules(mts.gormDB, pq, &sdkVersion)
+			mts.Require().NoError(err)
+			mts.Require().Equal(int64(len(tc.expected)), paginator.Total)
+
+			ids := []uint{}
+			for _, m := range mods {
+				ids = append(ids, m.ID)
+			}
+
+			mts.Require().Equal(tc.expected, ids)
+		})
+	}
+
+	// ensure all modules are returned without an SDK version
+	mods, _, err := models.GetAllModules(mts.gormDB, pq, nil)
+	mts.Require().NoError(err)
+	mts.Require().Len(mods, 3)
+	mts.Require().Equal(gov.ID, mods[2].ID)
+
+	// ensure search results are filtered by SDK version
+	sdkVersion := semver.MustParse("v0.43.1")
+
+	mods, paginator, err := models.SearchModules(mts.gormDB, "tokens", pq, &sdkVersion)
+	mts.Require().NoError(err)
+	mts.Require().Equal(int64(1), paginator.Total)
+	mts.Require().Len(mods, 1)
+	mts.Require().Equal(staking.ID, mods[0].ID)
+
+	// ensure modules compatible only via yanked versions are excluded
+	mv, err := models.QueryModuleVersion(mts.gormDB, map[string]interface{}{"module_id": staking.ID, "version": "v1.0.0"})
+	mts.Require().NoError(err)
+
+	_, err = mv.Yank(mts.gormDB, mv.PublishedBy, "broken")
+	mts.Require().NoError(err)
+
+	mods, paginator, err = models.SearchModules(mts.gormDB, "tokens", pq, &sdkVersion)
+	mts.Require().NoError(err)
+	mts.Require().Equal(int64(0), paginator.Total)
+	mts.Require().Empty(mods)
+}
+
+func (mts *ModelsTestSuite) TestModuleDependencies() {
+	mts.resetDB()
+
+	staking := models.Module{
+		Name: "x/staking",
+		Team: "cosmonauts",
+		Authors: []models.User{
+			{Name: "admin"},
+		},
+		Owners: []models.User{
+			{Name: "admin"},
+		},
+		Version: models.ModuleVersion{
+			Version: "v1.0.0",
+			Repo:    "https://github.com/cosmos/cosmos-sdk",
+		},
+		BugTracker: models.BugTracker{},
+	}
+
+	staking, err := staking.Upsert(mts.gormDB)
+	mts.Require().NoError(err)
+
+	poa := models.Module{
+		Name: "x/poa",
+		Team: "cosmonauts",
+		Authors: []models.User{
+			{Name: "admin"},
+		},
+		Owners: []models.User{
+			{Name: "admin"},
+		},
+		Version: models.ModuleVersion{
+			Version: "v1.0.0",
+			Repo:    "https://github.com/cosmos/cosmos-sdk",
+			Dependencies: []models.ModuleDependency{
+				{DependencyID: staking.ID, Constraint: "^1.0.0"},
+			},
+		},
+		BugTracker: models.BugTracker{},
+	}
+
+	record, err := poa.Upsert(mts.gormDB)
+	mts.Require().NoError(err)
+
+	expected := models.DependencyEdge{
+		DependentModuleID:  record.ID,
+		DependentTeam:      "cosmonauts",
+		DependentName:      "x/poa",
+		DependentVersion:   "v1.0.0",
+		DependencyModuleID: staking.ID,
+		DependencyTeam:     "cosmonauts",
+		DependencyName:     "x/staking",
+		Constraint:         "^1.0.0",
+	}
+
+	deps, err := models.GetModuleDependencies(mts.gormDB, record.ID)
+	mts.Require().NoError(err)
+	mts.Require().Equal([]models.DependencyEdge{expected}, deps)
+
+	dependents, err := models.GetModuleDependents(mts.gormDB, staking.ID)
+	mts.Require().NoError(err)
+	mts.Require().Equal([]models.DependencyEdge{expected}, dependents)
+
+	deps, err = models.GetModuleDependencies(mts.gormDB, staking.ID)
+	mts.Require().NoError(err)
+	mts.Require().Empty(deps)
+
+	// publish a new version without any dependencies
+	poa.Version = models.ModuleVersion{
+		Version: "v1.1.0",
+		Repo:    "https://github.com/cosmos/cosmos-sdk",
+	}
+
+	_, err = poa.Upsert(mts.gormDB)
+	mts.Require().NoError(err)
+
+	// ensure only the latest version's dependencies are considered
+	deps, err = models.GetModuleDependencies(mts.gormDB, record.ID)
+	mts.Require().NoError(err)
+	mts.Require().Empty(deps)
+
+	dependents, err = models.GetModuleDependents(mts.gormDB, staking.ID)
+	mts.Require().NoError(err)
+	mts.Require().Empty(dependents)
+
+	// yank the latest version such that the previous version is the latest
+	mv, err := models.QueryModuleVersion(mts.gormDB, map[string]interface{}{"module_id": record.ID, "version": "v1.1.0"})
+	mts.Require().NoError(err)
+
+	_, err = mv.Yank(mts.gormDB, mv.PublishedBy, "missing dependency")
+	mts.Require().NoError(err)
+
+	dependents, err = models.GetModuleDependents(mts.gormDB, staking.ID)
+	mts.Require().NoError(err)
+	mts.Require().Equal([]models.DependencyEdge{expected}, dependents)
+}
+
+func (mts *ModelsTestSuite) TestDependencyImpactReport() {
+	mts.resetDB()
+
+	newModule := func(name, version string, deps ...models.ModuleDependency) models.Module {
+		return models.Module{
+			Name: name,
+			Team: "cosmonauts",
+			Authors: []models.User{
+				{Name: "admin"},
+			},
+			Owners: []models.User{
+				{Name: "admin"},
+			},
+			Version: models.ModuleVersion{
+				Version:      version,
+				Repo:         "https://github.com/cosmos/cosmos-sdk",
+				Dependencies: deps,
+			},
+			BugTracker: models.BugTracker{},
+		}
+	}
+
+	staking, err := newModule("x/staking", "v1.0.0").Upsert(mts.gormDB)
+	mts.Require().NoError(err)
+
+	poa, err := newModule("x/poa", "v1.0.0", models.ModuleDependency{DependencyID: staking.ID, Constraint: "^1.0.0"}).Upsert(mts.gormDB)
+	mts.Require().NoError(err)
+
+	gov, err := newModule("x/gov", "v0.3.0", models.ModuleDependency{DependencyID: staking.ID, Constraint: ">=1.0.0"}).Upsert(mts.gormDB)
+	mts.Require().NoError(err)
+
+	testCases := []struct {
+		version  string
+		expected []models.DependencyEdge
+	}{
+		{"v1.1.0", []models.DependencyEdge{}},
+		{
+			"v2.0.0",
+			[]models.DependencyEdge{
+				{
+					DependentModuleID:  poa.ID,
+					DependentTeam:      "cosmonauts",
+					DependentName:      "x/poa",
+					DependentVersion:   "v1.0.0",
+					DependencyModuleID: staking.ID,
+					DependencyTeam:     "cosmonauts",
+					DependencyName:     "x/staking",
+					DependencyVersion:  "v2.0.0",
+					Constraint:         "^1.0.0",
+				},
+			},
+		},
+		{
+			"v0.9.0",
+			[]models.DependencyEdge{
+				{
+					DependentModuleID:  gov.ID,
+					DependentTeam:      "cosmonauts",
+					DependentName:      "x/gov",
+					DependentVersion:   "v0.3.0",
+					DependencyModuleID: staking.ID,
+					DependencyTeam:     "cosmonauts",
+					DependencyName:     "x/staking",
+					DependencyVersion:  "v0.9.0",
+					Constraint:         ">=1.0.0",
+				},
+				{
+					DependentModuleID:  poa.ID,
+					DependentTeam:      "cosmonauts",
+					DependentName:      "x/poa",
+					DependentVersion:   "v1.0.0",
+					DependencyModuleID: staking.ID,
+					DependencyTeam:     "cosmonauts",
+					DependencyName:     "x/staking",
+					DependencyVersion:  "v0.9.0",
+					Constraint:         "^1.0.0",
+				},
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+
+		mts.Run(tc.version, func() {
+			_, err := newModule("x/staking", tc.version).Upsert(mts.gormDB)
+			mts.Require().NoError(err)
+
+			mv, err := models.QueryModuleVersion(mts.gormDB, map[string]interface{}{"module_id": staking.ID, "version": tc.version})
+			mts.Require().NoError(err)
+
+			report, err := models.GetDependencyImpactReport(mts.gormDB, mv.ID)
+			mts.Require().NoError(err)
+			mts.Require().Equal(tc.expected, report)
+		})
+	}
+
+	// ensure the report of the initial version is empty
+	mv, err := models.QueryModuleVersion(mts.gormDB, map[string]interface{}{"module_id": staking.ID, "version": "v1.0.0"})
+	mts.Require().NoError(err)
+
+	report, err := models.GetDependencyImpactReport(mts.gormDB, mv.ID)
+	mts.Require().NoError(err)
+	mts.Require().Empty(report)
+}
+
+func (mts *ModelsTestSuite) TestUserEmailConfirmation_Upsert() {
+	mts.resetDB()
+
+	mod := models.Module{
+		Name: "x/bank",
+		Team: "cosmonauts",
+		Owners: []models.User{
+			{Name: "foo"},
+		},
+		Authors: []models.User{
+			{Name: "foo"},
+		},
+		Version: models.ModuleVersion{
+			Version:       "v1.0.0",
+			Repo:          "https://github.com/cosmos/cosmos-sdk/releases/tag/v0.39.1",
+			Documentation: "https://raw.githubusercontent.com/cosmos/cosmos-sdk/v0.39.1/x/bank/README.md",
+		},
+		Keywords: []models.Keyword{
+			{Name: "tokens"}, {Name: "transfer"},
+		},
+		BugTracker: models.BugTracker{
+			URL:     models.NewNullString("cosmonauts.com"),
+			Contact: models.NewNullString("contact@cosmonauts.com"),
+		},
+	}
+
+	// create module
+	mod, err := mod.Upsert(mts.gormDB)
+	mts.Require().NoError(err)
+
+	user, err := models.GetUserByID(mts.gormDB, mod.Owners[0].ID)
+	mts.Require().NoError(err)
+	mts.Require().False(user.EmailConfirmed)
+
+	uec, err := models.UserEmailConfirmation{UserID: mod.Owners[0].ID, Email: "foo@email.com"}.Upsert(mts.gormDB)
+	token1 := uec.Token
+	mts.Require().NoError(err)
+	mts.Require().NotEqual(uuid.UUID{}, token1)
+	mts.Require().Equal("foo@email.com", uec.Email)
+
+	uec, err = models.UserEmailConfirmation{UserID: mod.Owners[0].ID, Email: "bar@email.com"}.Upsert(mts.gormDB)
+	token2 := uec.Token
+	mts.Require().NoError(err)
+	mts.Require().NotEqual(uuid.UUID{}, token2)
+	mts.Require().NotEqual(token1, token2)
+	mts.Require().Equal("bar@email.com", uec.Email)
+
+	var count int64
+	mts.Require().NoError(mts.gormDB.Table("user_email_confirmations").Count(&count).Error)
+	mts.Require().Equal(int64(1), count)
+}
+
+func (mts *ModelsTestSuite) TestQueryUserEmailConfirmation() {
+	mts.resetDB()
+
+	mod := models.Module{
+		Name: "x/bank",
+		Team: "cosmonauts",
+		Owners: []models.User{
+			{Name: "foo", Email: models.NewNullString("foo@cosmonauts.com")},
+		},
+		Authors: []models.User{
+			{Name: "foo", Email: models.NewNullString("foo@cosmonauts.com")},
+		},
+		Version: models.ModuleVersion{
+			Version:       "v1.0.0",
+			Repo:          "https://github.com/cosmos/cosmos-sdk/releases/tag/v0.39.1",
+			Documentation: "https://raw.githubusercontent.com/cosmos/cosmos-sdk/v0.39.1/x/bank/README.md",
+		},
+		Keywords: []models.Keyword{
+			{Name: "tokens"}, {Name: "transfer"},
+		},
+		BugTracker: models.BugTracker{
+			URL:     models.NewNullString("cosmonauts.com"),
+			Contact: models.NewNullString("contact@cosmonauts.com"),
+		},
+	}
+
+	// create module
+	mod, err := mod.Upsert(mts.gormDB)
+	mts.Require().NoError(err)
+
+	user, err := models.GetUserByID(mts.gormDB, mod.Owners[0].ID)
+	mts.Require().NoError(err)
+	mts.Require().False(user.EmailConfirmed)
+
+	query := map[string]interface{}{"user_id": mod.Owners[0].ID}
+
+	uec, err := models.QueryUserEmailConfirmation(mts.gormDB, query)
+	mts.Require().Error(err)
+	mts.Require().Empty(uec)
+
+	uec, err = models.UserEmailConfirmation{UserID: mod.Owners[0].ID, Email: "foo@email.com"}.Upsert(mts.gormDB)
+	token := uec.Token
+	mts.Require().NoError(err)
+	mts.Require().NotEqual(uuid.UUID{}, token)
+	mts.Require().Equal("foo@email.com", uec.Email)
+
+	uec, err = models.QueryUserEmailConfirmation(mts.gormDB, query)
+	mts.Require().NoError(err)
+	mts.Require().Equal(mod.Owners[0].ID, uec.UserID)
+	mts.Require().Equal(token, uec.Token)
+	mts.Require().Equal("foo@email.com", uec.Email)
+}
+
+func (mts *ModelsTestSuite) TestUser_ConfirmEmail() {
+	mts.resetDB()
+
+	mod := models.Module{
+		Name: "x/bank",
+		Team: "cosmonauts",
+		Owners: []models.User{
+			{Name: "foo", Email: models.NewNullString("foo@cosmonauts.com")},
+		},
+		Authors: []models.User{
+			{Name: "foo", Email: models.NewNullString("foo@cosmonauts.com")},
+		},
+		Version: models.ModuleVersion{
+			Version:       "v1.0.0",
+			Repo:          "https://github.com/cosmos/cosmos-sdk/releases/tag/v0.39.1",
+			Documentation: "https://raw.githubusercontent.com/cosmos/cosmos-sdk/v0.39.1/x/bank/README.md",
+		},
+		Keywords: []models.Keyword{
+			{Name: "tokens"}, {Name: "transfer"},
+		},
+		BugTracker: models.BugTracker{
+			URL:     models.NewNullString("cosmonauts.com"),
+			Contact: models.NewNullString("contact@cosmonauts.com"),
+		},
+	}
+
+	// create module
+	mod, err := mod.Upsert(mts.gormDB)
+	mts.Require().NoError(err)
+
+	user, err := models.GetUserByID(mts.gormDB, mod.Owners[0].ID)
+	mts.Require().NoError(err)
+	mts.Require().False(user.EmailConfirmed)
+	mts.Require().Equal("foo@cosmonauts.com", user.Email.String)
+
+	uec, err := models.UserEmailConfirmation{UserID: mod.Owners[0].ID, Email: "foo@email.com"}.Upsert(mts.gormDB)
+	token := uec.Token
+	mts.Require().NoError(err)
+	mts.Require().NotEqual(uuid.UUID{}, token)
+	mts.Require().Equal("foo@email.com", uec.Email)
+
+	user, err = user.ConfirmEmail(mts.gormDB, uec)
+	mts.Require().NoError(err)
+	mts.Require().True(user.EmailConfirmed)
+	mts.Require().Equal("foo@email.com", user.Email.String)
+
+	query := map[string]interface{}{"user_id": mod.Owners[0].ID}
+	uec, err = models.QueryUserEmailConfirmation(mts.gormDB, query)
+	mts.Require().Error(err)
+	mts.Require().Empty(uec)
+}
+
+func (mts *ModelsTestSuite) TestModuleOwnerInvite_Upsert() {
+	mts.resetDB()
+
+	mod := models.Module{
+		Name: "x/bank",
+		Team: "cosmonauts",
+		Owners: []models.User{
+			{Name: "foo"},
+		},
+		Authors: []models.User{
+			{Name: "bar"},
+		},
+		Version: models.ModuleVersion{
+			Version:       "v1.0.0",
+			Repo:          "https://github.com/cosmos/cosmos-sdk/releases/tag/v0.39.1",
+			Documentation: "https://raw.githubusercontent.com/cosmos/cosmos-sdk/v0.39.1/x/bank/README.md",
+		},
+		Keywords: []models.Keyword{
+			{Name: "tokens"}, {Name: "transfer"},
+		},
+		BugTracker: models.BugTracker{
+			URL:     models.NewNullString("cosmonauts.com"),
+			Contact: models.NewNullString("contact@cosmonauts.com"),
+		},
+	}
+
+	// create module
+	mod, err := mod.Upsert(mts.gormDB)
+	mts.Require().NoError(err)
+
+	owner, err := models.GetUserByID(mts.gormDB, mod.Owners[0].ID)
+	mts.Require().NoError(err)
+
+	author, err := models.GetUserByID(mts.gormDB, mod.Authors[0].ID)
+	mts.Require().NoError(err)
+
+	moi, err := models.ModuleOwnerInvite{ModuleID: mod.ID, InvitedByUserID: owner.ID, InvitedUserID: author.ID}.Upsert(mts.gormDB)
+	token1 := moi.Token
+	mts.Require().NoError(err)
+	mts.Require().NotEqual(uuid.UUID{}, token1)
+
+	moi, err = models.ModuleOwnerInvite{ModuleID: mod.ID, InvitedByUserID: owner.ID, InvitedUserID: author.ID}.Upsert(mts.gormDB)
+	token2 := moi.Token
+	mts.Require().NoError(err)
+	mts.Require().NotEqual(uuid.UUID{}, token2)
+	mts.Require().NotEqual(token1, token2)
+
+	var count int64
+	mts.Require().NoError(mts.gormDB.Table("module_owner_invites").Count(&count).Error)
+	mts.Require().Equal(int64(1), count)
+}
+
+func (mts *ModelsTestSuite) TestQueryModuleOwnerInvite() {
+	mts.resetDB()
+
+	mod := models.Module{
+		Name: "x/bank",
+		Team: "cosmonauts",
+		Owners: []models.User{
+			{Name: "foo"},
+		},
+		Authors: []models.User{
+			{Name: "bar"},
+		},
+		Version: models.ModuleVersion{
+			Version:       "v1.0.0",
+			Repo:          "https://github.com/cosmos/cosmos-sdk/releases/tag/v0.39.1",
+			Documentation: "https://raw.githubusercontent.com/cosmos/cosmos-sdk/v0.39.1/x/bank/README.md",
+		},
+		Keywords: []models.Keyword{
+			{Name: "tokens"}, {Name: "transfer"},
+		},
+		BugTracker: models.BugTracker{
+			URL:     models.NewNullString("cosmonauts.com"),
+			Contact: models.NewNullString("contact@cosmonauts.com"),
+		},
+	}
+
+	// create module
+	mod, err := mod.Upsert(mts.gormDB)
+	mts.Require().NoError(err)
+
+	owner, err := models.GetUserByID(mts.gormDB, mod.Owners[0].ID)
+	mts.Require().NoError(err)
+
+	author, err := models.GetUserByID(mts.gormDB, mod.Authors[0].ID)
+	mts.Require().NoError(err)
+
+	query := map[string]interface{}{"module_id": mod.ID, "invited_user_id": author.ID, "invited_by_user_id": owner.ID}
+
+	moi, err := models.QueryModuleOwnerInvite(mts.gormDB, query)
+	mts.Require().Error(err)
+	mts.Require().Empty(moi)
+
+	moi, err = models.ModuleOwnerInvite{ModuleID: mod.ID, InvitedByUserID: owner.ID, InvitedUserID: author.ID}.Upsert(mts.gormDB)
+	token := moi.Token
+	mts.Require().NoError(err)
+	mts.Require().NotEqual(uuid.UUID{}, token)
+
+	moi, err = models.QueryModuleOwnerInvite(mts.gormDB, query)
+	mts.Require().NoError(err)
+	mts.Require().Equal(mod.ID, moi.ModuleID)
+	mts.Require().Equal(author.ID, moi.InvitedUserID)
+	mts.Require().Equal(owner.ID, moi.InvitedByUserID)
+	mts.Require().Equal(token, moi.Token)
+}
+
+func (mts *ModelsTestSuite) TestGetPendingModuleOwnerInvites() {
+	mts.resetDB()
+
+	mod := models.Module{
+		Name: "x/bank",
+		Team: "cosmonauts",
+		Owners: []models.User{
+			{Name: "foo"},
+		},
+		Authors: []models.User{
+			{Name: "bar"}, {Name: "baz"},
+		},
+		Version: models.ModuleVersion{
+			Version:       "v1.0.0",
+			Repo:          "https://github.com/cosmos/cosmos-sdk/releases/tag/v0.39.1",
+			Documentation: "https://raw.githubusercontent.com/cosmos/cosmos-sdk/v0.39.1/x/bank/README.md",
+		},
+	}
+
+	mod, err := mod.Upsert(mts.gormDB)
+	mts.Require().NoError(err)
+
+	owner := mod.Owners[0]
+	bar, baz := mod.Authors[0], mod.Authors[1]
+
+	for _, invitee := range []models.User{bar, baz} {
+		_, err := models.ModuleOwnerInvite{ModuleID: mod.ID, InvitedByUserID: owner.ID, InvitedUserID: invitee.ID}.Upsert(mts.gormDB)
+		mts.Require().NoError(err)
+	}
+
+	ttl := time.Hour
+
+	// expire the invitation to baz
+	mts.Require().NoError(
+		mts.gormDB.Model(&models.ModuleOwnerInvite{}).
+			Where("module_id = ? AND invited_user_id = ?", mod.ID, baz.ID).
+			UpdateColumn("updated_at", time.Now().Add(-2*ttl)).Error,
+	)
+
+	sent, err := models.GetPendingModuleOwnerInvites(mts.gormDB, map[string]interface{}{"invited_by_user_id": owner.ID}, ttl)
+	mts.Require().NoError(err)
+	mts.Require().Len(sent, 1)
+	mts.Require().False(sent[0].Expired(ttl))
+
+	inviteJSON := sent[0].NewModuleOwnerInviteJSON(ttl)
+	mts.Require().Equal("x/bank", inviteJSON.Module)
+	mts.Require().Equal("cosmonauts", inviteJSON.Team)
+	mts.Require().Equal("bar", inviteJSON.InvitedUser)
+	mts.Require().Equal("foo", inviteJSON.InvitedBy)
+	mts.Require().Equal(sent[0].UpdatedAt.Add(ttl), inviteJSON.ExpiresAt)
+
+	received, err := models.GetPendingModuleOwnerInvites(mts.gormDB, map[string]interface{}{"invited_user_id": baz.ID}, ttl)
+	mts.Require().NoError(err)
+	mts.Require().Empty(received)
+
+	// purge the expired invitation
+	n, err := models.PurgeModuleOwnerInvites(mts.gormDB, ttl)
+	mts.Require().NoError(err)
+	mts.Require().Equal(int64(1), n)
+
+	_, err = models.QueryModuleOwnerInvite(mts.gormDB, map[string]interface{}{"invited_user_id": baz.ID})
+	mts.Require().Error(err)
+
+	// revoke the pending invitation
+	mts.Require().NoError(sent[0].Revoke(mts.gormDB))
+
+	events, _, err := models.GetAuditEvents(
+		mts.gormDB,
+		map[string]interface{}{"actor_id": owner.ID, "action": models.AuditActionOwnerInviteRevoke},
+		httputil.PaginationQuery{Page: 1, Limit: 10, Order: "id"},
+	)
+	mts.Require().NoError(err)
+	mts.Require().Len(events, 1)
+
+	sent, err = models.GetPendingModuleOwnerInvites(mts.gormDB, map[string]interface{}{"invited_by_user_id": owner.ID}, ttl)
+	mts.Require().NoError(err)
+	mts.Require().Empty(sent)
+}
+
+func (mts *ModelsTestSuite) TestModule_AddOwner() {
+	mts.resetDB()
+
+	mod := models.Module{
+		Name: "x/bank",
+		Team: "cosmonauts",
+		Owners: []models.User{
+			{Name: "foo"},
+		},
+		Authors: []models.User{
+			{Name: "bar"},
+		},
+		Version: models.ModuleVersion{
+			Version:       "v1.0.0",
+			Repo:          "https://github.com/cosmos/cosmos-sdk/releases/tag/v0.39.1",
+			Documentation: "https://raw.githubusercontent.com/cosmos/cosmos-sdk/v0.39.1/x/bank/README.md",
+		},
+		Keywords: []models.Keyword{
+			{Name: "tokens"}, {Name: "transfer"},
+		},
+		BugTracker: models.BugTracker{
+			URL:     models.NewNullString("cosmonauts.com"),
+			Contact: models.NewNullString("contact@cosmonauts.com"),
+		},
+	}
+
+	// create module
+	mod, err := mod.Upsert(mts.gormDB)
+	mts.Require().NoError(err)
+
+	owner, err := models.GetUserByID(mts.gormDB, mod.Owners[0].ID)
+	mts.Require().NoError(err)
+
+	author, err := models.GetUserByID(mts.gormDB, mod.Authors[0].ID)
+	mts.Require().NoError(err)
+
+	moi, err := models.ModuleOwnerInvite{ModuleID: mod.ID, InvitedByUserID: owner.ID, InvitedUserID: author.ID}.Upsert(mts.gormDB)
+	token := moi.Token
+	mts.Require().NoError(err)
+	mts.Require().NotEqual(uuid.UUID{}, token)
+
+	mod, err = mod.AddOwner(mts.gormDB, author)
+	mts.Require().NoError(err)
+	mts.Require().Len(mod.Owners, 2)
+
+	ownerNames := make([]string, len(mod.Owners))
+	for i, o := range mod.Owners {
+		ownerNames[i] = o.Name
+	}
+	mts.Require().Contains(ownerNames, author.Name)
+
+	query := map[string]interface{}{"module_id": mod.ID, "invited_user_id": author.ID, "invited_by_user_id": owner.ID}
+	moi, err = models.QueryModuleOwnerInvite(mts.gormDB, query)
+	mts.Require().Error(err)
+	mts.Require().Empty(moi)
+}
+
+func (mts *ModelsTestSuite) TestTeamMembers() {
+	mts.resetDB()
+
+	admin, err := models.User{Name: "foo"}.Upsert(mts.gormDB)
+	mts.Require().NoError(err)
+
+	member, err := models.User{Name: "bar"}.Upsert(mts.gormDB)
+	mts.Require().NoError(err)
+
+	team, err := models.CreateTeam(mts.gormDB, "Cosmonauts", admin)
+	mts.Require().NoError(err)
+	mts.Require().Equal("cosmonauts", team.Name)
+	mts.Require().Len(team.Members, 1)
+	mts.Require().True(team.IsAdmin(admin.ID))
+	mts.Require().Equal("foo", team.Members[0].User.Name)
+
+	// a team name must be unique
+	_, err = models.CreateTeam(mts.gormDB, "cosmonauts", member)
+	mts.Require().Error(err)
+
+	// add a member and update their role
+	team, err = team.UpsertMember(mts.gormDB, admin, member, models.TeamRoleViewer)
+	mts.Require().NoError(err)
+	mts.Require().Len(team.Members, 2)
+
+	role, ok := team.MemberRole(member.ID)
+	mts.Require().True(ok)
+	mts.Require().Equal(models.TeamRoleViewer, role)
+
+	team, err = team.UpsertMember(mts.gormDB, admin, member, models.TeamRolePublisher)
+	mts.Require().NoError(err)
+	mts.Require().Len(team.Members, 2)
+
+	role, ok = team.MemberRole(member.ID)
+	mts.Require().True(ok)
+	mts.Require().Equal(models.TeamRolePublisher, role)
+
+	// the last admin cannot be demoted or removed
+	_, err = team.UpsertMember(mts.gormDB, admin, admin, models.TeamRolePublisher)
+	mts.Require().Error(err)
+	mts.Require().ErrorIs(err, models.ErrLastTeamAdmin)
+
+	_, err = team.RemoveMember(mts.gormDB, admin, admin.ID)
+	mts.Require().Error(err)
+	mts.Require().ErrorIs(err, models.ErrLastTeamAdmin)
+
+	// remove a member
+	team, err = team.RemoveMember(mts.gormDB, admin, member.ID)
+	mts.Require().NoError(err)
+	mts.Require().Len(team.Members, 1)
+
+	// membership changes are recorded in the audit log
+	events, _, err := models.GetAuditEvents(
+		mts.gormDB,
+		map[string]interface{}{"actor_id": admin.ID},
+		httputil.PaginationQuery{Page: 1, Limit: 10, Order: "id"},
+	)
+	mts.Require().NoError(err)
+	mts.Require().Len(events, 3)
+	mts.Require().Equal(models.AuditActionTeamMemberAdd, events[0].Action)
+	mts.Require().Equal(models.AuditActionTeamMemberRoleChange, events[1].Action)
+	mts.Require().Equal(models.AuditActionTeamMemberRemove, events[2].Action)
+	mts.Require().JSONEq(fmt.Sprintf(`{"team":"cosmonauts","user_id":%d,"role":"publisher"}`, member.ID), events[1].Data)
+
+	_, ok = team.MemberRole(member.ID)
+	mts.Require().False(ok)
+
+	team, err = models.GetTeamByName(mts.gormDB, "COSMONAUTS")
+	mts.Require().NoError(err)
+	mts.Require().Len(team.Members, 1)
+
+	_, err = models.GetTeamByName(mts.gormDB, "astronauts")
+	mts.Require().Error(err)
+	mts.Require().ErrorIs(err, gorm.ErrRecordNotFound)
+}
+
+func (mts *ModelsTestSuite) TestTeamMemberInvites() {
+	mts.resetDB()
+
+	admin, err := models.User{Name: "foo"}.Upsert(mts.gormDB)
+	mts.Require().NoError(err)
+
+	invitee, err := models.User{Name: "bar"}.Upsert(mts.gormDB)
+	mts.Require().NoError(err)
+
+	team, err := models.CreateTeam(mts.gormDB, "cosmonauts", admin)
+	mts.Require().NoError(err)
+
+	tmi, err := team.InviteMember(mts.gormDB, admin, invitee, models.TeamRoleViewer)
+	mts.Require().NoError(err)
+	mts.Require().Equal("cosmonauts", tmi.Team.Name)
+	mts.Require().False(tmi.Expired(time.Hour))
+
+	// re-inviting updates the role and regenerates the token
+	resent, err := team.InviteMember(mts.gormDB, admin, invitee, models.TeamRolePublisher)
+	mts.Require().NoError(err)
+	mts.Require().Equal(models.TeamRolePublisher, resent.Role)
+	mts.Require().NotEqual(tmi.Token, resent.Token)
+
+	_, err = models.QueryTeamMemberInvite(mts.gormDB, map[string]interface{}{"token": tmi.Token})
+	mts.Require().Error(err)
+
+	// the invitee is not a member until accepting the invitation
+	team, err = models.GetTeamByName(mts.gormDB, "cosmonauts")
+	mts.Require().NoError(err)
+
+	_, ok := team.MemberRole(invitee.ID)
+	mts.Require().False(ok)
+
+	team, err = resent.Accept(mts.gormDB)
+	mts.Require().NoError(err)
+
+	role, ok := team.MemberRole(invitee.ID)
+	mts.Require().True(ok)
+	mts.Require().Equal(models.TeamRolePublisher, role)
+
+	_, err = models.QueryTeamMemberInvite(mts.gormDB, map[string]interface{}{"token": resent.Token})
+	mts.Require().Error(err)
+	mts.Require().ErrorIs(err, gorm.ErrRecordNotFound)
+
+	// a declined invitation is deleted
+	other, err := models.User{Name: "baz"}.Upsert(mts.gormDB)
+	mts.Require().NoError(err)
+
+	tmi, err = team.InviteMember(mts.gormDB, admin, other, models.TeamRoleViewer)
+	mts.Require().NoError(err)
+	mts.Require().NoError(tmi.Decline(mts.gormDB))
+
+	_, err = models.QueryTeamMemberInvite(mts.gormDB, map[string]interface{}{"token": tmi.Token})
+	mts.Require().Error(err)
+
+	// invitations and their responses are recorded in the audit log
+	events, _, err := models.GetAuditEvents(
+		mts.gormDB,
+		map[string]interface{}{},
+		httputil.PaginationQuery{Page: 1, Limit: 10, Order: "id"},
+	)
+	mts.Require().NoError(err)
+	mts.Require().Len(events, 5)
+	mts.Require().Equal(models.AuditActionTeamMemberInvite, events[0].Action)
+	mts.Require().Equal(models.AuditActionTeamMemberInviteResend, events[1].Action)
+	mts.Require().Equal(models.AuditActionTeamMemberAdd, events[2].Action)
+	mts.Require().Equal(invitee.ID, events[2].ActorID)
+	mts.Require().Equal(models.AuditActionTeamMemberInvite, events[3].Action)
+	mts.Require().Equal(models.AuditActionTeamMemberInviteDecline, events[4].Action)
+	mts.Require().Equal(other.ID, events[4].ActorID)
+}
+
+func (mts *ModelsTestSuite) TestModule_AddOwnerTeam() {
+	mts.resetDB()
+
+	mod := models.Module{
+		Name: "x/bank",
+		Team: "cosmonauts",
+		Owners: []models.User{
+			{Name: "foo"},
+		},
+		Authors: []models.User{
+			{Name: "foo"},
+		},
+		Version: models.ModuleVersion{
+			Version:       "v1.0.0",
+			Repo:          "https://github.com/cosmos/cosmos-sdk/releases/tag/v0.39.1",
+			Documentation: "https://raw.githubusercontent.com/cosmos/cosmos-sdk/v0.39.1/x/bank/README.md",
+		},
+	}
+
+	mod, err := mod.Upsert(mts.gormDB)
+	mts.Require().NoError(err)
+
+	admin, err := models.User{Name: "bar"}.Upsert(mts.gormDB)
+	mts.Require().NoError(err)
+
+	publisher, err := models.User{Name: "baz"}.Upsert(mts.gormDB)
+	mts.Require().NoError(err)
+
+	viewer, err := models.User{Name: "qux"}.Upsert(mts.gormDB)
+	mts.Require().NoError(err)
+
+	team, err := models.CreateTeam(mts.gormDB, "astronauts", admin)
+	mts.Require().NoError(err)
+
+	team, err = team.UpsertMember(mts.gormDB, admin, publisher, models.TeamRolePublisher)
+	mts.Require().NoError(err)
+
+	team, err = team.UpsertMember(mts.gormDB, admin, viewer, models.TeamRoleViewer)
+	mts.Require().NoError(err)
+
+	mod, err = models.GetModuleByID(mts.gormDB, mod.ID)
 	mts.Require().NoError(err)
+	mts.Require().False(mod.IsOwner(admin.ID))
 
-	author, err := models.GetUserByID(mts.gormDB, mod.Authors[0].ID)
+	mod, err = mod.AddOwnerTeam(mts.gormDB, admin, team)
 	mts.Require().NoError(err)
+	mts.Require().Len(mod.OwnerTeams, 1)
+	mts.Require().Equal([]string{"astronauts"}, mod.NewModuleJSON().OwnerTeams)
 
-	moi, err := models.ModuleOwnerInvite{ModuleID: mod.ID, InvitedByUserID: owner.ID, InvitedUserID: author.ID}.Upsert(mts.gormDB)
-	token1 := moi.Token
+	// granting ownership again has no effect
+	mod, err = mod.AddOwnerTeam(mts.gormDB, admin, team)
 	mts.Require().NoError(err)
-	mts.Require().NotEqual(uuid.UUID{}, token1)
+	mts.Require().Len(mod.OwnerTeams, 1)
 
-	moi, err = models.ModuleOwnerInvite{ModuleID: mod.ID, InvitedByUserID: owner.ID, InvitedUserID: author.ID}.Upsert(mts.gormDB)
-	token2 := moi.Token
+	mts.Require().True(mod.IsOwner(mod.Owners[0].ID))
+	mts.Require().True(mod.IsOwner(admin.ID))
+	mts.Require().True(mod.IsOwner(publisher.ID))
+	mts.Require().False(mod.IsOwner(viewer.ID))
+
+	mod, err = models.QueryModule(mts.gormDB, map[string]interface{}{"name": "x/bank", "team": "cosmonauts"})
 	mts.Require().NoError(err)
-	mts.Require().NotEqual(uuid.UUID{}, token2)
-	mts.Require().NotEqual(token1, token2)
+	mts.Require().True(mod.IsOwner(publisher.ID))
 
-	var count int64
-	mts.Require().NoError(mts.gormDB.Table("module_owner_invites").Count(&count).Error)
-	mts.Require().Equal(int64(1), count)
+	// the team's modules only include modules whose ownership was granted to it
+	modules, err := team.GetModules(mts.gormDB)
+	mts.Require().NoError(err)
+	mts.Require().Len(modules, 1)
+	mts.Require().Equal("x/bank", modules[0].Name)
 }
 
-func (mts *ModelsTestSuite) TestQueryModuleOwnerInvite() {
+func (mts *ModelsTestSuite) TestModule_FormerOwnerInvites() {
 	mts.resetDB()
 
 	mod := models.Module{
@@ -1366,52 +2496,73 @@ func (mts *ModelsTestSuite) TestQueryModuleOwnerInvite() {
 			{Name: "foo"},
 		},
 		Authors: []models.User{
-			{Name: "bar"},
+			{Name: "foo"},
 		},
 		Version: models.ModuleVersion{
 			Version:       "v1.0.0",
 			Repo:          "https://github.com/cosmos/cosmos-sdk/releases/tag/v0.39.1",
 			Documentation: "https://raw.githubusercontent.com/cosmos/cosmos-sdk/v0.39.1/x/bank/README.md",
 		},
-		Keywords: []models.Keyword{
-			{Name: "tokens"}, {Name: "transfer"},
-		},
-		BugTracker: models.BugTracker{
-			URL:     models.NewNullString("cosmonauts.com"),
-			Contact: models.NewNullString("contact@cosmonauts.com"),
-		},
 	}
 
-	// create module
 	mod, err := mod.Upsert(mts.gormDB)
 	mts.Require().NoError(err)
 
-	owner, err := models.GetUserByID(mts.gormDB, mod.Owners[0].ID)
+	foo := mod.Owners[0]
+
+	newUser := func(name string) models.User {
+		u, err := models.User{Name: name}.Upsert(mts.gormDB)
+		mts.Require().NoError(err)
+		return u
+	}
+
+	bar := newUser("bar")
+	baz := newUser("baz")
+	qux := newUser("qux")
+
+	mod, err = mod.AddOwner(mts.gormDB, bar)
 	mts.Require().NoError(err)
 
-	author, err := models.GetUserByID(mts.gormDB, mod.Authors[0].ID)
+	_, err = models.ModuleOwnerInvite{ModuleID: mod.ID, InvitedByUserID: foo.ID, InvitedUserID: baz.ID}.Upsert(mts.gormDB)
 	mts.Require().NoError(err)
 
-	query := map[string]interface{}{"module_id": mod.ID, "invited_user_id": author.ID, "invited_by_user_id": owner.ID}
+	_, err = models.ModuleOwnerInvite{ModuleID: mod.ID, InvitedByUserID: bar.ID, InvitedUserID: qux.ID}.Upsert(mts.gormDB)
+	mts.Require().NoError(err)
 
-	moi, err := models.QueryModuleOwnerInvite(mts.gormDB, query)
+	// removing an owner deletes the invitations they sent
+	mod, err = mod.RemoveOwner(mts.gormDB, bar, foo)
+	mts.Require().NoError(err)
+
+	_, err = models.QueryModuleOwnerInvite(mts.gormDB, map[string]interface{}{"module_id": mod.ID, "invited_user_id": baz.ID})
 	mts.Require().Error(err)
-	mts.Require().Empty(moi)
+	mts.Require().ErrorIs(err, gorm.ErrRecordNotFound)
 
-	moi, err = models.ModuleOwnerInvite{ModuleID: mod.ID, InvitedByUserID: owner.ID, InvitedUserID: author.ID}.Upsert(mts.gormDB)
-	token := moi.Token
+	_, err = models.QueryModuleOwnerInvite(mts.gormDB, map[string]interface{}{"module_id": mod.ID, "invited_user_id": qux.ID})
 	mts.Require().NoError(err)
-	mts.Require().NotEqual(uuid.UUID{}, token)
 
-	moi, err = models.QueryModuleOwnerInvite(mts.gormDB, query)
+	// transferring ownership deletes the invitations sent by former owners
+	mod, err = mod.TransferToUser(mts.gormDB, bar, foo)
 	mts.Require().NoError(err)
-	mts.Require().Equal(mod.ID, moi.ModuleID)
-	mts.Require().Equal(author.ID, moi.InvitedUserID)
-	mts.Require().Equal(owner.ID, moi.InvitedByUserID)
-	mts.Require().Equal(token, moi.Token)
+
+	_, err = models.QueryModuleOwnerInvite(mts.gormDB, map[string]interface{}{"module_id": mod.ID, "invited_user_id": qux.ID})
+	mts.Require().Error(err)
+	mts.Require().ErrorIs(err, gorm.ErrRecordNotFound)
+
+	// the deletions are recorded in the audit log on behalf of the actor
+	events, _, err := models.GetAuditEvents(
+		mts.gormDB,
+		map[string]interface{}{"action": models.AuditActionOwnerInviteRevoke},
+		httputil.PaginationQuery{Page: 1, Limit: 10, Order: "id"},
+	)
+	mts.Require().NoError(err)
+	mts.Require().Len(events, 2)
+	mts.Require().Equal(bar.ID, events[0].ActorID)
+	mts.Require().JSONEq(fmt.Sprintf(`{"invited_user_id":%d,"invited_by_user_id":%d}`, baz.ID, foo.ID), events[0].Data)
+	mts.Require().Equal(bar.ID, events[1].ActorID)
+	mts.Require().JSONEq(fmt.Sprintf(`{"invited_user_id":%d,"invited_by_user_id":%d}`, qux.ID, bar.ID), events[1].Data)
 }
 
-func (mts *ModelsTestSuite) TestModule_AddOwner() {
+func (mts *ModelsTestSuite) TestModule_Ownership() {
 	mts.resetDB()
 
 	mod := models.Module{
@@ -1421,51 +2572,96 @@ func (mts *ModelsTestSuite) TestModule_AddOwner() {
 			{Name: "foo"},
 		},
 		Authors: []models.User{
-			{Name: "bar"},
+			{Name: "foo"},
 		},
 		Version: models.ModuleVersion{
 			Version:       "v1.0.0",
 			Repo:          "https://github.com/cosmos/cosmos-sdk/releases/tag/v0.39.1",
 			Documentation: "https://raw.githubusercontent.com/cosmos/cosmos-sdk/v0.39.1/x/bank/README.md",
 		},
-		Keywords: []models.Keyword{
-			{Name: "tokens"}, {Name: "transfer"},
-		},
-		BugTracker: models.BugTracker{
-			URL:     models.NewNullString("cosmonauts.com"),
-			Contact: models.NewNullString("contact@cosmonauts.com"),
-		},
 	}
 
-	// create module
 	mod, err := mod.Upsert(mts.gormDB)
 	mts.Require().NoError(err)
 
-	owner, err := models.GetUserByID(mts.gormDB, mod.Owners[0].ID)
-	mts.Require().NoError(err)
+	foo := mod.Owners[0]
 
-	author, err := models.GetUserByID(mts.gormDB, mod.Authors[0].ID)
+	bar, err := models.User{Name: "bar"}.Upsert(mts.gormDB)
 	mts.Require().NoError(err)
 
-	moi, err := models.ModuleOwnerInvite{ModuleID: mod.ID, InvitedByUserID: owner.ID, InvitedUserID: author.ID}.Upsert(mts.gormDB)
-	token := moi.Token
+	team, err := models.CreateTeam(mts.gormDB, "astronauts", bar)
 	mts.Require().NoError(err)
-	mts.Require().NotEqual(uuid.UUID{}, token)
 
-	mod, err = mod.AddOwner(mts.gormDB, author)
+	// the last owner cannot be removed
+	_, err = mod.RemoveOwner(mts.gormDB, foo, foo)
+	mts.Require().Error(err)
+	mts.Require().ErrorIs(err, models.ErrLastModuleOwner)
+
+	// an owner may remove a co-owner
+	mod, err = mod.AddOwner(mts.gormDB, bar)
 	mts.Require().NoError(err)
 	mts.Require().Len(mod.Owners, 2)
 
-	ownerNames := make([]string, len(mod.Owners))
-	for i, o := range mod.Owners {
-		ownerNames[i] = o.Name
-	}
-	mts.Require().Contains(ownerNames, author.Name)
+	mod, err = mod.RemoveOwner(mts.gormDB, foo, bar)
+	mts.Require().NoError(err)
+	mts.Require().Len(mod.Owners, 1)
+	mts.Require().False(mod.IsOwner(bar.ID))
 
-	query := map[string]interface{}{"module_id": mod.ID, "invited_user_id": author.ID, "invited_by_user_id": owner.ID}
-	moi, err = models.QueryModuleOwnerInvite(mts.gormDB, query)
+	_, err = mod.RemoveOwner(mts.gormDB, foo, bar)
 	mts.Require().Error(err)
-	mts.Require().Empty(moi)
+
+	// an owning team counts as an owner
+	mod, err = mod.AddOwnerTeam(mts.gormDB, foo, team)
+	mts.Require().NoError(err)
+
+	mod, err = mod.RemoveOwner(mts.gormDB, foo, foo)
+	mts.Require().NoError(err)
+	mts.Require().Empty(mod.Owners)
+	mts.Require().True(mod.IsOwner(bar.ID))
+
+	_, err = mod.RemoveOwnerTeam(mts.gormDB, bar, team)
+	mts.Require().Error(err)
+	mts.Require().ErrorIs(err, models.ErrLastModuleOwner)
+
+	// transfer ownership to a user and back to the team
+	mod, err = mod.TransferToUser(mts.gormDB, bar, foo)
+	mts.Require().NoError(err)
+	mts.Require().Len(mod.Owners, 1)
+	mts.Require().Equal(foo.ID, mod.Owners[0].ID)
+	mts.Require().Empty(mod.OwnerTeams)
+	mts.Require().False(mod.IsOwner(bar.ID))
+
+	mod, err = mod.TransferToTeam(mts.gormDB, foo, team)
+	mts.Require().NoError(err)
+	mts.Require().Empty(mod.Owners)
+	mts.Require().Len(mod.OwnerTeams, 1)
+	mts.Require().True(mod.IsOwner(bar.ID))
+	mts.Require().False(mod.IsOwner(foo.ID))
+
+	events, err := mod.GetOwnerEvents(mts.gormDB)
+	mts.Require().NoError(err)
+
+	type event struct {
+		action, actor, user, team string
+	}
+
+	expected := []event{
+		{models.OwnerEventOwnerAdded, "foo", "foo", ""},
+		{models.OwnerEventOwnerAdded, "bar", "bar", ""},
+		{models.OwnerEventOwnerRemoved, "foo", "bar", ""},
+		{models.OwnerEventTeamAdded, "foo", "", "astronauts"},
+		{models.OwnerEventOwnerRemoved, "foo", "foo", ""},
+		{models.OwnerEventTeamRemoved, "bar", "", "astronauts"},
+		{models.OwnerEventTransferredUser, "bar", "foo", ""},
+		{models.OwnerEventOwnerRemoved, "foo", "foo", ""},
+		{models.OwnerEventTransferredTeam, "foo", "", "astronauts"},
+	}
+
+	mts.Require().Len(events, len(expected))
+	for i, e := range events {
+		mts.Require().Equal(expected[i], event{e.Action, e.Actor, e.UserName.String, e.TeamName.String})
+		mts.Require().Equal(mod.ID, e.ModuleID)
+	}
 }
 
 func (mts *ModelsTestSuite) TestNewBugTrackerJSON() {
@@ -1492,12 +2688,14 @@ func (mts *ModelsTestSuite) TestNewModuleVersionJSON() {
 			CreatedAt: time.Now().UTC(),
 			UpdatedAt: time.Now().UTC(),
 		},
-		Documentation: "documentation",
-		Repo:          "repo",
-		Version:       "version",
-		SDKCompat:     models.NewNullString("sdk compat"),
-		ModuleID:      1,
-		PublishedBy:   1,
+		Documentation:        "documentation",
+		Repo:                 "repo",
+		Version:              "version",
+		SDKCompat:            models.NewNullString("sdk compat"),
+		ModuleID:             1,
+		PublishedBy:          1,
+		CommitSHA:            models.NewNullString("6d2f2ea4dd1d7a3cd3b1c5dc4dc0a1b7e2d4a5b8"),
+		PublishAuthorization: models.NewNullString(models.PublishAuthOrgMember),
 	}
 	versionJSON := version.NewModuleVersionJSON()
 	mts.Require().Equal(version.Documentation, versionJSON.Documentation)
@@ -1506,6 +2704,8 @@ func (mts *ModelsTestSuite) TestNewModuleVersionJSON() {
 	mts.Require().Equal(version.SDKCompat.String, versionJSON.SDKCompat)
 	mts.Require().Equal(version.ModuleID, versionJSON.ModuleID)
 	mts.Require().Equal(version.PublishedBy, versionJSON.PublishedBy)
+	mts.Require().Equal(version.CommitSHA.String, versionJSON.CommitSHA)
+	mts.Require().Equal(version.PublishAuthorization.String, versionJSON.PublishAuthorization)
 	mts.Require().Equal(version.CreatedAt, versionJSON.CreatedAt)
 	mts.Require().Equal(version.UpdatedAt, versionJSON.UpdatedAt)
 }
@@ -1704,6 +2904,31 @@ func (mts *ModelsTestSuite) TestNodeUpsert() {
 			},
 			false,
 		},
+		{
+			"updated sync info",
+			models.Node{
+				Location: models.Location{
+					Country:   "US",
+					Region:    "US",
+					City:      "Baltimore",
+					Latitude:  "33.7128",
+					Longitude: "25.0060",
+				},
+				Address:           "127.0.0.1",
+				RPCPort:           "26657",
+				P2PPort:           "26656",
+				Moniker:           "test",
+				NodeID:            "0000FF",
+				Network:           "testnet",
+				Version:           "1.0.1",
+				TxIndex:           "false",
+				LatestBlockHeight: 1042,
+				LatestBlockTime:   models.NewNullTime(time.Now().Add(-time.Minute)),
+				CatchingUp:        true,
+				VotingPower:       100,
+			},
+			false,
+		},
 		{
 			"same address for different network",
 			models.Node{
@@ -1744,6 +2969,11 @@ func (mts *ModelsTestSuite) TestNodeUpsert() {
 				mts.Require().Equal(tc.node.Network, record.Network)
 				mts.Require().Equal(tc.node.Version, record.Version)
 				mts.Require().Equal(tc.node.TxIndex, record.TxIndex)
+				mts.Require().Equal(tc.node.LatestBlockHeight, record.LatestBlockHeight)
+				mts.Require().Equal(tc.node.LatestBlockTime.Valid, record.LatestBlockTime.Valid)
+				mts.Require().Equal(tc.node.LatestBlockTime.Time.Unix(), record.LatestBlockTime.Time.Unix())
+				mts.Require().Equal(tc.node.CatchingUp, record.CatchingUp)
+				mts.Require().Equal(tc.node.VotingPower, record.VotingPower)
 				mts.Require().Equal(tc.node.Location.Country, record.Location.Country)
 				mts.Require().Equal(tc.node.Location.Region, record.Location.Region)
 				mts.Require().Equal(tc.node.Location.City, record.Location.City)
@@ -1754,6 +2984,88 @@ func (mts *ModelsTestSuite) TestNodeUpsert() {
 	}
 }
 
+func (mts *ModelsTestSuite) TestNodeUpsert_UnknownLocation() {
+	mts.resetDB()
+
+	node := models.Node{
+		Address: "127.0.0.1",
+		RPCPort: "26657",
+		P2PPort: "26656",
+		Moniker: "test",
+		NodeID:  "0000FF",
+		Network: "testnet",
+		Version: "1.0.1",
+		TxIndex: "false",
+	}
+
+	// a node which could not be geolocated has no location
+	record, err := node.Upsert(mts.gormDB)
+	mts.Require().NoError(err)
+	mts.Require().Nil(record.LocationID)
+	mts.Require().False(record.Location.HasCoordinates())
+
+	node.Location = models.Location{
+		Country:   "US",
+		Region:    "US",
+		City:      "New York",
+		Latitude:  "40.7128",
+		Longitude: "74.0060",
+	}
+
+	record, err = node.Upsert(mts.gormDB)
+	mts.Require().NoError(err)
+	mts.Require().NotNil(record.LocationID)
+	mts.Require().Equal(node.Location.City, record.Location.City)
+
+	// the existing location is retained when the node cannot be geolocated
+	node.Location = models.Location{}
+	node.Version = "1.0.2"
+
+	record, err = node.Upsert(mts.gormDB)
+	mts.Require().NoError(err)
+	mts.Require().NotNil(record.LocationID)
+	mts.Require().Equal("New York", record.Location.City)
+	mts.Require().Equal(node.Version, record.Version)
+}
+
+func (mts *ModelsTestSuite) TestNodeUpsert_NoStatus() {
+	mts.resetDB()
+
+	node := models.Node{
+		Address:           "127.0.0.1",
+		RPCPort:           "26657",
+		P2PPort:           "26656",
+		Moniker:           "test",
+		NodeID:            "0000FF",
+		Network:           "testnet",
+		Version:           "1.0.1",
+		TxIndex:           "on",
+		LatestBlockHeight: 100,
+		LatestBlockTime:   models.NewNullTime(time.Now()),
+		VotingPower:       10,
+	}
+
+	_, err := node.Upsert(mts.gormDB)
+	mts.Require().NoError(err)
+
+	// a node crawled without its status retains its status-derived metadata
+	record, err := models.Node{
+		Address: "127.0.0.1",
+		RPCPort: "26657",
+		P2PPort: "26656",
+		Network: "testnet",
+	}.Upsert(mts.gormDB)
+	mts.Require().NoError(err)
+	mts.Require().True(record.Online)
+	mts.Require().Equal("test", record.Moniker)
+	mts.Require().Equal("0000FF", record.NodeID)
+	mts.Require().Equal("1.0.1", record.Version)
+	mts.Require().Equal("on", record.TxIndex)
+	mts.Require().Equal(int64(100), record.LatestBlockHeight)
+	mts.Require().True(record.LatestBlockTime.Valid)
+	mts.Require().Equal(int64(10), record.VotingPower)
+}
+
 func (mts *ModelsTestSuite) TestNodeSearch() {
 	mts.resetDB()
 
@@ -1809,7 +3121,7 @@ func (mts *ModelsTestSuite) TestNodeSearch() {
 		mts.Require().NoError(err)
 	}
 
-	mods, paginator, err := models.SearchNodes(mts.gormDB, "foo", httputil.PaginationQuery{Page: 1, Limit: 10, Order: "id"})
+	mods, paginator, err := models.SearchNodes(mts.gormDB, "foo", httputil.PaginationQuery{Page: 1, Limit: 10, Order: "id"}, nil)
 	mts.Require().NoError(err)
 	mts.Require().Empty(mods)
 	mts.Require().Zero(paginator.PrevPage)
@@ -1906,7 +3218,7 @@ func (mts *ModelsTestSuite) TestNodeSearch() {
 		tc := tc
 
 		mts.Run(tc.name, func() {
-			nodes, paginator, err := models.SearchNodes(mts.gormDB, tc.query, tc.pageQuery)
+			nodes, paginator, err := models.SearchNodes(mts.gormDB, tc.query, tc.pageQuery, nil)
 			mts.Require().NoError(err)
 			mts.Require().Len(nodes, len(tc.expectedRecords))
 			mts.Require().Equal(tc.expectedPaginator, paginator)
@@ -1916,21 +3228,53 @@ func (mts *ModelsTestSuite) TestNodeSearch() {
 			}
 		})
 	}
+
+	// nodes are filtered by whether they are online
+	mts.Require().NoError(models.Node{Address: "127.0.0.1", Network: "network2"}.MarkOffline(mts.gormDB))
+
+	online, offline := true, false
+	pq := httputil.PaginationQuery{Page: 1, Limit: 10, Order: "id"}
+
+	nodes, paginator, err := models.SearchNodes(mts.gormDB, "", pq, &online)
+	mts.Require().NoError(err)
+	mts.Require().Len(nodes, 9)
+	mts.Require().Equal(int64(9), paginator.Total)
+
+	nodes, _, err = models.SearchNodes(mts.gormDB, "", pq, &offline)
+	mts.Require().NoError(err)
+	mts.Require().Len(nodes, 1)
+	mts.Require().Equal("127.0.0.1", nodes[0].Address)
+
+	nodes, paginator, err = models.SearchNodes(mts.gormDB, "network2", pq, &online)
+	mts.Require().NoError(err)
+	mts.Require().Len(nodes, 4)
+	mts.Require().Equal(int64(4), paginator.Total)
+
+	for _, n := range nodes {
+		mts.Require().True(n.Online)
+	}
 }
 
 func (mts *ModelsTestSuite) TestNewNodeJSON() {
 	node := models.Node{
-		ID:        1,
-		CreatedAt: time.Now().UTC(),
-		UpdatedAt: time.Now().UTC(),
-		Address:   "127.0.0.1",
-		RPCPort:   "25626",
-		P2PPort:   "25627",
-		Moniker:   "foo",
-		NodeID:    "00000FF",
-		Network:   "testnet-3",
-		Version:   "1.0",
-		TxIndex:   "false",
+		ID:         1,
+		CreatedAt:  time.Now().UTC(),
+		UpdatedAt:  time.Now().UTC(),
+		Address:    "127.0.0.1",
+		RPCPort:    "25626",
+		P2PPort:    "25627",
+		Moniker:    "foo",
+		NodeID:     "00000FF",
+		Network:    "testnet-3",
+		Version:    "1.0",
+		TxIndex:    "false",
+		Online:     true,
+		LastSeenAt: models.NewNullTime(time.Now().UTC()),
+
+		LatestBlockHeight: 1042,
+		LatestBlockTime:   models.NewNullTime(time.Now().UTC()),
+		CatchingUp:        true,
+		VotingPower:       100,
 	}
 
 	nodeJSON := node.NewNodeJSON()
@@ -1945,6 +3289,12 @@ func (mts *ModelsTestSuite) TestNewNodeJSON() {
 	mts.Require().Equal(node.Network, nodeJSON.Network)
 	mts.Require().Equal(node.Version, nodeJSON.Version)
 	mts.Require().Equal(node.TxIndex, nodeJSON.TxIndex)
+	mts.Require().Equal(node.LatestBlockHeight, nodeJSON.LatestBlockHeight)
+	mts.Require().Equal(node.LatestBlockTime.Time, nodeJSON.LatestBlockTime)
+	mts.Require().Equal(node.CatchingUp, nodeJSON.CatchingUp)
+	mts.Require().Equal(node.VotingPower, nodeJSON.VotingPower)
+	mts.Require().Equal(node.Online, nodeJSON.Online)
+	mts.Require().Equal(node.LastSeenAt.Time, nodeJSON.LastSeenAt)
 }
 
 func (mts *ModelsTestSuite) TestNodeDelete() {
@@ -2021,6 +3371,187 @@ func (mts *ModelsTestSuite) TestGetStaleNodes() {
 	mts.Require().Empty(nodes)
 }
 
+func (mts *ModelsTestSuite) TestNodeMarkOffline() {
+	mts.resetDB()
+
+	node := models.Node{Address: "127.0.0.1", Network: "testnet"}
+	mts.Require().NoError(node.MarkOffline(mts.gormDB))
+
+	node = models.Node{
+		Location: models.Location{
+			Country:   "US",
+			Region:    "US",
+			City:      "New York",
+			Latitude:  "40.7128",
+			Longitude: "74.0060",
+		},
+		Address: "127.0.0.1",
+		RPCPort: "26657",
+		P2PPort: "26656",
+		Moniker: "test",
+		NodeID:  "0000FF",
+		Network: "testnet",
+		Version: "1.0.1",
+		TxIndex: "false",
+	}
+
+	record, err := node.Upsert(mts.gormDB)
+	mts.Require().NoError(err)
+	mts.Require().True(record.Online)
+	mts.Require().True(record.LastSeenAt.Valid)
+
+	mts.Require().NoError(models.Node{Address: node.Address}.MarkOffline(mts.gormDB))
+
+	offline, err := models.QueryNode(
+		mts.gormDB,
+		map[string]interface{}{"address": node.Address, "network": node.Network},
+	)
+	mts.Require().NoError(err)
+	mts.Require().False(offline.Online)
+	mts.Require().Equal(record.LastSeenAt.Time.Unix(), offline.LastSeenAt.Time.Unix())
+	mts.Require().Equal(node.Moniker, offline.Moniker)
+
+	observations, err := models.GetNodeObservations(mts.gormDB, record.ID, time.Now().Add(-time.Hour))
+	mts.Require().NoError(err)
+	mts.Require().Len(observations, 1)
+	mts.Require().False(observations[0].Reachable)
+
+	// the node is back online once crawled again
+	record, err = node.Upsert(mts.gormDB)
+	mts.Require().NoError(err)
+	mts.Require().True(record.Online)
+}
+
+func (mts *ModelsTestSuite) TestGetNodeUptime() {
+	mts.resetDB()
+
+	node := models.Node{
+		Location: models.Location{
+			Country:   "US",
+			Region:    "US",
+			City:      "New York",
+			Latitude:  "40.7128",
+			Longitude: "74.0060",
+		},
+		Address: "127.0.0.1",
+		RPCPort: "26657",
+		P2PPort: "26656",
+		Moniker: "test",
+		NodeID:  "0000FF",
+		Network: "testnet",
+		Version: "1.0.1",
+		TxIndex: "false",
+	}
+
+	record, err := node.Upsert(mts.gormDB)
+	mts.Require().NoError(err)
+
+	start := time.Now().UTC().Truncate(24 * time.Hour).Add(-48 * time.Hour)
+	reachable := []bool{true, false, false, true, true, true, true, false}
+
+	for i, ok := range reachable {
+		obs := models.NodeObservation{
+			CreatedAt: start.Add(time.Duration(i) * 6 * time.Hour),
+			Reachable: ok,
+		}
+		if ok {
+			obs.LatencyMS = 10
+			obs.Height = models.NewNullInt64(int64(100 + i))
+			obs.Version = models.NewNullString(node.Version)
+		}
+
+		_, err := record.RecordObservation(mts.gormDB, obs)
+		mts.Require().NoError(err)
+	}
+
+	uptime, err := models.GetNodeUptime(mts.gormDB, record.ID, start.Add(-time.Hour))
+	mts.Require().NoError(err)
+	mts.Require().Equal(record.ID, uptime.NodeID)
+	mts.Require().Equal(len(reachable), uptime.Observations)
+	mts.Require().Equal(62.5, uptime.Availability)
+	mts.Require().Equal([]models.NodeAvailability{
+		{Date: start.Format("2006-01-02"), Observations: 4, Availability: 50},
+		{Date: start.Add(24 * time.Hour).Format("2006-01-02"), Observations: 4, Availability: 75},
+	}, uptime.Daily)
+
+	mts.Require().Len(uptime.Outages, 2)
+	mts.Require().Equal(start.Add(6*time.Hour).Unix(), uptime.Outages[0].Start.Unix())
+	mts.Require().NotNil(uptime.Outages[0].End)
+	mts.Require().Equal(start.Add(18*time.Hour).Unix(), uptime.Outages[0].End.Unix())
+	mts.Require().Equal(start.Add(42*time.Hour).Unix(), uptime.Outages[1].Start.Unix())
+	mts.Require().Nil(uptime.Outages[1].End)
+
+	// only observations within the window are considered
+	uptime, err = models.GetNodeUptime(mts.gormDB, record.ID, start.Add(24*time.Hour))
+	mts.Require().NoError(err)
+	mts.Require().Equal(4, uptime.Observations)
+	mts.Require().Equal(75.0, uptime.Availability)
+	mts.Require().Len(uptime.Outages, 1)
+
+	// expired observations are purged
+	purged, err := models.PurgeNodeObservations(mts.gormDB, start.Add(24*time.Hour))
+	mts.Require().NoError(err)
+	mts.Require().Equal(int64(4), purged)
+
+	uptime, err = models.GetNodeUptime(mts.gormDB, record.ID, start.Add(-time.Hour))
+	mts.Require().NoError(err)
+	mts.Require().Equal(4, uptime.Observations)
+}
+
+func (mts *ModelsTestSuite) TestGetNetworks() {
+	mts.resetDB()
+
+	us := models.Location{Country: "US", Region: "NY", City: "New York", Latitude: "40.7128", Longitude: "74.0060"}
+	de := models.Location{Country: "DE", Region: "BE", City: "Berlin", Latitude: "52.5200", Longitude: "13.4050"}
+
+	nodes := []models.Node{
+		{Location: us, Address: "127.0.0.1", Network: "testnet", Version: "v1.0.0", TxIndex: "on", LatestBlockHeight: 100},
+		{Location: de, Address: "127.0.0.2", Network: "testnet", Version: "v1.0.0", TxIndex: "off", LatestBlockHeight: 102},
+		{Location: us, Address: "127.0.0.3", Network: "testnet", Version: "v1.1.0", TxIndex: "on", LatestBlockHeight: 101},
+		{Location: de, Address: "127.0.0.4", Network: "testnet", Version: "v0.9.0", TxIndex: "on", LatestBlockHeight: 50},
+		{Location: us, Address: "127.0.0.5", Network: "other", Version: "v2.0.0", TxIndex: "off"},
+	}
+
+	for _, n := range nodes {
+		n.RPCPort = "26657"
+		n.P2PPort = "26656"
+
+		_, err := n.Upsert(mts.gormDB)
+		mts.Require().NoError(err)
+	}
+
+	// offline nodes are only included in the node counts
+	mts.Require().NoError(nodes[3].MarkOffline(mts.gormDB))
+
+	networks, err := models.GetNetworks(mts.gormDB)
+	mts.Require().NoError(err)
+	mts.Require().Len(networks, 2)
+
+	other := networks[0]
+	mts.Require().Equal("other", other.ChainID)
+	mts.Require().Equal(int64(1), other.Nodes)
+	mts.Require().Equal(int64(1), other.OnlineNodes)
+	mts.Require().Equal(map[string]int64{"v2.0.0": 1}, other.Versions)
+	mts.Require().Equal(map[string]int64{"US": 1}, other.Countries)
+	mts.Require().Equal(0.0, other.TxIndexShare)
+	mts.Require().Equal(int64(0), other.MedianBlockHeight)
+	mts.Require().Equal("testnet", networks[1].ChainID)
+
+	testnet, err := models.GetNetwork(mts.gormDB, "testnet")
+	mts.Require().NoError(err)
+	mts.Require().Equal("testnet", testnet.ChainID)
+	mts.Require().Equal(int64(4), testnet.Nodes)
+	mts.Require().Equal(int64(3), testnet.OnlineNodes)
+	mts.Require().Equal(map[string]int64{"v1.0.0": 2, "v1.1.0": 1}, testnet.Versions)
+	mts.Require().Equal(map[string]int64{"US": 2, "DE": 1}, testnet.Countries)
+	mts.Require().Equal(66.67, testnet.TxIndexShare)
+	mts.Require().Equal(int64(101), testnet.MedianBlockHeight)
+	mts.Require().WithinDuration(time.Now(), testnet.LastCrawledAt, time.Minute)
+
+	_, err = models.GetNetwork(mts.gormDB, "unknown")
+	mts.Require().ErrorIs(err, gorm.ErrRecordNotFound)
+}
+
 func (mts *ModelsTestSuite) resetDB() {
 	mts.T().Helper()
 
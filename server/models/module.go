@@ -5,6 +5,7 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"sort"
 	"strings"
 	"time"
 
@@ -14,19 +15,39 @@ import (
 	uuid "github.com/satori/go.uuid"
 
 	"github.com/cosmos/atlas/server/httputil"
+	"github.com/cosmos/atlas/server/semver"
 )
 
+// Publish authorization modes, which define how the publisher of a
+// ModuleVersion was authorized to publish it on behalf of the module's
+// repository owner.
+const (
+	PublishAuthContributor = "contributor"
+	PublishAuthOrgMember   = "org_member"
+	PublishAuthTeamMember  = "team_member"
+)
+
+// DefaultModuleOwnerInviteTTL defines the default duration after which a module
+// owner invitation, since it was last sent, expires.
+const DefaultModuleOwnerInviteTTL = 24 * time.Hour
+
 type (
 	// BugTrackerJSON defines the JSON-encodeable type for a ModuleVersion.
 	ModuleVersionJSON struct {
 		GormModelJSON
 
-		Version       string      `json:"version"`
-		Documentation string      `json:"documentation"`
-		Repo          string      `json:"repo"`
-		SDKCompat     interface{} `json:"sdk_compat"`
-		ModuleID      uint        `json:"module_id"`
-		PublishedBy   uint        `json:"published_by"`
+		Version              string      `json:"version"`
+		Documentation        string      `json:"documentation"`
+		Repo                 string      `json:"repo"`
+		SDKCompat            interface{} `json:"sdk_compat"`
+		ModuleID             uint        `json:"module_id"`
+		PublishedBy          uint        `json:"published_by"`
+		Yanked               bool        `json:"yanked"`
+		YankReason           interface{} `json:"yank_reason"`
+		CommitSHA            interface{} `json:"commit_sha"`
+		SourceChecksum       interface{} `json:"source_checksum"`
+		SourcePath           interface{} `json:"source_path"`
+		PublishAuthorization interface{} `json:"publish_authorization"`
 	}
 
 	// ModuleVersion defines a version associated with a unique module.
@@ -39,6 +60,50 @@ type (
 		SDKCompat     sql.NullString
 		ModuleID      uint
 		PublishedBy   uint
+
+		// SortKey defines the byte-wise sortable form of Version which follows
+		// Semantic Versioning precedence rules. It is set automatically upon
+		// creation.
+		SortKey string
+
+		// A yanked version remains accessible by its exact version, but it is
+		// never resolved as a module's latest version.
+		Yanked     bool
+		YankReason sql.NullString
+
+		// CommitSHA defines the commit the version's tag refers to in the module's
+		// repository at the time of publishing, where SourceChecksum defines the
+		// content hash of the module's source tree at that commit. SourcePath
+		// defines the module's directory relative to the repository root, where
+		// NULL denotes the repository root.
+		CommitSHA      sql.NullString
+		SourceChecksum sql.NullString
+		SourcePath     sql.NullString
+
+		// PublishAuthorization defines the mode by which the publisher was
+		// authorized to publish the version, e.g. as a repository contributor.
+		PublishAuthorization sql.NullString
+
+		// Dependencies defines the set of dependencies to create along with a new
+		// ModuleVersion. It is not loaded when querying for ModuleVersion records.
+		Dependencies []ModuleDependency `gorm:"-"`
+
+		// Readme defines the Markdown README to create along with a new
+		// ModuleVersion. It is not loaded when querying for ModuleVersion records.
+		Readme string `gorm:"-"`
+	}
+
+	// SDKCompatRange defines a range of Cosmos SDK versions a ModuleVersion is
+	// compatible with. A ModuleVersion's SDK compatibility constraint is reduced
+	// to one or more ranges upon creation so that compatibility can be queried.
+	// MinKey and MaxKey are Semantic Versioning sort keys where MinKey is
+	// inclusive and MaxKey is exclusive. A NULL key is unbounded.
+	SDKCompatRange struct {
+		gorm.Model
+
+		ModuleVersionID uint
+		MinKey          sql.NullString
+		MaxKey          sql.NullString
 	}
 
 	// ModuleKeywords defines the type relationship between a module and all the
@@ -87,6 +152,7 @@ type (
 		Keywords    []KeywordJSON       `json:"keywords"`
 		Authors     []UserJSON          `json:"authors"`
 		Owners      []UserJSON          `json:"owners"`
+		OwnerTeams  []string            `json:"owner_teams"`
 		Versions    []ModuleVersionJSON `json:"versions"`
 	}
 
@@ -96,14 +162,31 @@ type (
 		ModuleID uint `json:"module_id"`
 	}
 
+	// ModuleOwnerInviteJSON defines the JSON-encodeable type for a
+	// ModuleOwnerInvite. The token is only included for the invitee.
+	ModuleOwnerInviteJSON struct {
+		CreatedAt   time.Time   `json:"created_at"`
+		UpdatedAt   time.Time   `json:"updated_at"`
+		ExpiresAt   time.Time   `json:"expires_at"`
+		ModuleID    uint        `json:"module_id"`
+		Module      string      `json:"module"`
+		Team        string      `json:"team"`
+		InvitedUser string      `json:"invited_user"`
+		InvitedBy   string      `json:"invited_by"`
+		Token       interface{} `json:"token,omitempty"`
+	}
+
 	// ModuleOwnerInvite defines the a module owner invitation relationship.
 	ModuleOwnerInvite struct {
 		CreatedAt time.Time
 		UpdatedAt time.Time
 
 		ModuleID        uint
+		Module          Module
 		InvitedUserID   uint
+		InvitedUser     User `gorm:"foreignKey:InvitedUserID"`
 		InvitedByUserID uint
+		InvitedByUser   User `gorm:"foreignKey:InvitedByUserID"`
 		Token           uuid.UUID
 	}
 
@@ -120,6 +203,7 @@ type (
 		Keywords    []Keyword       `gorm:"many2many:module_keywords"`
 		Authors     []User          `gorm:"many2many:module_authors"`
 		Owners      []User          `gorm:"many2many:module_owners"`
+		OwnerTeams  []Team          `gorm:"many2many:module_owner_teams"`
 		Versions    []ModuleVersion `gorm:"foreignKey:module_id"`
 
 		Version ModuleVersion `gorm:"-"` // current version in manifest
@@ -133,6 +217,11 @@ func (mv ModuleVersion) MarshalJSON() ([]byte, error) {
 
 func (mv ModuleVersion) NewModuleVersionJSON() ModuleVersionJSON {
 	sdkCompat, _ := mv.SDKCompat.Value()
+	yankReason, _ := mv.YankReason.Value()
+	commitSHA, _ := mv.CommitSHA.Value()
+	sourceChecksum, _ := mv.SourceChecksum.Value()
+	sourcePath, _ := mv.SourcePath.Value()
+	publishAuthorization, _ := mv.PublishAuthorization.Value()
 
 	return ModuleVersionJSON{
 		GormModelJSON: GormModelJSON{
@@ -140,13 +229,144 @@ func (mv ModuleVersion) NewModuleVersionJSON() ModuleVersionJSON {
 			CreatedAt: mv.CreatedAt,
 			UpdatedAt: mv.UpdatedAt,
 		},
-		Documentation: mv.Documentation,
-		Repo:          mv.Repo,
-		Version:       mv.Version,
-		SDKCompat:     sdkCompat,
-		ModuleID:      mv.ModuleID,
-		PublishedBy:   mv.PublishedBy,
+		Documentation:        mv.Documentation,
+		Repo:                 mv.Repo,
+		Version:              mv.Version,
+		SDKCompat:            sdkCompat,
+		ModuleID:             mv.ModuleID,
+		PublishedBy:          mv.PublishedBy,
+		Yanked:               mv.Yanked,
+		YankReason:           yankReason,
+		CommitSHA:            commitSHA,
+		SourceChecksum:       sourceChecksum,
+		SourcePath:           sourcePath,
+		PublishAuthorization: publishAuthorization,
+	}
+}
+
+// Yank marks a ModuleVersion as yanked by the given user with the given reason.
+// It returns an error upon failure.
+func (mv ModuleVersion) Yank(db *gorm.DB, actorID uint, reason string) (ModuleVersion, error) {
+	mv.Yanked = true
+	mv.YankReason = NewNullString(reason)
+
+	err := db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&mv).Updates(map[string]interface{}{
+			"yanked":      mv.Yanked,
+			"yank_reason": mv.YankReason,
+		}).Error; err != nil {
+			return fmt.Errorf("failed to yank module version: %w", err)
+		}
+
+		return recordAuditEvent(tx, AuditActionModuleYank, actorID, mv.ModuleID, map[string]interface{}{
+			"version": mv.Version,
+			"reason":  reason,
+		})
+	})
+	if err != nil {
+		return ModuleVersion{}, err
+	}
+
+	return mv, nil
+}
+
+// UnYank reverts a yanked ModuleVersion by the given user and clears the yank
+// reason. It returns an error upon failure.
+func (mv ModuleVersion) UnYank(db *gorm.DB, actorID uint) (ModuleVersion, error) {
+	mv.Yanked = false
+	mv.YankReason = sql.NullString{}
+
+	err := db.Transaction(func(tx *gorm.DB) error {
+		// Note: We use a map as GORM does not update zero-valued struct fields.
+		if err := tx.Model(&mv).Updates(map[string]interface{}{
+			"yanked":      mv.Yanked,
+			"yank_reason": mv.YankReason,
+		}).Error; err != nil {
+			return fmt.Errorf("failed to un-yank module version: %w", err)
+		}
+
+		return recordAuditEvent(tx, AuditActionModuleUnYank, actorID, mv.ModuleID, map[string]interface{}{"version": mv.Version})
+	})
+	if err != nil {
+		return ModuleVersion{}, err
+	}
+
+	return mv, nil
+}
+
+// QueryModuleVersion performs a query for a ModuleVersion record. The resulting
+// record, if it exists, is returned. If the query fails or the record does not
+// exist, an error is returned.
+func QueryModuleVersion(db *gorm.DB, query map[string]interface{}) (ModuleVersion, error) {
+	var record ModuleVersion
+
+	if err := db.Where(query).First(&record).Error; err != nil {
+		return ModuleVersion{}, fmt.Errorf("failed to query module version: %w", err)
+	}
+
+	return record, nil
+}
+
+// BeforeCreate implements a GORM hook for validating a ModuleVersion record's
+// version as a Semantic Version and its SDK compatibility as a Semantic Version
+// constraint, and setting its sort key before it is created. Versions are
+// immutable once published, so existing records, which may also be saved as part
+// of a Module's associations, are skipped.
+func (mv *ModuleVersion) BeforeCreate(_ *gorm.DB) error {
+	if mv.ID != 0 {
+		return nil
+	}
+
+	v, err := semver.Parse(mv.Version)
+	if err != nil {
+		return err
+	}
+
+	if mv.SDKCompat.Valid {
+		if _, err := semver.ParseConstraint(mv.SDKCompat.String); err != nil {
+			return fmt.Errorf("invalid SDK compatibility: %w", err)
+		}
 	}
+
+	mv.SortKey = v.SortKey()
+	return nil
+}
+
+// recordPublish records the publishing of the module version by its publisher
+// in the audit log.
+func (mv ModuleVersion) recordPublish(tx *gorm.DB) error {
+	return recordAuditEvent(tx, AuditActionModulePublish, mv.PublishedBy, mv.ModuleID, map[string]interface{}{"version": mv.Version})
+}
+
+// createSDKCompatRanges creates the SDKCompatRange records for a newly created
+// ModuleVersion, if it defines an SDK compatibility constraint.
+func (mv ModuleVersion) createSDKCompatRanges(db *gorm.DB) error {
+	if !mv.SDKCompat.Valid {
+		return nil
+	}
+
+	c, err := semver.ParseConstraint(mv.SDKCompat.String)
+	if err != nil {
+		return fmt.Errorf("invalid SDK compatibility: %w", err)
+	}
+
+	ranges := make([]SDKCompatRange, len(c.Ranges()))
+	for i, r := range c.Ranges() {
+		ranges[i].ModuleVersionID = mv.ID
+
+		if r.Min != nil {
+			ranges[i].MinKey = NewNullString(r.Min.SortKey())
+		}
+		if r.Max != nil {
+			ranges[i].MaxKey = NewNullString(r.Max.SortKey())
+		}
+	}
+
+	if err := db.Create(&ranges).Error; err != nil {
+		return fmt.Errorf("failed to create SDK compatibility ranges: %w", err)
+	}
+
+	return nil
 }
 
 // MarshalJSON implements custom JSON marshaling for the BugTracker model.
@@ -172,6 +392,10 @@ func (bt BugTracker) NewBugTrackerJSON() BugTrackerJSON {
 
 // MarshalJSON implements custom JSON marshaling for the Module model.
 func (m Module) MarshalJSON() ([]byte, error) {
+	return json.Marshal(m.NewModuleJSON())
+}
+
+func (m Module) NewModuleJSON() ModuleJSON {
 	versionsJSON := make([]ModuleVersionJSON, len(m.Versions))
 	for i, v := range m.Versions {
 		versionsJSON[i] = v.NewModuleVersionJSON()
@@ -182,6 +406,11 @@ func (m Module) MarshalJSON() ([]byte, error) {
 		ownersJSON[i] = o.NewUserJSON()
 	}
 
+	ownerTeams := make([]string, len(m.OwnerTeams))
+	for i, t := range m.OwnerTeams {
+		ownerTeams[i] = t.Name
+	}
+
 	authorsJSON := make([]UserJSON, len(m.Authors))
 	for i, a := range m.Authors {
 		authorsJSON[i] = a.NewUserJSON()
@@ -192,7 +421,7 @@ func (m Module) MarshalJSON() ([]byte, error) {
 		keywordsJSON[i] = k.NewKeywordJSON()
 	}
 
-	return json.Marshal(ModuleJSON{
+	return ModuleJSON{
 		GormModelJSON: GormModelJSON{
 			ID:        m.ID,
 			CreatedAt: m.CreatedAt,
@@ -205,10 +434,11 @@ func (m Module) MarshalJSON() ([]byte, error) {
 		BugTracker:  m.BugTracker.NewBugTrackerJSON(),
 		Keywords:    keywordsJSON,
 		Owners:      ownersJSON,
+		OwnerTeams:  ownerTeams,
 		Authors:     authorsJSON,
 		Versions:    versionsJSON,
 		Stars:       m.Stars,
-	})
+	}
 }
 
 // BeforeSave implements a GORM hook for updating a Module record before it is
@@ -226,6 +456,13 @@ func (m *Module) BeforeSave(tx *gorm.DB) error {
 	return nil
 }
 
+// AfterFind implements a GORM hook for ordering a Module record's versions, if
+// loaded, by Semantic Versioning precedence from highest to lowest.
+func (m *Module) AfterFind(_ *gorm.DB) error {
+	sortVersions(m.Versions)
+	return nil
+}
+
 // Upsert will attempt to either create a new Module record or update an
 // existing record. A Module record is considered unique by a (name, team) index.
 // In the case of the record existing, all primary and one-to-one fields will be
@@ -275,6 +512,29 @@ func (m Module) Upsert(db *gorm.DB) (Module, error) {
 					return fmt.Errorf("failed to create module: %w", err)
 				}
 
+				if err := m.Versions[0].createSDKCompatRanges(tx); err != nil {
+					return err
+				}
+
+				if err := m.Versions[0].createDependencies(tx); err != nil {
+					return err
+				}
+
+				if err := m.Versions[0].createReadme(tx); err != nil {
+					return err
+				}
+
+				if err := m.Versions[0].recordPublish(tx); err != nil {
+					return err
+				}
+
+				// the initial owners, i.e. the publisher, add themselves
+				for _, o := range m.Owners {
+					if err := recordOwnerEvent(tx, m.ID, OwnerEventOwnerAdded, o, o.Name, ""); err != nil {
+						return err
+					}
+				}
+
 				// commit the tx
 				return nil
 			} else {
@@ -318,15 +578,41 @@ func (m Module) Upsert(db *gorm.DB) (Module, error) {
 		versionQuery := &ModuleVersion{Version: m.Version.Version, ModuleID: record.ID}
 		if err := tx.Where(versionQuery).First(&ModuleVersion{}).Error; err != nil && errors.Is(err, gorm.ErrRecordNotFound) {
 			modVer := ModuleVersion{
-				Documentation: m.Version.Documentation,
-				Repo:          m.Version.Repo,
-				Version:       m.Version.Version,
-				SDKCompat:     m.Version.SDKCompat,
-				PublishedBy:   m.Version.PublishedBy,
+				Documentation:        m.Version.Documentation,
+				Repo:                 m.Version.Repo,
+				Version:              m.Version.Version,
+				SDKCompat:            m.Version.SDKCompat,
+				PublishedBy:          m.Version.PublishedBy,
+				CommitSHA:            m.Version.CommitSHA,
+				SourceChecksum:       m.Version.SourceChecksum,
+				SourcePath:           m.Version.SourcePath,
+				Dependencies:         m.Version.Dependencies,
+				Readme:               m.Version.Readme,
+				PublishAuthorization: m.Version.PublishAuthorization,
 			}
 			if err := tx.Model(&record).Association("Versions").Append(&modVer); err != nil {
 				return fmt.Errorf("failed to update module version: %w", err)
 			}
+
+			if err := modVer.createSDKCompatRanges(tx); err != nil {
+				return err
+			}
+
+			if err := modVer.createDependencies(tx); err != nil {
+				return err
+			}
+
+			if err := modVer.createDependencyImpacts(tx); err != nil {
+				return err
+			}
+
+			if err := modVer.createReadme(tx); err != nil {
+				return err
+			}
+
+			if err := modVer.recordPublish(tx); err != nil {
+				return err
+			}
 		}
 
 		// update primary fields
@@ -382,12 +668,19 @@ func (m Module) Star(db *gorm.DB, userID uint) (int64, error) {
 		return m.Stars, nil
 	}
 
-	if err := db.Create(&UserModuleFavorite{ModuleID: m.ID, UserID: userID}).Error; err != nil {
-		return 0, fmt.Errorf("failed to favorite module: %w", err)
-	}
+	err = db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&UserModuleFavorite{ModuleID: m.ID, UserID: userID}).Error; err != nil {
+			return fmt.Errorf("failed to favorite module: %w", err)
+		}
 
-	if err := db.Save(&m).Error; err != nil {
-		return 0, fmt.Errorf("failed to update module: %w", err)
+		if err := tx.Save(&m).Error; err != nil {
+			return fmt.Errorf("failed to update module: %w", err)
+		}
+
+		return recordAuditEvent(tx, AuditActionModuleStar, userID, m.ID, nil)
+	})
+	if err != nil {
+		return 0, err
 	}
 
 	return m.Stars, nil
@@ -406,12 +699,19 @@ func (m Module) UnStar(db *gorm.DB, userID uint) (int64, error) {
 		return m.Stars, nil
 	}
 
-	if err := db.Delete(UserModuleFavorite{}, "module_id = ? AND user_id = ?", m.ID, userID).Error; err != nil {
-		return 0, fmt.Errorf("failed to remove favorite for module: %w", err)
-	}
+	err = db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Delete(UserModuleFavorite{}, "module_id = ? AND user_id = ?", m.ID, userID).Error; err != nil {
+			return fmt.Errorf("failed to remove favorite for module: %w", err)
+		}
 
-	if err := db.Save(&m).Error; err != nil {
-		return 0, fmt.Errorf("failed to update module: %w", err)
+		if err := tx.Save(&m).Error; err != nil {
+			return fmt.Errorf("failed to update module: %w", err)
+		}
+
+		return recordAuditEvent(tx, AuditActionModuleUnStar, userID, m.ID, nil)
+	})
+	if err != nil {
+		return 0, err
 	}
 
 	return m.Stars, nil
@@ -427,21 +727,45 @@ func QueryModule(db *gorm.DB, query map[string]interface{}) (Module, error) {
 		return Module{}, fmt.Errorf("failed to query module: %w", err)
 	}
 
+	if err := record.loadOwnerTeamMembers(db); err != nil {
+		return Module{}, err
+	}
+
 	return record, nil
 }
 
 // GetLatestVersion returns a module's latest version record, if the module
-// exists.
+// exists. The latest version is the non-yanked version with the highest Semantic
+// Versioning precedence, regardless of when it was published.
 func (m Module) GetLatestVersion(db *gorm.DB) (ModuleVersion, error) {
 	var mv ModuleVersion
 
-	if err := db.Order("created_at desc").Where("module_id = ?", m.ID).First(&mv).Error; err != nil {
+	if err := db.Order(versionOrderBy).Where("module_id = ? AND yanked = ?", m.ID, false).First(&mv).Error; err != nil {
 		return ModuleVersion{}, fmt.Errorf("failed to get latest module version: %w", err)
 	}
 
 	return mv, nil
 }
 
+// IsOwner returns true if a user by ID is an owner of the Module, either
+// directly or as an admin or publisher of a team that owns the Module. The
+// Module's owners and owner teams, along with their members, must be loaded.
+func (m Module) IsOwner(userID uint) bool {
+	for _, o := range m.Owners {
+		if o.ID == userID {
+			return true
+		}
+	}
+
+	for _, t := range m.OwnerTeams {
+		if t.isPublisher(userID) {
+			return true
+		}
+	}
+
+	return false
+}
+
 // AddOwner adds a given User as an owner to a Module and deletes the corresponding
 // ModuleOwnerInvite record. It returns an error upon failure.
 func (m Module) AddOwner(db *gorm.DB, owner User) (Module, error) {
@@ -461,8 +785,7 @@ func (m Module) AddOwner(db *gorm.DB, owner User) (Module, error) {
 			return fmt.Errorf("failed to delete module owner invitation: %w", err)
 		}
 
-		// commit the tx
-		return nil
+		return recordOwnerEvent(tx, m.ID, OwnerEventOwnerAdded, owner, owner.Name, "")
 	})
 	if err != nil {
 		return Module{}, err
@@ -471,6 +794,46 @@ func (m Module) AddOwner(db *gorm.DB, owner User) (Module, error) {
 	return record, nil
 }
 
+// AddOwnerTeam grants ownership of a Module to a given Team on behalf of an
+// actor. Granting ownership to a team that already owns the Module has no
+// effect. It returns an error upon failure.
+func (m Module) AddOwnerTeam(db *gorm.DB, actor User, team Team) (Module, error) {
+	for _, t := range m.OwnerTeams {
+		if t.ID == team.ID {
+			return m, nil
+		}
+	}
+
+	err := db.Transaction(func(tx *gorm.DB) error {
+		// only the association itself is saved, not the team's members
+		team.Members = nil
+
+		if err := tx.Model(&m).Association("OwnerTeams").Append(&team); err != nil {
+			return fmt.Errorf("failed to add module owner team: %w", err)
+		}
+
+		return recordOwnerEvent(tx, m.ID, OwnerEventTeamAdded, actor, "", team.Name)
+	})
+	if err != nil {
+		return Module{}, err
+	}
+
+	return GetModuleByID(db, m.ID)
+}
+
+// loadOwnerTeamMembers loads the members of each of the Module's owner teams,
+// which are required to determine if a user is an owner through a team. The
+// Module's owner teams association must be loaded.
+func (m *Module) loadOwnerTeamMembers(db *gorm.DB) error {
+	for i := range m.OwnerTeams {
+		if err := db.Where("team_id = ?", m.OwnerTeams[i].ID).Find(&m.OwnerTeams[i].Members).Error; err != nil {
+			return fmt.Errorf("failed to query for module owner team members: %w", err)
+		}
+	}
+
+	return nil
+}
+
 // GetModuleByID returns a module by ID. If the module doesn't exist or if the
 // query fails, an error is returned.
 func GetModuleByID(db *gorm.DB, id uint) (Module, error) {
@@ -480,24 +843,30 @@ func GetModuleByID(db *gorm.DB, id uint) (Module, error) {
 		return Module{}, fmt.Errorf("failed to query for module by ID: %w", err)
 	}
 
+	if err := m.loadOwnerTeamMembers(db); err != nil {
+		return Module{}, err
+	}
+
 	return m, nil
 }
 
 // GetAllModules returns a slice of Module objects paginated by an offset, order
-// and limit. An error is returned upon database query failure.
-func GetAllModules(db *gorm.DB, pq httputil.PaginationQuery) ([]Module, Paginator, error) {
+// and limit. If an SDK version is provided, only modules with a non-yanked
+// version compatible with that SDK version are returned. An error is returned
+// upon database query failure.
+func GetAllModules(db *gorm.DB, pq httputil.PaginationQuery, sdkVersion *semver.Version) ([]Module, Paginator, error) {
 	var (
 		modules []Module
 		total   int64
 	)
 
-	tx := db.Preload(clause.Associations)
+	tx := db.Preload(clause.Associations).Scopes(sdkCompatScope(sdkVersion))
 
 	if err := tx.Scopes(paginateScope(pq, &modules)).Error; err != nil {
 		return nil, Paginator{}, fmt.Errorf("failed to query for modules: %w", err)
 	}
 
-	if err := db.Model(&Module{}).Count(&total).Error; err != nil {
+	if err := db.Model(&Module{}).Scopes(sdkCompatScope(sdkVersion)).Count(&total).Error; err != nil {
 		return nil, Paginator{}, fmt.Errorf("failed to query for module count: %w", err)
 	}
 
@@ -505,9 +874,10 @@ func GetAllModules(db *gorm.DB, pq httputil.PaginationQuery) ([]Module, Paginato
 }
 
 // SearchModules performs a paginated query for a set of modules by name, team,
-// description or keywords. If no matching modules exist, an empty slice is
-// returned.
-func SearchModules(db *gorm.DB, query string, pq httputil.PaginationQuery) ([]Module, Paginator, error) {
+// description or keywords. If an SDK version is provided, only modules with a
+// non-yanked version compatible with that SDK version are returned. If no
+// matching modules exist, an empty slice is returned.
+func SearchModules(db *gorm.DB, query string, pq httputil.PaginationQuery, sdkVersion *semver.Version) ([]Module, Paginator, error) {
 	if len(query) == 0 {
 		return []Module{}, Paginator{}, nil
 	}
@@ -561,6 +931,19 @@ FROM
 		moduleIDs = append(moduleIDs, qr.ModuleID)
 	}
 
+	// filter the matching module IDs by SDK compatibility, if requested
+	if len(moduleIDs) > 0 && sdkVersion != nil {
+		var compatibleIDs []uint
+		if err := db.Model(&Module{}).
+			Scopes(sdkCompatScope(sdkVersion)).
+			Where("modules.id IN ?", moduleIDs).
+			Pluck("modules.id", &compatibleIDs).Error; err != nil {
+			return nil, Paginator{}, fmt.Errorf("failed to search for modules: %w", err)
+		}
+
+		moduleIDs = compatibleIDs
+	}
+
 	if len(moduleIDs) == 0 {
 		return []Module{}, Paginator{}, nil
 	}
@@ -600,8 +983,7 @@ func (moi ModuleOwnerInvite) Upsert(db *gorm.DB) (ModuleOwnerInvite, error) {
 					return fmt.Errorf("failed to create module owner invitation: %w", err)
 				}
 
-				// commit the tx
-				return nil
+				return moi.recordInvite(tx)
 			} else {
 				return fmt.Errorf("failed to query for module owner invitation: %w", err)
 			}
@@ -611,8 +993,7 @@ func (moi ModuleOwnerInvite) Upsert(db *gorm.DB) (ModuleOwnerInvite, error) {
 			return fmt.Errorf("failed to update module owner invitation: %w", err)
 		}
 
-		// commit the tx
-		return nil
+		return recordAuditEvent(tx, AuditActionOwnerInviteResend, moi.InvitedByUserID, moi.ModuleID, map[string]interface{}{"invited_user_id": moi.InvitedUserID})
 	})
 	if err != nil {
 		return ModuleOwnerInvite{}, err
@@ -622,6 +1003,12 @@ func (moi ModuleOwnerInvite) Upsert(db *gorm.DB) (ModuleOwnerInvite, error) {
 	return record, err
 }
 
+// recordInvite records the sending of the invitation by the inviter in the audit
+// log.
+func (moi ModuleOwnerInvite) recordInvite(tx *gorm.DB) error {
+	return recordAuditEvent(tx, AuditActionOwnerInvite, moi.InvitedByUserID, moi.ModuleID, map[string]interface{}{"invited_user_id": moi.InvitedUserID})
+}
+
 // QueryModuleOwnerInvite performs a query for a ModuleOwnerInvite record.
 // The resulting record, if it exists, is returned. If the query fails or the
 // record does not exist, an error is returned.
@@ -634,3 +1021,134 @@ func QueryModuleOwnerInvite(db *gorm.DB, query map[string]interface{}) (ModuleOw
 
 	return record, nil
 }
+
+// NewModuleOwnerInviteJSON returns the JSON-encodeable type for a
+// ModuleOwnerInvite which expires after the given TTL. The invitation's module
+// and users must be loaded.
+func (moi ModuleOwnerInvite) NewModuleOwnerInviteJSON(ttl time.Duration) ModuleOwnerInviteJSON {
+	return ModuleOwnerInviteJSON{
+		CreatedAt:   moi.CreatedAt,
+		UpdatedAt:   moi.UpdatedAt,
+		ExpiresAt:   moi.UpdatedAt.Add(ttl),
+		ModuleID:    moi.ModuleID,
+		Module:      moi.Module.Name,
+		Team:        moi.Module.Team,
+		InvitedUser: moi.InvitedUser.Name,
+		InvitedBy:   moi.InvitedByUser.Name,
+		Token:       moi.Token,
+	}
+}
+
+// Expired returns true if the ModuleOwnerInvite was last sent more than the
+// given TTL ago.
+func (moi ModuleOwnerInvite) Expired(ttl time.Duration) bool {
+	return time.Since(moi.UpdatedAt) > ttl
+}
+
+// Revoke deletes the ModuleOwnerInvite on behalf of the inviter. It returns an
+// error upon failure.
+func (moi ModuleOwnerInvite) Revoke(db *gorm.DB) error {
+	return moi.delete(db, AuditActionOwnerInviteRevoke, moi.InvitedByUserID)
+}
+
+// Decline deletes the ModuleOwnerInvite on behalf of the invited user. It
+// returns an error upon failure.
+func (moi ModuleOwnerInvite) Decline(db *gorm.DB) error {
+	return moi.delete(db, AuditActionOwnerInviteDecline, moi.InvitedUserID)
+}
+
+// delete deletes the ModuleOwnerInvite and records the given audit event action
+// caused by the given user.
+func (moi ModuleOwnerInvite) delete(db *gorm.DB, action string, actorID uint) error {
+	return db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("module_id = ? AND invited_user_id = ?", moi.ModuleID, moi.InvitedUserID).Delete(ModuleOwnerInvite{}).Error; err != nil {
+			return fmt.Errorf("failed to delete module owner invitation: %w", err)
+		}
+
+		return recordAuditEvent(tx, action, actorID, moi.ModuleID, map[string]interface{}{"invited_user_id": moi.InvitedUserID})
+	})
+}
+
+// GetPendingModuleOwnerInvites returns all ModuleOwnerInvite records matching a
+// query which have not expired given a TTL, along with their module and users,
+// ordered from oldest to newest. An error is returned upon query failure.
+func GetPendingModuleOwnerInvites(db *gorm.DB, query map[string]interface{}, ttl time.Duration) ([]ModuleOwnerInvite, error) {
+	var invites []ModuleOwnerInvite
+
+	if err := db.Preload("Module").Preload("InvitedUser").Preload("InvitedByUser").
+		Where(query).
+		Where("updated_at > ?", time.Now().Add(-ttl)).
+		Order("created_at ASC").
+		Find(&invites).Error; err != nil {
+		return nil, fmt.Errorf("failed to query for module owner invitations: %w", err)
+	}
+
+	return invites, nil
+}
+
+// PurgeModuleOwnerInvites deletes all ModuleOwnerInvite records which have
+// expired given a TTL. It returns the number of deleted records and an error
+// upon failure.
+func PurgeModuleOwnerInvites(db *gorm.DB, ttl time.Duration) (int64, error) {
+	result := db.Where("updated_at <= ?", time.Now().Add(-ttl)).Delete(ModuleOwnerInvite{})
+	if result.Error != nil {
+		return 0, fmt.Errorf("failed to purge module owner invitations: %w", result.Error)
+	}
+
+	return result.RowsAffected, nil
+}
+
+// versionOrderBy defines the ORDER BY clause that orders ModuleVersion records
+// from highest to lowest Semantic Versioning precedence. Records without a sort
+// key (i.e. published before sort keys were introduced) are ordered last.
+const versionOrderBy = "sort_key DESC NULLS LAST, created_at DESC"
+
+// sdkCompatScope returns a GORM scope that filters modules by having at least
+// one non-yanked version compatible with the given SDK version. If no SDK version
+// is provided, no filter is applied.
+func sdkCompatScope(sdkVersion *semver.Version) func(*gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		if sdkVersion == nil {
+			return db
+		}
+
+		key := sdkVersion.SortKey()
+		return db.Where(`modules.id IN (
+  SELECT
+    mv.module_id
+  FROM
+    module_versions mv
+    INNER JOIN
+      sdk_compat_ranges r
+      ON (mv.id = r.module_version_id)
+  WHERE
+    mv.yanked = FALSE
+    AND mv.deleted_at IS NULL
+    AND r.deleted_at IS NULL
+    AND (r.min_key IS NULL OR r.min_key <= ?)
+    AND (r.max_key IS NULL OR r.max_key > ?)
+)`, key, key)
+	}
+}
+
+// sortVersions sorts a slice of ModuleVersion records in place, consistent with
+// versionOrderBy.
+func sortVersions(versions []ModuleVersion) {
+	sort.SliceStable(versions, func(i, j int) bool {
+		a, b := versions[i], versions[j]
+
+		switch {
+		case a.SortKey == b.SortKey:
+			return a.CreatedAt.After(b.CreatedAt)
+
+		case a.SortKey == "":
+			return false
+
+		case b.SortKey == "":
+			return true
+
+		default:
+			return a.SortKey > b.SortKey
+		}
+	})
+}
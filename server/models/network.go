@@ -0,0 +1,173 @@
+package models
+
+import (
+	"fmt"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// txIndexEnabled defines the tx indexing status reported by a Tendermint node
+// which has tx indexing enabled.
+const txIndexEnabled = "on"
+
+// Network defines summary statistics of a Tendermint network, i.e. chain-id,
+// aggregated from its crawled nodes. The node counts include offline nodes,
+// whereas the remaining statistics are aggregated from online nodes only, as an
+// offline node's last crawled metadata may be outdated. The country distribution
+// omits nodes without a location and the median block height only considers
+// nodes that reported their sync info.
+type Network struct {
+	ChainID           string           `json:"chain_id"`
+	Nodes             int64            `json:"nodes"`
+	OnlineNodes       int64            `json:"online_nodes"`
+	Versions          map[string]int64 `json:"versions"`
+	Countries         map[string]int64 `json:"countries"`
+	TxIndexShare      float64          `json:"tx_index_share"`
+	MedianBlockHeight int64            `json:"median_block_height"`
+	LastCrawledAt     time.Time        `json:"last_crawled_at"`
+}
+
+type (
+	networkRow struct {
+		ChainID           string
+		Nodes             int64
+		OnlineNodes       int64
+		TxIndexNodes      int64
+		MedianBlockHeight int64
+		LastCrawledAt     time.Time
+	}
+
+	networkCountRow struct {
+		ChainID string
+		Value   string
+		Count   int64
+	}
+)
+
+// GetNetworks returns the summary statistics of all crawled networks ordered by
+// chain-id. An error is returned upon database query failure.
+func GetNetworks(db *gorm.DB) ([]Network, error) {
+	networks, err := queryNetworks(db, "TRUE")
+	if err != nil {
+		return nil, fmt.Errorf("failed to query for networks: %w", err)
+	}
+
+	return networks, nil
+}
+
+// GetNetwork returns the summary statistics of a crawled network by chain-id.
+// An error wrapping gorm.ErrRecordNotFound is returned if no nodes of the
+// network have been crawled and an error is returned upon database query
+// failure.
+func GetNetwork(db *gorm.DB, chainID string) (Network, error) {
+	networks, err := queryNetworks(db, "n.network = ?", chainID)
+	if err != nil {
+		return Network{}, fmt.Errorf("failed to query for network: %w", err)
+	}
+
+	if len(networks) == 0 {
+		return Network{}, fmt.Errorf("failed to query for network: %w", gorm.ErrRecordNotFound)
+	}
+
+	return networks[0], nil
+}
+
+// queryNetworks aggregates the summary statistics of the networks of all nodes
+// matching the given condition.
+func queryNetworks(db *gorm.DB, cond string, args ...interface{}) ([]Network, error) {
+	var rows []networkRow
+
+	err := db.Raw(fmt.Sprintf(`SELECT
+  n.network AS chain_id,
+  COUNT(*) AS nodes,
+  COUNT(*) FILTER (WHERE n.online) AS online_nodes,
+  COUNT(*) FILTER (WHERE n.online AND n.tx_index = '%s') AS tx_index_nodes,
+  COALESCE(
+    percentile_disc(0.5) WITHIN GROUP (ORDER BY n.latest_block_height) FILTER (WHERE n.online AND n.latest_block_height > 0),
+    0
+  ) AS median_block_height,
+  MAX(n.updated_at) AS last_crawled_at
+FROM
+  nodes n
+WHERE
+  %s
+GROUP BY
+  n.network
+ORDER BY
+  n.network;
+`, txIndexEnabled, cond), args...).Scan(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+
+	versions, err := queryNetworkCounts(db, "n.version", cond, args...)
+	if err != nil {
+		return nil, err
+	}
+
+	countries, err := queryNetworkCounts(db, "l.country", cond, args...)
+	if err != nil {
+		return nil, err
+	}
+
+	networks := make([]Network, len(rows))
+	for i, row := range rows {
+		network := Network{
+			ChainID:           row.ChainID,
+			Nodes:             row.Nodes,
+			OnlineNodes:       row.OnlineNodes,
+			Versions:          map[string]int64{},
+			Countries:         map[string]int64{},
+			TxIndexShare:      percentage(int(row.TxIndexNodes), int(row.OnlineNodes)),
+			MedianBlockHeight: row.MedianBlockHeight,
+			LastCrawledAt:     row.LastCrawledAt,
+		}
+
+		for _, c := range versions {
+			if c.ChainID == row.ChainID {
+				network.Versions[c.Value] = c.Count
+			}
+		}
+
+		for _, c := range countries {
+			if c.ChainID == row.ChainID {
+				network.Countries[c.Value] = c.Count
+			}
+		}
+
+		networks[i] = network
+	}
+
+	return networks, nil
+}
+
+// queryNetworkCounts returns the number of online nodes per network and value of
+// the given column, for all nodes matching the given condition. Nodes without a
+// value, e.g. nodes without a location, are omitted.
+func queryNetworkCounts(db *gorm.DB, column, cond string, args ...interface{}) ([]networkCountRow, error) {
+	var rows []networkCountRow
+
+	err := db.Raw(fmt.Sprintf(`SELECT
+  n.network AS chain_id,
+  %s AS value,
+  COUNT(*) AS count
+FROM
+  nodes n
+  LEFT JOIN
+    locations l
+    ON (n.location_id = l.id)
+WHERE
+  n.online
+  AND %s IS NOT NULL
+  AND %s
+GROUP BY
+  n.network,
+  %s;
+`, column, column, cond, column), args...).Scan(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+
+	return rows, nil
+}
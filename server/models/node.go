@@ -1,6 +1,7 @@
 package models
 
 import (
+	"database/sql"
 	"encoding/json"
 	"errors"
 	"fmt"
@@ -49,15 +50,29 @@ type NodeJSON struct {
 	Network  string       `json:"network"`
 	Version  string       `json:"version"`
 	TxIndex  string       `json:"tx_index"`
+
+	LatestBlockHeight int64       `json:"latest_block_height"`
+	LatestBlockTime   interface{} `json:"latest_block_time"`
+	CatchingUp        bool        `json:"catching_up"`
+	VotingPower       int64       `json:"voting_power"`
+
+	Online     bool        `json:"online"`
+	LastSeenAt interface{} `json:"last_seen_at"`
 }
 
-// Node defines a crawled Tendermint node.
+// Node defines a crawled Tendermint node. A node that can no longer be reached
+// is marked offline, retaining its last crawled metadata, rather than removed.
+// A node's location is unknown, i.e. it has no Location, if it could not be
+// geolocated.
+// The node's sync info, i.e. its latest block and whether it is catching up, and
+// its voting power, which is zero unless the node is a validator, are recorded
+// as of its last successful status RPC call.
 type Node struct {
 	ID        uint `gorm:"primarykey"`
 	CreatedAt time.Time
 	UpdatedAt time.Time
 
-	LocationID uint
+	LocationID *uint
 	Location   Location
 	Address    string
 	RPCPort    string `gorm:"column:rpc_port"`
@@ -67,6 +82,26 @@ type Node struct {
 	Network    string
 	Version    string
 	TxIndex    string
+	Online     bool
+	LastSeenAt sql.NullTime
+
+	LatestBlockHeight int64
+	LatestBlockTime   sql.NullTime
+	CatchingUp        bool
+	VotingPower       int64
+}
+
+// HasStatus returns true if the Node's metadata was retrieved via its status RPC
+// call, which is the only source of its node ID. A node crawled without its
+// status has only its address, ports, network and location set.
+func (n Node) HasStatus() bool {
+	return n.NodeID != ""
+}
+
+// HasCoordinates returns true if the Location has both a latitude and longitude,
+// which is required for it to be persisted.
+func (l Location) HasCoordinates() bool {
+	return l.Latitude != "" && l.Longitude != ""
 }
 
 // MarshalJSON implements custom JSON marshaling for the Location model.
@@ -95,6 +130,9 @@ func (n Node) MarshalJSON() ([]byte, error) {
 }
 
 func (n Node) NewNodeJSON() NodeJSON {
+	lastSeenAt, _ := n.LastSeenAt.Value()
+	latestBlockTime, _ := n.LatestBlockTime.Value()
+
 	return NodeJSON{
 		GormModelJSON: GormModelJSON{
 			ID:        n.ID,
@@ -110,6 +148,14 @@ func (n Node) NewNodeJSON() NodeJSON {
 		Network:  n.Network,
 		Version:  n.Version,
 		TxIndex:  n.TxIndex,
+
+		LatestBlockHeight: n.LatestBlockHeight,
+		LatestBlockTime:   latestBlockTime,
+		CatchingUp:        n.CatchingUp,
+		VotingPower:       n.VotingPower,
+
+		Online:     n.Online,
+		LastSeenAt: lastSeenAt,
 	}
 }
 
@@ -117,7 +163,7 @@ func (n Node) NewNodeJSON() NodeJSON {
 // will be created. Otherwise, the existing record is updated. An error is returned
 // upon failure. The updated or created record is returned upon success.
 func (l Location) Upsert(db *gorm.DB) (Location, error) {
-	if l.Longitude == "" || l.Latitude == "" {
+	if !l.HasCoordinates() {
 		return Location{}, errors.New("longitude and latitude are required")
 	}
 
@@ -171,20 +217,31 @@ func QueryLocation(db *gorm.DB, query map[string]interface{}) (Location, error)
 }
 
 // Upsert creates or updates a Node record. If no record exists, a new one will
-// be created. Otherwise, the existing record is updated. An error is returned
-// upon failure. The updated or created record is returned upon success.
+// be created. Otherwise, the existing record is updated. In either case, the
+// node is marked as online and last seen now, as it has just been crawled. If
+// the node's Location has no coordinates, i.e. it could not be geolocated, the
+// node's existing location, if any, is retained. Likewise, if the node has no
+// status, its existing status-derived metadata, e.g. its moniker, version and
+// latest block, is retained. An error is returned upon failure. The updated or
+// created record is returned upon success.
 func (n Node) Upsert(db *gorm.DB) (Node, error) {
 	var record Node
 
+	n.Online = true
+	n.LastSeenAt = NewNullTime(time.Now())
+
 	err := db.Transaction(func(tx *gorm.DB) error {
-		loc, err := n.Location.Upsert(tx)
-		if err != nil {
-			return err
-		}
+		hasLocation := n.Location.HasCoordinates()
+		if hasLocation {
+			loc, err := n.Location.Upsert(tx)
+			if err != nil {
+				return err
+			}
 
-		n.Location = loc
+			n.Location = loc
+		}
 
-		err = tx.Where("address = ? AND network = ?", n.Address, n.Network).First(&record).Error
+		err := tx.Where("address = ? AND network = ?", n.Address, n.Network).First(&record).Error
 		if err != nil {
 			if errors.Is(err, gorm.ErrRecordNotFound) {
 				if err := tx.Create(&n).Error; err != nil {
@@ -198,18 +255,30 @@ func (n Node) Upsert(db *gorm.DB) (Node, error) {
 			}
 		}
 
-		if err := tx.Model(&record).Association("Location").Replace(&n.Location); err != nil {
-			return fmt.Errorf("failed to update node location: %w", err)
+		if hasLocation {
+			if err := tx.Model(&record).Association("Location").Replace(&n.Location); err != nil {
+				return fmt.Errorf("failed to update node location: %w", err)
+			}
 		}
 
 		record.Address = n.Address
 		record.RPCPort = n.RPCPort
 		record.P2PPort = n.P2PPort
-		record.Moniker = n.Moniker
-		record.NodeID = n.NodeID
 		record.Network = n.Network
-		record.Version = n.Version
-		record.TxIndex = n.TxIndex
+		record.Online = n.Online
+		record.LastSeenAt = n.LastSeenAt
+
+		if n.HasStatus() {
+			record.Moniker = n.Moniker
+			record.NodeID = n.NodeID
+			record.Version = n.Version
+			record.TxIndex = n.TxIndex
+			record.LatestBlockHeight = n.LatestBlockHeight
+			record.LatestBlockTime = n.LatestBlockTime
+			record.CatchingUp = n.CatchingUp
+			record.VotingPower = n.VotingPower
+		}
+
 		if err := tx.Save(&record).Error; err != nil {
 			return fmt.Errorf("failed to update node: %w", err)
 		}
@@ -235,21 +304,54 @@ func (n Node) Delete(db *gorm.DB) error {
 	return nil
 }
 
+// MarkOffline marks a Node record as offline and records a failed observation of
+// it. The record is matched by its address and network or, if the network is
+// unknown, by its address only, in which case all nodes at the address are marked
+// offline. The node's last crawled metadata is retained. An error is not returned
+// if no record exists, i.e. if the node was never successfully crawled.
+func (n Node) MarkOffline(db *gorm.DB) error {
+	return db.Transaction(func(tx *gorm.DB) error {
+		query := tx.Where("address = ?", n.Address)
+		if n.Network != "" {
+			query = query.Where("network = ?", n.Network)
+		}
+
+		var records []Node
+		if err := query.Find(&records).Error; err != nil {
+			return fmt.Errorf("failed to query for node: %w", err)
+		}
+
+		for _, record := range records {
+			if err := tx.Model(&record).Update("online", false).Error; err != nil {
+				return fmt.Errorf("failed to mark node offline: %w", err)
+			}
+
+			if _, err := record.RecordObservation(tx, NodeObservation{Reachable: false}); err != nil {
+				return err
+			}
+		}
+
+		// commit the tx
+		return nil
+	})
+}
+
 // GetAllNodes returns a slice of Node records paginated by an offset, order
-// and limit. An error is returned upon database query failure.
-func GetAllNodes(db *gorm.DB, pq httputil.PaginationQuery) ([]Node, Paginator, error) {
+// and limit. If online is provided, only nodes which are online, or offline,
+// accordingly are returned. An error is returned upon database query failure.
+func GetAllNodes(db *gorm.DB, pq httputil.PaginationQuery, online *bool) ([]Node, Paginator, error) {
 	var (
 		nodes []Node
 		total int64
 	)
 
-	tx := db.Preload(clause.Associations)
+	tx := db.Preload(clause.Associations).Scopes(nodeOnlineScope(online))
 
 	if err := tx.Scopes(paginateScope(pq, &nodes)).Error; err != nil {
 		return nil, Paginator{}, fmt.Errorf("failed to query for nodes: %w", err)
 	}
 
-	if err := db.Model(&Node{}).Count(&total).Error; err != nil {
+	if err := db.Model(&Node{}).Scopes(nodeOnlineScope(online)).Count(&total).Error; err != nil {
 		return nil, Paginator{}, fmt.Errorf("failed to query for node count: %w", err)
 	}
 
@@ -257,18 +359,24 @@ func GetAllNodes(db *gorm.DB, pq httputil.PaginationQuery) ([]Node, Paginator, e
 }
 
 // SearchNodes performs a paginated query for a set of Node records by moniker,
-// network, version or location. If any empty query is provided, we return a
-// paginated list of all Node records. Otherwise, if no matching Node records
-// exist, an empty slice is returned.
-func SearchNodes(db *gorm.DB, query string, pq httputil.PaginationQuery) ([]Node, Paginator, error) {
+// network, version or location. If online is provided, only nodes which are
+// online, or offline, accordingly are matched. If any empty query is provided,
+// we return a paginated list of all Node records. Otherwise, if no matching Node
+// records exist, an empty slice is returned.
+func SearchNodes(db *gorm.DB, query string, pq httputil.PaginationQuery, online *bool) ([]Node, Paginator, error) {
 	if query == "" {
-		return GetAllNodes(db, pq)
+		return GetAllNodes(db, pq, online)
 	}
 
 	type queryRow struct {
 		NodeID uint
 	}
 
+	var onlineFilter sql.NullBool
+	if online != nil {
+		onlineFilter = sql.NullBool{Bool: *online, Valid: true}
+	}
+
 	rows, err := db.Raw(`SELECT DISTINCT
   ON (node_id) results.node_id AS node_id
 FROM
@@ -288,9 +396,10 @@ FROM
         ON (n.location_id = l.id)
     WHERE
       to_tsvector('english', COALESCE(n.moniker, '') || ' ' || COALESCE(n.network, '') || ' ' || COALESCE(n.version, '') || ' ' || COALESCE(l.country, '') || ' ' || COALESCE(l.region, '') || ' ' || COALESCE(l.city, '')) @@ websearch_to_tsquery('english', ?)
+      AND (CAST(? AS BOOLEAN) IS NULL OR n.online = ?)
   )
   AS results;
-`, query).Rows()
+`, query, onlineFilter, onlineFilter).Rows()
 	if err != nil {
 		return nil, Paginator{}, fmt.Errorf("failed to search for nodes: %w", err)
 	}
@@ -324,6 +433,18 @@ FROM
 	return nodes, buildPaginator(pq, int64(len(nodeIDs))), nil
 }
 
+// nodeOnlineScope returns a GORM scope that filters nodes by whether they are
+// online. If online is not provided, no filter is applied.
+func nodeOnlineScope(online *bool) func(*gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		if online == nil {
+			return db
+		}
+
+		return db.Where("online = ?", *online)
+	}
+}
+
 // QueryNode performs a query for a Node record. The resulting record, if it
 // exists, is returned. If the query fails or the record does not exist, an
 // error is returned.
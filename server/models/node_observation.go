@@ -0,0 +1,196 @@
+package models
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"math"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// NodeObservationRetention defines the duration for which node observations are
+// retained, which bounds the window over which a node's uptime can be queried.
+const NodeObservationRetention = 30 * 24 * time.Hour
+
+type (
+	// NodeObservationJSON defines the JSON-encodeable type for a NodeObservation.
+	NodeObservationJSON struct {
+		ID        uint        `json:"id"`
+		CreatedAt time.Time   `json:"created_at"`
+		NodeID    uint        `json:"node_id"`
+		Reachable bool        `json:"reachable"`
+		LatencyMS int64       `json:"latency_ms"`
+		Height    interface{} `json:"height"`
+		Version   interface{} `json:"version"`
+	}
+
+	// NodeObservation defines the result of a single crawl attempt of a Node,
+	// i.e. whether the node was reachable, the latency of reaching its P2P
+	// address and, if its status could be retrieved, its latest block height and
+	// version at the time.
+	NodeObservation struct {
+		ID        uint `gorm:"primaryKey"`
+		CreatedAt time.Time
+
+		NodeID    uint
+		Reachable bool
+		LatencyMS int64 `gorm:"column:latency_ms"`
+		Height    sql.NullInt64
+		Version   sql.NullString
+	}
+
+	// NodeAvailability defines the availability of a Node over a single day, as
+	// the percentage of the day's observations in which the node was reachable.
+	NodeAvailability struct {
+		Date         string  `json:"date"`
+		Observations int     `json:"observations"`
+		Availability float64 `json:"availability"`
+	}
+
+	// NodeOutage defines a period in which a Node was unreachable, starting at
+	// the first failed observation and ending at the next successful one. An
+	// ongoing outage has no end.
+	NodeOutage struct {
+		Start time.Time  `json:"start"`
+		End   *time.Time `json:"end"`
+	}
+
+	// NodeUptime defines the availability of a Node over a window of time, as the
+	// percentage of observations in which the node was reachable, along with the
+	// daily availability and the timeline of outages within the window.
+	NodeUptime struct {
+		NodeID       uint               `json:"node_id"`
+		Since        time.Time          `json:"since"`
+		Until        time.Time          `json:"until"`
+		Observations int                `json:"observations"`
+		Availability float64            `json:"availability"`
+		Daily        []NodeAvailability `json:"daily"`
+		Outages      []NodeOutage       `json:"outages"`
+	}
+)
+
+// MarshalJSON implements custom JSON marshaling for the NodeObservation model.
+func (no NodeObservation) MarshalJSON() ([]byte, error) {
+	height, _ := no.Height.Value()
+	version, _ := no.Version.Value()
+
+	return json.Marshal(NodeObservationJSON{
+		ID:        no.ID,
+		CreatedAt: no.CreatedAt,
+		NodeID:    no.NodeID,
+		Reachable: no.Reachable,
+		LatencyMS: no.LatencyMS,
+		Height:    height,
+		Version:   version,
+	})
+}
+
+// RecordObservation records an observation of a crawl attempt of a Node. The
+// created observation is returned.
+func (n Node) RecordObservation(db *gorm.DB, obs NodeObservation) (NodeObservation, error) {
+	obs.NodeID = n.ID
+
+	if err := db.Create(&obs).Error; err != nil {
+		return NodeObservation{}, fmt.Errorf("failed to record node observation: %w", err)
+	}
+
+	return obs, nil
+}
+
+// GetNodeObservations returns a node's observations made since the given time
+// ordered from oldest to newest. An error is returned upon query failure.
+func GetNodeObservations(db *gorm.DB, nodeID uint, since time.Time) ([]NodeObservation, error) {
+	var observations []NodeObservation
+
+	if err := db.Where("node_id = ? AND created_at >= ?", nodeID, since).Order("created_at ASC, id ASC").Find(&observations).Error; err != nil {
+		return nil, fmt.Errorf("failed to query for node observations: %w", err)
+	}
+
+	return observations, nil
+}
+
+// PurgeNodeObservations deletes all node observations made before the given
+// time. The number of deleted observations is returned.
+func PurgeNodeObservations(db *gorm.DB, before time.Time) (int64, error) {
+	result := db.Where("created_at < ?", before).Delete(&NodeObservation{})
+	if result.Error != nil {
+		return 0, fmt.Errorf("failed to purge node observations: %w", result.Error)
+	}
+
+	return result.RowsAffected, nil
+}
+
+// GetNodeUptime returns the uptime of a Node since the given time based on its
+// observations. Days without any observations are omitted from the daily
+// availability. An error is returned upon query failure.
+func GetNodeUptime(db *gorm.DB, nodeID uint, since time.Time) (NodeUptime, error) {
+	observations, err := GetNodeObservations(db, nodeID, since)
+	if err != nil {
+		return NodeUptime{}, err
+	}
+
+	uptime := NodeUptime{
+		NodeID:       nodeID,
+		Since:        since,
+		Until:        time.Now(),
+		Observations: len(observations),
+		Daily:        []NodeAvailability{},
+		Outages:      []NodeOutage{},
+	}
+
+	var (
+		reachable      int
+		dailyReachable []int
+		outage         *NodeOutage
+	)
+
+	for _, obs := range observations {
+		date := obs.CreatedAt.UTC().Format("2006-01-02")
+		if len(uptime.Daily) == 0 || uptime.Daily[len(uptime.Daily)-1].Date != date {
+			uptime.Daily = append(uptime.Daily, NodeAvailability{Date: date})
+			dailyReachable = append(dailyReachable, 0)
+		}
+
+		day := len(uptime.Daily) - 1
+		uptime.Daily[day].Observations++
+
+		if obs.Reachable {
+			reachable++
+			dailyReachable[day]++
+
+			if outage != nil {
+				end := obs.CreatedAt
+				outage.End = &end
+				uptime.Outages = append(uptime.Outages, *outage)
+				outage = nil
+			}
+		} else if outage == nil {
+			outage = &NodeOutage{Start: obs.CreatedAt}
+		}
+	}
+
+	// the node is still unreachable as of its latest observation
+	if outage != nil {
+		uptime.Outages = append(uptime.Outages, *outage)
+	}
+
+	for i := range uptime.Daily {
+		uptime.Daily[i].Availability = percentage(dailyReachable[i], uptime.Daily[i].Observations)
+	}
+
+	uptime.Availability = percentage(reachable, len(observations))
+
+	return uptime, nil
+}
+
+// percentage returns the percentage, rounded to two decimals, of a part of a
+// total, where the percentage of an empty total is zero.
+func percentage(part, total int) float64 {
+	if total == 0 {
+		return 0
+	}
+
+	return math.Round(float64(part)/float64(total)*10000) / 100
+}
@@ -0,0 +1,361 @@
+package models
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Module ownership event actions.
+const (
+	OwnerEventOwnerAdded      = "owner_added"
+	OwnerEventOwnerRemoved    = "owner_removed"
+	OwnerEventTeamAdded       = "team_added"
+	OwnerEventTeamRemoved     = "team_removed"
+	OwnerEventTransferredUser = "transferred_to_user"
+	OwnerEventTransferredTeam = "transferred_to_team"
+)
+
+// ErrLastModuleOwner defines a sentinel error when an operation would leave a
+// module without any owner, i.e. without an owning user or team.
+var ErrLastModuleOwner = errors.New("module must have at least one owner")
+
+type (
+	// ModuleOwnerEventJSON defines the JSON-encodeable type for a
+	// ModuleOwnerEvent.
+	ModuleOwnerEventJSON struct {
+		ID        uint        `json:"id"`
+		CreatedAt time.Time   `json:"created_at"`
+		ModuleID  uint        `json:"module_id"`
+		Action    string      `json:"action"`
+		Actor     string      `json:"actor"`
+		User      interface{} `json:"user"`
+		Team      interface{} `json:"team"`
+	}
+
+	// ModuleOwnerEvent defines an entry in a module's ownership audit trail. The
+	// actor is the name of the user who made the change and the subject is the
+	// name of either the user or the team whose ownership changed. Names are
+	// recorded, rather than references, such that the trail is preserved when
+	// users or teams change.
+	ModuleOwnerEvent struct {
+		ID        uint `gorm:"primaryKey"`
+		CreatedAt time.Time
+
+		ModuleID uint
+		Action   string
+		Actor    string
+		UserName sql.NullString
+		TeamName sql.NullString
+	}
+)
+
+// MarshalJSON implements custom JSON marshaling for the ModuleOwnerEvent model.
+func (moe ModuleOwnerEvent) MarshalJSON() ([]byte, error) {
+	return json.Marshal(moe.NewModuleOwnerEventJSON())
+}
+
+// NewModuleOwnerEventJSON returns the JSON-encodeable type for a
+// ModuleOwnerEvent.
+func (moe ModuleOwnerEvent) NewModuleOwnerEventJSON() ModuleOwnerEventJSON {
+	userName, _ := moe.UserName.Value()
+	teamName, _ := moe.TeamName.Value()
+
+	return ModuleOwnerEventJSON{
+		ID:        moe.ID,
+		CreatedAt: moe.CreatedAt,
+		ModuleID:  moe.ModuleID,
+		Action:    moe.Action,
+		Actor:     moe.Actor,
+		User:      userName,
+		Team:      teamName,
+	}
+}
+
+// GetOwnerEvents returns a module's ownership audit trail ordered from oldest to
+// newest. An error is returned upon query failure.
+func (m Module) GetOwnerEvents(db *gorm.DB) ([]ModuleOwnerEvent, error) {
+	var events []ModuleOwnerEvent
+
+	if err := db.Where("module_id = ?", m.ID).Order("id ASC").Find(&events).Error; err != nil {
+		return nil, fmt.Errorf("failed to query for module owner events: %w", err)
+	}
+
+	return events, nil
+}
+
+// RemoveOwner removes a given User as an owner of a Module on behalf of an actor.
+// An error wrapping ErrLastModuleOwner is returned if the Module would be left
+// without any owner. The updated Module is returned.
+func (m Module) RemoveOwner(db *gorm.DB, actor, owner User) (Module, error) {
+	err := db.Transaction(func(tx *gorm.DB) error {
+		record, err := GetModuleByID(tx, m.ID)
+		if err != nil {
+			return err
+		}
+
+		isOwner := false
+		for _, o := range record.Owners {
+			if o.ID == owner.ID {
+				isOwner = true
+				break
+			}
+		}
+
+		if !isOwner {
+			return fmt.Errorf("'%s' is not an owner of the module", owner.Name)
+		}
+
+		if len(record.Owners)+len(record.OwnerTeams) <= 1 {
+			return fmt.Errorf("failed to remove module owner: %w", ErrLastModuleOwner)
+		}
+
+		if err := tx.Model(&record).Association("Owners").Delete(&owner); err != nil {
+			return fmt.Errorf("failed to remove module owner: %w", err)
+		}
+
+		if err := m.removeFormerOwnerGrants(tx, actor); err != nil {
+			return err
+		}
+
+		return recordOwnerEvent(tx, m.ID, OwnerEventOwnerRemoved, actor, owner.Name, "")
+	})
+	if err != nil {
+		return Module{}, err
+	}
+
+	return GetModuleByID(db, m.ID)
+}
+
+// RemoveOwnerTeam revokes the ownership of a Module from a given Team on behalf
+// of an actor. An error wrapping ErrLastModuleOwner is returned if the Module
+// would be left without any owner. The updated Module is returned.
+func (m Module) RemoveOwnerTeam(db *gorm.DB, actor User, team Team) (Module, error) {
+	err := db.Transaction(func(tx *gorm.DB) error {
+		record, err := GetModuleByID(tx, m.ID)
+		if err != nil {
+			return err
+		}
+
+		isOwner := false
+		for _, t := range record.OwnerTeams {
+			if t.ID == team.ID {
+				isOwner = true
+				break
+			}
+		}
+
+		if !isOwner {
+			return fmt.Errorf("team '%s' is not an owner of the module", team.Name)
+		}
+
+		if len(record.Owners)+len(record.OwnerTeams) <= 1 {
+			return fmt.Errorf("failed to remove module owner team: %w", ErrLastModuleOwner)
+		}
+
+		team.Members = nil
+		if err := tx.Model(&record).Association("OwnerTeams").Delete(&team); err != nil {
+			return fmt.Errorf("failed to remove module owner team: %w", err)
+		}
+
+		if err := m.removeFormerOwnerGrants(tx, actor); err != nil {
+			return err
+		}
+
+		return recordOwnerEvent(tx, m.ID, OwnerEventTeamRemoved, actor, "", team.Name)
+	})
+	if err != nil {
+		return Module{}, err
+	}
+
+	return GetModuleByID(db, m.ID)
+}
+
+// TransferToUser transfers ownership of a Module to a given User on behalf of an
+// actor, such that the User becomes the Module's only owner. All other owners
+// and owner teams are removed. The updated Module is returned.
+func (m Module) TransferToUser(db *gorm.DB, actor, owner User) (Module, error) {
+	err := db.Transaction(func(tx *gorm.DB) error {
+		if err := m.transferOwnership(tx, actor, []User{owner}, nil); err != nil {
+			return err
+		}
+
+		return recordOwnerEvent(tx, m.ID, OwnerEventTransferredUser, actor, owner.Name, "")
+	})
+	if err != nil {
+		return Module{}, err
+	}
+
+	return GetModuleByID(db, m.ID)
+}
+
+// TransferToTeam transfers ownership of a Module to a given Team on behalf of an
+// actor, such that the Team becomes the Module's only owner. All owners and
+// other owner teams are removed. The updated Module is returned.
+func (m Module) TransferToTeam(db *gorm.DB, actor User, team Team) (Module, error) {
+	err := db.Transaction(func(tx *gorm.DB) error {
+		team.Members = nil
+		if err := m.transferOwnership(tx, actor, nil, []Team{team}); err != nil {
+			return err
+		}
+
+		return recordOwnerEvent(tx, m.ID, OwnerEventTransferredTeam, actor, "", team.Name)
+	})
+	if err != nil {
+		return Module{}, err
+	}
+
+	return GetModuleByID(db, m.ID)
+}
+
+// transferOwnership replaces a Module's owners and owner teams within a
+// transaction, recording the removal of every previous owner and owner team
+// that is not retained. The trust policies and pending owner invitations of
+// users who are no longer owners are removed.
+func (m Module) transferOwnership(tx *gorm.DB, actor User, owners []User, teams []Team) error {
+	if len(owners)+len(teams) == 0 {
+		return fmt.Errorf("failed to transfer module ownership: %w", ErrLastModuleOwner)
+	}
+
+	record, err := GetModuleByID(tx, m.ID)
+	if err != nil {
+		return err
+	}
+
+	retained := func(id uint, ids []uint) bool {
+		for _, other := range ids {
+			if id == other {
+				return true
+			}
+		}
+
+		return false
+	}
+
+	ownerIDs := make([]uint, len(owners))
+	for i, o := range owners {
+		ownerIDs[i] = o.ID
+	}
+
+	teamIDs := make([]uint, len(teams))
+	for i, t := range teams {
+		teamIDs[i] = t.ID
+	}
+
+	for _, o := range record.Owners {
+		if !retained(o.ID, ownerIDs) {
+			if err := recordOwnerEvent(tx, m.ID, OwnerEventOwnerRemoved, actor, o.Name, ""); err != nil {
+				return err
+			}
+		}
+	}
+
+	for _, t := range record.OwnerTeams {
+		if !retained(t.ID, teamIDs) {
+			if err := recordOwnerEvent(tx, m.ID, OwnerEventTeamRemoved, actor, "", t.Name); err != nil {
+				return err
+			}
+		}
+	}
+
+	ownersAssoc := tx.Model(&record).Association("Owners")
+	if len(owners) == 0 {
+		err = ownersAssoc.Clear()
+	} else {
+		err = ownersAssoc.Replace(owners)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to update module owners: %w", err)
+	}
+
+	teamsAssoc := tx.Model(&record).Association("OwnerTeams")
+	if len(teams) == 0 {
+		err = teamsAssoc.Clear()
+	} else {
+		err = teamsAssoc.Replace(teams)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to update module owner teams: %w", err)
+	}
+
+	return m.removeFormerOwnerGrants(tx, actor)
+}
+
+// removeFormerOwnerGrants removes everything granted on behalf of users who are
+// no longer owners of the Module within a transaction, i.e. their trust policies
+// and the owner invitations they sent, recording each removal on behalf of an
+// actor. It must be called after any change which may revoke a user's
+// ownership, such that a former owner can neither publish via a trusted workflow
+// nor regain ownership via a pending invitation.
+func (m Module) removeFormerOwnerGrants(tx *gorm.DB, actor User) error {
+	if err := m.removeStaleTrustPolicies(tx, actor); err != nil {
+		return err
+	}
+
+	return m.removeStaleOwnerInvites(tx, actor)
+}
+
+// removeStaleOwnerInvites removes the Module's pending owner invitations sent by
+// users who are no longer owners of the Module within a transaction, recording
+// each revocation on behalf of an actor.
+func (m Module) removeStaleOwnerInvites(tx *gorm.DB, actor User) error {
+	record, err := GetModuleByID(tx, m.ID)
+	if err != nil {
+		return err
+	}
+
+	var invites []ModuleOwnerInvite
+	if err := tx.Where("module_id = ?", m.ID).Find(&invites).Error; err != nil {
+		return fmt.Errorf("failed to query for module owner invitations: %w", err)
+	}
+
+	for _, moi := range invites {
+		if record.IsOwner(moi.InvitedByUserID) {
+			continue
+		}
+
+		if err := tx.Where("module_id = ? AND invited_user_id = ?", moi.ModuleID, moi.InvitedUserID).Delete(ModuleOwnerInvite{}).Error; err != nil {
+			return fmt.Errorf("failed to delete module owner invitation: %w", err)
+		}
+
+		if err := recordAuditEvent(tx, AuditActionOwnerInviteRevoke, actor.ID, m.ID, map[string]interface{}{
+			"invited_user_id":    moi.InvitedUserID,
+			"invited_by_user_id": moi.InvitedByUserID,
+		}); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// recordOwnerEvent appends an entry to a module's ownership audit trail, where
+// the subject is either a user or a team by name. The change is also recorded
+// in the audit log.
+func recordOwnerEvent(tx *gorm.DB, moduleID uint, action string, actor User, userName, teamName string) error {
+	event := ModuleOwnerEvent{
+		ModuleID: moduleID,
+		Action:   action,
+		Actor:    actor.Name,
+		UserName: NewNullString(userName),
+		TeamName: NewNullString(teamName),
+	}
+
+	if err := tx.Create(&event).Error; err != nil {
+		return fmt.Errorf("failed to record module owner event: %w", err)
+	}
+
+	data := map[string]interface{}{}
+	if userName != "" {
+		data["user"] = userName
+	}
+	if teamName != "" {
+		data["team"] = teamName
+	}
+
+	return recordAuditEvent(tx, "module."+action, actor.ID, moduleID, data)
+}
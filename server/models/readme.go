@@ -0,0 +1,53 @@
+package models
+
+import (
+	"fmt"
+
+	"gorm.io/gorm"
+)
+
+type (
+	// ModuleVersionReadmeJSON defines the JSON-encodeable type for a rendered
+	// ModuleVersionReadme.
+	ModuleVersionReadmeJSON struct {
+		ModuleID uint   `json:"module_id"`
+		Version  string `json:"version"`
+		HTML     string `json:"html"`
+	}
+
+	// ModuleVersionReadme defines the Markdown README, or other long-form
+	// documentation, published along with a ModuleVersion.
+	ModuleVersionReadme struct {
+		gorm.Model
+
+		ModuleVersionID uint
+		Markdown        string
+	}
+)
+
+// GetModuleVersionReadme returns the README of a module version by ID. If the
+// module version has no README or if the query fails, an error is returned.
+func GetModuleVersionReadme(db *gorm.DB, moduleVersionID uint) (ModuleVersionReadme, error) {
+	var record ModuleVersionReadme
+
+	if err := db.Where("module_version_id = ?", moduleVersionID).First(&record).Error; err != nil {
+		return ModuleVersionReadme{}, fmt.Errorf("failed to query for module version README: %w", err)
+	}
+
+	return record, nil
+}
+
+// createReadme creates the ModuleVersionReadme record for a newly created
+// ModuleVersion, if it has a README.
+func (mv ModuleVersion) createReadme(db *gorm.DB) error {
+	if mv.Readme == "" {
+		return nil
+	}
+
+	readme := ModuleVersionReadme{ModuleVersionID: mv.ID, Markdown: mv.Readme}
+	if err := db.Create(&readme).Error; err != nil {
+		return fmt.Errorf("failed to create module version README: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,516 @@
+package models
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	uuid "github.com/satori/go.uuid"
+)
+
+// Team member roles. Admins manage a team's members and, along with publishers,
+// are owners of the modules owned by the team. Viewers have no privileges.
+const (
+	TeamRoleAdmin     = "admin"
+	TeamRolePublisher = "publisher"
+	TeamRoleViewer    = "viewer"
+)
+
+// ErrLastTeamAdmin defines a sentinel error when an operation would leave a team
+// without an admin.
+var ErrLastTeamAdmin = errors.New("team must have at least one admin")
+
+type (
+	// TeamMemberJSON defines the JSON-encodeable type for a TeamMember.
+	TeamMemberJSON struct {
+		User UserJSON `json:"user"`
+		Role string   `json:"role"`
+	}
+
+	// TeamJSON defines the JSON-encodeable type for a Team.
+	TeamJSON struct {
+		GormModelJSON
+
+		Name    string           `json:"name"`
+		Members []TeamMemberJSON `json:"members"`
+	}
+
+	// TeamModulesJSON defines the JSON-encodeable type for a Team along with the
+	// modules that belong to it.
+	TeamModulesJSON struct {
+		TeamJSON
+
+		Modules []ModuleJSON `json:"modules"`
+	}
+
+	// TeamMemberInviteJSON defines the JSON-encodeable type for a
+	// TeamMemberInvite.
+	TeamMemberInviteJSON struct {
+		CreatedAt   time.Time   `json:"created_at"`
+		UpdatedAt   time.Time   `json:"updated_at"`
+		ExpiresAt   time.Time   `json:"expires_at"`
+		Team        string      `json:"team"`
+		Role        string      `json:"role"`
+		InvitedUser string      `json:"invited_user"`
+		InvitedBy   string      `json:"invited_by"`
+		Token       interface{} `json:"token,omitempty"`
+	}
+
+	// TeamMember defines the membership of a User in a Team with a given role.
+	TeamMember struct {
+		CreatedAt time.Time
+		UpdatedAt time.Time
+
+		TeamID uint `gorm:"primaryKey"`
+		UserID uint `gorm:"primaryKey"`
+		User   User
+		Role   string `gorm:"not null;default:null"`
+	}
+
+	// TeamMemberInvite defines an invitation of a User to join a Team with a
+	// given role, which the user must accept to become a member.
+	TeamMemberInvite struct {
+		CreatedAt time.Time
+		UpdatedAt time.Time
+
+		TeamID          uint
+		Team            Team
+		InvitedUserID   uint
+		InvitedUser     User `gorm:"foreignKey:InvitedUserID"`
+		InvitedByUserID uint
+		InvitedByUser   User   `gorm:"foreignKey:InvitedByUserID"`
+		Role            string `gorm:"not null;default:null"`
+		Token           uuid.UUID
+	}
+
+	// Team defines a named group of users, typically corresponding to the
+	// organization that owns a module's repository, i.e. a module's team.
+	// Ownership of a module may be granted to a whole team.
+	Team struct {
+		gorm.Model
+
+		Name    string       `gorm:"not null;default:null"`
+		Members []TeamMember `gorm:"foreignKey:team_id"`
+	}
+)
+
+// MarshalJSON implements custom JSON marshaling for the Team model.
+func (t Team) MarshalJSON() ([]byte, error) {
+	return json.Marshal(t.NewTeamJSON())
+}
+
+// NewTeamJSON returns the JSON-encodeable type for a Team.
+func (t Team) NewTeamJSON() TeamJSON {
+	membersJSON := make([]TeamMemberJSON, len(t.Members))
+	for i, m := range t.Members {
+		membersJSON[i] = TeamMemberJSON{User: m.User.NewUserJSON(), Role: m.Role}
+	}
+
+	return TeamJSON{
+		GormModelJSON: GormModelJSON{
+			ID:        t.ID,
+			CreatedAt: t.CreatedAt,
+			UpdatedAt: t.UpdatedAt,
+		},
+		Name:    t.Name,
+		Members: membersJSON,
+	}
+}
+
+// NewTeamModulesJSON returns the JSON-encodeable type for a Team along with the
+// given modules that belong to it.
+func (t Team) NewTeamModulesJSON(modules []Module) TeamModulesJSON {
+	modulesJSON := make([]ModuleJSON, len(modules))
+	for i, m := range modules {
+		modulesJSON[i] = m.NewModuleJSON()
+	}
+
+	return TeamModulesJSON{
+		TeamJSON: t.NewTeamJSON(),
+		Modules:  modulesJSON,
+	}
+}
+
+// BeforeSave implements a GORM hook for updating a Team record before it is
+// created or updated.
+func (t *Team) BeforeSave(_ *gorm.DB) error {
+	t.Name = strings.ToLower(t.Name)
+	return nil
+}
+
+// CreateTeam creates a new Team by name with the given user as its first admin.
+// It returns an error if a team by that name already exists or upon database
+// failure.
+func CreateTeam(db *gorm.DB, name string, admin User) (Team, error) {
+	team := Team{
+		Name:    name,
+		Members: []TeamMember{{UserID: admin.ID, Role: TeamRoleAdmin}},
+	}
+
+	err := db.Transaction(func(tx *gorm.DB) error {
+		var count int64
+		if err := tx.Model(&Team{}).Where("name = ?", strings.ToLower(name)).Count(&count).Error; err != nil {
+			return fmt.Errorf("failed to query for team: %w", err)
+		}
+
+		if count > 0 {
+			return fmt.Errorf("team '%s' already exists", strings.ToLower(name))
+		}
+
+		if err := tx.Create(&team).Error; err != nil {
+			return fmt.Errorf("failed to create team: %w", err)
+		}
+
+		// commit the tx
+		return nil
+	})
+	if err != nil {
+		return Team{}, err
+	}
+
+	return GetTeamByName(db, team.Name)
+}
+
+// GetTeamByName returns a team, along with its members, by name. If the team
+// does not exist or if the query fails, an error is returned.
+func GetTeamByName(db *gorm.DB, name string) (Team, error) {
+	var record Team
+
+	if err := db.Preload("Members", func(db *gorm.DB) *gorm.DB {
+		return db.Order("created_at ASC")
+	}).Preload("Members.User").Where("name = ?", strings.ToLower(name)).First(&record).Error; err != nil {
+		return Team{}, fmt.Errorf("failed to query for team: %w", err)
+	}
+
+	return record, nil
+}
+
+// GetModules returns all the modules that belong to a team, i.e. the modules
+// whose ownership has been granted to the team. A module whose repository owner
+// matches the team's name does not belong to the team unless ownership of it has
+// been granted to the team. An error is returned upon query failure.
+func (t Team) GetModules(db *gorm.DB) ([]Module, error) {
+	var modules []Module
+
+	if err := db.Preload(clause.Associations).
+		Where("id IN (SELECT module_id FROM module_owner_teams WHERE team_id = ?)", t.ID).
+		Order("name ASC").
+		Find(&modules).Error; err != nil {
+		return nil, fmt.Errorf("failed to query for team modules: %w", err)
+	}
+
+	return modules, nil
+}
+
+// GetModulesByTeamName returns all the modules whose team, i.e. the owner of
+// their repository, is the given name. An error is returned upon query failure.
+func GetModulesByTeamName(db *gorm.DB, name string) ([]Module, error) {
+	var modules []Module
+
+	if err := db.Preload(clause.Associations).
+		Where("team = ?", strings.ToLower(name)).
+		Order("name ASC").
+		Find(&modules).Error; err != nil {
+		return nil, fmt.Errorf("failed to query for modules: %w", err)
+	}
+
+	return modules, nil
+}
+
+// MemberRole returns the role of a user by ID in the team and a boolean
+// defining if the user is a member. The team's members must be loaded.
+func (t Team) MemberRole(userID uint) (string, bool) {
+	for _, m := range t.Members {
+		if m.UserID == userID {
+			return m.Role, true
+		}
+	}
+
+	return "", false
+}
+
+// IsAdmin returns true if a user by ID is an admin of the team. The team's
+// members must be loaded.
+func (t Team) IsAdmin(userID uint) bool {
+	role, ok := t.MemberRole(userID)
+	return ok && role == TeamRoleAdmin
+}
+
+// isPublisher returns true if a user by ID is a member of the team with a role
+// that allows publishing, i.e. an admin or a publisher. The team's members must
+// be loaded.
+func (t Team) isPublisher(userID uint) bool {
+	role, ok := t.MemberRole(userID)
+	return ok && (role == TeamRoleAdmin || role == TeamRolePublisher)
+}
+
+// UpsertMember adds a user to the team with the given role or updates the role
+// of an existing member on behalf of the given actor. Users must only be added on
+// their own behalf, i.e. upon accepting a TeamMemberInvite. An error wrapping
+// ErrLastTeamAdmin is returned if the last admin would be demoted. The updated
+// team is returned.
+func (t Team) UpsertMember(db *gorm.DB, actor User, user User, role string) (Team, error) {
+	err := db.Transaction(func(tx *gorm.DB) error {
+		if err := t.ensureAdminRemains(tx, user.ID, role); err != nil {
+			return err
+		}
+
+		var member TeamMember
+
+		err := tx.Where("team_id = ? AND user_id = ?", t.ID, user.ID).First(&member).Error
+		if err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				member = TeamMember{TeamID: t.ID, UserID: user.ID, Role: role}
+				if err := tx.Create(&member).Error; err != nil {
+					return fmt.Errorf("failed to create team member: %w", err)
+				}
+
+				return t.recordMemberEvent(tx, AuditActionTeamMemberAdd, actor.ID, user.ID, role)
+			}
+
+			return fmt.Errorf("failed to query for team member: %w", err)
+		}
+
+		if member.Role == role {
+			// commit the tx
+			return nil
+		}
+
+		member.Role = role
+		if err := tx.Save(&member).Error; err != nil {
+			return fmt.Errorf("failed to update team member: %w", err)
+		}
+
+		if err := t.removeFormerOwnerGrants(tx, actor); err != nil {
+			return err
+		}
+
+		return t.recordMemberEvent(tx, AuditActionTeamMemberRoleChange, actor.ID, user.ID, role)
+	})
+	if err != nil {
+		return Team{}, err
+	}
+
+	return GetTeamByName(db, t.Name)
+}
+
+// RemoveMember removes a user by ID from the team on behalf of the given actor.
+// An error wrapping ErrLastTeamAdmin is returned if the user is the team's last
+// admin. The updated team is returned.
+func (t Team) RemoveMember(db *gorm.DB, actor User, userID uint) (Team, error) {
+	err := db.Transaction(func(tx *gorm.DB) error {
+		if err := t.ensureAdminRemains(tx, userID, ""); err != nil {
+			return err
+		}
+
+		if err := tx.Where("team_id = ? AND user_id = ?", t.ID, userID).Delete(&TeamMember{}).Error; err != nil {
+			return fmt.Errorf("failed to remove team member: %w", err)
+		}
+
+		if err := t.removeFormerOwnerGrants(tx, actor); err != nil {
+			return err
+		}
+
+		return t.recordMemberEvent(tx, AuditActionTeamMemberRemove, actor.ID, userID, "")
+	})
+	if err != nil {
+		return Team{}, err
+	}
+
+	return GetTeamByName(db, t.Name)
+}
+
+// removeFormerOwnerGrants removes the trust policies and pending owner
+// invitations of every module the team owns which were granted on behalf of
+// users who are no longer owners, e.g. after a member was removed or demoted.
+func (t Team) removeFormerOwnerGrants(tx *gorm.DB, actor User) error {
+	modules, err := t.GetModules(tx)
+	if err != nil {
+		return err
+	}
+
+	for _, m := range modules {
+		if err := m.removeFormerOwnerGrants(tx, actor); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// recordMemberEvent records a change of a team member's membership caused by the
+// given actor by ID in the audit log, where an empty role denotes removal.
+func (t Team) recordMemberEvent(tx *gorm.DB, action string, actorID uint, userID uint, role string) error {
+	data := map[string]interface{}{"team": t.Name, "user_id": userID}
+	if role != "" {
+		data["role"] = role
+	}
+
+	return recordAuditEvent(tx, action, actorID, 0, data)
+}
+
+// ensureAdminRemains returns an error wrapping ErrLastTeamAdmin if changing the
+// role of a user by ID to the given role, where an empty role denotes removal,
+// would leave the team without an admin.
+func (t Team) ensureAdminRemains(tx *gorm.DB, userID uint, role string) error {
+	if role == TeamRoleAdmin {
+		return nil
+	}
+
+	var count int64
+	if err := tx.Model(&TeamMember{}).
+		Where("team_id = ? AND user_id <> ? AND role = ?", t.ID, userID, TeamRoleAdmin).
+		Count(&count).Error; err != nil {
+		return fmt.Errorf("failed to query for team admins: %w", err)
+	}
+
+	if count == 0 {
+		return fmt.Errorf("failed to update team member: %w", ErrLastTeamAdmin)
+	}
+
+	return nil
+}
+
+// InviteMember invites a user to join the team with the given role on behalf of
+// the given inviter. If the user has already been invited, the invitation's
+// role and inviter are updated and its token is regenerated, which resets its
+// expiration. It returns an error upon database failure.
+func (t Team) InviteMember(db *gorm.DB, inviter User, invitee User, role string) (TeamMemberInvite, error) {
+	var record TeamMemberInvite
+
+	query := "team_id = ? AND invited_user_id = ?"
+	err := db.Transaction(func(tx *gorm.DB) error {
+		err := tx.Where(query, t.ID, invitee.ID).First(&record).Error
+		if err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				record = TeamMemberInvite{TeamID: t.ID, InvitedUserID: invitee.ID, InvitedByUserID: inviter.ID, Role: role}
+				if err := tx.Create(&record).Error; err != nil {
+					return fmt.Errorf("failed to create team member invitation: %w", err)
+				}
+
+				return t.recordMemberEvent(tx, AuditActionTeamMemberInvite, inviter.ID, invitee.ID, role)
+			}
+
+			return fmt.Errorf("failed to query for team member invitation: %w", err)
+		}
+
+		record.InvitedByUserID = inviter.ID
+		record.Role = role
+
+		if err := tx.Where(query, t.ID, invitee.ID).Save(&record).Error; err != nil {
+			return fmt.Errorf("failed to update team member invitation: %w", err)
+		}
+
+		return t.recordMemberEvent(tx, AuditActionTeamMemberInviteResend, inviter.ID, invitee.ID, role)
+	})
+	if err != nil {
+		return TeamMemberInvite{}, err
+	}
+
+	return QueryTeamMemberInvite(db, map[string]interface{}{"team_id": t.ID, "invited_user_id": invitee.ID})
+}
+
+// BeforeSave will create and set the TeamMemberInvite UUID.
+func (tmi *TeamMemberInvite) BeforeSave(_ *gorm.DB) error {
+	tmi.Token = uuid.NewV4()
+	return nil
+}
+
+// QueryTeamMemberInvite performs a query for a TeamMemberInvite record along
+// with its team and users. If the query fails or the record does not exist, an
+// error is returned.
+func QueryTeamMemberInvite(db *gorm.DB, query map[string]interface{}) (TeamMemberInvite, error) {
+	var record TeamMemberInvite
+
+	if err := db.Preload("Team").Preload("InvitedUser").Preload("InvitedByUser").
+		Where(query).
+		First(&record).Error; err != nil {
+		return TeamMemberInvite{}, fmt.Errorf("failed to query team member invitation: %w", err)
+	}
+
+	return record, nil
+}
+
+// NewTeamMemberInviteJSON returns the JSON-encodeable type for a
+// TeamMemberInvite which expires after the given TTL. The invitation's team and
+// users must be loaded.
+func (tmi TeamMemberInvite) NewTeamMemberInviteJSON(ttl time.Duration) TeamMemberInviteJSON {
+	return TeamMemberInviteJSON{
+		CreatedAt:   tmi.CreatedAt,
+		UpdatedAt:   tmi.UpdatedAt,
+		ExpiresAt:   tmi.UpdatedAt.Add(ttl),
+		Team:        tmi.Team.Name,
+		Role:        tmi.Role,
+		InvitedUser: tmi.InvitedUser.Name,
+		InvitedBy:   tmi.InvitedByUser.Name,
+		Token:       tmi.Token,
+	}
+}
+
+// Expired returns true if the TeamMemberInvite was last sent more than the given
+// TTL ago.
+func (tmi TeamMemberInvite) Expired(ttl time.Duration) bool {
+	return time.Since(tmi.UpdatedAt) > ttl
+}
+
+// Accept adds the invited user to the team with the invitation's role and
+// deletes the invitation. If the user is already a member, their role is left
+// unchanged. The membership is recorded as caused by the invited user. The
+// invitation's team must be loaded. The updated team is returned.
+func (tmi TeamMemberInvite) Accept(db *gorm.DB) (Team, error) {
+	err := db.Transaction(func(tx *gorm.DB) error {
+		if err := tmi.delete(tx); err != nil {
+			return err
+		}
+
+		var count int64
+		if err := tx.Model(&TeamMember{}).
+			Where("team_id = ? AND user_id = ?", tmi.TeamID, tmi.InvitedUserID).
+			Count(&count).Error; err != nil {
+			return fmt.Errorf("failed to query for team member: %w", err)
+		}
+
+		if count > 0 {
+			// commit the tx
+			return nil
+		}
+
+		member := TeamMember{TeamID: tmi.TeamID, UserID: tmi.InvitedUserID, Role: tmi.Role}
+		if err := tx.Create(&member).Error; err != nil {
+			return fmt.Errorf("failed to create team member: %w", err)
+		}
+
+		return tmi.Team.recordMemberEvent(tx, AuditActionTeamMemberAdd, tmi.InvitedUserID, tmi.InvitedUserID, tmi.Role)
+	})
+	if err != nil {
+		return Team{}, err
+	}
+
+	return GetTeamByName(db, tmi.Team.Name)
+}
+
+// Decline deletes the TeamMemberInvite on behalf of the invited user. It returns
+// an error upon failure. The invitation's team must be loaded.
+func (tmi TeamMemberInvite) Decline(db *gorm.DB) error {
+	return db.Transaction(func(tx *gorm.DB) error {
+		if err := tmi.delete(tx); err != nil {
+			return err
+		}
+
+		return tmi.Team.recordMemberEvent(tx, AuditActionTeamMemberInviteDecline, tmi.InvitedUserID, tmi.InvitedUserID, "")
+	})
+}
+
+// delete deletes the TeamMemberInvite.
+func (tmi TeamMemberInvite) delete(tx *gorm.DB) error {
+	if err := tx.Where("team_id = ? AND invited_user_id = ?", tmi.TeamID, tmi.InvitedUserID).Delete(TeamMemberInvite{}).Error; err != nil {
+		return fmt.Errorf("failed to delete team member invitation: %w", err)
+	}
+
+	return nil
+}
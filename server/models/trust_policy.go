@@ -0,0 +1,207 @@
+package models
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// ErrTrustPolicyConflict defines a sentinel error when a workflow is already
+// trusted on behalf of another user, as a workflow may only publish on behalf
+// of a single user.
+var ErrTrustPolicyConflict = errors.New("workflow is trusted on behalf of another user")
+
+type (
+	// ModuleTrustPolicyJSON defines the JSON-encodeable type for a
+	// ModuleTrustPolicy.
+	ModuleTrustPolicyJSON struct {
+		ID         uint      `json:"id"`
+		CreatedAt  time.Time `json:"created_at"`
+		ModuleID   uint      `json:"module_id"`
+		UserID     uint      `json:"user_id"`
+		Repository string    `json:"repository"`
+		Workflow   string    `json:"workflow"`
+	}
+
+	// ModuleTrustPolicy defines a CI workflow trusted to publish a module, i.e. a
+	// workflow file, e.g. 'release.yml', of a GitHub repository in the form of
+	// '<owner>/<repo>'. An OIDC token issued to a run of the workflow can be
+	// exchanged for a short-lived API token which publishes the module on behalf
+	// of the owner who registered the policy.
+	ModuleTrustPolicy struct {
+		ID        uint `gorm:"primaryKey"`
+		CreatedAt time.Time
+
+		ModuleID   uint
+		UserID     uint
+		Repository string
+		Workflow   string
+	}
+)
+
+// MarshalJSON implements custom JSON marshaling for the ModuleTrustPolicy model.
+func (mtp ModuleTrustPolicy) MarshalJSON() ([]byte, error) {
+	return json.Marshal(ModuleTrustPolicyJSON{
+		ID:         mtp.ID,
+		CreatedAt:  mtp.CreatedAt,
+		ModuleID:   mtp.ModuleID,
+		UserID:     mtp.UserID,
+		Repository: mtp.Repository,
+		Workflow:   mtp.Workflow,
+	})
+}
+
+// GetTrustPolicies returns a module's trust policies ordered from oldest to
+// newest. An error is returned upon query failure.
+func (m Module) GetTrustPolicies(db *gorm.DB) ([]ModuleTrustPolicy, error) {
+	var policies []ModuleTrustPolicy
+
+	if err := db.Where("module_id = ?", m.ID).Order("id ASC").Find(&policies).Error; err != nil {
+		return nil, fmt.Errorf("failed to query for module trust policies: %w", err)
+	}
+
+	return policies, nil
+}
+
+// AddTrustPolicy registers a workflow of a repository as trusted to publish a
+// Module on behalf of the given owner. Repositories are matched case
+// insensitively. An error wrapping ErrTrustPolicyConflict is returned if the
+// workflow is already trusted on behalf of another user. Registering an existing
+// policy has no effect. The policy is returned.
+func (m Module) AddTrustPolicy(db *gorm.DB, owner User, repository, workflow string) (ModuleTrustPolicy, error) {
+	policy := ModuleTrustPolicy{
+		ModuleID:   m.ID,
+		UserID:     owner.ID,
+		Repository: strings.ToLower(repository),
+		Workflow:   workflow,
+	}
+
+	err := db.Transaction(func(tx *gorm.DB) error {
+		var existing []ModuleTrustPolicy
+		if err := tx.Where("repository = ? AND workflow = ?", policy.Repository, policy.Workflow).Find(&existing).Error; err != nil {
+			return fmt.Errorf("failed to query for module trust policies: %w", err)
+		}
+
+		for _, e := range existing {
+			if e.UserID != owner.ID {
+				return fmt.Errorf("failed to add module trust policy: %w", ErrTrustPolicyConflict)
+			}
+
+			if e.ModuleID == m.ID {
+				policy = e
+				return nil
+			}
+		}
+
+		if err := tx.Create(&policy).Error; err != nil {
+			return fmt.Errorf("failed to add module trust policy: %w", err)
+		}
+
+		return recordAuditEvent(tx, AuditActionTrustPolicyAdd, owner.ID, m.ID, map[string]interface{}{
+			"repository": policy.Repository,
+			"workflow":   policy.Workflow,
+		})
+	})
+	if err != nil {
+		return ModuleTrustPolicy{}, err
+	}
+
+	return policy, nil
+}
+
+// RemoveTrustPolicy removes a Module's trust policy by ID on behalf of an actor.
+// An error wrapping gorm.ErrRecordNotFound is returned if the Module has no such
+// policy.
+func (m Module) RemoveTrustPolicy(db *gorm.DB, actor User, id uint) error {
+	return db.Transaction(func(tx *gorm.DB) error {
+		var policy ModuleTrustPolicy
+		if err := tx.Where("id = ? AND module_id = ?", id, m.ID).First(&policy).Error; err != nil {
+			return fmt.Errorf("failed to query for module trust policy: %w", err)
+		}
+
+		if err := tx.Delete(&policy).Error; err != nil {
+			return fmt.Errorf("failed to remove module trust policy: %w", err)
+		}
+
+		return recordAuditEvent(tx, AuditActionTrustPolicyRemove, actor.ID, m.ID, map[string]interface{}{
+			"repository": policy.Repository,
+			"workflow":   policy.Workflow,
+		})
+	})
+}
+
+// removeStaleTrustPolicies removes the Module's trust policies registered on
+// behalf of users who are no longer owners of the Module within a transaction,
+// recording each removal on behalf of an actor, such that a workflow is never
+// trusted on behalf of a former owner. See removeFormerOwnerGrants.
+func (m Module) removeStaleTrustPolicies(tx *gorm.DB, actor User) error {
+	record, err := GetModuleByID(tx, m.ID)
+	if err != nil {
+		return err
+	}
+
+	policies, err := record.GetTrustPolicies(tx)
+	if err != nil {
+		return err
+	}
+
+	for _, policy := range policies {
+		if record.IsOwner(policy.UserID) {
+			continue
+		}
+
+		if err := tx.Delete(&policy).Error; err != nil {
+			return fmt.Errorf("failed to remove module trust policy: %w", err)
+		}
+
+		if err := recordAuditEvent(tx, AuditActionTrustPolicyRemove, actor.ID, m.ID, map[string]interface{}{
+			"repository": policy.Repository,
+			"workflow":   policy.Workflow,
+			"user_id":    policy.UserID,
+		}); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// CreateTrustedPublishToken creates a UserToken, expiring at the given time, in
+// exchange for a verified OIDC token issued to a run of a repository's workflow.
+// The token belongs to the user on whose behalf the workflow is trusted and is
+// granted publishing each module with a matching trust policy. An error wrapping
+// gorm.ErrRecordNotFound is returned if the workflow is not trusted to publish
+// any module.
+func CreateTrustedPublishToken(db *gorm.DB, repository, workflow string, expiresAt time.Time) (UserToken, error) {
+	var policies []ModuleTrustPolicy
+
+	repository = strings.ToLower(repository)
+	if err := db.Where("repository = ? AND workflow = ?", repository, workflow).Order("id ASC").Find(&policies).Error; err != nil {
+		return UserToken{}, fmt.Errorf("failed to query for module trust policies: %w", err)
+	}
+
+	if len(policies) == 0 {
+		return UserToken{}, fmt.Errorf("failed to query for module trust policies: %w", gorm.ErrRecordNotFound)
+	}
+
+	user, err := GetUserByID(db, policies[0].UserID)
+	if err != nil {
+		return UserToken{}, err
+	}
+
+	scopes := make([]string, len(policies))
+	for i, p := range policies {
+		scopes[i] = PublishModuleScope(p.ModuleID)
+	}
+
+	return user.createToken(db, UserToken{
+		Name:              fmt.Sprintf("trusted publishing: %s/%s", repository, workflow),
+		Scopes:            scopes,
+		ExpiresAt:         NewNullTime(expiresAt),
+		TrustedPublishing: true,
+	})
+}
@@ -1,12 +1,19 @@
 package models
 
 import (
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
 	"database/sql"
+	"encoding/hex"
 	"encoding/json"
 	"errors"
 	"fmt"
+	"strconv"
+	"strings"
 	"time"
 
+	"github.com/lib/pq"
 	uuid "github.com/satori/go.uuid"
 	"gorm.io/gorm"
 	"gorm.io/gorm/clause"
@@ -14,27 +21,127 @@ import (
 	"github.com/cosmos/atlas/server/httputil"
 )
 
+const (
+	// UserTokenPrefixLen defines the length of the prefix of an API token which
+	// is stored to identify the token.
+	UserTokenPrefixLen = 8
+
+	userTokenSaltLen = 16
+)
+
+// API token scopes. A token is only authorized for the requests its scopes
+// grant, where requests authenticated by a session cookie are authorized for
+// every scope.
+const (
+	// TokenScopeRead grants reading the user's account, e.g. their invitations.
+	TokenScopeRead = "read"
+	// TokenScopePublish grants publishing and yanking any module the user owns,
+	// including new modules.
+	TokenScopePublish = "publish"
+	// TokenScopeInvite grants sending, resending, revoking, accepting and
+	// declining module owner invitations.
+	TokenScopeInvite = "invite"
+	// TokenScopeOwners grants managing the owners and owner teams of the modules
+	// the user owns.
+	TokenScopeOwners = "owners:manage"
+	// TokenScopeTeams grants creating teams and managing their members.
+	TokenScopeTeams = "teams:manage"
+	// TokenScopeTokens grants creating, listing and revoking API tokens.
+	TokenScopeTokens = "tokens:manage"
+	// TokenScopeUser grants updating the user's account and stars.
+	TokenScopeUser = "user:write"
+
+	// tokenScopePublishModulePrefix defines the prefix of a scope granting
+	// publishing and yanking a single existing module by ID.
+	tokenScopePublishModulePrefix = TokenScopePublish + ":module/"
+)
+
+var (
+	// TokenScopes defines all the API token scopes other than the module specific
+	// publish scopes.
+	TokenScopes = []string{
+		TokenScopeRead,
+		TokenScopePublish,
+		TokenScopeInvite,
+		TokenScopeOwners,
+		TokenScopeTeams,
+		TokenScopeTokens,
+		TokenScopeUser,
+	}
+
+	// DefaultTokenScopes defines the scopes granted to an API token when none are
+	// requested, i.e. the scopes required by the CLI.
+	DefaultTokenScopes = []string{TokenScopeRead, TokenScopePublish}
+)
+
 type (
 	// UserTokenJSON defines the JSON-encodeable type for a UserToken.
 	UserTokenJSON struct {
 		GormModelJSON
 
-		Name    string    `json:"name"`
-		UserID  uint      `json:"user_id"`
-		Count   uint      `json:"count"`
-		Token   uuid.UUID `json:"token"`
-		Revoked bool      `json:"revoked"`
+		Name       string      `json:"name"`
+		UserID     uint        `json:"user_id"`
+		Count      uint        `json:"count"`
+		Prefix     string      `json:"prefix"`
+		Token      interface{} `json:"token,omitempty"`
+		Revoked    bool        `json:"revoked"`
+		Scopes     []string    `json:"scopes"`
+		ExpiresAt  interface{} `json:"expires_at"`
+		LastUsedAt interface{} `json:"last_used_at"`
+
+		TrustedPublishing bool `json:"trusted_publishing"`
+	}
+
+	// UserTokenUsageJSON defines the JSON-encodeable type for a UserTokenUsage.
+	UserTokenUsageJSON struct {
+		ID          uint      `json:"id"`
+		CreatedAt   time.Time `json:"created_at"`
+		UserTokenID uint      `json:"user_token_id"`
+		IP          string    `json:"ip"`
+		UserAgent   string    `json:"user_agent"`
+		Method      string    `json:"method"`
+		Path        string    `json:"path"`
 	}
 
-	// UserToken defines a user created API token.
+	// UserToken defines a user created API token. A token is only authorized for
+	// the requests its scopes grant and, if it has an expiry, until it expires.
+	//
+	// The token itself is never stored. Instead, a salted hash of the token is
+	// stored along with the token's prefix, which identifies the token and
+	// narrows down the records to verify a token against. The token is only
+	// known, and thus only returned, when the token is created.
+	//
+	// Tokens issued in exchange for an OIDC token of a trusted workflow, see
+	// ModuleTrustPolicy, are flagged as trusted publishing tokens.
 	UserToken struct {
 		gorm.Model
 
-		Name    string
-		UserID  uint
-		Count   uint
-		Token   uuid.UUID
-		Revoked bool
+		Name       string
+		UserID     uint
+		Count      uint
+		Token      uuid.UUID `gorm:"-"`
+		Prefix     string
+		Salt       string
+		Hash       string
+		Revoked    bool
+		Scopes     pq.StringArray `gorm:"type:varchar[]"`
+		ExpiresAt  sql.NullTime
+		LastUsedAt sql.NullTime
+
+		TrustedPublishing bool
+	}
+
+	// UserTokenUsage defines a single authenticated use of a UserToken, i.e. the
+	// request's client IP, user agent and the endpoint called.
+	UserTokenUsage struct {
+		ID        uint `gorm:"primaryKey"`
+		CreatedAt time.Time
+
+		UserTokenID uint
+		IP          string
+		UserAgent   string
+		Method      string
+		Path        string
 	}
 
 	// UserJSON defines the JSON-encodeable type for a User.
@@ -87,20 +194,104 @@ type (
 
 // MarshalJSON implements custom JSON marshaling for the UserToken model.
 func (ut UserToken) MarshalJSON() ([]byte, error) {
+	expiresAt, _ := ut.ExpiresAt.Value()
+	lastUsedAt, _ := ut.LastUsedAt.Value()
+
+	// only include the token upon creation
+	var token interface{}
+	if ut.Token != uuid.Nil {
+		token = ut.Token
+	}
+
 	return json.Marshal(UserTokenJSON{
 		GormModelJSON: GormModelJSON{
 			ID:        ut.ID,
 			CreatedAt: ut.CreatedAt,
 			UpdatedAt: ut.UpdatedAt,
 		},
-		Name:    ut.Name,
-		UserID:  ut.UserID,
-		Token:   ut.Token,
-		Revoked: ut.Revoked,
-		Count:   ut.Count,
+		Name:       ut.Name,
+		UserID:     ut.UserID,
+		Prefix:     ut.Prefix,
+		Token:      token,
+		Revoked:    ut.Revoked,
+		Count:      ut.Count,
+		Scopes:     ut.Scopes,
+		ExpiresAt:  expiresAt,
+		LastUsedAt: lastUsedAt,
+
+		TrustedPublishing: ut.TrustedPublishing,
+	})
+}
+
+// MarshalJSON implements custom JSON marshaling for the UserTokenUsage model.
+func (utu UserTokenUsage) MarshalJSON() ([]byte, error) {
+	return json.Marshal(UserTokenUsageJSON{
+		ID:          utu.ID,
+		CreatedAt:   utu.CreatedAt,
+		UserTokenID: utu.UserTokenID,
+		IP:          utu.IP,
+		UserAgent:   utu.UserAgent,
+		Method:      utu.Method,
+		Path:        utu.Path,
 	})
 }
 
+// PublishModuleScope returns the API token scope granting publishing and
+// yanking a single existing module by ID.
+func PublishModuleScope(moduleID uint) string {
+	return fmt.Sprintf("%s%d", tokenScopePublishModulePrefix, moduleID)
+}
+
+// IsValidTokenScope returns true if a scope is a valid API token scope, i.e.
+// one of TokenScopes or a module specific publish scope.
+func IsValidTokenScope(scope string) bool {
+	for _, s := range TokenScopes {
+		if scope == s {
+			return true
+		}
+	}
+
+	if strings.HasPrefix(scope, tokenScopePublishModulePrefix) {
+		id, err := strconv.ParseUint(strings.TrimPrefix(scope, tokenScopePublishModulePrefix), 10, 64)
+		return err == nil && id > 0
+	}
+
+	return false
+}
+
+// HasScope returns true if the token is granted a given scope. A token granted
+// the publish scope is also granted every module specific publish scope.
+func (ut UserToken) HasScope(scope string) bool {
+	for _, s := range ut.Scopes {
+		if s == scope {
+			return true
+		}
+
+		if s == TokenScopePublish && strings.HasPrefix(scope, tokenScopePublishModulePrefix) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// CanPublish returns true if the token is granted the publish scope or any
+// module specific publish scope.
+func (ut UserToken) CanPublish() bool {
+	for _, s := range ut.Scopes {
+		if s == TokenScopePublish || strings.HasPrefix(s, tokenScopePublishModulePrefix) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Expired returns true if the token has an expiry which has passed.
+func (ut UserToken) Expired() bool {
+	return ut.ExpiresAt.Valid && !time.Now().Before(ut.ExpiresAt.Time)
+}
+
 // MarshalJSON implements custom JSON marshaling for the User model.
 func (u User) MarshalJSON() ([]byte, error) {
 	return json.Marshal(u.NewUserJSON())
@@ -189,8 +380,7 @@ func (u User) ConfirmEmail(db *gorm.DB, uec UserEmailConfirmation) (User, error)
 			return fmt.Errorf("failed to delete user confirmation email: %w", err)
 		}
 
-		// commit the tx
-		return nil
+		return recordAuditEvent(tx, AuditActionEmailChange, user.ID, 0, map[string]interface{}{"email": uec.Email})
 	})
 	if err != nil {
 		return User{}, err
@@ -295,10 +485,21 @@ func GetAllUsers(db *gorm.DB, pq httputil.PaginationQuery) ([]User, Paginator, e
 
 // Revoke revokes a token. It returns an error upon failure.
 func (ut UserToken) Revoke(db *gorm.DB) (UserToken, error) {
-	if err := db.Model(&ut).Updates(UserToken{
-		Revoked: true,
-	}).Error; err != nil {
-		return UserToken{}, fmt.Errorf("failed to revoke user token: %w", err)
+	err := db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&ut).Updates(UserToken{
+			Revoked: true,
+		}).Error; err != nil {
+			return fmt.Errorf("failed to revoke user token: %w", err)
+		}
+
+		return recordAuditEvent(tx, AuditActionTokenRevoke, ut.UserID, 0, map[string]interface{}{
+			"token_id": ut.ID,
+			"name":     ut.Name,
+			"prefix":   ut.Prefix,
+		})
+	})
+	if err != nil {
+		return UserToken{}, err
 	}
 
 	return ut, nil
@@ -315,12 +516,76 @@ func (ut UserToken) IncrCount(db *gorm.DB) (UserToken, error) {
 	return ut, nil
 }
 
-// BeforeCreate will create and set the UserToken UUID.
+// RecordUsage records an authenticated use of a token, incrementing its count
+// and setting its last used time. It returns an error upon failure.
+func (ut UserToken) RecordUsage(db *gorm.DB, usage UserTokenUsage) (UserToken, error) {
+	err := db.Transaction(func(tx *gorm.DB) error {
+		usage.UserTokenID = ut.ID
+		if err := tx.Create(&usage).Error; err != nil {
+			return fmt.Errorf("failed to record user token usage: %w", err)
+		}
+
+		if err := tx.Model(&ut).Updates(UserToken{
+			Count:      ut.Count + 1,
+			LastUsedAt: NewNullTime(usage.CreatedAt),
+		}).Error; err != nil {
+			return fmt.Errorf("failed to increment user token count: %w", err)
+		}
+
+		// commit the tx
+		return nil
+	})
+	if err != nil {
+		return UserToken{}, err
+	}
+
+	return ut, nil
+}
+
+// GetUsage returns a paginated set of the recorded uses of a token. It returns
+// an error upon failure.
+func (ut UserToken) GetUsage(db *gorm.DB, pq httputil.PaginationQuery) ([]UserTokenUsage, Paginator, error) {
+	var (
+		usage []UserTokenUsage
+		total int64
+	)
+
+	tx := db.Where("user_token_id = ?", ut.ID)
+	if err := tx.Scopes(paginateScope(pq, &usage)).Error; err != nil {
+		return nil, Paginator{}, fmt.Errorf("failed to query for user token usage: %w", err)
+	}
+
+	if err := db.Model(&UserTokenUsage{}).Where("user_token_id = ?", ut.ID).Count(&total).Error; err != nil {
+		return nil, Paginator{}, fmt.Errorf("failed to query for user token usage count: %w", err)
+	}
+
+	return usage, buildPaginator(pq, total), nil
+}
+
+// BeforeCreate will create and set the UserToken UUID along with its prefix,
+// salt and salted hash.
 func (ut *UserToken) BeforeCreate(_ *gorm.DB) error {
+	salt := make([]byte, userTokenSaltLen)
+	if _, err := rand.Read(salt); err != nil {
+		return fmt.Errorf("failed to generate user token salt: %w", err)
+	}
+
 	ut.Token = uuid.NewV4()
+	ut.Prefix = ut.Token.String()[:UserTokenPrefixLen]
+	ut.Salt = hex.EncodeToString(salt)
+	ut.Hash = hashUserToken(ut.Salt, ut.Token.String())
+
 	return nil
 }
 
+// hashUserToken returns the hex-encoded SHA-256 hash of a salted token. Note,
+// tokens are random UUIDs, so a fast hash suffices as opposed to a password
+// hashing function.
+func hashUserToken(salt, token string) string {
+	h := sha256.Sum256([]byte(salt + token))
+	return hex.EncodeToString(h[:])
+}
+
 // QueryUserToken performs a query for a UserToken record. The resulting record,
 // if it exists, is returned. If the query fails or the record does not exist,
 // an error is returned.
@@ -334,14 +599,72 @@ func QueryUserToken(db *gorm.DB, query map[string]interface{}) (UserToken, error
 	return record, nil
 }
 
-// CreateToken creates a new UserToken for a given User model. It returns an
-// error upon failure.
-func (u User) CreateToken(db *gorm.DB, name string) (UserToken, error) {
-	token := UserToken{UserID: u.ID, Name: name}
+// AuthenticateUserToken returns the non-revoked UserToken record matching a
+// given token. The records sharing the token's prefix are verified against the
+// token's salted hash. An error wrapping gorm.ErrRecordNotFound is returned if
+// no record matches and an error is returned if the query fails.
+func AuthenticateUserToken(db *gorm.DB, token string) (UserToken, error) {
+	if len(token) < UserTokenPrefixLen {
+		return UserToken{}, fmt.Errorf("failed to query user token: %w", gorm.ErrRecordNotFound)
+	}
+
+	var records []UserToken
+
+	if err := db.Where("prefix = ? AND revoked = ?", token[:UserTokenPrefixLen], false).Find(&records).Error; err != nil {
+		return UserToken{}, fmt.Errorf("failed to query user token: %w", err)
+	}
+
+	for _, record := range records {
+		if subtle.ConstantTimeCompare([]byte(hashUserToken(record.Salt, token)), []byte(record.Hash)) == 1 {
+			return record, nil
+		}
+	}
+
+	return UserToken{}, fmt.Errorf("failed to query user token: %w", gorm.ErrRecordNotFound)
+}
+
+// CreateToken creates a new UserToken for a given User model which is granted
+// the given scopes, or DefaultTokenScopes if none are given, and which expires
+// at the given time, if valid. It returns an error upon failure.
+func (u User) CreateToken(db *gorm.DB, name string, scopes []string, expiresAt sql.NullTime) (UserToken, error) {
+	if len(scopes) == 0 {
+		scopes = DefaultTokenScopes
+	}
 
-	// Note: The Append call will create a new UserToken record.
-	if err := db.Model(&u).Association("Tokens").Append(&token); err != nil {
-		return UserToken{}, fmt.Errorf("failed to assign token to user: %w", err)
+	for _, s := range scopes {
+		if !IsValidTokenScope(s) {
+			return UserToken{}, fmt.Errorf("invalid token scope '%s'", s)
+		}
+	}
+
+	return u.createToken(db, UserToken{Name: name, Scopes: scopes, ExpiresAt: expiresAt})
+}
+
+// createToken creates the given UserToken for a User model. It returns an error
+// upon failure.
+func (u User) createToken(db *gorm.DB, token UserToken) (UserToken, error) {
+	token.UserID = u.ID
+
+	err := db.Transaction(func(tx *gorm.DB) error {
+		// Note: The Append call will create a new UserToken record.
+		if err := tx.Model(&u).Association("Tokens").Append(&token); err != nil {
+			return fmt.Errorf("failed to assign token to user: %w", err)
+		}
+
+		data := map[string]interface{}{
+			"token_id": token.ID,
+			"name":     token.Name,
+			"prefix":   token.Prefix,
+			"scopes":   token.Scopes,
+		}
+		if token.TrustedPublishing {
+			data["trusted_publishing"] = true
+		}
+
+		return recordAuditEvent(tx, AuditActionTokenCreate, u.ID, 0, data)
+	})
+	if err != nil {
+		return UserToken{}, err
 	}
 
 	return token, nil
@@ -359,9 +682,10 @@ func (u User) GetTokens(db *gorm.DB) ([]UserToken, error) {
 	return tokens, nil
 }
 
-// CountTokens returns the total number of API tokens belonging to a User.
+// CountTokens returns the total number of API tokens belonging to a User, other
+// than the short-lived trusted publishing tokens issued to trusted workflows.
 func (u User) CountTokens(db *gorm.DB) int64 {
-	return db.Model(&u).Association("Tokens").Count()
+	return db.Model(&u).Where("trusted_publishing = ?", false).Association("Tokens").Count()
 }
 
 // BeforeSave will create and set the UserEmailConfirmation UUID.
@@ -0,0 +1,211 @@
+package v1
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+// giteaPageSize defines the page size used when listing resources from the
+// Gitea API, which is the maximum page size of a default Gitea instance.
+const giteaPageSize = 50
+
+type (
+	// GiteaClient implements a minimal client of the Gitea v1 REST API which
+	// serves the repositories of a single Gitea instance.
+	GiteaClient struct {
+		baseURL    *url.URL
+		token      string
+		httpClient *http.Client
+	}
+
+	giteaUser struct {
+		Login     string `json:"login"`
+		AvatarURL string `json:"avatar_url"`
+	}
+
+	giteaRepository struct {
+		Owner giteaUser `json:"owner"`
+	}
+
+	giteaTag struct {
+		Commit struct {
+			SHA string `json:"sha"`
+		} `json:"commit"`
+	}
+)
+
+// NewGiteaClient returns a GiteaClient for the Gitea instance at baseURL. If a
+// token is provided, it is used as an access token for all requests.
+func NewGiteaClient(baseURL, token string) (*GiteaClient, error) {
+	u, err := url.Parse(strings.TrimSuffix(baseURL, "/"))
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse Gitea URL: %w", err)
+	}
+
+	if u.Scheme == "" || u.Host == "" {
+		return nil, fmt.Errorf("invalid Gitea URL: %s", baseURL)
+	}
+
+	return &GiteaClient{baseURL: u, token: token, httpClient: http.DefaultClient}, nil
+}
+
+// Host returns the host name of repositories served by the client.
+func (gc *GiteaClient) Host() string {
+	return strings.ToLower(gc.baseURL.Hostname())
+}
+
+// GetRepository returns a Repository object which contains information needed
+// when publishing a Cosmos SDK module, where the repository's contributors are
+// its collaborators along with its owner, if the owner is a user. It returns an
+// error if the repository URL is invalid or if any resource fails to be fetched
+// from the Gitea API.
+func (gc *GiteaClient) GetRepository(repoURL string) (Repository, error) {
+	u, err := url.Parse(repoURL)
+	if err != nil {
+		return Repository{}, fmt.Errorf("failed to parse repo URL: %w", err)
+	}
+
+	// remove the instance's base path, if any, and parse the remaining path
+	u.Path = strings.TrimPrefix(u.Path, gc.baseURL.Path)
+
+	repo, err := parseGitHubRepo(u.String())
+	if err != nil {
+		return Repository{}, err
+	}
+
+	var giteaRepo giteaRepository
+
+	req, err := gc.newRequest(gc.repoPath(repo), nil)
+	if err != nil {
+		return Repository{}, err
+	}
+
+	if _, err := doJSONRequest(gc.httpClient, req, &giteaRepo); err != nil {
+		return Repository{}, fmt.Errorf("failed to fetch repository: %w", err)
+	}
+
+	if repo.Owner != giteaRepo.Owner.Login {
+		return Repository{}, fmt.Errorf("unexpected owner; got: %s, want: %s", giteaRepo.Owner.Login, repo.Owner)
+	}
+
+	contributors := make(map[string]Contributor)
+	for page := 1; ; page++ {
+		var collaborators []giteaUser
+
+		query := url.Values{"page": {strconv.Itoa(page)}, "limit": {strconv.Itoa(giteaPageSize)}}
+		req, err := gc.newRequest(gc.repoPath(repo)+"/collaborators", query)
+		if err != nil {
+			return Repository{}, err
+		}
+
+		if _, err := doJSONRequest(gc.httpClient, req, &collaborators); err != nil {
+			return Repository{}, fmt.Errorf("failed to get repository contributors: %w", err)
+		}
+
+		for _, c := range collaborators {
+			contributors[c.Login] = Contributor{Login: c.Login, AvatarURL: c.AvatarURL}
+		}
+
+		if len(collaborators) < giteaPageSize {
+			break
+		}
+	}
+
+	// The owner of a personal repository is not one of its collaborators, so we
+	// include the owner unless it is an organization.
+	isOrg, err := gc.isOrganization(giteaRepo.Owner.Login)
+	if err != nil {
+		return Repository{}, err
+	}
+
+	if !isOrg {
+		contributors[giteaRepo.Owner.Login] = Contributor{Login: giteaRepo.Owner.Login, AvatarURL: giteaRepo.Owner.AvatarURL}
+	}
+
+	repo.Contributors = contributors
+
+	return repo, nil
+}
+
+// GetTagCommit returns the SHA of the commit a tag refers to in the given
+// repository. An error wrapping ErrTagNotFound is returned if the tag does not
+// exist.
+func (gc *GiteaClient) GetTagCommit(repo Repository, tag string) (string, error) {
+	var giteaTag giteaTag
+
+	req, err := gc.newRequest(gc.repoPath(repo)+"/tags/"+url.PathEscape(tag), nil)
+	if err != nil {
+		return "", err
+	}
+
+	resp, err := doJSONRequest(gc.httpClient, req, &giteaTag)
+	if err != nil {
+		if resp != nil && resp.StatusCode == http.StatusNotFound {
+			return "", fmt.Errorf("%w: %s", ErrTagNotFound, tag)
+		}
+
+		return "", fmt.Errorf("failed to fetch tag: %w", err)
+	}
+
+	return giteaTag.Commit.SHA, nil
+}
+
+// GetTarball returns the gzipped tarball archive of a repository at the given
+// ref. The caller is responsible for closing the returned reader.
+func (gc *GiteaClient) GetTarball(repo Repository, ref string) (io.ReadCloser, error) {
+	req, err := gc.newRequest(gc.repoPath(repo)+"/archive/"+url.PathEscape(ref)+".tar.gz", nil)
+	if err != nil {
+		return nil, err
+	}
+
+	return doArchiveRequest(gc.httpClient, req)
+}
+
+// isOrganization returns true if the given owner is an organization.
+func (gc *GiteaClient) isOrganization(owner string) (bool, error) {
+	var org giteaUser
+
+	req, err := gc.newRequest("/orgs/"+url.PathEscape(owner), nil)
+	if err != nil {
+		return false, err
+	}
+
+	resp, err := doJSONRequest(gc.httpClient, req, &org)
+	if err != nil {
+		if resp != nil && resp.StatusCode == http.StatusNotFound {
+			return false, nil
+		}
+
+		return false, fmt.Errorf("failed to fetch repository owner: %w", err)
+	}
+
+	return true, nil
+}
+
+// repoPath returns the API path of a repository.
+func (gc *GiteaClient) repoPath(repo Repository) string {
+	return "/repos/" + url.PathEscape(repo.Owner) + "/" + url.PathEscape(repo.Repo)
+}
+
+// newRequest returns an authenticated GET request for a Gitea v1 API path.
+func (gc *GiteaClient) newRequest(apiPath string, query url.Values) (*http.Request, error) {
+	reqURL := gc.baseURL.String() + "/api/v1" + apiPath
+	if len(query) > 0 {
+		reqURL += "?" + query.Encode()
+	}
+
+	req, err := http.NewRequest(http.MethodGet, reqURL, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create Gitea request: %w", err)
+	}
+
+	if gc.token != "" {
+		req.Header.Set("Authorization", "token "+gc.token)
+	}
+
+	return req, nil
+}
@@ -0,0 +1,157 @@
+package v1
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func newTestGiteaServer(t *testing.T) *httptest.Server {
+	mux := http.NewServeMux()
+
+	mux.HandleFunc("/api/v1/", func(w http.ResponseWriter, r *http.Request) {
+		require.Equal(t, "token test_token", r.Header.Get("Authorization"))
+
+		switch r.URL.EscapedPath() {
+		case "/api/v1/repos/foo/cosmos-sdk":
+			_ = json.NewEncoder(w).Encode(map[string]interface{}{
+				"owner": map[string]string{"login": "foo", "avatar_url": "https://gitea.example.com/foo.png"},
+			})
+
+		case "/api/v1/repos/cosmos/cosmos-sdk":
+			_ = json.NewEncoder(w).Encode(map[string]interface{}{
+				"owner": map[string]string{"login": "cosmos", "avatar_url": "https://gitea.example.com/cosmos.png"},
+			})
+
+		case "/api/v1/repos/foo/cosmos-sdk/collaborators", "/api/v1/repos/cosmos/cosmos-sdk/collaborators":
+			collaborators := []map[string]string{}
+			if r.URL.Query().Get("page") == "1" {
+				collaborators = append(collaborators, map[string]string{"login": "bar", "avatar_url": "https://gitea.example.com/bar.png"})
+			}
+
+			_ = json.NewEncoder(w).Encode(collaborators)
+
+		case "/api/v1/orgs/cosmos":
+			_ = json.NewEncoder(w).Encode(map[string]string{"login": "cosmos"})
+
+		case "/api/v1/repos/cosmos/cosmos-sdk/tags/x%2Fbank%2Fv1.0.0":
+			_ = json.NewEncoder(w).Encode(map[string]interface{}{
+				"commit": map[string]interface{}{"sha": "a9b3d4b6f5c2e1f0d8c7b6a5f4e3d2c1b0a9f8e7"},
+			})
+
+		case "/api/v1/repos/cosmos/cosmos-sdk/archive/abc123.tar.gz":
+			_, _ = w.Write([]byte("abc123"))
+
+		default:
+			http.NotFound(w, r)
+		}
+	})
+
+	return httptest.NewServer(mux)
+}
+
+func TestGiteaClient_GetRepository(t *testing.T) {
+	server := newTestGiteaServer(t)
+	defer server.Close()
+
+	client, err := NewGiteaClient(server.URL, "test_token")
+	require.NoError(t, err)
+
+	testCases := []struct {
+		name               string
+		repoURL            string
+		expectOwner        string
+		expectContributors []string
+		expectErr          bool
+	}{
+		{
+			"invalid repo",
+			server.URL + "/cosmos",
+			"",
+			nil,
+			true,
+		},
+		{
+			"unknown repo",
+			server.URL + "/bar/cosmos-sdk",
+			"",
+			nil,
+			true,
+		},
+		{
+			"valid user repo",
+			server.URL + "/foo/cosmos-sdk.git",
+			"foo",
+			[]string{"bar", "foo"},
+			false,
+		},
+		{
+			"valid organization repo",
+			server.URL + "/cosmos/cosmos-sdk/releases/tag/v1.0.0",
+			"cosmos",
+			[]string{"bar"},
+			false,
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+
+		t.Run(tc.name, func(t *testing.T) {
+			repo, err := client.GetRepository(tc.repoURL)
+			if tc.expectErr {
+				require.Error(t, err)
+			} else {
+				require.NoError(t, err)
+				require.Equal(t, tc.expectOwner, repo.Owner)
+				require.Equal(t, "cosmos-sdk", repo.Repo)
+				require.Len(t, repo.Contributors, len(tc.expectContributors))
+
+				for _, login := range tc.expectContributors {
+					contributor, ok := repo.Contributors[login]
+					require.True(t, ok)
+					require.Equal(t, "https://gitea.example.com/"+login+".png", contributor.AvatarURL)
+				}
+			}
+		})
+	}
+}
+
+func TestGiteaClient_GetTagCommit(t *testing.T) {
+	server := newTestGiteaServer(t)
+	defer server.Close()
+
+	client, err := NewGiteaClient(server.URL, "test_token")
+	require.NoError(t, err)
+
+	repo := Repository{Owner: "cosmos", Repo: "cosmos-sdk"}
+
+	sha, err := client.GetTagCommit(repo, "x/bank/v1.0.0")
+	require.NoError(t, err)
+	require.Equal(t, "a9b3d4b6f5c2e1f0d8c7b6a5f4e3d2c1b0a9f8e7", sha)
+
+	_, err = client.GetTagCommit(repo, "v0.0.0-nonexistent")
+	require.Error(t, err)
+	require.ErrorIs(t, err, ErrTagNotFound)
+}
+
+func TestGiteaClient_GetTarball(t *testing.T) {
+	server := newTestGiteaServer(t)
+	defer server.Close()
+
+	client, err := NewGiteaClient(server.URL, "test_token")
+	require.NoError(t, err)
+
+	tarball, err := client.GetTarball(Repository{Owner: "cosmos", Repo: "cosmos-sdk"}, "abc123")
+	require.NoError(t, err)
+
+	defer tarball.Close()
+
+	bz, err := ioutil.ReadAll(tarball)
+	require.NoError(t, err)
+	require.Equal(t, "abc123", string(bz))
+}
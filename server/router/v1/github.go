@@ -3,6 +3,8 @@ package v1
 import (
 	"context"
 	"fmt"
+	"io"
+	"net/http"
 	"net/url"
 	"strings"
 
@@ -10,81 +12,180 @@ import (
 	"golang.org/x/oauth2"
 )
 
-type (
-	// GitHubClientI defines the interface used to retrieve GitHub repository
-	// information.
-	GitHubClientI interface {
-		GetRepository(repoURL string) (Repository, error)
-	}
-
-	// Repository defines the relevant information Atlas needs for a GitHub
-	// repository in order to publish modules.
-	Repository struct {
-		Owner        string
-		Repo         string
-		Contributors map[string]*github.Contributor
-	}
-)
+// gitHubHost defines the host name of repositories served by a GitHubClient.
+const gitHubHost = "github.com"
 
 // GitHubClient implements a wrapper around a GitHub v3 API client.
 type GitHubClient struct {
 	*github.Client
+
+	httpClient *http.Client
 }
 
 func NewGitHubClient(token string) *GitHubClient {
 	if len(token) != 0 {
+		httpClient := oauth2.NewClient(context.Background(),
+			oauth2.StaticTokenSource(&oauth2.Token{AccessToken: token}),
+		)
+
 		return &GitHubClient{
-			Client: github.NewClient(
-				oauth2.NewClient(context.Background(),
-					oauth2.StaticTokenSource(&oauth2.Token{AccessToken: token}),
-				),
-			),
+			Client:     github.NewClient(httpClient),
+			httpClient: httpClient,
 		}
 	}
 
-	return &GitHubClient{Client: github.NewClient(nil)}
+	return &GitHubClient{Client: github.NewClient(nil), httpClient: http.DefaultClient}
+}
+
+// Host returns the host name of repositories served by the client.
+func (gc *GitHubClient) Host() string {
+	return gitHubHost
 }
 
 // GetRepository returns a Repository object which contains information needed
 // when publishing a Cosmos SDK module. It returns an error if the repository
 // URL is invalid or if any resource fails to be fetched from the GitHub API.
 func (gc *GitHubClient) GetRepository(repoURL string) (Repository, error) {
+	repo, _, err := gc.GetRepositoryIfModified(repoURL, "")
+	return repo, err
+}
+
+// GetRepositoryIfModified performs a conditional request for a repository given
+// the entity tag of a previous response. If the repository has not been
+// modified, ErrNotModified is returned without fetching its contributors and the
+// request does not count against the API rate limit. Otherwise, the repository
+// is returned as with GetRepository along with its current entity tag.
+func (gc *GitHubClient) GetRepositoryIfModified(repoURL, etag string) (Repository, string, error) {
 	repo, err := parseGitHubRepo(repoURL)
 	if err != nil {
-		return Repository{}, err
+		return Repository{}, "", err
 	}
 
-	ghRepo, _, err := gc.Repositories.Get(context.Background(), repo.Owner, repo.Repo)
+	req, err := gc.NewRequest(http.MethodGet, fmt.Sprintf("repos/%s/%s", repo.Owner, repo.Repo), nil)
 	if err != nil {
-		return Repository{}, fmt.Errorf("failed to fetch repository: %w", err)
+		return Repository{}, "", fmt.Errorf("failed to create repository request: %w", err)
+	}
+
+	if etag != "" {
+		req.Header.Set("If-None-Match", etag)
+	}
+
+	ghRepo := new(github.Repository)
+
+	resp, err := gc.Do(context.Background(), req, ghRepo)
+	if resp != nil && resp.StatusCode == http.StatusNotModified {
+		return Repository{}, etag, ErrNotModified
+	}
+	if err != nil {
+		return Repository{}, "", fmt.Errorf("failed to fetch repository: %w", err)
 	}
 
 	if repo.Owner != ghRepo.Owner.GetLogin() {
-		return Repository{}, fmt.Errorf("unexpected owner; got: %s, want: %s", ghRepo.Owner.GetLogin(), repo.Owner)
+		return Repository{}, "", fmt.Errorf("unexpected owner; got: %s, want: %s", ghRepo.Owner.GetLogin(), repo.Owner)
 	}
 
 	opts := &github.ListContributorsOptions{Anon: "false", ListOptions: github.ListOptions{Page: 1, PerPage: 100}}
 	ghContributors, _, err := gc.Repositories.ListContributors(context.Background(), repo.Owner, repo.Repo, opts)
 	if err != nil {
-		return Repository{}, fmt.Errorf("failed to get repository contributors: %w", err)
+		return Repository{}, "", fmt.Errorf("failed to get repository contributors: %w", err)
 	}
 
-	contributors := make(map[string]*github.Contributor)
+	contributors := make(map[string]Contributor)
 	for len(ghContributors) > 0 {
 		for _, c := range ghContributors {
-			contributors[c.GetLogin()] = c
+			contributors[c.GetLogin()] = Contributor{Login: c.GetLogin(), AvatarURL: c.GetAvatarURL()}
 		}
 
 		opts = &github.ListContributorsOptions{Anon: "false", ListOptions: github.ListOptions{Page: opts.Page + 1, PerPage: 100}}
 		ghContributors, _, err = gc.Repositories.ListContributors(context.Background(), repo.Owner, repo.Repo, opts)
 		if err != nil {
-			return Repository{}, fmt.Errorf("failed to get repository contributors: %w", err)
+			return Repository{}, "", fmt.Errorf("failed to get repository contributors: %w", err)
 		}
 	}
 
 	repo.Contributors = contributors
 
-	return repo, nil
+	return repo, resp.Header.Get("ETag"), nil
+}
+
+// GetTagCommit returns the SHA of the commit a tag refers to in the given
+// repository, where both lightweight and annotated tags are supported. An error
+// wrapping ErrTagNotFound is returned if the tag does not exist.
+func (gc *GitHubClient) GetTagCommit(repo Repository, tag string) (string, error) {
+	ref, resp, err := gc.Git.GetRef(context.Background(), repo.Owner, repo.Repo, "tags/"+tag)
+	if err != nil {
+		if resp != nil && resp.StatusCode == http.StatusNotFound {
+			return "", fmt.Errorf("%w: %s", ErrTagNotFound, tag)
+		}
+
+		return "", fmt.Errorf("failed to fetch tag: %w", err)
+	}
+
+	obj := ref.GetObject()
+
+	switch obj.GetType() {
+	case "commit":
+		return obj.GetSHA(), nil
+
+	case "tag":
+		// an annotated tag refers to a tag object which in turn refers to the commit
+		ghTag, _, err := gc.Git.GetTag(context.Background(), repo.Owner, repo.Repo, obj.GetSHA())
+		if err != nil {
+			return "", fmt.Errorf("failed to fetch annotated tag: %w", err)
+		}
+
+		return ghTag.GetObject().GetSHA(), nil
+
+	default:
+		return "", fmt.Errorf("unexpected tag object type: %s", obj.GetType())
+	}
+}
+
+// IsOrgMember returns true if the user is an active member of the given GitHub
+// organization. The client must be authenticated as a member of the
+// organization with the read:org scope in order to see private memberships.
+func (gc *GitHubClient) IsOrgMember(org, user string) (bool, error) {
+	membership, resp, err := gc.Organizations.GetOrgMembership(context.Background(), user, org)
+	return isActiveMembership(membership, resp, err)
+}
+
+// IsTeamMember returns true if the user is an active member of the team, given
+// by its slug, of the given GitHub organization.
+func (gc *GitHubClient) IsTeamMember(org, team, user string) (bool, error) {
+	membership, resp, err := gc.Teams.GetTeamMembershipBySlug(context.Background(), org, team, user)
+	return isActiveMembership(membership, resp, err)
+}
+
+// GetTarball returns the gzipped tarball archive of a repository at the given
+// ref. The caller is responsible for closing the returned reader.
+func (gc *GitHubClient) GetTarball(repo Repository, ref string) (io.ReadCloser, error) {
+	archiveURL, _, err := gc.Repositories.GetArchiveLink(
+		context.Background(), repo.Owner, repo.Repo, github.Tarball, &github.RepositoryContentGetOptions{Ref: ref}, true,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get repository archive link: %w", err)
+	}
+
+	req, err := http.NewRequest(http.MethodGet, archiveURL.String(), nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create repository archive request: %w", err)
+	}
+
+	return doArchiveRequest(gc.httpClient, req)
+}
+
+// isActiveMembership returns true if a membership fetched from the GitHub API is
+// active, where a missing or inaccessible membership is considered inactive.
+func isActiveMembership(membership *github.Membership, resp *github.Response, err error) (bool, error) {
+	if err != nil {
+		if resp != nil && (resp.StatusCode == http.StatusNotFound || resp.StatusCode == http.StatusForbidden) {
+			return false, nil
+		}
+
+		return false, fmt.Errorf("failed to fetch membership: %w", err)
+	}
+
+	return membership.GetState() == "active", nil
 }
 
 func parseGitHubRepo(repoURL string) (Repository, error) {
@@ -69,3 +69,16 @@ func TestGitHubClient_GetRepository(t *testing.T) {
 		})
 	}
 }
+
+func TestGitHubClient_GetTagCommit(t *testing.T) {
+	client := NewGitHubClient(os.Getenv("ATLAS_TEST_GITHUB_ACCESS_KEY"))
+	repo := Repository{Owner: "cosmos", Repo: "cosmos-sdk"}
+
+	sha, err := client.GetTagCommit(repo, "v0.39.1")
+	require.NoError(t, err)
+	require.Len(t, sha, 40)
+
+	_, err = client.GetTagCommit(repo, "v0.0.0-nonexistent")
+	require.Error(t, err)
+	require.ErrorIs(t, err, ErrTagNotFound)
+}
@@ -0,0 +1,197 @@
+package v1
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// GitLabURL defines the base URL of the public GitLab instance.
+const GitLabURL = "https://gitlab.com"
+
+type (
+	// GitLabClient implements a minimal client of the GitLab v4 REST API which
+	// serves the repositories of a single GitLab instance.
+	GitLabClient struct {
+		baseURL    *url.URL
+		token      string
+		httpClient *http.Client
+	}
+
+	gitLabProject struct {
+		Namespace struct {
+			FullPath string `json:"full_path"`
+		} `json:"namespace"`
+	}
+
+	gitLabMember struct {
+		Username  string `json:"username"`
+		AvatarURL string `json:"avatar_url"`
+	}
+
+	gitLabTag struct {
+		Commit struct {
+			ID string `json:"id"`
+		} `json:"commit"`
+	}
+)
+
+// NewGitLabClient returns a GitLabClient for the GitLab instance at baseURL. If
+// a token is provided, it is used as a personal access token for all requests.
+func NewGitLabClient(baseURL, token string) (*GitLabClient, error) {
+	u, err := url.Parse(strings.TrimSuffix(baseURL, "/"))
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse GitLab URL: %w", err)
+	}
+
+	if u.Scheme == "" || u.Host == "" {
+		return nil, fmt.Errorf("invalid GitLab URL: %s", baseURL)
+	}
+
+	return &GitLabClient{baseURL: u, token: token, httpClient: http.DefaultClient}, nil
+}
+
+// Host returns the host name of repositories served by the client.
+func (gc *GitLabClient) Host() string {
+	return strings.ToLower(gc.baseURL.Hostname())
+}
+
+// GetRepository returns a Repository object which contains information needed
+// when publishing a Cosmos SDK module, where the repository's contributors are
+// the members of the GitLab project, including inherited members. It returns an
+// error if the repository URL is invalid or if any resource fails to be fetched
+// from the GitLab API.
+func (gc *GitLabClient) GetRepository(repoURL string) (Repository, error) {
+	repo, err := gc.parseRepo(repoURL)
+	if err != nil {
+		return Repository{}, err
+	}
+
+	var project gitLabProject
+
+	req, err := gc.newRequest(gc.projectPath(repo), nil)
+	if err != nil {
+		return Repository{}, err
+	}
+
+	if _, err := doJSONRequest(gc.httpClient, req, &project); err != nil {
+		return Repository{}, fmt.Errorf("failed to fetch repository: %w", err)
+	}
+
+	if repo.Owner != project.Namespace.FullPath {
+		return Repository{}, fmt.Errorf("unexpected owner; got: %s, want: %s", project.Namespace.FullPath, repo.Owner)
+	}
+
+	contributors := make(map[string]Contributor)
+	for page := "1"; page != ""; {
+		var members []gitLabMember
+
+		query := url.Values{"page": {page}, "per_page": {"100"}}
+		req, err := gc.newRequest(gc.projectPath(repo)+"/members/all", query)
+		if err != nil {
+			return Repository{}, err
+		}
+
+		resp, err := doJSONRequest(gc.httpClient, req, &members)
+		if err != nil {
+			return Repository{}, fmt.Errorf("failed to get repository contributors: %w", err)
+		}
+
+		for _, m := range members {
+			contributors[m.Username] = Contributor{Login: m.Username, AvatarURL: m.AvatarURL}
+		}
+
+		page = resp.Header.Get("X-Next-Page")
+	}
+
+	repo.Contributors = contributors
+
+	return repo, nil
+}
+
+// GetTagCommit returns the SHA of the commit a tag refers to in the given
+// repository. An error wrapping ErrTagNotFound is returned if the tag does not
+// exist.
+func (gc *GitLabClient) GetTagCommit(repo Repository, tag string) (string, error) {
+	var glTag gitLabTag
+
+	req, err := gc.newRequest(gc.projectPath(repo)+"/repository/tags/"+url.PathEscape(tag), nil)
+	if err != nil {
+		return "", err
+	}
+
+	resp, err := doJSONRequest(gc.httpClient, req, &glTag)
+	if err != nil {
+		if resp != nil && resp.StatusCode == http.StatusNotFound {
+			return "", fmt.Errorf("%w: %s", ErrTagNotFound, tag)
+		}
+
+		return "", fmt.Errorf("failed to fetch tag: %w", err)
+	}
+
+	return glTag.Commit.ID, nil
+}
+
+// GetTarball returns the gzipped tarball archive of a repository at the given
+// ref. The caller is responsible for closing the returned reader.
+func (gc *GitLabClient) GetTarball(repo Repository, ref string) (io.ReadCloser, error) {
+	req, err := gc.newRequest(gc.projectPath(repo)+"/repository/archive.tar.gz", url.Values{"sha": {ref}})
+	if err != nil {
+		return nil, err
+	}
+
+	return doArchiveRequest(gc.httpClient, req)
+}
+
+// projectPath returns the API path of a repository's project, which is
+// identified by its URL-encoded full path.
+func (gc *GitLabClient) projectPath(repo Repository) string {
+	return "/projects/" + url.PathEscape(repo.Owner+"/"+repo.Repo)
+}
+
+// newRequest returns an authenticated GET request for a GitLab v4 API path.
+func (gc *GitLabClient) newRequest(apiPath string, query url.Values) (*http.Request, error) {
+	reqURL := gc.baseURL.String() + "/api/v4" + apiPath
+	if len(query) > 0 {
+		reqURL += "?" + query.Encode()
+	}
+
+	req, err := http.NewRequest(http.MethodGet, reqURL, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create GitLab request: %w", err)
+	}
+
+	if gc.token != "" {
+		req.Header.Set("PRIVATE-TOKEN", gc.token)
+	}
+
+	return req, nil
+}
+
+// parseRepo parses a GitLab repository URL, where the owner is the project's
+// full namespace path, which may contain nested groups, e.g.
+// https://gitlab.com/group/subgroup/project/-/tags/v1.0.0.
+func (gc *GitLabClient) parseRepo(repoURL string) (Repository, error) {
+	u, err := url.Parse(repoURL)
+	if err != nil {
+		return Repository{}, fmt.Errorf("failed to parse repo URL: %w", err)
+	}
+
+	// remove the instance's base path, if any
+	path := strings.TrimPrefix(u.Path, gc.baseURL.Path)
+
+	// remove any sub-page of the project, e.g. '/-/tags/v1.0.0'
+	path = strings.Split(path, "/-/")[0]
+
+	// remove .git extension
+	path = strings.TrimSuffix(strings.Trim(path, "/"), ".git")
+
+	i := strings.LastIndex(path, "/")
+	if i <= 0 || i == len(path)-1 || strings.Contains(path, "//") {
+		return Repository{}, fmt.Errorf("invalid repository: %s", repoURL)
+	}
+
+	return Repository{Owner: path[:i], Repo: path[i+1:]}, nil
+}
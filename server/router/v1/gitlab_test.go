@@ -0,0 +1,150 @@
+package v1
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func newTestGitLabServer(t *testing.T) *httptest.Server {
+	mux := http.NewServeMux()
+
+	mux.HandleFunc("/gitlab/api/v4/projects/", func(w http.ResponseWriter, r *http.Request) {
+		require.Equal(t, "test_token", r.Header.Get("PRIVATE-TOKEN"))
+
+		switch r.URL.EscapedPath() {
+		case "/gitlab/api/v4/projects/cosmos%2Fsdk%2Fcosmos-sdk":
+			_ = json.NewEncoder(w).Encode(map[string]interface{}{
+				"namespace": map[string]interface{}{"full_path": "cosmos/sdk"},
+			})
+
+		case "/gitlab/api/v4/projects/cosmos%2Fsdk%2Fcosmos-sdk/members/all":
+			members := []map[string]string{{"username": "foo", "avatar_url": "https://gitlab.example.com/foo.png"}}
+			if r.URL.Query().Get("page") == "1" {
+				w.Header().Set("X-Next-Page", "2")
+			} else {
+				members = []map[string]string{{"username": "bar", "avatar_url": "https://gitlab.example.com/bar.png"}}
+			}
+
+			_ = json.NewEncoder(w).Encode(members)
+
+		case "/gitlab/api/v4/projects/cosmos%2Fsdk%2Fcosmos-sdk/repository/tags/x%2Fbank%2Fv1.0.0":
+			_ = json.NewEncoder(w).Encode(map[string]interface{}{
+				"commit": map[string]interface{}{"id": "a9b3d4b6f5c2e1f0d8c7b6a5f4e3d2c1b0a9f8e7"},
+			})
+
+		case "/gitlab/api/v4/projects/cosmos%2Fsdk%2Fcosmos-sdk/repository/archive.tar.gz":
+			_, _ = w.Write([]byte(r.URL.Query().Get("sha")))
+
+		default:
+			http.NotFound(w, r)
+		}
+	})
+
+	return httptest.NewServer(mux)
+}
+
+func TestGitLabClient_GetRepository(t *testing.T) {
+	server := newTestGitLabServer(t)
+	defer server.Close()
+
+	client, err := NewGitLabClient(server.URL+"/gitlab/", "test_token")
+	require.NoError(t, err)
+
+	testCases := []struct {
+		name        string
+		repoURL     string
+		expectOwner string
+		expectRepo  string
+		expectErr   bool
+	}{
+		{
+			"invalid repo",
+			server.URL + "/gitlab/cosmos",
+			"", "",
+			true,
+		},
+		{
+			"unknown repo",
+			server.URL + "/gitlab/cosmos/cosmos-sdk",
+			"", "",
+			true,
+		},
+		{
+			"valid repo with nested group",
+			server.URL + "/gitlab/cosmos/sdk/cosmos-sdk",
+			"cosmos/sdk", "cosmos-sdk",
+			false,
+		},
+		{
+			"valid repo with extension",
+			server.URL + "/gitlab/cosmos/sdk/cosmos-sdk.git",
+			"cosmos/sdk", "cosmos-sdk",
+			false,
+		},
+		{
+			"valid repo with sub-page",
+			server.URL + "/gitlab/cosmos/sdk/cosmos-sdk/-/tags/v1.0.0",
+			"cosmos/sdk", "cosmos-sdk",
+			false,
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+
+		t.Run(tc.name, func(t *testing.T) {
+			repo, err := client.GetRepository(tc.repoURL)
+			if tc.expectErr {
+				require.Error(t, err)
+			} else {
+				require.NoError(t, err)
+				require.Equal(t, tc.expectOwner, repo.Owner)
+				require.Equal(t, tc.expectRepo, repo.Repo)
+				require.Equal(t, map[string]Contributor{
+					"foo": {Login: "foo", AvatarURL: "https://gitlab.example.com/foo.png"},
+					"bar": {Login: "bar", AvatarURL: "https://gitlab.example.com/bar.png"},
+				}, repo.Contributors)
+			}
+		})
+	}
+}
+
+func TestGitLabClient_GetTagCommit(t *testing.T) {
+	server := newTestGitLabServer(t)
+	defer server.Close()
+
+	client, err := NewGitLabClient(server.URL+"/gitlab", "test_token")
+	require.NoError(t, err)
+
+	repo := Repository{Owner: "cosmos/sdk", Repo: "cosmos-sdk"}
+
+	sha, err := client.GetTagCommit(repo, "x/bank/v1.0.0")
+	require.NoError(t, err)
+	require.Equal(t, "a9b3d4b6f5c2e1f0d8c7b6a5f4e3d2c1b0a9f8e7", sha)
+
+	_, err = client.GetTagCommit(repo, "v0.0.0-nonexistent")
+	require.Error(t, err)
+	require.ErrorIs(t, err, ErrTagNotFound)
+}
+
+func TestGitLabClient_GetTarball(t *testing.T) {
+	server := newTestGitLabServer(t)
+	defer server.Close()
+
+	client, err := NewGitLabClient(server.URL+"/gitlab", "test_token")
+	require.NoError(t, err)
+
+	tarball, err := client.GetTarball(Repository{Owner: "cosmos/sdk", Repo: "cosmos-sdk"}, "abc123")
+	require.NoError(t, err)
+
+	defer tarball.Close()
+
+	bz, err := ioutil.ReadAll(tarball)
+	require.NoError(t, err)
+	require.Equal(t, "abc123", string(bz))
+}
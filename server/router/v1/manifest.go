@@ -1,7 +1,13 @@
 package v1
 
 import (
+	"errors"
+
+	"github.com/go-playground/validator/v10"
+
+	"github.com/cosmos/atlas/server/checksum"
 	"github.com/cosmos/atlas/server/models"
+	"github.com/cosmos/atlas/server/semver"
 )
 
 type (
@@ -11,6 +17,12 @@ type (
 		Keywords    []string `json:"keywords" toml:"keywords" validate:"omitempty,gt=0,unique,dive,gt=0"`
 		Description string   `json:"description" toml:"description"`
 		Homepage    string   `json:"homepage" toml:"homepage" validate:"omitempty,url"`
+
+		// Path defines the module's directory relative to the root of its
+		// repository, e.g. x/bank, which is used to resolve the module's README
+		// and whose source tree the version's source checksum covers. An empty
+		// path denotes the repository root.
+		Path string `json:"path" toml:"path" validate:"omitempty,module_path"`
 	}
 
 	// AuthorsManifest defines author information in a module's manifest.
@@ -29,20 +41,113 @@ type (
 	VersionManifest struct {
 		Repo          string `json:"repo" toml:"repo" validate:"required,url"`
 		Documentation string `json:"documentation" toml:"documentation" validate:"omitempty,url"`
-		Version       string `json:"version" toml:"version" validate:"required"`
-		SDKCompat     string `json:"sdk_compat" toml:"sdk_compat"`
+		Version       string `json:"version" toml:"version" validate:"required,semver"`
+		SDKCompat     string `json:"sdk_compat" toml:"sdk_compat" validate:"omitempty,semver_constraint"`
+
+		// ReadmePath defines the path, relative to the manifest, of the Markdown
+		// README to publish along with the version. It is resolved by the CLI, which
+		// sets Readme to the file's contents.
+		ReadmePath string `json:"-" toml:"readme"`
+		Readme     string `json:"readme" toml:"-" validate:"omitempty,max=524288"`
+	}
+
+	// DependencyManifest defines a dependency on another Atlas module in a
+	// module's manifest. The dependency is referenced by its team and name, and
+	// Version defines a Semantic Version constraint its version must satisfy.
+	DependencyManifest struct {
+		Name    string `json:"name" toml:"name" validate:"required"`
+		Team    string `json:"team" toml:"team" validate:"required"`
+		Version string `json:"version" toml:"version" validate:"required,semver_constraint"`
 	}
 
 	// Manifest defines a Cosmos SDK module manifest. It translates directly into
 	// a Module model.
+	//
+	// A manifest may instead define multiple modules, e.g. in a monorepo, via
+	// Modules, in which case the bug tracker, authors, version and dependencies
+	// are shared by all the modules. Such a manifest must be expanded into a
+	// manifest per module via Expand prior to publishing.
 	Manifest struct {
-		Module     ModuleManifest    `json:"module" toml:"module"`
-		BugTracker BugTackerManifest `json:"bug_tracker" toml:"bug_tracker" validate:"omitempty,dive"`
-		Authors    []AuthorsManifest `json:"authors" toml:"authors" validate:"required,gt=0,unique=Name,dive"`
-		Version    VersionManifest   `json:"version" toml:"version" validate:"required,dive"`
+		Module       ModuleManifest       `json:"module" toml:"module"`
+		Modules      []ModuleManifest     `json:"-" toml:"modules"`
+		BugTracker   BugTackerManifest    `json:"bug_tracker" toml:"bug_tracker" validate:"omitempty,dive"`
+		Authors      []AuthorsManifest    `json:"authors" toml:"authors" validate:"required,gt=0,unique=Name,dive"`
+		Version      VersionManifest      `json:"version" toml:"version" validate:"required,dive"`
+		Dependencies []DependencyManifest `json:"dependencies" toml:"dependencies" validate:"omitempty,dive"`
+	}
+
+	// BatchManifest defines a set of Cosmos SDK module manifests that are
+	// published atomically.
+	BatchManifest struct {
+		Manifests []Manifest `json:"manifests" validate:"required,gt=0,dive"`
 	}
 )
 
+// Expand returns a manifest per module defined by a multi-module manifest, each
+// of which inherits the shared fields. A single-module manifest is returned as
+// is. An error is returned if the manifest defines both a single module and
+// multiple modules.
+func (m Manifest) Expand() ([]Manifest, error) {
+	if len(m.Modules) == 0 {
+		return []Manifest{m}, nil
+	}
+
+	if m.Module.Name != "" {
+		return nil, errors.New("manifest cannot define both 'module' and 'modules'")
+	}
+
+	manifests := make([]Manifest, len(m.Modules))
+	for i, mm := range m.Modules {
+		manifest := m
+		manifest.Module = mm
+		manifest.Modules = nil
+
+		manifests[i] = manifest
+	}
+
+	return manifests, nil
+}
+
+// NewValidator returns a new validator with all custom manifest and request
+// validation tags registered.
+func NewValidator() *validator.Validate {
+	validate := validator.New()
+
+	// Note: Registering a validation can only fail due to an empty tag or a nil
+	// function, so it is safe to ignore the errors.
+	_ = validate.RegisterValidation("semver", validateSemVer)
+	_ = validate.RegisterValidation("semver_constraint", validateSemVerConstraint)
+	_ = validate.RegisterValidation("token_scope", validateTokenScope)
+	_ = validate.RegisterValidation("module_path", validateModulePath)
+
+	return validate
+}
+
+// validateSemVer validates that a field is a valid Semantic Version.
+func validateSemVer(fl validator.FieldLevel) bool {
+	_, err := semver.Parse(fl.Field().String())
+	return err == nil
+}
+
+// validateSemVerConstraint validates that a field is a valid Semantic Version
+// constraint, e.g. ">=0.40, <0.43".
+func validateSemVerConstraint(fl validator.FieldLevel) bool {
+	_, err := semver.ParseConstraint(fl.Field().String())
+	return err == nil
+}
+
+// validateModulePath validates that a field is a relative slash separated path
+// contained in a repository.
+func validateModulePath(fl validator.FieldLevel) bool {
+	_, err := checksum.CleanSubdir(fl.Field().String())
+	return err == nil
+}
+
+// validateTokenScope validates that a field is a valid API token scope.
+func validateTokenScope(fl validator.FieldLevel) bool {
+	return models.IsValidTokenScope(fl.Field().String())
+}
+
 // Sanitizer defines a sanitization interface for cleaning HTML input.
 type Sanitizer interface {
 	Sanitize(string) string
@@ -65,11 +170,17 @@ func ModuleFromManifest(manifest Manifest, sanitizer Sanitizer) models.Module {
 		Contact: models.NewNullString(sanitizer.Sanitize(manifest.BugTracker.Contact)),
 	}
 
+	// Note: The path is validated as part of the manifest, so it is safe to
+	// ignore the error.
+	sourcePath, _ := checksum.CleanSubdir(manifest.Module.Path)
+
 	version := models.ModuleVersion{
 		Repo:          sanitizer.Sanitize(manifest.Version.Repo),
 		Documentation: sanitizer.Sanitize(manifest.Version.Documentation),
 		Version:       manifest.Version.Version,
 		SDKCompat:     models.NewNullString(manifest.Version.SDKCompat),
+		SourcePath:    models.NewNullString(sourcePath),
+		Readme:        manifest.Version.Readme,
 	}
 
 	return models.Module{
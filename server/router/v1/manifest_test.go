@@ -3,7 +3,6 @@ package v1
 import (
 	"testing"
 
-	"github.com/go-playground/validator/v10"
 	"github.com/stretchr/testify/require"
 )
 
@@ -33,6 +32,7 @@ func TestModuleFromManifest(t *testing.T) {
 
 	module := ModuleFromManifest(manifest, newSanitizer())
 	require.Equal(t, manifest.Module.Name, module.Name)
+	require.False(t, module.Version.SourcePath.Valid)
 	require.Equal(t, manifest.Module.Description, module.Description)
 	require.Equal(t, manifest.Module.Homepage, module.Homepage)
 	require.Len(t, module.Keywords, 2)
@@ -49,10 +49,15 @@ func TestModuleFromManifest(t *testing.T) {
 	require.Equal(t, manifest.Version.Documentation, module.Version.Documentation)
 	require.Equal(t, manifest.Version.Version, module.Version.Version)
 	require.Equal(t, manifest.Version.SDKCompat, module.Version.SDKCompat.String)
+
+	// the module's path is cleaned
+	manifest.Module.Path = "./x/test/"
+	module = ModuleFromManifest(manifest, newSanitizer())
+	require.Equal(t, "x/test", module.Version.SourcePath.String)
 }
 
 func TestValidateManifest(t *testing.T) {
-	validate := validator.New()
+	validate := NewValidator()
 
 	testCases := []struct {
 		name      string
@@ -133,6 +138,95 @@ func TestValidateManifest(t *testing.T) {
 			},
 			true,
 		},
+		{
+			"invalid version",
+			Manifest{
+				Module: ModuleManifest{
+					Name:        "x/test",
+					Keywords:    []string{"tokens", "transfer"},
+					Description: "A test description about a test module.",
+					Homepage:    "https://testmodule.com",
+				},
+				Authors: []AuthorsManifest{
+					{Name: "test_author1", Email: "testauthor1@testmodule.com"},
+				},
+				Version: VersionManifest{
+					Repo:          "https://github.com/test/test-repo",
+					Documentation: "https://github.com/test/test-repo/blob/master/x/test/readme.md",
+					Version:       "v1.0",
+					SDKCompat:     "v0.40.x",
+				},
+			},
+			true,
+		},
+		{
+			"valid SDK compatibility range",
+			Manifest{
+				Module: ModuleManifest{
+					Name: "x/test",
+				},
+				Authors: []AuthorsManifest{
+					{Name: "test_author1", Email: "testauthor1@testmodule.com"},
+				},
+				Version: VersionManifest{
+					Repo:      "https://github.com/test/test-repo",
+					Version:   "v1.0.0",
+					SDKCompat: ">=0.40, <0.43",
+				},
+			},
+			false,
+		},
+		{
+			"invalid SDK compatibility",
+			Manifest{
+				Module: ModuleManifest{
+					Name: "x/test",
+				},
+				Authors: []AuthorsManifest{
+					{Name: "test_author1", Email: "testauthor1@testmodule.com"},
+				},
+				Version: VersionManifest{
+					Repo:      "https://github.com/test/test-repo",
+					Version:   "v1.0.0",
+					SDKCompat: "stargate",
+				},
+			},
+			true,
+		},
+		{
+			"valid path",
+			Manifest{
+				Module: ModuleManifest{
+					Name: "x/test",
+					Path: "x/test",
+				},
+				Authors: []AuthorsManifest{
+					{Name: "test_author1", Email: "testauthor1@testmodule.com"},
+				},
+				Version: VersionManifest{
+					Repo:    "https://github.com/test/test-repo",
+					Version: "v1.0.0",
+				},
+			},
+			false,
+		},
+		{
+			"path outside of repository",
+			Manifest{
+				Module: ModuleManifest{
+					Name: "x/test",
+					Path: "../x/test",
+				},
+				Authors: []AuthorsManifest{
+					{Name: "test_author1", Email: "testauthor1@testmodule.com"},
+				},
+				Version: VersionManifest{
+					Repo:    "https://github.com/test/test-repo",
+					Version: "v1.0.0",
+				},
+			},
+			true,
+		},
 	}
 
 	for _, tc := range testCases {
@@ -144,3 +238,41 @@ func TestValidateManifest(t *testing.T) {
 		})
 	}
 }
+
+func TestManifest_Expand(t *testing.T) {
+	manifest := Manifest{
+		Modules: []ModuleManifest{
+			{Name: "x/foo", Keywords: []string{"foo"}},
+			{Name: "x/bar", Description: "A bar module."},
+		},
+		Authors: []AuthorsManifest{
+			{Name: "test_author1", Email: "testauthor1@testmodule.com"},
+		},
+		Version: VersionManifest{
+			Repo:    "https://github.com/test/test-repo",
+			Version: "v1.0.0",
+		},
+	}
+
+	manifests, err := manifest.Expand()
+	require.NoError(t, err)
+	require.Len(t, manifests, 2)
+
+	for i, m := range manifests {
+		require.Equal(t, manifest.Modules[i], m.Module)
+		require.Empty(t, m.Modules)
+		require.Equal(t, manifest.Authors, m.Authors)
+		require.Equal(t, manifest.Version, m.Version)
+	}
+
+	// a single-module manifest is returned as is
+	single := manifests[0]
+	manifests, err = single.Expand()
+	require.NoError(t, err)
+	require.Equal(t, []Manifest{single}, manifests)
+
+	// a manifest cannot define both a single module and multiple modules
+	manifest.Module = ModuleManifest{Name: "x/baz"}
+	_, err = manifest.Expand()
+	require.Error(t, err)
+}
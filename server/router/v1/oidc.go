@@ -0,0 +1,319 @@
+package v1
+
+import (
+	"crypto"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// GitHubActionsOIDCIssuer defines the issuer of GitHub Actions OIDC tokens,
+	// which is the default trusted issuer.
+	GitHubActionsOIDCIssuer = "https://token.actions.githubusercontent.com"
+
+	// DefaultOIDCAudience defines the default audience an OIDC token must be
+	// issued for to be exchanged for an API token.
+	DefaultOIDCAudience = "atlas"
+
+	// DefaultTrustedPublishTokenTTL defines the default duration after which an
+	// API token issued in exchange for an OIDC token expires.
+	DefaultTrustedPublishTokenTTL = 15 * time.Minute
+
+	// oidcJWKSMinRefreshInterval defines the minimum interval at which the JWKS
+	// is refetched upon encountering an unknown key ID, e.g. after the issuer
+	// rotated its keys.
+	oidcJWKSMinRefreshInterval = time.Minute
+
+	// oidcLeeway defines the allowed clock skew when verifying the expiry and
+	// not before times of an OIDC token.
+	oidcLeeway = 30 * time.Second
+
+	// workflowsDir defines the directory of a GitHub repository's workflows.
+	workflowsDir = ".github/workflows/"
+)
+
+// ErrInvalidOIDCToken defines a sentinel error when an OIDC token is malformed
+// or fails verification.
+var ErrInvalidOIDCToken = errors.New("invalid OIDC token")
+
+type (
+	// OIDCClaims defines the verified claims of an OIDC token issued to a GitHub
+	// Actions workflow run which are relevant to trusted publishing.
+	OIDCClaims struct {
+		Issuer         string       `json:"iss"`
+		Subject        string       `json:"sub"`
+		Audience       oidcAudience `json:"aud"`
+		ExpiresAt      int64        `json:"exp"`
+		NotBefore      int64        `json:"nbf"`
+		Repository     string       `json:"repository"`
+		JobWorkflowRef string       `json:"job_workflow_ref"`
+	}
+
+	// OIDCVerifier implements verification of RS256 signed OIDC tokens issued by
+	// a single issuer for a given audience. The issuer's signing keys are fetched
+	// from its JWKS URL and cached, where the JWKS is refetched when a token is
+	// signed by an unknown key.
+	OIDCVerifier struct {
+		issuer   string
+		audience string
+		jwksURL  string
+		client   *http.Client
+		now      func() time.Time
+
+		mtx       sync.Mutex
+		keys      map[string]*rsa.PublicKey
+		fetchedAt time.Time
+	}
+
+	// oidcAudience defines an OIDC token audience, which may be encoded either as
+	// a single string or an array of strings.
+	oidcAudience []string
+
+	jwtHeader struct {
+		Algorithm string `json:"alg"`
+		KeyID     string `json:"kid"`
+	}
+
+	jwks struct {
+		Keys []struct {
+			KeyType string `json:"kty"`
+			KeyID   string `json:"kid"`
+			N       string `json:"n"`
+			E       string `json:"e"`
+		} `json:"keys"`
+	}
+)
+
+// NewOIDCVerifier returns an OIDCVerifier for the given issuer, JWKS URL and
+// audience. The issuer defaults to GitHubActionsOIDCIssuer, the JWKS URL to the
+// issuer's '/.well-known/jwks' and the audience to DefaultOIDCAudience.
+func NewOIDCVerifier(issuer, jwksURL, audience string) *OIDCVerifier {
+	if issuer == "" {
+		issuer = GitHubActionsOIDCIssuer
+	}
+	if jwksURL == "" {
+		jwksURL = strings.TrimSuffix(issuer, "/") + "/.well-known/jwks"
+	}
+	if audience == "" {
+		audience = DefaultOIDCAudience
+	}
+
+	return &OIDCVerifier{
+		issuer:   issuer,
+		audience: audience,
+		jwksURL:  jwksURL,
+		client:   &http.Client{Timeout: 10 * time.Second},
+		now:      time.Now,
+		keys:     make(map[string]*rsa.PublicKey),
+	}
+}
+
+// Verify verifies the signature, issuer, audience and validity period of a
+// compact serialized OIDC token and returns its claims. An error wrapping
+// ErrInvalidOIDCToken is returned if the token is invalid and an error is
+// returned if the issuer's JWKS cannot be fetched.
+func (v *OIDCVerifier) Verify(token string) (OIDCClaims, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return OIDCClaims{}, fmt.Errorf("%w: malformed token", ErrInvalidOIDCToken)
+	}
+
+	var header jwtHeader
+	if err := decodeJWTSegment(parts[0], &header); err != nil {
+		return OIDCClaims{}, fmt.Errorf("%w: malformed header: %s", ErrInvalidOIDCToken, err)
+	}
+
+	if header.Algorithm != "RS256" {
+		return OIDCClaims{}, fmt.Errorf("%w: unsupported signing algorithm '%s'", ErrInvalidOIDCToken, header.Algorithm)
+	}
+
+	sig, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return OIDCClaims{}, fmt.Errorf("%w: malformed signature: %s", ErrInvalidOIDCToken, err)
+	}
+
+	key, err := v.key(header.KeyID)
+	if err != nil {
+		return OIDCClaims{}, err
+	}
+
+	digest := sha256.Sum256([]byte(parts[0] + "." + parts[1]))
+	if err := rsa.VerifyPKCS1v15(key, crypto.SHA256, digest[:], sig); err != nil {
+		return OIDCClaims{}, fmt.Errorf("%w: invalid signature", ErrInvalidOIDCToken)
+	}
+
+	var claims OIDCClaims
+	if err := decodeJWTSegment(parts[1], &claims); err != nil {
+		return OIDCClaims{}, fmt.Errorf("%w: malformed claims: %s", ErrInvalidOIDCToken, err)
+	}
+
+	if claims.Issuer != v.issuer {
+		return OIDCClaims{}, fmt.Errorf("%w: untrusted issuer '%s'", ErrInvalidOIDCToken, claims.Issuer)
+	}
+
+	if !claims.Audience.contains(v.audience) {
+		return OIDCClaims{}, fmt.Errorf("%w: token is not issued for audience '%s'", ErrInvalidOIDCToken, v.audience)
+	}
+
+	now := v.now()
+	if claims.ExpiresAt == 0 || now.After(time.Unix(claims.ExpiresAt, 0).Add(oidcLeeway)) {
+		return OIDCClaims{}, fmt.Errorf("%w: expired token", ErrInvalidOIDCToken)
+	}
+
+	if claims.NotBefore != 0 && now.Add(oidcLeeway).Before(time.Unix(claims.NotBefore, 0)) {
+		return OIDCClaims{}, fmt.Errorf("%w: token is not yet valid", ErrInvalidOIDCToken)
+	}
+
+	return claims, nil
+}
+
+// Workflow returns the file name of the workflow the token was issued to, e.g.
+// 'release.yml', from the token's job workflow reference, which takes the form
+// of '<owner>/<repo>/.github/workflows/<file>@<ref>'. An error is returned if
+// the workflow is not defined in the token's repository, e.g. a reusable
+// workflow of another repository.
+func (c OIDCClaims) Workflow() (string, error) {
+	ref := c.JobWorkflowRef
+	if i := strings.LastIndex(ref, "@"); i >= 0 {
+		ref = ref[:i]
+	}
+
+	prefix := c.Repository + "/" + workflowsDir
+	if c.Repository == "" || !strings.HasPrefix(ref, prefix) {
+		return "", fmt.Errorf("%w: workflow '%s' is not defined in repository '%s'", ErrInvalidOIDCToken, c.JobWorkflowRef, c.Repository)
+	}
+
+	workflow := strings.TrimPrefix(ref, prefix)
+	if !isWorkflowFile(workflow) {
+		return "", fmt.Errorf("%w: invalid workflow '%s'", ErrInvalidOIDCToken, c.JobWorkflowRef)
+	}
+
+	return workflow, nil
+}
+
+// key returns the issuer's public key by ID, refetching the JWKS if the key is
+// unknown and the JWKS has not been fetched recently.
+func (v *OIDCVerifier) key(kid string) (*rsa.PublicKey, error) {
+	v.mtx.Lock()
+	defer v.mtx.Unlock()
+
+	if key, ok := v.keys[kid]; ok {
+		return key, nil
+	}
+
+	if v.now().Sub(v.fetchedAt) < oidcJWKSMinRefreshInterval {
+		return nil, fmt.Errorf("%w: unknown signing key '%s'", ErrInvalidOIDCToken, kid)
+	}
+
+	keys, err := v.fetchKeys()
+	if err != nil {
+		return nil, err
+	}
+
+	v.keys = keys
+	v.fetchedAt = v.now()
+
+	key, ok := v.keys[kid]
+	if !ok {
+		return nil, fmt.Errorf("%w: unknown signing key '%s'", ErrInvalidOIDCToken, kid)
+	}
+
+	return key, nil
+}
+
+// fetchKeys fetches the RSA public keys of the issuer's JWKS by key ID.
+func (v *OIDCVerifier) fetchKeys() (map[string]*rsa.PublicKey, error) {
+	resp, err := v.client.Get(v.jwksURL)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch JWKS: %w", err)
+	}
+
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("failed to fetch JWKS: unexpected status code %d", resp.StatusCode)
+	}
+
+	var set jwks
+	if err := json.NewDecoder(resp.Body).Decode(&set); err != nil {
+		return nil, fmt.Errorf("failed to decode JWKS: %w", err)
+	}
+
+	keys := make(map[string]*rsa.PublicKey, len(set.Keys))
+	for _, k := range set.Keys {
+		if k.KeyType != "RSA" {
+			continue
+		}
+
+		n, err := base64.RawURLEncoding.DecodeString(k.N)
+		if err != nil {
+			return nil, fmt.Errorf("failed to decode JWKS key '%s': %w", k.KeyID, err)
+		}
+
+		e, err := base64.RawURLEncoding.DecodeString(k.E)
+		if err != nil {
+			return nil, fmt.Errorf("failed to decode JWKS key '%s': %w", k.KeyID, err)
+		}
+
+		keys[k.KeyID] = &rsa.PublicKey{
+			N: new(big.Int).SetBytes(n),
+			E: int(new(big.Int).SetBytes(e).Int64()),
+		}
+	}
+
+	return keys, nil
+}
+
+// UnmarshalJSON implements custom JSON unmarshaling for an oidcAudience, which
+// may be encoded as a single string or an array of strings.
+func (a *oidcAudience) UnmarshalJSON(bz []byte) error {
+	var single string
+	if err := json.Unmarshal(bz, &single); err == nil {
+		*a = oidcAudience{single}
+		return nil
+	}
+
+	var multi []string
+	if err := json.Unmarshal(bz, &multi); err != nil {
+		return err
+	}
+
+	*a = multi
+	return nil
+}
+
+func (a oidcAudience) contains(audience string) bool {
+	for _, aud := range a {
+		if aud == audience {
+			return true
+		}
+	}
+
+	return false
+}
+
+// decodeJWTSegment decodes a base64url encoded JSON segment of a JWT.
+func decodeJWTSegment(segment string, v interface{}) error {
+	bz, err := base64.RawURLEncoding.DecodeString(segment)
+	if err != nil {
+		return err
+	}
+
+	return json.Unmarshal(bz, v)
+}
+
+// isWorkflowFile returns true if the given name is the file name of a workflow,
+// i.e. a YAML file name without any directory.
+func isWorkflowFile(name string) bool {
+	return name != "" && !strings.ContainsAny(name, "/@") &&
+		(strings.HasSuffix(name, ".yml") || strings.HasSuffix(name, ".yaml"))
+}
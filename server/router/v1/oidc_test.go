@@ -0,0 +1,161 @@
+package v1
+
+import (
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+// testOIDCIssuer implements a local OIDC issuer which serves its JWKS and signs
+// tokens with its key.
+type testOIDCIssuer struct {
+	*httptest.Server
+
+	key *rsa.PrivateKey
+	kid string
+}
+
+func newTestOIDCIssuer(t *testing.T) *testOIDCIssuer {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+
+	issuer := &testOIDCIssuer{key: key, kid: "test-key"}
+	issuer.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{
+			"keys": []map[string]string{
+				{
+					"kty": "RSA",
+					"kid": issuer.kid,
+					"n":   base64.RawURLEncoding.EncodeToString(key.N.Bytes()),
+					"e":   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(key.E)).Bytes()),
+				},
+			},
+		})
+	}))
+
+	return issuer
+}
+
+// sign returns a compact serialized RS256 JWT of the given claims signed by the
+// given key.
+func (toi *testOIDCIssuer) sign(t *testing.T, key *rsa.PrivateKey, claims map[string]interface{}) string {
+	header, err := json.Marshal(map[string]string{"alg": "RS256", "kid": toi.kid, "typ": "JWT"})
+	require.NoError(t, err)
+
+	payload, err := json.Marshal(claims)
+	require.NoError(t, err)
+
+	signingInput := base64.RawURLEncoding.EncodeToString(header) + "." + base64.RawURLEncoding.EncodeToString(payload)
+	digest := sha256.Sum256([]byte(signingInput))
+
+	sig, err := rsa.SignPKCS1v15(rand.Reader, key, crypto.SHA256, digest[:])
+	require.NoError(t, err)
+
+	return signingInput + "." + base64.RawURLEncoding.EncodeToString(sig)
+}
+
+// workflowClaims returns valid claims of a token issued to a run of the given
+// workflow of a repository.
+func workflowClaims(repository, workflow string) map[string]interface{} {
+	return map[string]interface{}{
+		"iss":              GitHubActionsOIDCIssuer,
+		"sub":              "repo:" + repository + ":ref:refs/tags/v1.0.0",
+		"aud":              DefaultOIDCAudience,
+		"exp":              time.Now().Add(5 * time.Minute).Unix(),
+		"nbf":              time.Now().Add(-time.Minute).Unix(),
+		"repository":       repository,
+		"job_workflow_ref": repository + "/.github/workflows/" + workflow + "@refs/tags/v1.0.0",
+	}
+}
+
+func TestOIDCVerifier_Verify(t *testing.T) {
+	issuer := newTestOIDCIssuer(t)
+	defer issuer.Close()
+
+	otherKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+
+	verifier := NewOIDCVerifier("", issuer.URL, "")
+
+	withClaim := func(key string, value interface{}) map[string]interface{} {
+		claims := workflowClaims("cosmos/cosmos-sdk", "release.yml")
+		claims[key] = value
+		return claims
+	}
+
+	testCases := []struct {
+		name      string
+		token     string
+		expectErr bool
+	}{
+		{"valid", issuer.sign(t, issuer.key, workflowClaims("cosmos/cosmos-sdk", "release.yml")), false},
+		{"audience array", issuer.sign(t, issuer.key, withClaim("aud", []string{"other", DefaultOIDCAudience})), false},
+		{"malformed", "not.a.token.at.all", true},
+		{"invalid signature", issuer.sign(t, otherKey, workflowClaims("cosmos/cosmos-sdk", "release.yml")), true},
+		{"untrusted issuer", issuer.sign(t, issuer.key, withClaim("iss", "https://issuer.example.com")), true},
+		{"wrong audience", issuer.sign(t, issuer.key, withClaim("aud", "other")), true},
+		{"expired", issuer.sign(t, issuer.key, withClaim("exp", time.Now().Add(-time.Hour).Unix())), true},
+		{"not yet valid", issuer.sign(t, issuer.key, withClaim("nbf", time.Now().Add(time.Hour).Unix())), true},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+
+		t.Run(tc.name, func(t *testing.T) {
+			claims, err := verifier.Verify(tc.token)
+			if tc.expectErr {
+				require.ErrorIs(t, err, ErrInvalidOIDCToken)
+				return
+			}
+
+			require.NoError(t, err)
+			require.Equal(t, "cosmos/cosmos-sdk", claims.Repository)
+
+			workflow, err := claims.Workflow()
+			require.NoError(t, err)
+			require.Equal(t, "release.yml", workflow)
+		})
+	}
+}
+
+func TestOIDCClaims_Workflow(t *testing.T) {
+	testCases := []struct {
+		name           string
+		jobWorkflowRef string
+		expected       string
+		expectErr      bool
+	}{
+		{"workflow", "cosmos/cosmos-sdk/.github/workflows/release.yml@refs/tags/v1.0.0", "release.yml", false},
+		{"yaml workflow", "cosmos/cosmos-sdk/.github/workflows/release.yaml@refs/heads/main", "release.yaml", false},
+		{"other repository", "cosmos/other/.github/workflows/release.yml@refs/heads/main", "", true},
+		{"nested path", "cosmos/cosmos-sdk/.github/workflows/x/release.yml@refs/heads/main", "", true},
+		{"not a workflow", "cosmos/cosmos-sdk/scripts/release.sh@refs/heads/main", "", true},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+
+		t.Run(tc.name, func(t *testing.T) {
+			claims := OIDCClaims{Repository: "cosmos/cosmos-sdk", JobWorkflowRef: tc.jobWorkflowRef}
+
+			workflow, err := claims.Workflow()
+			if tc.expectErr {
+				require.ErrorIs(t, err, ErrInvalidOIDCToken)
+				return
+			}
+
+			require.NoError(t, err)
+			require.Equal(t, tc.expected, workflow)
+		})
+	}
+}
@@ -0,0 +1,177 @@
+package v1
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+const (
+	// archiveTimeout defines the maximum duration of fetching a repository
+	// archive, including reading its body.
+	archiveTimeout = time.Minute
+
+	// maxArchiveSize defines the maximum size, in bytes, of a repository archive.
+	maxArchiveSize = 256 << 20
+)
+
+var (
+	// ErrTagNotFound defines a sentinel error when a tag does not exist in a
+	// repository.
+	ErrTagNotFound = errors.New("tag not found")
+
+	// ErrNotModified defines a sentinel error when a conditionally requested
+	// repository has not been modified.
+	ErrNotModified = errors.New("repository not modified")
+
+	// ErrArchiveTooLarge defines a sentinel error when a repository archive
+	// exceeds the maximum size.
+	ErrArchiveTooLarge = errors.New("repository archive too large")
+)
+
+type (
+	// GitHubClientI defines the interface used to retrieve GitHub repository
+	// information.
+	GitHubClientI interface {
+		GetRepository(repoURL string) (Repository, error)
+		GetTagCommit(repo Repository, tag string) (string, error)
+		GetTarball(repo Repository, ref string) (io.ReadCloser, error)
+	}
+
+	// RepositoryClientI defines the provider agnostic interface used to retrieve
+	// repository information from a Git hosting provider, e.g. GitLab or Gitea.
+	// A client serves all repositories of a single host.
+	RepositoryClientI interface {
+		GitHubClientI
+
+		Host() string
+	}
+
+	// ConditionalGitHubClientI defines the interface of a client which supports
+	// conditional requests of a repository, such that an unmodified repository
+	// can be revalidated cheaply.
+	ConditionalGitHubClientI interface {
+		GitHubClientI
+
+		GetRepositoryIfModified(repoURL, etag string) (Repository, string, error)
+	}
+
+	// GitHubOrgClientI defines the interface used to verify a user's membership
+	// of a GitHub organization or team, where only active memberships count.
+	GitHubOrgClientI interface {
+		IsOrgMember(org, user string) (bool, error)
+		IsTeamMember(org, team, user string) (bool, error)
+	}
+
+	// Repository defines the relevant information Atlas needs for a hosted
+	// repository in order to publish modules. For providers that support nested
+	// namespaces, the owner is the full namespace path.
+	Repository struct {
+		Owner        string
+		Repo         string
+		Contributors map[string]Contributor
+
+		// Stale defines whether the repository was served from a cache without
+		// being revalidated, in which case its contributors are omitted
+		Stale bool
+	}
+
+	// Contributor defines a user who has contributed to, or is a member of, a
+	// repository, keyed by their login on the repository's provider.
+	Contributor struct {
+		Login     string
+		AvatarURL string
+	}
+)
+
+// repositoryHost returns the lower-cased host name, without a port, of a
+// repository URL.
+func repositoryHost(repoURL string) (string, error) {
+	u, err := url.Parse(repoURL)
+	if err != nil {
+		return "", fmt.Errorf("failed to parse repo URL: %w", err)
+	}
+
+	if u.Host == "" {
+		return "", fmt.Errorf("invalid repository: %s", repoURL)
+	}
+
+	return strings.ToLower(u.Hostname()), nil
+}
+
+// doJSONRequest executes an HTTP request against a provider's REST API and
+// decodes the JSON response body into v. The HTTP response is returned along
+// with any error so callers may inspect the status code. An error is returned
+// for any non-2xx status code.
+func doJSONRequest(httpClient *http.Client, req *http.Request, v interface{}) (*http.Response, error) {
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return resp, fmt.Errorf("unexpected status code %d", resp.StatusCode)
+	}
+
+	if err := json.NewDecoder(resp.Body).Decode(v); err != nil {
+		return resp, fmt.Errorf("failed to decode response: %w", err)
+	}
+
+	return resp, nil
+}
+
+// doArchiveRequest executes an HTTP request for a repository archive, which
+// must be fetched within archiveTimeout and must not exceed maxArchiveSize. The
+// caller is responsible for closing the returned reader.
+func doArchiveRequest(httpClient *http.Client, req *http.Request) (io.ReadCloser, error) {
+	client := *httpClient
+	if client.Timeout == 0 || client.Timeout > archiveTimeout {
+		client.Timeout = archiveTimeout
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch repository archive: %w", err)
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		_ = resp.Body.Close()
+		return nil, fmt.Errorf("failed to fetch repository archive: unexpected status code %d", resp.StatusCode)
+	}
+
+	if resp.ContentLength > maxArchiveSize {
+		_ = resp.Body.Close()
+		return nil, fmt.Errorf("failed to fetch repository archive: %w", ErrArchiveTooLarge)
+	}
+
+	return &archiveReader{Reader: io.LimitReader(resp.Body, maxArchiveSize+1), Closer: resp.Body}, nil
+}
+
+// archiveReader implements a reader of a repository archive which fails once
+// more than maxArchiveSize bytes are read.
+type archiveReader struct {
+	io.Reader
+	io.Closer
+
+	n int64
+}
+
+func (ar *archiveReader) Read(p []byte) (int, error) {
+	n, err := ar.Reader.Read(p)
+
+	ar.n += int64(n)
+	if ar.n > maxArchiveSize {
+		return n, fmt.Errorf("failed to read repository archive: %w", ErrArchiveTooLarge)
+	}
+
+	return n, err
+}
@@ -0,0 +1,530 @@
+package v1
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/rs/zerolog"
+
+	"github.com/cosmos/atlas/server/checksum"
+)
+
+const (
+	// DefaultRepositoryCacheTTL defines the default duration for which a cached
+	// repository is considered fresh.
+	DefaultRepositoryCacheTTL = 10 * time.Minute
+
+	// DefaultRepositoryCacheMaxStale defines the default duration for which a
+	// cached repository's metadata may be served when it cannot be revalidated,
+	// e.g. during a provider outage. Entries which are not requested within this
+	// duration are evicted.
+	DefaultRepositoryCacheMaxStale = 30 * time.Minute
+)
+
+type (
+	// RepositoryCache implements a cache of repositories, and thus their
+	// contributor sets, in front of a GitHubClientI. Repositories are cached per
+	// client identity, such that a repository fetched with one user's credentials
+	// is never served to another. Cached repositories are fresh for a TTL after
+	// which they are revalidated, using a conditional request if the client
+	// supports it. A background refresher revalidates entries fetched by shared
+	// clients before they expire, such that publishing does not wait on the
+	// provider, where entries fetched by a user's client are only revalidated when
+	// requested by that user. If revalidation fails, the stale entry's metadata
+	// continues to be served for up to maxStale without its contributors, which
+	// must not be used for authorization.
+	//
+	// The commits tags refer to and the source trees of commits are cached per
+	// client identity as well, such that a module can be published while its
+	// provider is unavailable if its repository is fresh and its version's tag
+	// was recently fetched, e.g. when publishing the modules of a monorepo. Tag
+	// commits are cached like repositories but are not refreshed in the
+	// background, whereas source trees are immutable and thus never revalidated.
+	RepositoryCache struct {
+		logger   zerolog.Logger
+		ttl      time.Duration
+		maxStale time.Duration
+		now      func() time.Time
+		doneCh   chan struct{}
+
+		mtx     sync.RWMutex
+		entries map[string]*repositoryCacheEntry
+		tags    map[string]*tagCacheEntry
+		trees   map[string]*sourceTreeCacheEntry
+
+		hits          uint64
+		misses        uint64
+		revalidations uint64
+		staleHits     uint64
+		refreshes     uint64
+		errors        uint64
+	}
+
+	// RepositoryCacheStats defines the metrics of a RepositoryCache. The hit rate
+	// is the ratio of lookups served without fully fetching the repository.
+	RepositoryCacheStats struct {
+		Entries       int     `json:"entries"`
+		Tags          int     `json:"tags"`
+		SourceTrees   int     `json:"source_trees"`
+		Hits          uint64  `json:"hits"`
+		Misses        uint64  `json:"misses"`
+		Revalidations uint64  `json:"revalidations"`
+		StaleHits     uint64  `json:"stale_hits"`
+		Refreshes     uint64  `json:"refreshes"`
+		Errors        uint64  `json:"errors"`
+		HitRate       float64 `json:"hit_rate"`
+	}
+
+	repositoryCacheEntry struct {
+		repoURL      string
+		repo         Repository
+		etag         string
+		validatedAt  time.Time
+		lastAccessed time.Time
+
+		// client defines the shared client used to refresh the repository in the
+		// background, which is nil for entries fetched on behalf of a user
+		client GitHubClientI
+	}
+
+	tagCacheEntry struct {
+		sha          string
+		validatedAt  time.Time
+		lastAccessed time.Time
+	}
+
+	sourceTreeCacheEntry struct {
+		tree         checksum.Tree
+		lastAccessed time.Time
+	}
+
+	// cachedGitHubClient implements a GitHubClientI which retrieves repositories
+	// through a RepositoryCache on behalf of a client identity.
+	cachedGitHubClient struct {
+		GitHubClientI
+
+		cache    *RepositoryCache
+		identity string
+	}
+)
+
+// NewRepositoryCache returns a RepositoryCache with the given TTL and maximum
+// staleness, where the defaults are used for non-positive values.
+func NewRepositoryCache(logger zerolog.Logger, ttl, maxStale time.Duration) *RepositoryCache {
+	if ttl <= 0 {
+		ttl = DefaultRepositoryCacheTTL
+	}
+	if maxStale <= 0 {
+		maxStale = DefaultRepositoryCacheMaxStale
+	}
+
+	return &RepositoryCache{
+		logger:   logger.With().Str("module", "repository_cache").Logger(),
+		ttl:      ttl,
+		maxStale: maxStale,
+		now:      time.Now,
+		doneCh:   make(chan struct{}),
+		entries:  make(map[string]*repositoryCacheEntry),
+		tags:     make(map[string]*tagCacheEntry),
+		trees:    make(map[string]*sourceTreeCacheEntry),
+	}
+}
+
+// Wrap returns a GitHubClientI which retrieves repositories, tag commits and
+// source trees through the cache using the given shared client, i.e. a client
+// which is not bound to a user's credentials, upon a miss or revalidation.
+// Repositories fetched by the client are refreshed in the background. All other
+// requests are passed through to the client.
+func (rc *RepositoryCache) Wrap(client GitHubClientI) GitHubClientI {
+	return cachedGitHubClient{GitHubClientI: client, cache: rc}
+}
+
+// WrapToken returns a GitHubClientI which retrieves repositories, tag commits
+// and source trees through the cache using the given client, bound to a user's
+// access token, upon a miss or revalidation. Entries are only shared with
+// clients bound to the same token and are never refreshed in the background, so
+// it should only be used for data the shared client cannot access, e.g. private
+// repositories. All other requests are passed through to the client.
+func (rc *RepositoryCache) WrapToken(client GitHubClientI, token string) GitHubClientI {
+	sum := sha256.Sum256([]byte(token))
+	return cachedGitHubClient{GitHubClientI: client, cache: rc, identity: "token:" + hex.EncodeToString(sum[:])}
+}
+
+// GetRepository returns a repository from the cache, fetching or revalidating
+// it using the wrapped client as necessary.
+func (cgc cachedGitHubClient) GetRepository(repoURL string) (Repository, error) {
+	return cgc.cache.getRepository(cgc.GitHubClientI, cgc.identity, repoURL)
+}
+
+// GetTagCommit returns the commit a tag refers to from the cache, fetching it
+// using the wrapped client as necessary.
+func (cgc cachedGitHubClient) GetTagCommit(repo Repository, tag string) (string, error) {
+	return cgc.cache.getTagCommit(cgc.GitHubClientI, cgc.identity, repo, tag)
+}
+
+// GetSourceTree returns the source tree of a repository at the given commit
+// from the cache, fetching the repository's archive using the wrapped client
+// upon a miss.
+func (cgc cachedGitHubClient) GetSourceTree(repo Repository, sha string) (checksum.Tree, error) {
+	return cgc.cache.getSourceTree(cgc.GitHubClientI, cgc.identity, repo, sha)
+}
+
+// Start starts a blocking process which periodically refreshes all repositories
+// of shared clients that are at least half way through their TTL and evicts
+// entries which have not been requested within maxStale. It continues until
+// Stop is called.
+func (rc *RepositoryCache) Start() {
+	ticker := time.NewTicker(rc.ttl / 2)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			rc.refresh()
+
+			stats := rc.Stats()
+			rc.logger.Info().
+				Int("entries", stats.Entries).
+				Uint64("hits", stats.Hits).
+				Uint64("misses", stats.Misses).
+				Uint64("revalidations", stats.Revalidations).
+				Uint64("stale_hits", stats.StaleHits).
+				Uint64("errors", stats.Errors).
+				Float64("hit_rate", stats.HitRate).
+				Msg("refreshed repository cache")
+
+		case <-rc.doneCh:
+			return
+		}
+	}
+}
+
+// Stop signals to the refresher that it should halt.
+func (rc *RepositoryCache) Stop() {
+	close(rc.doneCh)
+}
+
+// Stats returns the current metrics of the cache.
+func (rc *RepositoryCache) Stats() RepositoryCacheStats {
+	rc.mtx.RLock()
+	numEntries, numTags, numTrees := len(rc.entries), len(rc.tags), len(rc.trees)
+	rc.mtx.RUnlock()
+
+	stats := RepositoryCacheStats{
+		Entries:       numEntries,
+		Tags:          numTags,
+		SourceTrees:   numTrees,
+		Hits:          atomic.LoadUint64(&rc.hits),
+		Misses:        atomic.LoadUint64(&rc.misses),
+		Revalidations: atomic.LoadUint64(&rc.revalidations),
+		StaleHits:     atomic.LoadUint64(&rc.staleHits),
+		Refreshes:     atomic.LoadUint64(&rc.refreshes),
+		Errors:        atomic.LoadUint64(&rc.errors),
+	}
+
+	served := stats.Hits + stats.Revalidations + stats.StaleHits
+	if total := served + stats.Misses; total > 0 {
+		stats.HitRate = float64(served) / float64(total)
+	}
+
+	return stats
+}
+
+// Status implements the health checker interface, reporting the cache's
+// metrics as the check's details.
+func (rc *RepositoryCache) Status() (interface{}, error) {
+	return rc.Stats(), nil
+}
+
+func (rc *RepositoryCache) getRepository(client GitHubClientI, identity, repoURL string) (Repository, error) {
+	key := repositoryCacheKey(identity, repoURL)
+	now := rc.now()
+
+	var validatedAt time.Time
+
+	rc.mtx.Lock()
+	entry, ok := rc.entries[key]
+	if ok {
+		entry.lastAccessed = now
+		validatedAt = entry.validatedAt
+	}
+	rc.mtx.Unlock()
+
+	if !ok {
+		atomic.AddUint64(&rc.misses, 1)
+
+		entry, err := rc.fetch(client, identity, repoURL, nil)
+		if err != nil {
+			atomic.AddUint64(&rc.errors, 1)
+			return Repository{}, err
+		}
+
+		return entry.repo.clone(), nil
+	}
+
+	if now.Sub(validatedAt) < rc.ttl {
+		atomic.AddUint64(&rc.hits, 1)
+		return entry.repo.clone(), nil
+	}
+
+	updated, err := rc.fetch(client, identity, repoURL, entry)
+	switch {
+	case err == nil && updated == entry:
+		atomic.AddUint64(&rc.revalidations, 1)
+		return entry.repo.clone(), nil
+
+	case err == nil:
+		atomic.AddUint64(&rc.misses, 1)
+		return updated.repo.clone(), nil
+
+	case now.Sub(validatedAt) < rc.maxStale:
+		atomic.AddUint64(&rc.errors, 1)
+		atomic.AddUint64(&rc.staleHits, 1)
+
+		rc.logger.Warn().Err(err).Str("repository", repoURL).Msg("serving stale repository")
+		return entry.repo.stale(), nil
+
+	default:
+		atomic.AddUint64(&rc.errors, 1)
+		return Repository{}, err
+	}
+}
+
+// fetch fetches a repository on behalf of a client identity and stores it in the
+// cache. If a previous entry is provided and the client supports conditional
+// requests, the repository is revalidated against it, where the same entry is
+// returned if the repository has not been modified. The client is only retained
+// for background refreshes if it is shared, i.e. has no identity.
+func (rc *RepositoryCache) fetch(client GitHubClientI, identity, repoURL string, prev *repositoryCacheEntry) (*repositoryCacheEntry, error) {
+	var (
+		repo Repository
+		etag string
+		err  error
+	)
+
+	if condClient, ok := client.(ConditionalGitHubClientI); ok {
+		var prevETag string
+		if prev != nil {
+			prevETag = prev.etag
+		}
+
+		repo, etag, err = condClient.GetRepositoryIfModified(repoURL, prevETag)
+	} else {
+		repo, err = client.GetRepository(repoURL)
+	}
+
+	key := repositoryCacheKey(identity, repoURL)
+	now := rc.now()
+
+	var refreshClient GitHubClientI
+	if identity == "" {
+		refreshClient = client
+	}
+
+	rc.mtx.Lock()
+	defer rc.mtx.Unlock()
+
+	if errors.Is(err, ErrNotModified) && prev != nil {
+		prev.validatedAt = now
+		return prev, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	entry := &repositoryCacheEntry{
+		repoURL:      repoURL,
+		repo:         repo,
+		etag:         etag,
+		validatedAt:  now,
+		lastAccessed: now,
+		client:       refreshClient,
+	}
+
+	if prev != nil {
+		entry.lastAccessed = prev.lastAccessed
+	}
+
+	rc.entries[key] = entry
+
+	return entry, nil
+}
+
+// getTagCommit returns the commit a tag refers to on behalf of a client
+// identity. A cached commit is fresh for the TTL after which the tag is fetched
+// again, where the cached commit continues to be served for up to maxStale if
+// the fetch fails. Tags which do not exist are not cached.
+func (rc *RepositoryCache) getTagCommit(client GitHubClientI, identity string, repo Repository, tag string) (string, error) {
+	key := repositoryRefCacheKey(identity, client, repo, tag)
+	now := rc.now()
+
+	var sha string
+	var validatedAt time.Time
+
+	rc.mtx.Lock()
+	entry, ok := rc.tags[key]
+	if ok {
+		entry.lastAccessed = now
+		sha, validatedAt = entry.sha, entry.validatedAt
+	}
+	rc.mtx.Unlock()
+
+	if ok && now.Sub(validatedAt) < rc.ttl {
+		return sha, nil
+	}
+
+	fetched, err := client.GetTagCommit(repo, tag)
+	switch {
+	case err == nil:
+		rc.mtx.Lock()
+		rc.tags[key] = &tagCacheEntry{sha: fetched, validatedAt: now, lastAccessed: now}
+		rc.mtx.Unlock()
+
+		return fetched, nil
+
+	case errors.Is(err, ErrTagNotFound):
+		rc.mtx.Lock()
+		delete(rc.tags, key)
+		rc.mtx.Unlock()
+
+		return "", err
+
+	case ok && now.Sub(validatedAt) < rc.maxStale:
+		atomic.AddUint64(&rc.errors, 1)
+
+		rc.logger.Warn().Err(err).Str("repository", repo.Owner+"/"+repo.Repo).Str("tag", tag).Msg("serving stale tag commit")
+		return sha, nil
+
+	default:
+		atomic.AddUint64(&rc.errors, 1)
+		return "", err
+	}
+}
+
+// getSourceTree returns the source tree of a repository at the given commit on
+// behalf of a client identity, fetching and reading the repository's archive
+// upon a miss. As the source tree of a commit cannot change, cached trees are
+// never revalidated.
+func (rc *RepositoryCache) getSourceTree(client GitHubClientI, identity string, repo Repository, sha string) (checksum.Tree, error) {
+	key := repositoryRefCacheKey(identity, client, repo, sha)
+	now := rc.now()
+
+	rc.mtx.Lock()
+	entry, ok := rc.trees[key]
+	if ok {
+		entry.lastAccessed = now
+	}
+	rc.mtx.Unlock()
+
+	if ok {
+		return entry.tree, nil
+	}
+
+	tree, err := fetchSourceTree(client, repo, sha)
+	if err != nil {
+		atomic.AddUint64(&rc.errors, 1)
+		return nil, err
+	}
+
+	rc.mtx.Lock()
+	rc.trees[key] = &sourceTreeCacheEntry{tree: tree, lastAccessed: now}
+	rc.mtx.Unlock()
+
+	return tree, nil
+}
+
+// refresh revalidates all repositories of shared clients which are at least half
+// way through their TTL and evicts entries which have not been requested within
+// maxStale.
+func (rc *RepositoryCache) refresh() {
+	now := rc.now()
+
+	var stale []*repositoryCacheEntry
+
+	rc.mtx.Lock()
+	for key, entry := range rc.entries {
+		switch {
+		case now.Sub(entry.lastAccessed) >= rc.maxStale:
+			delete(rc.entries, key)
+
+		case entry.client != nil && now.Sub(entry.validatedAt) >= rc.ttl/2:
+			stale = append(stale, entry)
+		}
+	}
+	for key, entry := range rc.tags {
+		if now.Sub(entry.lastAccessed) >= rc.maxStale {
+			delete(rc.tags, key)
+		}
+	}
+	for key, entry := range rc.trees {
+		if now.Sub(entry.lastAccessed) >= rc.maxStale {
+			delete(rc.trees, key)
+		}
+	}
+	rc.mtx.Unlock()
+
+	for _, entry := range stale {
+		atomic.AddUint64(&rc.refreshes, 1)
+
+		if _, err := rc.fetch(entry.client, "", entry.repoURL, entry); err != nil {
+			atomic.AddUint64(&rc.errors, 1)
+			rc.logger.Warn().Err(err).Str("repository", entry.repoURL).Msg("failed to refresh repository")
+		}
+	}
+}
+
+// clone returns a copy of the repository such that callers cannot mutate the
+// cached contributor set.
+func (r Repository) clone() Repository {
+	contributors := make(map[string]Contributor, len(r.Contributors))
+	for login, c := range r.Contributors {
+		contributors[login] = c
+	}
+
+	r.Contributors = contributors
+	return r
+}
+
+// stale returns a copy of the repository's metadata which is marked as stale,
+// where its contributors are omitted as they may no longer be accurate.
+func (r Repository) stale() Repository {
+	r.Contributors = map[string]Contributor{}
+	r.Stale = true
+	return r
+}
+
+// repositoryCacheKey returns the key of a repository URL fetched on behalf of a
+// client identity in the cache, which is the identity followed by the URL
+// without any query or fragment and with a lower-cased host.
+func repositoryCacheKey(identity, repoURL string) string {
+	u, err := url.Parse(repoURL)
+	if err != nil {
+		return identity + " " + repoURL
+	}
+
+	u.Host = strings.ToLower(u.Host)
+	u.RawQuery = ""
+	u.Fragment = ""
+
+	return identity + " " + u.String()
+}
+
+// repositoryRefCacheKey returns the key of a ref, i.e. a tag or commit, of a
+// repository fetched by a client on behalf of a client identity in the cache,
+// which is the identity followed by the lower-cased host the client serves and
+// the repository's path and ref.
+func repositoryRefCacheKey(identity string, client GitHubClientI, repo Repository, ref string) string {
+	host := gitHubHost
+	if hostClient, ok := client.(interface{ Host() string }); ok {
+		host = hostClient.Host()
+	}
+
+	return fmt.Sprintf("%s %s/%s/%s@%s", identity, strings.ToLower(host), repo.Owner, repo.Repo, ref)
+}
@@ -0,0 +1,282 @@
+package v1
+
+import (
+	"errors"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/knadh/koanf"
+	"github.com/knadh/koanf/providers/confmap"
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/require"
+
+	"github.com/cosmos/atlas/config"
+	"github.com/cosmos/atlas/server/models"
+)
+
+// testConditionalClient implements a ConditionalGitHubClientI which counts full
+// fetches and conditional requests, where the repository is modified whenever
+// its contributors change.
+type testConditionalClient struct {
+	testGitHubClient
+
+	etag         string
+	fetches      int
+	conditionals int
+	err          error
+}
+
+func (tcc *testConditionalClient) GetRepositoryIfModified(repoURL, etag string) (Repository, string, error) {
+	if tcc.err != nil {
+		return Repository{}, "", tcc.err
+	}
+
+	if etag != "" {
+		tcc.conditionals++
+
+		if etag == tcc.etag {
+			return Repository{}, etag, ErrNotModified
+		}
+	}
+
+	tcc.fetches++
+
+	repo, err := tcc.GetRepository(repoURL)
+	return repo, tcc.etag, err
+}
+
+func (tcc *testConditionalClient) GetTagCommit(repo Repository, tag string) (string, error) {
+	if tcc.err != nil {
+		return "", tcc.err
+	}
+
+	return tcc.testGitHubClient.GetTagCommit(repo, tag)
+}
+
+func (tcc *testConditionalClient) GetTarball(repo Repository, ref string) (io.ReadCloser, error) {
+	if tcc.err != nil {
+		return nil, tcc.err
+	}
+
+	return tcc.testGitHubClient.GetTarball(repo, ref)
+}
+
+func TestRepositoryCache(t *testing.T) {
+	now := time.Now()
+	cache := NewRepositoryCache(zerolog.Nop(), time.Minute, time.Hour)
+	cache.now = func() time.Time { return now }
+
+	client := &testConditionalClient{testGitHubClient: testGitHubClient{contributors: []string{"foo"}}, etag: `"1"`}
+	cachedClient := cache.Wrap(client)
+
+	// the first lookup is a miss and query and fragment are ignored
+	repo, err := cachedClient.GetRepository("https://github.com/cosmos/cosmos-sdk?foo=bar")
+	require.NoError(t, err)
+	require.Equal(t, "cosmos", repo.Owner)
+	require.Equal(t, 1, client.fetches)
+
+	// mutating a returned repository does not affect the cache
+	delete(repo.Contributors, "foo")
+
+	// a fresh entry is served without a request
+	repo, err = cachedClient.GetRepository("https://GitHub.com/cosmos/cosmos-sdk#readme")
+	require.NoError(t, err)
+	require.Len(t, repo.Contributors, 1)
+	require.Equal(t, 1, client.fetches)
+	require.Equal(t, 0, client.conditionals)
+
+	// an expired but unmodified entry is revalidated
+	now = now.Add(2 * time.Minute)
+
+	_, err = cachedClient.GetRepository("https://github.com/cosmos/cosmos-sdk")
+	require.NoError(t, err)
+	require.Equal(t, 1, client.fetches)
+	require.Equal(t, 1, client.conditionals)
+
+	// an expired and modified entry is fetched again
+	now = now.Add(2 * time.Minute)
+	client.etag = `"2"`
+	client.contributors = []string{"foo", "bar"}
+
+	repo, err = cachedClient.GetRepository("https://github.com/cosmos/cosmos-sdk")
+	require.NoError(t, err)
+	require.Len(t, repo.Contributors, 2)
+	require.Equal(t, 2, client.fetches)
+	require.Equal(t, 2, client.conditionals)
+
+	// a stale entry's metadata is served while the provider is unavailable
+	now = now.Add(2 * time.Minute)
+	client.err = errors.New("service unavailable")
+
+	repo, err = cachedClient.GetRepository("https://github.com/cosmos/cosmos-sdk")
+	require.NoError(t, err)
+	require.True(t, repo.Stale)
+	require.Equal(t, "cosmos", repo.Owner)
+	require.Empty(t, repo.Contributors)
+
+	// an entry is no longer served once it exceeds the maximum staleness
+	now = now.Add(time.Hour)
+
+	_, err = cachedClient.GetRepository("https://github.com/cosmos/cosmos-sdk")
+	require.Error(t, err)
+
+	// a lookup of an unknown repository fails while the provider is unavailable
+	_, err = cachedClient.GetRepository("https://github.com/cosmos/gaia")
+	require.Error(t, err)
+
+	stats := cache.Stats()
+	require.Equal(t, RepositoryCacheStats{
+		Entries:       1,
+		Hits:          1,
+		Misses:        3,
+		Revalidations: 1,
+		StaleHits:     1,
+		Errors:        3,
+		HitRate:       0.5,
+	}, stats)
+}
+
+func TestRepositoryCache_Refresh(t *testing.T) {
+	now := time.Now()
+	cache := NewRepositoryCache(zerolog.Nop(), time.Minute, time.Hour)
+	cache.now = func() time.Time { return now }
+
+	client := &testConditionalClient{testGitHubClient: testGitHubClient{contributors: []string{"foo"}}, etag: `"1"`}
+	cachedClient := cache.Wrap(client)
+
+	_, err := cachedClient.GetRepository("https://github.com/cosmos/cosmos-sdk")
+	require.NoError(t, err)
+
+	_, err = cachedClient.GetRepository("https://github.com/cosmos/gaia")
+	require.NoError(t, err)
+	require.Equal(t, 2, client.fetches)
+
+	// entries are refreshed half way through their TTL
+	now = now.Add(30 * time.Second)
+	client.etag = `"2"`
+	cache.refresh()
+	require.Equal(t, 4, client.fetches)
+	require.Equal(t, 2, client.conditionals)
+
+	// refreshed entries are fresh when requested
+	now = now.Add(45 * time.Second)
+
+	_, err = cachedClient.GetRepository("https://github.com/cosmos/cosmos-sdk")
+	require.NoError(t, err)
+	require.Equal(t, 4, client.fetches)
+	require.Equal(t, 2, client.conditionals)
+
+	// entries which are not requested within the maximum staleness are evicted
+	now = now.Add(59 * time.Minute)
+	cache.refresh()
+	require.Equal(t, 1, cache.Stats().Entries)
+}
+
+func TestRepositoryCache_Token(t *testing.T) {
+	now := time.Now()
+	cache := NewRepositoryCache(zerolog.Nop(), time.Minute, time.Hour)
+	cache.now = func() time.Time { return now }
+
+	fooClient := &testConditionalClient{testGitHubClient: testGitHubClient{contributors: []string{"foo"}}, etag: `"1"`}
+	barClient := &testConditionalClient{testGitHubClient: testGitHubClient{contributors: []string{"bar"}}, etag: `"1"`}
+
+	_, err := cache.WrapToken(fooClient, "foo-token").GetRepository("https://github.com/cosmos/cosmos-sdk")
+	require.NoError(t, err)
+	require.Equal(t, 1, fooClient.fetches)
+
+	// a repository fetched with one token is not served to another
+	repo, err := cache.WrapToken(barClient, "bar-token").GetRepository("https://github.com/cosmos/cosmos-sdk")
+	require.NoError(t, err)
+	require.Contains(t, repo.Contributors, "bar")
+	require.Equal(t, 1, barClient.fetches)
+
+	// but is served to clients with the same token
+	repo, err = cache.WrapToken(barClient, "foo-token").GetRepository("https://github.com/cosmos/cosmos-sdk")
+	require.NoError(t, err)
+	require.Contains(t, repo.Contributors, "foo")
+	require.Equal(t, 1, barClient.fetches)
+	require.Equal(t, 2, cache.Stats().Entries)
+
+	// entries fetched with a token are not refreshed in the background
+	now = now.Add(45 * time.Second)
+	cache.refresh()
+	require.Equal(t, 1, fooClient.fetches)
+	require.Equal(t, 0, fooClient.conditionals)
+	require.Equal(t, uint64(0), cache.Stats().Refreshes)
+}
+
+func TestRepositoryCache_Outage(t *testing.T) {
+	now := time.Now()
+	cache := NewRepositoryCache(zerolog.Nop(), time.Minute, time.Hour)
+	cache.now = func() time.Time { return now }
+
+	appClient := &testConditionalClient{testGitHubClient: testGitHubClient{contributors: []string{"foo"}}, etag: `"1"`}
+	userClient := &testConditionalClient{testGitHubClient: testGitHubClient{contributors: []string{"foo"}}, etag: `"1"`}
+
+	k := koanf.New(".")
+	require.NoError(t, k.Load(confmap.Provider(map[string]interface{}{
+		config.GHToken: "app-token",
+	}, "."), nil))
+
+	r := &Router{
+		cfg:       k,
+		sanitizer: newSanitizer(),
+		repoCache: cache,
+		ghClientCreator: func(token string) GitHubClientI {
+			if token == "app-token" {
+				return appClient
+			}
+
+			return userClient
+		},
+	}
+
+	foo := models.User{Name: "foo", GithubAccessToken: models.NewNullString("foo-token")}
+	manifest := func(name string) Manifest {
+		return Manifest{
+			Module:  ModuleManifest{Name: name},
+			Authors: []AuthorsManifest{{Name: "foo"}},
+			Version: VersionManifest{Repo: "https://github.com/cosmos/cosmos-sdk", Version: "v1.0.0"},
+		}
+	}
+
+	// a public repository is fetched with the server's token
+	bank, err := r.moduleFromManifest(foo, manifest("bank"), make(sourceTrees))
+	require.NoError(t, err)
+	require.Equal(t, 1, appClient.fetches)
+	require.Equal(t, 0, userClient.fetches)
+
+	// and is refreshed in the background
+	now = now.Add(45 * time.Second)
+	cache.refresh()
+	require.Equal(t, 1, appClient.conditionals)
+
+	// a module of the same repository and version is published while the
+	// provider is unavailable
+	now = now.Add(45 * time.Second)
+	appClient.err = errors.New("service unavailable")
+	userClient.err = appClient.err
+
+	staking, err := r.moduleFromManifest(foo, manifest("staking"), make(sourceTrees))
+	require.NoError(t, err)
+	require.Equal(t, bank.Version.CommitSHA, staking.Version.CommitSHA)
+	require.Equal(t, bank.Version.SourceChecksum, staking.Version.SourceChecksum)
+	require.Equal(t, models.PublishAuthContributor, staking.Version.PublishAuthorization.String)
+
+	// but not once the repository is stale, as its contributors are unknown
+	now = now.Add(2 * time.Minute)
+
+	_, err = r.moduleFromManifest(foo, manifest("gov"), make(sourceTrees))
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "provider unavailable")
+
+	// a private repository is fetched with the publisher's token
+	now = now.Add(2 * time.Hour)
+	appClient.err = errors.New("not found")
+	userClient.err = nil
+
+	_, err = r.moduleFromManifest(foo, manifest("gov"), make(sourceTrees))
+	require.NoError(t, err)
+	require.Equal(t, 1, userClient.fetches)
+}
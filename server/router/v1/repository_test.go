@@ -0,0 +1,122 @@
+package v1
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+
+	"github.com/knadh/koanf"
+	"github.com/knadh/koanf/providers/confmap"
+	"github.com/stretchr/testify/require"
+
+	"github.com/cosmos/atlas/config"
+	"github.com/cosmos/atlas/server/models"
+)
+
+func TestDoArchiveRequest_TooLarge(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Length", strconv.Itoa(maxArchiveSize+1))
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	req, err := http.NewRequest(http.MethodGet, server.URL, nil)
+	require.NoError(t, err)
+
+	_, err = doArchiveRequest(http.DefaultClient, req)
+	require.ErrorIs(t, err, ErrArchiveTooLarge)
+}
+
+// countingGitHubClient implements a GitHubClientI which counts the fetched
+// repository archives.
+type countingGitHubClient struct {
+	testGitHubClient
+
+	tarballs int
+}
+
+func (cgc *countingGitHubClient) GetTarball(repo Repository, ref string) (io.ReadCloser, error) {
+	cgc.tarballs++
+	return cgc.testGitHubClient.GetTarball(repo, ref)
+}
+
+func TestSourceTrees_Checksum(t *testing.T) {
+	client := &countingGitHubClient{}
+	repo := Repository{Owner: "cosmos", Repo: "cosmos-sdk"}
+	repoURL := "https://github.com/cosmos/cosmos-sdk"
+	trees := make(sourceTrees)
+
+	sum, err := trees.checksum(client, repoURL, repo, "abc123", "")
+	require.NoError(t, err)
+
+	// the archive of a commit is only fetched once
+	cached, err := trees.checksum(client, repoURL, repo, "abc123", "")
+	require.NoError(t, err)
+	require.Equal(t, sum, cached)
+	require.Equal(t, 1, client.tarballs)
+
+	other, err := trees.checksum(client, repoURL, repo, "def456", "")
+	require.NoError(t, err)
+	require.NotEqual(t, sum, other)
+	require.Equal(t, 2, client.tarballs)
+
+	// a directory without files has no checksum
+	_, err = trees.checksum(client, repoURL, repo, "abc123", "x/bank")
+	require.Error(t, err)
+	require.Equal(t, 2, client.tarballs)
+}
+
+func TestModuleTeam(t *testing.T) {
+	require.Equal(t, "cosmos", moduleTeam("github.com", "cosmos"))
+	require.Equal(t, "cosmos", moduleTeam("www.github.com", "cosmos"))
+	require.Equal(t, "gitlab.com/cosmos/sdk", moduleTeam("gitlab.com", "cosmos/sdk"))
+	require.Equal(t, "gitea.example.com/cosmos", moduleTeam("gitea.example.com", "cosmos"))
+}
+
+func TestRouter_LinkedAccounts(t *testing.T) {
+	k := koanf.New(".")
+	require.NoError(t, k.Load(confmap.Provider(map[string]interface{}{
+		config.PublishAccounts: "gitlab.com/foo-gl=foo, gitlab.com/foo-old=Foo,gitea.example.com/bar=bar,invalid=foo,gitlab.com/=foo",
+	}, "."), nil))
+
+	r := &Router{cfg: k}
+
+	require.Equal(t, []string{"foo-gl", "foo-old"}, r.linkedAccounts(models.User{Name: "foo"}, "gitlab.com"))
+	require.Empty(t, r.linkedAccounts(models.User{Name: "foo"}, "gitea.example.com"))
+	require.Equal(t, []string{"bar"}, r.linkedAccounts(models.User{Name: "bar"}, "gitea.example.com"))
+	require.Empty(t, r.linkedAccounts(models.User{Name: "baz"}, "gitlab.com"))
+}
+
+func TestIsGitHubModule(t *testing.T) {
+	moduleVersion := func(id uint, repo string) models.ModuleVersion {
+		mv := models.ModuleVersion{Repo: repo}
+		mv.ID = id
+		return mv
+	}
+
+	require.False(t, isGitHubModule(models.Module{}))
+	require.True(t, isGitHubModule(models.Module{Versions: []models.ModuleVersion{
+		moduleVersion(1, "https://gitlab.com/cosmos/cosmos-sdk"),
+		moduleVersion(2, "https://github.com/cosmos/cosmos-sdk"),
+	}}))
+	require.False(t, isGitHubModule(models.Module{Versions: []models.ModuleVersion{
+		moduleVersion(2, "https://gitlab.com/cosmos/cosmos-sdk"),
+		moduleVersion(1, "https://github.com/cosmos/cosmos-sdk"),
+	}}))
+}
+
+func TestSanitizers(t *testing.T) {
+	r := &Router{sanitizer: newSanitizer(), mdSanitizer: newMarkdownSanitizer()}
+
+	// plain-text input is stripped of all markup
+	require.Equal(t, "a module", r.sanitizer.Sanitize(`<a href="https://example.com">a module</a><img src="x.png">`))
+
+	// rendered Markdown retains formatting and relative links and images
+	html := r.renderMarkdown("# Title\n\n[docs](docs/README.md) ![logo](img/logo.png)\n\n<script>alert(1)</script>")
+	require.Contains(t, html, "<h1>Title</h1>")
+	require.Contains(t, html, `<a href="docs/README.md" rel="nofollow">docs</a>`)
+	require.Contains(t, html, `<img src="img/logo.png" alt="logo"/>`)
+	require.NotContains(t, html, "<script>")
+}
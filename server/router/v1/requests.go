@@ -1,13 +1,19 @@
 package v1
 
+import "time"
+
 // User defines the request type when updating a user record.
 type User struct {
 	Email string `json:"email" validate:"required,email"`
 }
 
-// Token defines the request type when creating a new user API token.
+// Token defines the request type when creating a new user API token. If no
+// scopes are given, the token is granted models.DefaultTokenScopes. If no expiry
+// is given, the token does not expire.
 type Token struct {
-	Name string `json:"name" validate:"required"`
+	Name      string     `json:"name" validate:"required"`
+	Scopes    []string   `json:"scopes" validate:"omitempty,dive,token_scope"`
+	ExpiresAt *time.Time `json:"expires_at"`
 }
 
 // ModuleInvite defines the request type when inviting a user as an owner to a
@@ -16,3 +22,47 @@ type ModuleInvite struct {
 	ModuleID uint   `json:"module_id" validate:"required,gte=1"`
 	User     string `json:"user" validate:"required"`
 }
+
+// ModuleVersionYank defines the request type when yanking a module version.
+type ModuleVersionYank struct {
+	Reason string `json:"reason" validate:"required"`
+}
+
+// Team defines the request type when creating a team.
+type Team struct {
+	Name string `json:"name" validate:"required"`
+}
+
+// TeamMember defines the request type when inviting a user to a team or updating
+// the role of an existing member.
+type TeamMember struct {
+	User string `json:"user" validate:"required"`
+	Role string `json:"role" validate:"required,oneof=admin publisher viewer"`
+}
+
+// ModuleOwnerTeam defines the request type when granting ownership of a module
+// to a team.
+type ModuleOwnerTeam struct {
+	Team string `json:"team" validate:"required"`
+}
+
+// ModuleTransfer defines the request type when transferring ownership of a
+// module to either a user or a team.
+type ModuleTransfer struct {
+	User string `json:"user" validate:"required_without=Team,excluded_with=Team"`
+	Team string `json:"team" validate:"required_without=User,excluded_with=User"`
+}
+
+// TrustPolicy defines the request type when registering a GitHub Actions
+// workflow, by its file name, of a repository, in the form of '<owner>/<repo>',
+// as trusted to publish a module.
+type TrustPolicy struct {
+	Repository string `json:"repository" validate:"required"`
+	Workflow   string `json:"workflow" validate:"required"`
+}
+
+// OIDCTokenExchange defines the request type when exchanging a signed OIDC
+// token, e.g. a GitHub Actions ID token, for a short-lived API token.
+type OIDCTokenExchange struct {
+	Token string `json:"token" validate:"required"`
+}
@@ -5,7 +5,9 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"net"
 	"net/http"
+	"path"
 	"strconv"
 	"strings"
 	"time"
@@ -21,16 +23,23 @@ import (
 	_ "github.com/lib/pq"
 	"github.com/microcosm-cc/bluemonday"
 	"github.com/rs/zerolog"
+	"github.com/russross/blackfriday/v2"
 	uuid "github.com/satori/go.uuid"
 	"golang.org/x/oauth2"
 	"gorm.io/gorm"
 
 	"github.com/cosmos/atlas/config"
+	"github.com/cosmos/atlas/server/checksum"
 	"github.com/cosmos/atlas/server/httputil"
 	"github.com/cosmos/atlas/server/middleware"
 	"github.com/cosmos/atlas/server/models"
+	"github.com/cosmos/atlas/server/semver"
 )
 
+// errInvalidDependency defines a sentinel error when a module dependency cannot
+// be resolved.
+var errInvalidDependency = errors.New("invalid dependency")
+
 const (
 	sessionName        = "atlas_session"
 	sessionGithubID    = "github_id"
@@ -62,7 +71,11 @@ type Router struct {
 	healthChecker   *health.Health
 	validate        *validator.Validate
 	sanitizer       Sanitizer
+	mdSanitizer     Sanitizer
 	ghClientCreator func(string) GitHubClientI
+	repoClients     []RepositoryClientI
+	repoCache       *RepositoryCache
+	oidcVerifier    *OIDCVerifier
 }
 
 func NewRouter(
@@ -73,9 +86,21 @@ func NewRouter(
 	sStore *sessions.CookieStore,
 	oauth2Cfg *oauth2.Config,
 	ghClientCreator func(string) GitHubClientI,
+	repoClients []RepositoryClientI,
+	repoCache *RepositoryCache,
+	oidcVerifier *OIDCVerifier,
 ) (*Router, error) {
+	var healthChecks []*health.Config
+	if repoCache != nil {
+		healthChecks = append(healthChecks, &health.Config{
+			Name:     "repository-cache",
+			Checker:  repoCache,
+			Interval: time.Duration(1) * time.Minute,
+		})
+	}
+
 	sqlDB, _ := db.DB()
-	healthChecker, err := httputil.CreateHealthChecker(sqlDB, true)
+	healthChecker, err := httputil.CreateHealthChecker(sqlDB, true, healthChecks...)
 	if err != nil {
 		return nil, err
 	}
@@ -88,12 +113,38 @@ func NewRouter(
 		sessionStore:    sStore,
 		oauth2Cfg:       oauth2Cfg,
 		healthChecker:   healthChecker,
-		validate:        validator.New(),
+		validate:        NewValidator(),
 		sanitizer:       newSanitizer(),
+		mdSanitizer:     newMarkdownSanitizer(),
 		ghClientCreator: ghClientCreator,
+		repoClients:     repoClients,
+		repoCache:       repoCache,
+		oidcVerifier:    oidcVerifier,
 	}, nil
 }
 
+// InviteTTL returns the configured duration after which a module owner
+// invitation, since it was last sent, expires. It defaults to
+// models.DefaultModuleOwnerInviteTTL.
+func InviteTTL(cfg config.Config) time.Duration {
+	if ttl := cfg.Duration(config.InviteTTL); ttl > 0 {
+		return ttl
+	}
+
+	return models.DefaultModuleOwnerInviteTTL
+}
+
+// TrustedPublishTokenTTL returns the configured duration after which an API
+// token issued in exchange for an OIDC token expires. It defaults to
+// DefaultTrustedPublishTokenTTL.
+func TrustedPublishTokenTTL(cfg config.Config) time.Duration {
+	if ttl := cfg.Duration(config.OIDCTokenTTL); ttl > 0 {
+		return ttl
+	}
+
+	return DefaultTrustedPublishTokenTTL
+}
+
 // Register registers all v1 HTTP handlers with the provided mux router and
 // prefix path. All registered HTTP handlers come bundled with the appropriate
 // middleware.
@@ -142,6 +193,31 @@ func (r *Router) Register(rtr *mux.Router, prefix string) {
 		mChain.ThenFunc(r.GetModuleVersions()),
 	).Methods(httputil.MethodGET)
 
+	v1Router.Handle(
+		"/modules/{id:[0-9]+}/versions/{version}",
+		mChain.ThenFunc(r.GetModuleVersion()),
+	).Methods(httputil.MethodGET)
+
+	v1Router.Handle(
+		"/modules/{id:[0-9]+}/versions/{version}/readme",
+		mChain.ThenFunc(r.GetModuleVersionReadme()),
+	).Methods(httputil.MethodGET)
+
+	v1Router.Handle(
+		"/modules/{id:[0-9]+}/versions/{version}/impact",
+		mChain.ThenFunc(r.GetModuleVersionImpact()),
+	).Methods(httputil.MethodGET)
+
+	v1Router.Handle(
+		"/modules/{id:[0-9]+}/dependencies",
+		mChain.ThenFunc(r.GetModuleDependencies()),
+	).Methods(httputil.MethodGET)
+
+	v1Router.Handle(
+		"/modules/{id:[0-9]+}/dependents",
+		mChain.ThenFunc(r.GetModuleDependents()),
+	).Methods(httputil.MethodGET)
+
 	v1Router.Handle(
 		"/modules/{id:[0-9]+}/authors",
 		mChain.ThenFunc(r.GetModuleAuthors()),
@@ -152,6 +228,16 @@ func (r *Router) Register(rtr *mux.Router, prefix string) {
 		mChain.ThenFunc(r.GetModuleKeywords()),
 	).Methods(httputil.MethodGET)
 
+	v1Router.Handle(
+		"/modules/{id:[0-9]+}/owners/events",
+		mChain.ThenFunc(r.GetModuleOwnerEvents()),
+	).Methods(httputil.MethodGET)
+
+	v1Router.Handle(
+		"/modules/{id:[0-9]+}/audit",
+		mChain.ThenFunc(r.GetModuleAuditEvents()),
+	).Methods(httputil.MethodGET)
+
 	v1Router.Handle(
 		"/users/{name}",
 		mChain.ThenFunc(r.GetUserByName()),
@@ -172,6 +258,11 @@ func (r *Router) Register(rtr *mux.Router, prefix string) {
 		mChain.ThenFunc(r.GetAllKeywords()),
 	).Queries(paginationParams...).Methods(httputil.MethodGET)
 
+	v1Router.Handle(
+		"/teams/{name}",
+		mChain.ThenFunc(r.GetTeamByName()),
+	).Methods(httputil.MethodGET)
+
 	v1Router.Handle(
 		"/nodes/search",
 		mChain.ThenFunc(r.SearchNodes()),
@@ -183,6 +274,21 @@ func (r *Router) Register(rtr *mux.Router, prefix string) {
 		mChain.ThenFunc(r.SearchNodes()),
 	).Queries(paginationParams...).Methods(httputil.MethodGET)
 
+	v1Router.Handle(
+		"/nodes/{id:[0-9]+}/uptime",
+		mChain.ThenFunc(r.GetNodeUptime()),
+	).Methods(httputil.MethodGET)
+
+	v1Router.Handle(
+		"/networks",
+		mChain.ThenFunc(r.GetNetworks()),
+	).Methods(httputil.MethodGET)
+
+	v1Router.Handle(
+		"/networks/{chain_id}",
+		mChain.ThenFunc(r.GetNetwork()),
+	).Methods(httputil.MethodGET)
+
 	// ====================
 	// authenticated routes
 	// ====================
@@ -192,6 +298,11 @@ func (r *Router) Register(rtr *mux.Router, prefix string) {
 		mChain.ThenFunc(r.UpsertModule()),
 	).Methods(httputil.MethodPUT)
 
+	v1Router.Handle(
+		"/modules/batch",
+		mChain.ThenFunc(r.UpsertModules()),
+	).Methods(httputil.MethodPUT)
+
 	v1Router.Handle(
 		"/modules/{id:[0-9]+}/star",
 		mChain.ThenFunc(r.StarModule()),
@@ -202,6 +313,71 @@ func (r *Router) Register(rtr *mux.Router, prefix string) {
 		mChain.ThenFunc(r.UnStarModule()),
 	).Methods(httputil.MethodPUT)
 
+	v1Router.Handle(
+		"/modules/{id:[0-9]+}/versions/{version}/yank",
+		mChain.ThenFunc(r.YankModuleVersion()),
+	).Methods(httputil.MethodPUT)
+
+	v1Router.Handle(
+		"/modules/{id:[0-9]+}/versions/{version}/unyank",
+		mChain.ThenFunc(r.UnYankModuleVersion()),
+	).Methods(httputil.MethodPUT)
+
+	v1Router.Handle(
+		"/modules/{id:[0-9]+}/teams",
+		mChain.ThenFunc(r.AddModuleOwnerTeam()),
+	).Methods(httputil.MethodPUT)
+
+	v1Router.Handle(
+		"/modules/{id:[0-9]+}/teams/{team}",
+		mChain.ThenFunc(r.RemoveModuleOwnerTeam()),
+	).Methods(httputil.MethodDELETE)
+
+	v1Router.Handle(
+		"/modules/{id:[0-9]+}/owners/{user}",
+		mChain.ThenFunc(r.RemoveModuleOwner()),
+	).Methods(httputil.MethodDELETE)
+
+	v1Router.Handle(
+		"/modules/{id:[0-9]+}/transfer",
+		mChain.ThenFunc(r.TransferModuleOwnership()),
+	).Methods(httputil.MethodPUT)
+
+	v1Router.Handle(
+		"/modules/{id:[0-9]+}/trust-policies",
+		mChain.ThenFunc(r.GetModuleTrustPolicies()),
+	).Methods(httputil.MethodGET)
+
+	v1Router.Handle(
+		"/modules/{id:[0-9]+}/trust-policies",
+		mChain.ThenFunc(r.AddModuleTrustPolicy()),
+	).Methods(httputil.MethodPUT)
+
+	v1Router.Handle(
+		"/modules/{id:[0-9]+}/trust-policies/{policyID:[0-9]+}",
+		mChain.ThenFunc(r.RemoveModuleTrustPolicy()),
+	).Methods(httputil.MethodDELETE)
+
+	v1Router.Handle(
+		"/teams",
+		mChain.ThenFunc(r.CreateTeam()),
+	).Methods(httputil.MethodPUT)
+
+	v1Router.Handle(
+		"/teams/{name}/members",
+		mChain.ThenFunc(r.UpdateTeamMember()),
+	).Methods(httputil.MethodPUT)
+
+	v1Router.Handle(
+		"/teams/{name}/invites",
+		mChain.ThenFunc(r.InviteTeamMember()),
+	).Methods(httputil.MethodPUT)
+
+	v1Router.Handle(
+		"/teams/{name}/members/{user}",
+		mChain.ThenFunc(r.RemoveTeamMember()),
+	).Methods(httputil.MethodDELETE)
+
 	v1Router.Handle(
 		"/me",
 		mChain.ThenFunc(r.GetUser()),
@@ -227,6 +403,46 @@ func (r *Router) Register(rtr *mux.Router, prefix string) {
 		mChain.ThenFunc(r.AcceptOwnerInvite()),
 	).Methods(httputil.MethodPUT)
 
+	v1Router.Handle(
+		"/me/invite/decline/{inviteToken}",
+		mChain.ThenFunc(r.DeclineOwnerInvite()),
+	).Methods(httputil.MethodPUT)
+
+	v1Router.Handle(
+		"/me/invites",
+		mChain.ThenFunc(r.GetReceivedOwnerInvites()),
+	).Methods(httputil.MethodGET)
+
+	v1Router.Handle(
+		"/me/team-invites/accept/{inviteToken}",
+		mChain.ThenFunc(r.AcceptTeamInvite()),
+	).Methods(httputil.MethodPUT)
+
+	v1Router.Handle(
+		"/me/team-invites/decline/{inviteToken}",
+		mChain.ThenFunc(r.DeclineTeamInvite()),
+	).Methods(httputil.MethodPUT)
+
+	v1Router.Handle(
+		"/me/invites/sent",
+		mChain.ThenFunc(r.GetSentOwnerInvites()),
+	).Methods(httputil.MethodGET)
+
+	v1Router.Handle(
+		"/me/audit",
+		mChain.ThenFunc(r.GetUserAuditEvents()),
+	).Methods(httputil.MethodGET)
+
+	v1Router.Handle(
+		"/modules/{id:[0-9]+}/invites/{user}/resend",
+		mChain.ThenFunc(r.ResendOwnerInvite()),
+	).Methods(httputil.MethodPUT)
+
+	v1Router.Handle(
+		"/modules/{id:[0-9]+}/invites/{user}",
+		mChain.ThenFunc(r.RevokeOwnerInvite()),
+	).Methods(httputil.MethodDELETE)
+
 	v1Router.Handle(
 		"/me/tokens",
 		mChain.ThenFunc(r.CreateUserToken()),
@@ -242,6 +458,16 @@ func (r *Router) Register(rtr *mux.Router, prefix string) {
 		mChain.ThenFunc(r.RevokeUserToken()),
 	).Methods(httputil.MethodDELETE)
 
+	v1Router.Handle(
+		"/me/tokens/{id:[0-9]+}/usage",
+		mChain.ThenFunc(r.GetUserTokenUsage()),
+	).Methods(httputil.MethodGET)
+
+	v1Router.Handle(
+		"/oidc/token",
+		mChain.ThenFunc(r.ExchangeOIDCToken()),
+	).Methods(httputil.MethodPUT)
+
 	// ==============
 	// session routes
 	// ==============
@@ -266,29 +492,37 @@ func (r *Router) Register(rtr *mux.Router, prefix string) {
 // The authorized user is considered to be the publisher. The publisher must be
 // an owner of the module and a contributor to the GitHub repository. If the
 // module does not exist, the publisher is considered to be the first and only
-// owner and subsequent owners may be invited by the publisher. An error is
-// returned if the request body is invalid, the user is not authorized or if any
-// database transaction fails.
+// owner and subsequent owners may be invited by the publisher. The response
+// includes the published version's dependency impact report, i.e. the dependent
+// modules whose version constraints are not satisfied by the published version.
+// An error is returned if the request body is invalid, the user is not authorized
+// or if any database transaction fails.
 //
 // @Summary Publish a Cosmos SDK module
 // @Tags modules
 // @Accept  json
 // @Produce  json
 // @Param manifest body Manifest true "module manifest"
-// @Success 200 {object} models.ModuleJSON
+// @Success 200 {object} models.ModulePublishJSON
 // @Failure 400 {object} httputil.ErrResponse
 // @Failure 401 {object} httputil.ErrResponse
+// @Failure 403 {object} httputil.ErrResponse
 // @Failure 500 {object} httputil.ErrResponse
 // @Security APIKeyAuth
 // @Router /modules [put]
 func (r *Router) UpsertModule() http.HandlerFunc {
 	return func(w http.ResponseWriter, req *http.Request) {
-		authUser, ok, err := r.authorize(req)
-		if err != nil || !ok {
+		authUser, token, err := r.authenticate(req)
+		if err != nil {
 			httputil.RespondWithError(w, http.StatusUnauthorized, err)
 			return
 		}
 
+		if token != nil && !token.CanPublish() {
+			httputil.RespondWithError(w, http.StatusForbidden, errors.New("API token is not granted a publish scope"))
+			return
+		}
+
 		var request Manifest
 		if err := json.NewDecoder(req.Body).Decode(&request); err != nil {
 			httputil.RespondWithError(w, http.StatusBadRequest, fmt.Errorf("failed to read request: %w", err))
@@ -300,75 +534,111 @@ func (r *Router) UpsertModule() http.HandlerFunc {
 			return
 		}
 
-		module := ModuleFromManifest(request, r.sanitizer)
-		ghClient := r.ghClientCreator(authUser.GithubAccessToken.String)
-
-		repo, err := ghClient.GetRepository(module.Version.Repo)
+		module, err := r.moduleFromManifest(authUser, request, make(sourceTrees))
 		if err != nil {
 			httputil.RespondWithError(w, http.StatusBadRequest, err)
 			return
 		}
 
-		// set the module's team as the GitHub repository owner
-		module.Team = repo.Owner
+		resp, code, err := r.publishModule(r.db, authUser, token, module, request)
+		if err != nil {
+			httputil.RespondWithError(w, code, err)
+			return
+		}
+
+		httputil.RespondWithJSON(w, http.StatusOK, resp)
+	}
+}
 
-		// set the module's version publisher
-		module.Version.PublishedBy = authUser.ID
+// UpsertModules implements a request handler to atomically publish a set of
+// Cosmos SDK modules, e.g. from a multi-module manifest of a monorepo. Each
+// module is published as in UpsertModule, in the order given, such that a module
+// may depend on a module published before it in the same request. If any module
+// fails to be published, none of the modules are published.
+//
+// @Summary Atomically publish a set of Cosmos SDK modules
+// @Tags modules
+// @Accept  json
+// @Produce  json
+// @Param manifests body BatchManifest true "module manifests"
+// @Success 200 {array} models.ModulePublishJSON
+// @Failure 400 {object} httputil.ErrResponse
+// @Failure 401 {object} httputil.ErrResponse
+// @Failure 403 {object} httputil.ErrResponse
+// @Failure 500 {object} httputil.ErrResponse
+// @Security APIKeyAuth
+// @Router /modules/batch [put]
+func (r *Router) UpsertModules() http.HandlerFunc {
+	return func(w http.ResponseWriter, req *http.Request) {
+		authUser, token, err := r.authenticate(req)
+		if err != nil {
+			httputil.RespondWithError(w, http.StatusUnauthorized, err)
+			return
+		}
 
-		// verify the publisher is a contributor to the repository
-		var isContributor bool
-		for user := range repo.Contributors {
-			if authUser.Name == user {
-				isContributor = true
-				break
-			}
+		if token != nil && !token.CanPublish() {
+			httputil.RespondWithError(w, http.StatusForbidden, errors.New("API token is not granted a publish scope"))
+			return
 		}
 
-		if !isContributor {
-			httputil.RespondWithError(w, http.StatusBadRequest, fmt.Errorf("publisher '%s' is not a contributor of this module", authUser.Name))
+		var request BatchManifest
+		if err := json.NewDecoder(req.Body).Decode(&request); err != nil {
+			httputil.RespondWithError(w, http.StatusBadRequest, fmt.Errorf("failed to read request: %w", err))
 			return
 		}
 
-		// set the avatar URL for each author
-		for i, author := range module.Authors {
-			contributor, ok := repo.Contributors[author.Name]
-			if ok {
-				author.AvatarURL = contributor.GetAvatarURL()
-				module.Authors[i] = author
-			}
+		if err := r.validate.Struct(request); err != nil {
+			httputil.RespondWithError(w, http.StatusBadRequest, fmt.Errorf("invalid request: %w", httputil.TransformValidationError(err)))
+			return
 		}
 
-		// The publisher must already be an existing owner or must have accepted an
-		// invitation by an existing owner.
-		record, err := models.QueryModule(r.db, map[string]interface{}{"name": module.Name, "team": module.Team})
-		if err == nil {
-			// the module already exists so we check if the publisher is an owner
-			var isOwner bool
-			for i := 0; i < len(record.Owners) && !isOwner; i++ {
-				if record.Owners[i].ID == authUser.ID {
-					isOwner = true
-				}
+		// verify all the modules prior to publishing any of them, where the source
+		// trees are shared such that a repository is fetched once per commit
+		modules := make([]models.Module, len(request.Manifests))
+		seen := make(map[string]bool, len(request.Manifests))
+		trees := make(sourceTrees)
+
+		for i, manifest := range request.Manifests {
+			module, err := r.moduleFromManifest(authUser, manifest, trees)
+			if err != nil {
+				httputil.RespondWithError(w, http.StatusBadRequest, fmt.Errorf("invalid module '%s': %w", manifest.Module.Name, err))
+				return
 			}
 
-			if !isOwner {
-				httputil.RespondWithError(w, http.StatusBadRequest, errors.New("publisher must be an owner of the module"))
+			ref := fmt.Sprintf("%s/%s", module.Team, module.Name)
+			if seen[ref] {
+				httputil.RespondWithError(w, http.StatusBadRequest, fmt.Errorf("duplicate module '%s'", ref))
 				return
 			}
 
-			module.Owners = record.Owners
-		} else {
-			// Otherwise, the module is new and we automatically assign the publisher
-			// as the first and only owner.
-			module.Owners = []models.User{authUser}
+			seen[ref] = true
+			modules[i] = module
 		}
 
-		module, err = module.Upsert(r.db)
+		var (
+			resp = make([]models.ModulePublishJSON, len(modules))
+			code = http.StatusInternalServerError
+		)
+
+		err = r.db.Transaction(func(tx *gorm.DB) error {
+			for i, module := range modules {
+				var err error
+
+				resp[i], code, err = r.publishModule(tx, authUser, token, module, request.Manifests[i])
+				if err != nil {
+					return fmt.Errorf("failed to publish module '%s/%s': %w", module.Team, module.Name, err)
+				}
+			}
+
+			// commit the tx
+			return nil
+		})
 		if err != nil {
-			httputil.RespondWithError(w, http.StatusInternalServerError, err)
+			httputil.RespondWithError(w, code, err)
 			return
 		}
 
-		httputil.RespondWithJSON(w, http.StatusOK, module)
+		httputil.RespondWithJSON(w, http.StatusOK, resp)
 	}
 }
 
@@ -422,6 +692,7 @@ func (r *Router) GetModuleByID() http.HandlerFunc {
 // @Param reverse query string false "pagination reverse"  default(false)
 // @Param order query string false "pagination order by"  default(id)
 // @Param q query string true "search criteria"
+// @Param sdk query string false "compatible Cosmos SDK version"
 // @Success 200 {object} httputil.PaginationResponse
 // @Failure 400 {object} httputil.ErrResponse
 // @Failure 500 {object} httputil.ErrResponse
@@ -434,9 +705,15 @@ func (r *Router) SearchModules() http.HandlerFunc {
 			return
 		}
 
+		sdkVersion, err := parseSDKQueryParam(req)
+		if err != nil {
+			httputil.RespondWithError(w, http.StatusBadRequest, err)
+			return
+		}
+
 		query := req.URL.Query().Get("q")
 
-		modules, paginator, err := models.SearchModules(r.db, query, pQuery)
+		modules, paginator, err := models.SearchModules(r.db, query, pQuery, sdkVersion)
 		if err != nil {
 			httputil.RespondWithError(w, http.StatusInternalServerError, err)
 			return
@@ -454,7 +731,8 @@ func (r *Router) SearchModules() http.HandlerFunc {
 }
 
 // GetAllModules implements a request handler returning a paginated set of
-// modules.
+// modules. If an SDK version is provided, only modules compatible with that SDK
+// version are returned.
 //
 // @Summary Return a paginated set of all Cosmos SDK modules
 // @Tags modules
@@ -464,6 +742,7 @@ func (r *Router) SearchModules() http.HandlerFunc {
 // @Param limit query int true "pagination limit"  default(100)
 // @Param reverse query string false "pagination reverse"  default(false)
 // @Param order query string false "pagination order by"  default(id)
+// @Param sdk query string false "compatible Cosmos SDK version"
 // @Success 200 {object} httputil.PaginationResponse
 // @Failure 400 {object} httputil.ErrResponse
 // @Failure 500 {object} httputil.ErrResponse
@@ -476,7 +755,13 @@ func (r *Router) GetAllModules() http.HandlerFunc {
 			return
 		}
 
-		modules, paginator, err := models.GetAllModules(r.db, pQuery)
+		sdkVersion, err := parseSDKQueryParam(req)
+		if err != nil {
+			httputil.RespondWithError(w, http.StatusBadRequest, err)
+			return
+		}
+
+		modules, paginator, err := models.GetAllModules(r.db, pQuery, sdkVersion)
 		if err != nil {
 			httputil.RespondWithError(w, http.StatusInternalServerError, err)
 			return
@@ -488,7 +773,8 @@ func (r *Router) GetAllModules() http.HandlerFunc {
 }
 
 // GetModuleVersions implements a request handler to retrieve a module's set of
-// versions by ID.
+// versions by ID. Versions are ordered by Semantic Versioning precedence from
+// highest to lowest.
 //
 // @Summary Get all versions for a Cosmos SDK module by ID
 // @Tags modules
@@ -526,20 +812,21 @@ func (r *Router) GetModuleVersions() http.HandlerFunc {
 	}
 }
 
-// GetModuleAuthors implements a request handler to retrieve a module's set of
-// authors by ID.
+// GetModuleVersion implements a request handler to retrieve a single version of
+// a module by ID and exact version. Yanked versions are returned as well.
 //
-// @Summary Get all authors for a Cosmos SDK module by ID
+// @Summary Get a single version of a Cosmos SDK module by ID and version
 // @Tags modules
 // @Accept  json
 // @Produce  json
 // @Param id path int true "module ID"
-// @Success 200 {array} models.UserJSON
+// @Param version path string true "module version"
+// @Success 200 {object} models.ModuleVersionJSON
 // @Failure 400 {object} httputil.ErrResponse
 // @Failure 404 {object} httputil.ErrResponse
 // @Failure 500 {object} httputil.ErrResponse
-// @Router /modules/{id}/authors [get]
-func (r *Router) GetModuleAuthors() http.HandlerFunc {
+// @Router /modules/{id}/versions/{version} [get]
+func (r *Router) GetModuleVersion() http.HandlerFunc {
 	return func(w http.ResponseWriter, req *http.Request) {
 		params := mux.Vars(req)
 		idStr := params["id"]
@@ -550,7 +837,7 @@ func (r *Router) GetModuleAuthors() http.HandlerFunc {
 			return
 		}
 
-		module, err := models.GetModuleByID(r.db, uint(id))
+		mv, err := models.QueryModuleVersion(r.db, map[string]interface{}{"module_id": id, "version": params["version"]})
 		if err != nil {
 			code := http.StatusInternalServerError
 			if errors.Is(err, gorm.ErrRecordNotFound) {
@@ -561,24 +848,26 @@ func (r *Router) GetModuleAuthors() http.HandlerFunc {
 			return
 		}
 
-		httputil.RespondWithJSON(w, http.StatusOK, module.Authors)
+		httputil.RespondWithJSON(w, http.StatusOK, mv)
 	}
 }
 
-// GetModuleKeywords implements a request handler to retrieve a module's set of
-// keywords by ID.
+// GetModuleVersionReadme implements a request handler to retrieve the README of
+// a module version by module ID and exact version. The README is rendered from
+// Markdown to sanitized HTML.
 //
-// @Summary Get all keywords for a Cosmos SDK module by ID
+// @Summary Get the rendered README of a Cosmos SDK module version
 // @Tags modules
 // @Accept  json
 // @Produce  json
 // @Param id path int true "module ID"
-// @Success 200 {array} models.KeywordJSON
+// @Param version path string true "module version"
+// @Success 200 {object} models.ModuleVersionReadmeJSON
 // @Failure 400 {object} httputil.ErrResponse
 // @Failure 404 {object} httputil.ErrResponse
 // @Failure 500 {object} httputil.ErrResponse
-// @Router /modules/{id}/keywords [get]
-func (r *Router) GetModuleKeywords() http.HandlerFunc {
+// @Router /modules/{id}/versions/{version}/readme [get]
+func (r *Router) GetModuleVersionReadme() http.HandlerFunc {
 	return func(w http.ResponseWriter, req *http.Request) {
 		params := mux.Vars(req)
 		idStr := params["id"]
@@ -589,7 +878,7 @@ func (r *Router) GetModuleKeywords() http.HandlerFunc {
 			return
 		}
 
-		module, err := models.GetModuleByID(r.db, uint(id))
+		mv, err := models.QueryModuleVersion(r.db, map[string]interface{}{"module_id": id, "version": params["version"]})
 		if err != nil {
 			code := http.StatusInternalServerError
 			if errors.Is(err, gorm.ErrRecordNotFound) {
@@ -600,26 +889,7 @@ func (r *Router) GetModuleKeywords() http.HandlerFunc {
 			return
 		}
 
-		httputil.RespondWithJSON(w, http.StatusOK, module.Keywords)
-	}
-}
-
-// GetUserByID implements a request handler to retrieve a user by name.
-//
-// @Summary Get a user by name
-// @Tags users
-// @Accept  json
-// @Produce  json
-// @Param name path string true "user name"
-// @Success 200 {object} models.UserJSON
-// @Failure 404 {object} httputil.ErrResponse
-// @Failure 500 {object} httputil.ErrResponse
-// @Router /users/{name} [get]
-func (r *Router) GetUserByName() http.HandlerFunc {
-	return func(w http.ResponseWriter, req *http.Request) {
-		params := mux.Vars(req)
-
-		user, err := models.QueryUser(r.db, map[string]interface{}{"name": params["name"]})
+		readme, err := models.GetModuleVersionReadme(r.db, mv.ID)
 		if err != nil {
 			code := http.StatusInternalServerError
 			if errors.Is(err, gorm.ErrRecordNotFound) {
@@ -630,62 +900,1229 @@ func (r *Router) GetUserByName() http.HandlerFunc {
 			return
 		}
 
-		httputil.RespondWithJSON(w, http.StatusOK, user)
+		httputil.RespondWithJSON(w, http.StatusOK, models.ModuleVersionReadmeJSON{
+			ModuleID: mv.ModuleID,
+			Version:  mv.Version,
+			HTML:     r.renderMarkdown(readme.Markdown),
+		})
 	}
 }
 
-// GetAllUsers implements a request handler returning a paginated set of
-// users.
+// GetModuleVersionImpact implements a request handler to retrieve the dependency
+// impact report of a module version by module ID and exact version. The report
+// contains the dependent modules whose version constraints were not satisfied by
+// the version when it was published.
 //
-// @Summary Return a paginated set of all users
-// @Tags users
+// @Summary Get the dependency impact report of a Cosmos SDK module version
+// @Tags modules
 // @Accept  json
 // @Produce  json
-// @Param page query int true "pagination page"  default(1)
-// @Param limit query int true "pagination limit"  default(100)
-// @Param reverse query string false "pagination reverse"  default(false)
-// @Param order query string false "pagination order by"  default(id)
-// @Success 200 {object} httputil.PaginationResponse
+// @Param id path int true "module ID"
+// @Param version path string true "module version"
+// @Success 200 {array} models.DependencyEdgeJSON
 // @Failure 400 {object} httputil.ErrResponse
+// @Failure 404 {object} httputil.ErrResponse
 // @Failure 500 {object} httputil.ErrResponse
-// @Router /users [get]
-func (r *Router) GetAllUsers() http.HandlerFunc {
+// @Router /modules/{id}/versions/{version}/impact [get]
+func (r *Router) GetModuleVersionImpact() http.HandlerFunc {
 	return func(w http.ResponseWriter, req *http.Request) {
-		pQuery, err := httputil.ParsePaginationQueryParams(req)
+		params := mux.Vars(req)
+		idStr := params["id"]
+
+		id, err := strconv.ParseUint(idStr, 10, 64)
 		if err != nil {
-			httputil.RespondWithError(w, http.StatusBadRequest, err)
+			httputil.RespondWithError(w, http.StatusBadRequest, fmt.Errorf("invalid module ID: %w", err))
 			return
 		}
 
-		users, paginator, err := models.GetAllUsers(r.db, pQuery)
+		mv, err := models.QueryModuleVersion(r.db, map[string]interface{}{"module_id": id, "version": params["version"]})
+		if err != nil {
+			code := http.StatusInternalServerError
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				code = http.StatusNotFound
+			}
+
+			httputil.RespondWithError(w, code, err)
+			return
+		}
+
+		impact, err := models.GetDependencyImpactReport(r.db, mv.ID)
 		if err != nil {
 			httputil.RespondWithError(w, http.StatusInternalServerError, err)
 			return
 		}
 
-		paginated := httputil.NewPaginationResponse(pQuery, paginator.PrevPage, paginator.NextPage, paginator.Total, users)
-		httputil.RespondWithJSON(w, http.StatusOK, paginated)
+		httputil.RespondWithJSON(w, http.StatusOK, impact)
 	}
 }
 
-// GetUserModules implements a request handler to retrieve a set of modules
-// authored by a given user by name.
+// GetModuleDependencies implements a request handler to retrieve the set of
+// dependencies declared by the latest version of a module by ID.
 //
-// @Summary Return a set of all Cosmos SDK modules published by a given user
-// @Tags users
+// @Summary Get the dependencies of the latest version of a Cosmos SDK module by ID
+// @Tags modules
 // @Accept  json
 // @Produce  json
-// @Param name path string true "user name"
+// @Param id path int true "module ID"
+// @Success 200 {array} models.DependencyEdgeJSON
+// @Failure 400 {object} httputil.ErrResponse
+// @Failure 404 {object} httputil.ErrResponse
+// @Failure 500 {object} httputil.ErrResponse
+// @Router /modules/{id}/dependencies [get]
+func (r *Router) GetModuleDependencies() http.HandlerFunc {
+	return r.getDependencyEdges(models.GetModuleDependencies)
+}
+
+// GetModuleDependents implements a request handler to retrieve the set of
+// modules whose latest version depends on a module by ID.
+//
+// @Summary Get the dependents of a Cosmos SDK module by ID
+// @Tags modules
+// @Accept  json
+// @Produce  json
+// @Param id path int true "module ID"
+// @Success 200 {array} models.DependencyEdgeJSON
+// @Failure 400 {object} httputil.ErrResponse
+// @Failure 404 {object} httputil.ErrResponse
+// @Failure 500 {object} httputil.ErrResponse
+// @Router /modules/{id}/dependents [get]
+func (r *Router) GetModuleDependents() http.HandlerFunc {
+	return r.getDependencyEdges(models.GetModuleDependents)
+}
+
+func (r *Router) getDependencyEdges(query func(*gorm.DB, uint) ([]models.DependencyEdge, error)) http.HandlerFunc {
+	return func(w http.ResponseWriter, req *http.Request) {
+		params := mux.Vars(req)
+		idStr := params["id"]
+
+		id, err := strconv.ParseUint(idStr, 10, 64)
+		if err != nil {
+			httputil.RespondWithError(w, http.StatusBadRequest, fmt.Errorf("invalid module ID: %w", err))
+			return
+		}
+
+		module, err := models.GetModuleByID(r.db, uint(id))
+		if err != nil {
+			code := http.StatusInternalServerError
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				code = http.StatusNotFound
+			}
+
+			httputil.RespondWithError(w, code, err)
+			return
+		}
+
+		edges, err := query(r.db, module.ID)
+		if err != nil {
+			httputil.RespondWithError(w, http.StatusInternalServerError, err)
+			return
+		}
+
+		httputil.RespondWithJSON(w, http.StatusOK, edges)
+	}
+}
+
+// GetModuleAuthors implements a request handler to retrieve a module's set of
+// authors by ID.
+//
+// @Summary Get all authors for a Cosmos SDK module by ID
+// @Tags modules
+// @Accept  json
+// @Produce  json
+// @Param id path int true "module ID"
+// @Success 200 {array} models.UserJSON
+// @Failure 400 {object} httputil.ErrResponse
+// @Failure 404 {object} httputil.ErrResponse
+// @Failure 500 {object} httputil.ErrResponse
+// @Router /modules/{id}/authors [get]
+func (r *Router) GetModuleAuthors() http.HandlerFunc {
+	return func(w http.ResponseWriter, req *http.Request) {
+		params := mux.Vars(req)
+		idStr := params["id"]
+
+		id, err := strconv.ParseUint(idStr, 10, 64)
+		if err != nil {
+			httputil.RespondWithError(w, http.StatusBadRequest, fmt.Errorf("invalid module ID: %w", err))
+			return
+		}
+
+		module, err := models.GetModuleByID(r.db, uint(id))
+		if err != nil {
+			code := http.StatusInternalServerError
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				code = http.StatusNotFound
+			}
+
+			httputil.RespondWithError(w, code, err)
+			return
+		}
+
+		httputil.RespondWithJSON(w, http.StatusOK, module.Authors)
+	}
+}
+
+// GetModuleKeywords implements a request handler to retrieve a module's set of
+// keywords by ID.
+//
+// @Summary Get all keywords for a Cosmos SDK module by ID
+// @Tags modules
+// @Accept  json
+// @Produce  json
+// @Param id path int true "module ID"
+// @Success 200 {array} models.KeywordJSON
+// @Failure 400 {object} httputil.ErrResponse
+// @Failure 404 {object} httputil.ErrResponse
+// @Failure 500 {object} httputil.ErrResponse
+// @Router /modules/{id}/keywords [get]
+func (r *Router) GetModuleKeywords() http.HandlerFunc {
+	return func(w http.ResponseWriter, req *http.Request) {
+		params := mux.Vars(req)
+		idStr := params["id"]
+
+		id, err := strconv.ParseUint(idStr, 10, 64)
+		if err != nil {
+			httputil.RespondWithError(w, http.StatusBadRequest, fmt.Errorf("invalid module ID: %w", err))
+			return
+		}
+
+		module, err := models.GetModuleByID(r.db, uint(id))
+		if err != nil {
+			code := http.StatusInternalServerError
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				code = http.StatusNotFound
+			}
+
+			httputil.RespondWithError(w, code, err)
+			return
+		}
+
+		httputil.RespondWithJSON(w, http.StatusOK, module.Keywords)
+	}
+}
+
+// GetUserByID implements a request handler to retrieve a user by name.
+//
+// @Summary Get a user by name
+// @Tags users
+// @Accept  json
+// @Produce  json
+// @Param name path string true "user name"
+// @Success 200 {object} models.UserJSON
+// @Failure 404 {object} httputil.ErrResponse
+// @Failure 500 {object} httputil.ErrResponse
+// @Router /users/{name} [get]
+func (r *Router) GetUserByName() http.HandlerFunc {
+	return func(w http.ResponseWriter, req *http.Request) {
+		params := mux.Vars(req)
+
+		user, err := models.QueryUser(r.db, map[string]interface{}{"name": params["name"]})
+		if err != nil {
+			code := http.StatusInternalServerError
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				code = http.StatusNotFound
+			}
+
+			httputil.RespondWithError(w, code, err)
+			return
+		}
+
+		httputil.RespondWithJSON(w, http.StatusOK, user)
+	}
+}
+
+// GetAllUsers implements a request handler returning a paginated set of
+// users.
+//
+// @Summary Return a paginated set of all users
+// @Tags users
+// @Accept  json
+// @Produce  json
+// @Param page query int true "pagination page"  default(1)
+// @Param limit query int true "pagination limit"  default(100)
+// @Param reverse query string false "pagination reverse"  default(false)
+// @Param order query string false "pagination order by"  default(id)
+// @Success 200 {object} httputil.PaginationResponse
+// @Failure 400 {object} httputil.ErrResponse
+// @Failure 500 {object} httputil.ErrResponse
+// @Router /users [get]
+func (r *Router) GetAllUsers() http.HandlerFunc {
+	return func(w http.ResponseWriter, req *http.Request) {
+		pQuery, err := httputil.ParsePaginationQueryParams(req)
+		if err != nil {
+			httputil.RespondWithError(w, http.StatusBadRequest, err)
+			return
+		}
+
+		users, paginator, err := models.GetAllUsers(r.db, pQuery)
+		if err != nil {
+			httputil.RespondWithError(w, http.StatusInternalServerError, err)
+			return
+		}
+
+		paginated := httputil.NewPaginationResponse(pQuery, paginator.PrevPage, paginator.NextPage, paginator.Total, users)
+		httputil.RespondWithJSON(w, http.StatusOK, paginated)
+	}
+}
+
+// GetUserModules implements a request handler to retrieve a set of modules
+// authored by a given user by name.
+//
+// @Summary Return a set of all Cosmos SDK modules published by a given user
+// @Tags users
+// @Accept  json
+// @Produce  json
+// @Param name path string true "user name"
 // @Success 200 {array} models.ModuleJSON
 // @Failure 404 {object} httputil.ErrResponse
 // @Failure 500 {object} httputil.ErrResponse
-// @Router /users/{name}/modules [get]
-func (r *Router) GetUserModules() http.HandlerFunc {
+// @Router /users/{name}/modules [get]
+func (r *Router) GetUserModules() http.HandlerFunc {
+	return func(w http.ResponseWriter, req *http.Request) {
+		params := mux.Vars(req)
+
+		modules, err := models.GetUserModules(r.db, params["name"])
+		if err != nil {
+			code := http.StatusInternalServerError
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				code = http.StatusNotFound
+			}
+
+			httputil.RespondWithError(w, code, err)
+			return
+		}
+
+		httputil.RespondWithJSON(w, http.StatusOK, modules)
+	}
+}
+
+// GetTeamByName implements a request handler to retrieve a team by name along
+// with its members and the modules that belong to it.
+//
+// @Summary Get a team by name along with its members and modules
+// @Tags teams
+// @Accept  json
+// @Produce  json
+// @Param name path string true "team name"
+// @Success 200 {object} models.TeamModulesJSON
+// @Failure 404 {object} httputil.ErrResponse
+// @Failure 500 {object} httputil.ErrResponse
+// @Router /teams/{name} [get]
+func (r *Router) GetTeamByName() http.HandlerFunc {
+	return func(w http.ResponseWriter, req *http.Request) {
+		team, ok := r.getTeam(w, mux.Vars(req)["name"])
+		if !ok {
+			return
+		}
+
+		modules, err := team.GetModules(r.db)
+		if err != nil {
+			httputil.RespondWithError(w, http.StatusInternalServerError, err)
+			return
+		}
+
+		httputil.RespondWithJSON(w, http.StatusOK, team.NewTeamModulesJSON(modules))
+	}
+}
+
+// CreateTeam implements a request handler to create a team, where the
+// authenticated user becomes the team's first admin. If modules whose repository
+// owner is the team's name already exist, the user must be an owner of at least
+// one of them. Note, modules only belong to a team once their ownership has been
+// granted to it, regardless of the team's name.
+//
+// @Summary Create a team
+// @Tags teams
+// @Accept  json
+// @Produce  json
+// @Param team body Team true "team"
+// @Success 200 {object} models.TeamJSON
+// @Failure 400 {object} httputil.ErrResponse
+// @Failure 401 {object} httputil.ErrResponse
+// @Failure 403 {object} httputil.ErrResponse
+// @Failure 500 {object} httputil.ErrResponse
+// @Security APIKeyAuth
+// @Router /teams [put]
+func (r *Router) CreateTeam() http.HandlerFunc {
+	return func(w http.ResponseWriter, req *http.Request) {
+		authUser, ok, err := r.authorize(req, models.TokenScopeTeams)
+		if err != nil || !ok {
+			httputil.RespondWithError(w, http.StatusUnauthorized, err)
+			return
+		}
+
+		var requestBody Team
+		if err := json.NewDecoder(req.Body).Decode(&requestBody); err != nil {
+			httputil.RespondWithError(w, http.StatusBadRequest, fmt.Errorf("failed to read request: %w", err))
+			return
+		}
+
+		if err := r.validate.Struct(requestBody); err != nil {
+			httputil.RespondWithError(w, http.StatusBadRequest, fmt.Errorf("invalid request: %w", httputil.TransformValidationError(err)))
+			return
+		}
+
+		// ensure the user owns a module of an existing module team, if any
+		modules, err := models.GetModulesByTeamName(r.db, requestBody.Name)
+		if err != nil {
+			httputil.RespondWithError(w, http.StatusInternalServerError, err)
+			return
+		}
+
+		if len(modules) > 0 {
+			isOwner := false
+			for _, m := range modules {
+				if m.IsOwner(authUser.ID) {
+					isOwner = true
+					break
+				}
+			}
+
+			if !isOwner {
+				httputil.RespondWithError(w, http.StatusForbidden, fmt.Errorf("'%s' is not an owner of any of the team's modules", authUser.Name))
+				return
+			}
+		}
+
+		team, err := models.CreateTeam(r.db, requestBody.Name, authUser)
+		if err != nil {
+			httputil.RespondWithError(w, http.StatusBadRequest, err)
+			return
+		}
+
+		httputil.RespondWithJSON(w, http.StatusOK, team)
+	}
+}
+
+// UpdateTeamMember implements a request handler to update the role of an
+// existing member of a team. The authenticated user must be an admin of the team
+// and a team must always have at least one admin. Users are added to a team by
+// invitation only, see InviteTeamMember.
+//
+// @Summary Update a team member's role
+// @Tags teams
+// @Accept  json
+// @Produce  json
+// @Param name path string true "team name"
+// @Param member body TeamMember true "team member"
+// @Success 200 {object} models.TeamJSON
+// @Failure 400 {object} httputil.ErrResponse
+// @Failure 401 {object} httputil.ErrResponse
+// @Failure 403 {object} httputil.ErrResponse
+// @Failure 404 {object} httputil.ErrResponse
+// @Failure 500 {object} httputil.ErrResponse
+// @Security APIKeyAuth
+// @Router /teams/{name}/members [put]
+func (r *Router) UpdateTeamMember() http.HandlerFunc {
+	return func(w http.ResponseWriter, req *http.Request) {
+		authUser, ok, err := r.authorize(req, models.TokenScopeTeams)
+		if err != nil || !ok {
+			httputil.RespondWithError(w, http.StatusUnauthorized, err)
+			return
+		}
+
+		var requestBody TeamMember
+		if err := json.NewDecoder(req.Body).Decode(&requestBody); err != nil {
+			httputil.RespondWithError(w, http.StatusBadRequest, fmt.Errorf("failed to read request: %w", err))
+			return
+		}
+
+		if err := r.validate.Struct(requestBody); err != nil {
+			httputil.RespondWithError(w, http.StatusBadRequest, fmt.Errorf("invalid request: %w", httputil.TransformValidationError(err)))
+			return
+		}
+
+		team, ok := r.getTeam(w, mux.Vars(req)["name"])
+		if !ok {
+			return
+		}
+
+		if !team.IsAdmin(authUser.ID) {
+			httputil.RespondWithError(w, http.StatusForbidden, fmt.Errorf("'%s' is not an admin of the team", authUser.Name))
+			return
+		}
+
+		user, err := models.QueryUser(r.db, map[string]interface{}{"name": requestBody.User})
+		if err != nil {
+			code := http.StatusInternalServerError
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				code = http.StatusNotFound
+			}
+
+			httputil.RespondWithError(w, code, err)
+			return
+		}
+
+		if _, ok := team.MemberRole(user.ID); !ok {
+			httputil.RespondWithError(w, http.StatusNotFound, fmt.Errorf("'%s' is not a member of the team; users must be invited", requestBody.User))
+			return
+		}
+
+		team, err = team.UpsertMember(r.db, authUser, user, requestBody.Role)
+		if err != nil {
+			code := http.StatusInternalServerError
+			if errors.Is(err, models.ErrLastTeamAdmin) {
+				code = http.StatusBadRequest
+			}
+
+			httputil.RespondWithError(w, code, err)
+			return
+		}
+
+		httputil.RespondWithJSON(w, http.StatusOK, team)
+	}
+}
+
+// InviteTeamMember implements a request handler to invite a user to join a team
+// with a given role, which the user must accept to become a member. The
+// authenticated user must be an admin of the team and the invited user must have
+// a confirmed email address.
+//
+// @Summary Invite a user to join a team
+// @Tags teams
+// @Accept  json
+// @Produce  json
+// @Param name path string true "team name"
+// @Param member body TeamMember true "team member"
+// @Success 200 {object} boolean
+// @Failure 400 {object} httputil.ErrResponse
+// @Failure 401 {object} httputil.ErrResponse
+// @Failure 403 {object} httputil.ErrResponse
+// @Failure 404 {object} httputil.ErrResponse
+// @Failure 500 {object} httputil.ErrResponse
+// @Security APIKeyAuth
+// @Router /teams/{name}/invites [put]
+func (r *Router) InviteTeamMember() http.HandlerFunc {
+	return func(w http.ResponseWriter, req *http.Request) {
+		authUser, ok, err := r.authorize(req, models.TokenScopeTeams)
+		if err != nil || !ok {
+			httputil.RespondWithError(w, http.StatusUnauthorized, err)
+			return
+		}
+
+		var requestBody TeamMember
+		if err := json.NewDecoder(req.Body).Decode(&requestBody); err != nil {
+			httputil.RespondWithError(w, http.StatusBadRequest, fmt.Errorf("failed to read request: %w", err))
+			return
+		}
+
+		if err := r.validate.Struct(requestBody); err != nil {
+			httputil.RespondWithError(w, http.StatusBadRequest, fmt.Errorf("invalid request: %w", httputil.TransformValidationError(err)))
+			return
+		}
+
+		team, ok := r.getTeam(w, mux.Vars(req)["name"])
+		if !ok {
+			return
+		}
+
+		if !team.IsAdmin(authUser.ID) {
+			httputil.RespondWithError(w, http.StatusForbidden, fmt.Errorf("'%s' is not an admin of the team", authUser.Name))
+			return
+		}
+
+		invitee, err := models.QueryUser(r.db, map[string]interface{}{"name": requestBody.User})
+		if err != nil {
+			code := http.StatusInternalServerError
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				code = http.StatusNotFound
+			}
+
+			httputil.RespondWithError(w, code, err)
+			return
+		}
+
+		if _, ok := team.MemberRole(invitee.ID); ok {
+			httputil.RespondWithError(w, http.StatusBadRequest, fmt.Errorf("'%s' is already a member of the team", requestBody.User))
+			return
+		}
+
+		// ensure invitee has a verified email
+		if !invitee.EmailConfirmed {
+			httputil.RespondWithError(w, http.StatusBadRequest, fmt.Errorf("'%s' must confirm their email address", requestBody.User))
+			return
+		}
+
+		tmi, err := team.InviteMember(r.db, authUser, invitee, requestBody.Role)
+		if err != nil {
+			httputil.RespondWithError(w, http.StatusInternalServerError, err)
+			return
+		}
+
+		acceptURL := fmt.Sprintf("%s/teams/accept/%s", r.cfg.String(config.DomainName), tmi.Token)
+		if err := r.sendTeamInvitation(acceptURL, authUser.Name, invitee, team, tmi.Role); err != nil {
+			httputil.RespondWithError(w, http.StatusInternalServerError, err)
+			return
+		}
+
+		httputil.RespondWithJSON(w, http.StatusOK, true)
+	}
+}
+
+// AcceptTeamInvite implements a request handler for accepting a team member
+// invitation addressed to the authenticated user.
+//
+// @Summary Accept a team member invitation
+// @Tags teams
+// @Produce  json
+// @Param inviteToken path string true "invite token"
+// @Success 200 {object} models.TeamJSON
+// @Failure 400 {object} httputil.ErrResponse
+// @Failure 401 {object} httputil.ErrResponse
+// @Failure 404 {object} httputil.ErrResponse
+// @Failure 500 {object} httputil.ErrResponse
+// @Security APIKeyAuth
+// @Router /me/team-invites/accept/{inviteToken} [put]
+func (r *Router) AcceptTeamInvite() http.HandlerFunc {
+	return func(w http.ResponseWriter, req *http.Request) {
+		tmi, ok := r.getReceivedTeamInvite(w, req)
+		if !ok {
+			return
+		}
+
+		// prevent stale invites from being accepted
+		if tmi.Expired(InviteTTL(r.cfg)) {
+			httputil.RespondWithError(w, http.StatusBadRequest, errors.New("expired team member invitation"))
+			return
+		}
+
+		team, err := tmi.Accept(r.db)
+		if err != nil {
+			httputil.RespondWithError(w, http.StatusInternalServerError, err)
+			return
+		}
+
+		httputil.RespondWithJSON(w, http.StatusOK, team)
+	}
+}
+
+// DeclineTeamInvite implements a request handler for declining a team member
+// invitation addressed to the authenticated user.
+//
+// @Summary Decline a team member invitation
+// @Tags teams
+// @Produce  json
+// @Param inviteToken path string true "invite token"
+// @Success 200 {object} boolean
+// @Failure 401 {object} httputil.ErrResponse
+// @Failure 404 {object} httputil.ErrResponse
+// @Failure 500 {object} httputil.ErrResponse
+// @Security APIKeyAuth
+// @Router /me/team-invites/decline/{inviteToken} [put]
+func (r *Router) DeclineTeamInvite() http.HandlerFunc {
+	return func(w http.ResponseWriter, req *http.Request) {
+		tmi, ok := r.getReceivedTeamInvite(w, req)
+		if !ok {
+			return
+		}
+
+		if err := tmi.Decline(r.db); err != nil {
+			httputil.RespondWithError(w, http.StatusInternalServerError, err)
+			return
+		}
+
+		httputil.RespondWithJSON(w, http.StatusOK, true)
+	}
+}
+
+// getReceivedTeamInvite authorizes the request and returns the team member
+// invitation, referenced by the invite token in the request path, that is
+// addressed to the authorized user. Upon failure, an error response is written
+// and false is returned.
+func (r *Router) getReceivedTeamInvite(w http.ResponseWriter, req *http.Request) (models.TeamMemberInvite, bool) {
+	authUser, ok, err := r.authorize(req, models.TokenScopeTeams)
+	if err != nil || !ok {
+		httputil.RespondWithError(w, http.StatusUnauthorized, err)
+		return models.TeamMemberInvite{}, false
+	}
+
+	inviteToken := mux.Vars(req)["inviteToken"]
+	tmi, err := models.QueryTeamMemberInvite(r.db, map[string]interface{}{"invited_user_id": authUser.ID, "token": inviteToken})
+	if err != nil {
+		httputil.RespondWithError(w, http.StatusNotFound, err)
+		return models.TeamMemberInvite{}, false
+	}
+
+	return tmi, true
+}
+
+// RemoveTeamMember implements a request handler to remove a user from a team.
+// The authenticated user must be an admin of the team or the member being
+// removed and a team must always have at least one admin.
+//
+// @Summary Remove a team member
+// @Tags teams
+// @Produce  json
+// @Param name path string true "team name"
+// @Param user path string true "user name"
+// @Success 200 {object} models.TeamJSON
+// @Failure 400 {object} httputil.ErrResponse
+// @Failure 401 {object} httputil.ErrResponse
+// @Failure 403 {object} httputil.ErrResponse
+// @Failure 404 {object} httputil.ErrResponse
+// @Failure 500 {object} httputil.ErrResponse
+// @Security APIKeyAuth
+// @Router /teams/{name}/members/{user} [delete]
+func (r *Router) RemoveTeamMember() http.HandlerFunc {
+	return func(w http.ResponseWriter, req *http.Request) {
+		authUser, ok, err := r.authorize(req, models.TokenScopeTeams)
+		if err != nil || !ok {
+			httputil.RespondWithError(w, http.StatusUnauthorized, err)
+			return
+		}
+
+		params := mux.Vars(req)
+
+		team, ok := r.getTeam(w, params["name"])
+		if !ok {
+			return
+		}
+
+		var member *models.TeamMember
+		for i, m := range team.Members {
+			if m.User.Name == params["user"] {
+				member = &team.Members[i]
+				break
+			}
+		}
+
+		if member == nil {
+			httputil.RespondWithError(w, http.StatusNotFound, fmt.Errorf("'%s' is not a member of the team", params["user"]))
+			return
+		}
+
+		if member.UserID != authUser.ID && !team.IsAdmin(authUser.ID) {
+			httputil.RespondWithError(w, http.StatusForbidden, fmt.Errorf("'%s' is not an admin of the team", authUser.Name))
+			return
+		}
+
+		team, err = team.RemoveMember(r.db, authUser, member.UserID)
+		if err != nil {
+			code := http.StatusInternalServerError
+			if errors.Is(err, models.ErrLastTeamAdmin) {
+				code = http.StatusBadRequest
+			}
+
+			httputil.RespondWithError(w, code, err)
+			return
+		}
+
+		httputil.RespondWithJSON(w, http.StatusOK, team)
+	}
+}
+
+// AddModuleOwnerTeam implements a request handler to grant ownership of a module
+// to a team, such that the team's admins and publishers are owners of the module.
+// The authenticated user must be an owner of the module and an admin of the team.
+//
+// @Summary Grant ownership of a module to a team
+// @Tags modules
+// @Accept  json
+// @Produce  json
+// @Param id path int true "module ID"
+// @Param team body ModuleOwnerTeam true "team"
+// @Success 200 {object} models.ModuleJSON
+// @Failure 400 {object} httputil.ErrResponse
+// @Failure 401 {object} httputil.ErrResponse
+// @Failure 403 {object} httputil.ErrResponse
+// @Failure 404 {object} httputil.ErrResponse
+// @Failure 500 {object} httputil.ErrResponse
+// @Security APIKeyAuth
+// @Router /modules/{id}/teams [put]
+func (r *Router) AddModuleOwnerTeam() http.HandlerFunc {
+	return func(w http.ResponseWriter, req *http.Request) {
+		authUser, module, ok := r.authorizeModuleOwner(w, req, models.TokenScopeOwners)
+		if !ok {
+			return
+		}
+
+		var requestBody ModuleOwnerTeam
+		if err := json.NewDecoder(req.Body).Decode(&requestBody); err != nil {
+			httputil.RespondWithError(w, http.StatusBadRequest, fmt.Errorf("failed to read request: %w", err))
+			return
+		}
+
+		if err := r.validate.Struct(requestBody); err != nil {
+			httputil.RespondWithError(w, http.StatusBadRequest, fmt.Errorf("invalid request: %w", httputil.TransformValidationError(err)))
+			return
+		}
+
+		team, ok := r.getTeam(w, requestBody.Team)
+		if !ok {
+			return
+		}
+
+		if !team.IsAdmin(authUser.ID) {
+			httputil.RespondWithError(w, http.StatusForbidden, fmt.Errorf("'%s' is not an admin of the team", authUser.Name))
+			return
+		}
+
+		module, err := module.AddOwnerTeam(r.db, authUser, team)
+		if err != nil {
+			httputil.RespondWithError(w, http.StatusInternalServerError, err)
+			return
+		}
+
+		httputil.RespondWithJSON(w, http.StatusOK, module)
+	}
+}
+
+// RemoveModuleOwner implements a request handler to remove a user as an owner of
+// a module. The authenticated user must be an owner of the module, where owners
+// may also remove themselves. A module must always have at least one owning user
+// or team.
+//
+// @Summary Remove an owner of a module
+// @Tags modules
+// @Produce  json
+// @Param id path int true "module ID"
+// @Param user path string true "user name"
+// @Success 200 {object} models.ModuleJSON
+// @Failure 400 {object} httputil.ErrResponse
+// @Failure 401 {object} httputil.ErrResponse
+// @Failure 403 {object} httputil.ErrResponse
+// @Failure 404 {object} httputil.ErrResponse
+// @Failure 500 {object} httputil.ErrResponse
+// @Security APIKeyAuth
+// @Router /modules/{id}/owners/{user} [delete]
+func (r *Router) RemoveModuleOwner() http.HandlerFunc {
+	return func(w http.ResponseWriter, req *http.Request) {
+		authUser, module, ok := r.authorizeModuleOwner(w, req, models.TokenScopeOwners)
+		if !ok {
+			return
+		}
+
+		name := mux.Vars(req)["user"]
+
+		var owner *models.User
+		for i, o := range module.Owners {
+			if o.Name == name {
+				owner = &module.Owners[i]
+				break
+			}
+		}
+
+		if owner == nil {
+			httputil.RespondWithError(w, http.StatusNotFound, fmt.Errorf("'%s' is not an owner of the module", name))
+			return
+		}
+
+		module, err := module.RemoveOwner(r.db, authUser, *owner)
+		if err != nil {
+			code := http.StatusInternalServerError
+			if errors.Is(err, models.ErrLastModuleOwner) {
+				code = http.StatusBadRequest
+			}
+
+			httputil.RespondWithError(w, code, err)
+			return
+		}
+
+		httputil.RespondWithJSON(w, http.StatusOK, module)
+	}
+}
+
+// RemoveModuleOwnerTeam implements a request handler to revoke the ownership of a
+// module from a team. The authenticated user must be an owner of the module. A
+// module must always have at least one owning user or team.
+//
+// @Summary Revoke the ownership of a module from a team
+// @Tags modules
+// @Produce  json
+// @Param id path int true "module ID"
+// @Param team path string true "team name"
+// @Success 200 {object} models.ModuleJSON
+// @Failure 400 {object} httputil.ErrResponse
+// @Failure 401 {object} httputil.ErrResponse
+// @Failure 403 {object} httputil.ErrResponse
+// @Failure 404 {object} httputil.ErrResponse
+// @Failure 500 {object} httputil.ErrResponse
+// @Security APIKeyAuth
+// @Router /modules/{id}/teams/{team} [delete]
+func (r *Router) RemoveModuleOwnerTeam() http.HandlerFunc {
+	return func(w http.ResponseWriter, req *http.Request) {
+		authUser, module, ok := r.authorizeModuleOwner(w, req, models.TokenScopeOwners)
+		if !ok {
+			return
+		}
+
+		name := strings.ToLower(mux.Vars(req)["team"])
+
+		var team *models.Team
+		for i, t := range module.OwnerTeams {
+			if t.Name == name {
+				team = &module.OwnerTeams[i]
+				break
+			}
+		}
+
+		if team == nil {
+			httputil.RespondWithError(w, http.StatusNotFound, fmt.Errorf("team '%s' is not an owner of the module", name))
+			return
+		}
+
+		module, err := module.RemoveOwnerTeam(r.db, authUser, *team)
+		if err != nil {
+			code := http.StatusInternalServerError
+			if errors.Is(err, models.ErrLastModuleOwner) {
+				code = http.StatusBadRequest
+			}
+
+			httputil.RespondWithError(w, code, err)
+			return
+		}
+
+		httputil.RespondWithJSON(w, http.StatusOK, module)
+	}
+}
+
+// TransferModuleOwnership implements a request handler to transfer ownership of
+// a module to either a user or a team, which becomes the module's only owner.
+// The authenticated user must be an owner of the module. A user receiving
+// ownership must have a confirmed email address and, when transferring to a
+// team, the authenticated user must be an admin of the team.
+//
+// @Summary Transfer ownership of a module to a user or team
+// @Tags modules
+// @Accept  json
+// @Produce  json
+// @Param id path int true "module ID"
+// @Param transfer body ModuleTransfer true "new owner"
+// @Success 200 {object} models.ModuleJSON
+// @Failure 400 {object} httputil.ErrResponse
+// @Failure 401 {object} httputil.ErrResponse
+// @Failure 403 {object} httputil.ErrResponse
+// @Failure 404 {object} httputil.ErrResponse
+// @Failure 500 {object} httputil.ErrResponse
+// @Security APIKeyAuth
+// @Router /modules/{id}/transfer [put]
+func (r *Router) TransferModuleOwnership() http.HandlerFunc {
+	return func(w http.ResponseWriter, req *http.Request) {
+		authUser, module, ok := r.authorizeModuleOwner(w, req, models.TokenScopeOwners)
+		if !ok {
+			return
+		}
+
+		var requestBody ModuleTransfer
+		if err := json.NewDecoder(req.Body).Decode(&requestBody); err != nil {
+			httputil.RespondWithError(w, http.StatusBadRequest, fmt.Errorf("failed to read request: %w", err))
+			return
+		}
+
+		if err := r.validate.Struct(requestBody); err != nil {
+			httputil.RespondWithError(w, http.StatusBadRequest, fmt.Errorf("invalid request: %w", httputil.TransformValidationError(err)))
+			return
+		}
+
+		if requestBody.Team != "" {
+			team, ok := r.getTeam(w, requestBody.Team)
+			if !ok {
+				return
+			}
+
+			if !team.IsAdmin(authUser.ID) {
+				httputil.RespondWithError(w, http.StatusForbidden, fmt.Errorf("'%s' is not an admin of the team", authUser.Name))
+				return
+			}
+
+			module, err := module.TransferToTeam(r.db, authUser, team)
+			if err != nil {
+				httputil.RespondWithError(w, http.StatusInternalServerError, err)
+				return
+			}
+
+			httputil.RespondWithJSON(w, http.StatusOK, module)
+			return
+		}
+
+		owner, err := models.QueryUser(r.db, map[string]interface{}{"name": requestBody.User})
+		if err != nil {
+			code := http.StatusInternalServerError
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				code = http.StatusNotFound
+			}
+
+			httputil.RespondWithError(w, code, err)
+			return
+		}
+
+		// ensure the new owner has a verified email
+		if !owner.EmailConfirmed {
+			httputil.RespondWithError(w, http.StatusBadRequest, fmt.Errorf("'%s' must confirm their email address", requestBody.User))
+			return
+		}
+
+		module, err = module.TransferToUser(r.db, authUser, owner)
+		if err != nil {
+			httputil.RespondWithError(w, http.StatusInternalServerError, err)
+			return
+		}
+
+		httputil.RespondWithJSON(w, http.StatusOK, module)
+	}
+}
+
+// GetModuleOwnerEvents implements a request handler to retrieve the ownership
+// audit trail of a module, i.e. every owner and owner team added or removed and
+// every ownership transfer, ordered from oldest to newest.
+//
+// @Summary Get the ownership audit trail of a module
+// @Tags modules
+// @Produce  json
+// @Param id path int true "module ID"
+// @Success 200 {array} models.ModuleOwnerEventJSON
+// @Failure 400 {object} httputil.ErrResponse
+// @Failure 404 {object} httputil.ErrResponse
+// @Failure 500 {object} httputil.ErrResponse
+// @Router /modules/{id}/owners/events [get]
+func (r *Router) GetModuleOwnerEvents() http.HandlerFunc {
+	return func(w http.ResponseWriter, req *http.Request) {
+		id, err := strconv.ParseUint(mux.Vars(req)["id"], 10, 64)
+		if err != nil {
+			httputil.RespondWithError(w, http.StatusBadRequest, fmt.Errorf("invalid module ID: %w", err))
+			return
+		}
+
+		module, err := models.GetModuleByID(r.db, uint(id))
+		if err != nil {
+			code := http.StatusInternalServerError
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				code = http.StatusNotFound
+			}
+
+			httputil.RespondWithError(w, code, err)
+			return
+		}
+
+		events, err := module.GetOwnerEvents(r.db)
+		if err != nil {
+			httputil.RespondWithError(w, http.StatusInternalServerError, err)
+			return
+		}
+
+		httputil.RespondWithJSON(w, http.StatusOK, events)
+	}
+}
+
+// GetModuleAuditEvents implements a request handler returning a paginated set
+// of a module's audit events, i.e. every publish, owner invitation, ownership
+// change and star of the module. Only owners of the module may view its audit
+// events.
+//
+// @Summary Get the audit events of a module
+// @Tags modules
+// @Produce  json
+// @Param id path int true "module ID"
+// @Param page query int true "pagination page"  default(1)
+// @Param limit query int true "pagination limit"  default(100)
+// @Param reverse query string false "pagination reverse"  default(false)
+// @Param order query string false "pagination order by"  default(id)
+// @Success 200 {object} httputil.PaginationResponse
+// @Failure 400 {object} httputil.ErrResponse
+// @Failure 401 {object} httputil.ErrResponse
+// @Failure 403 {object} httputil.ErrResponse
+// @Failure 404 {object} httputil.ErrResponse
+// @Failure 500 {object} httputil.ErrResponse
+// @Security APIKeyAuth
+// @Router /modules/{id}/audit [get]
+func (r *Router) GetModuleAuditEvents() http.HandlerFunc {
+	return func(w http.ResponseWriter, req *http.Request) {
+		_, module, ok := r.authorizeModuleOwner(w, req, models.TokenScopeRead)
+		if !ok {
+			return
+		}
+
+		r.respondWithAuditEvents(w, req, map[string]interface{}{"module_id": module.ID})
+	}
+}
+
+// GetUserAuditEvents implements a request handler returning a paginated set of
+// the audit events performed by the authenticated user.
+//
+// @Summary Get the audit events performed by the authenticated user
+// @Tags users
+// @Produce  json
+// @Param page query int true "pagination page"  default(1)
+// @Param limit query int true "pagination limit"  default(100)
+// @Param reverse query string false "pagination reverse"  default(false)
+// @Param order query string false "pagination order by"  default(id)
+// @Success 200 {object} httputil.PaginationResponse
+// @Failure 400 {object} httputil.ErrResponse
+// @Failure 401 {object} httputil.ErrResponse
+// @Failure 500 {object} httputil.ErrResponse
+// @Security APIKeyAuth
+// @Router /me/audit [get]
+func (r *Router) GetUserAuditEvents() http.HandlerFunc {
+	return func(w http.ResponseWriter, req *http.Request) {
+		authUser, ok, err := r.authorize(req, models.TokenScopeRead)
+		if err != nil || !ok {
+			httputil.RespondWithError(w, http.StatusUnauthorized, err)
+			return
+		}
+
+		r.respondWithAuditEvents(w, req, map[string]interface{}{"actor_id": authUser.ID})
+	}
+}
+
+// respondWithAuditEvents writes a paginated set of the audit events matching a
+// query, as requested by the pagination query parameters, as the response.
+func (r *Router) respondWithAuditEvents(w http.ResponseWriter, req *http.Request, query map[string]interface{}) {
+	pQuery, err := httputil.ParsePaginationQueryParams(req)
+	if err != nil {
+		httputil.RespondWithError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	events, paginator, err := models.GetAuditEvents(r.db, query, pQuery)
+	if err != nil {
+		httputil.RespondWithError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	paginated := httputil.NewPaginationResponse(pQuery, paginator.PrevPage, paginator.NextPage, paginator.Total, events)
+	httputil.RespondWithJSON(w, http.StatusOK, paginated)
+}
+
+// GetModuleTrustPolicies implements a request handler returning the workflows
+// trusted to publish a module. Only owners of the module may view its trust
+// policies.
+//
+// @Summary Get the trust policies of a module
+// @Tags modules
+// @Produce  json
+// @Param id path int true "module ID"
+// @Success 200 {array} models.ModuleTrustPolicyJSON
+// @Failure 400 {object} httputil.ErrResponse
+// @Failure 401 {object} httputil.ErrResponse
+// @Failure 403 {object} httputil.ErrResponse
+// @Failure 404 {object} httputil.ErrResponse
+// @Failure 500 {object} httputil.ErrResponse
+// @Security APIKeyAuth
+// @Router /modules/{id}/trust-policies [get]
+func (r *Router) GetModuleTrustPolicies() http.HandlerFunc {
+	return func(w http.ResponseWriter, req *http.Request) {
+		_, module, ok := r.authorizeModuleOwner(w, req, models.TokenScopeRead)
+		if !ok {
+			return
+		}
+
+		policies, err := module.GetTrustPolicies(r.db)
+		if err != nil {
+			httputil.RespondWithError(w, http.StatusInternalServerError, err)
+			return
+		}
+
+		httputil.RespondWithJSON(w, http.StatusOK, policies)
+	}
+}
+
+// AddModuleTrustPolicy implements a request handler registering a GitHub
+// Actions workflow as trusted to publish a module on behalf of the
+// authenticated owner. The module must be hosted on GitHub, i.e. its most
+// recently published version's repository, and the workflow's repository must be
+// owned by the module's team. A workflow may only be trusted on behalf of a
+// single user.
+//
+// @Summary Register a workflow trusted to publish a module
+// @Tags modules
+// @Accept  json
+// @Produce  json
+// @Param id path int true "module ID"
+// @Param policy body TrustPolicy true "repository and workflow"
+// @Success 200 {object} models.ModuleTrustPolicyJSON
+// @Failure 400 {object} httputil.ErrResponse
+// @Failure 401 {object} httputil.ErrResponse
+// @Failure 403 {object} httputil.ErrResponse
+// @Failure 404 {object} httputil.ErrResponse
+// @Failure 409 {object} httputil.ErrResponse
+// @Failure 500 {object} httputil.ErrResponse
+// @Security APIKeyAuth
+// @Router /modules/{id}/trust-policies [put]
+func (r *Router) AddModuleTrustPolicy() http.HandlerFunc {
+	return func(w http.ResponseWriter, req *http.Request) {
+		authUser, module, ok := r.authorizeModuleOwner(w, req, models.TokenScopeOwners)
+		if !ok {
+			return
+		}
+
+		var request TrustPolicy
+		if err := json.NewDecoder(req.Body).Decode(&request); err != nil {
+			httputil.RespondWithError(w, http.StatusBadRequest, fmt.Errorf("failed to read request: %w", err))
+			return
+		}
+
+		if err := r.validate.Struct(request); err != nil {
+			httputil.RespondWithError(w, http.StatusBadRequest, fmt.Errorf("invalid request: %w", httputil.TransformValidationError(err)))
+			return
+		}
+
+		tokens := strings.Split(request.Repository, "/")
+		if len(tokens) != 2 || tokens[0] == "" || tokens[1] == "" {
+			httputil.RespondWithError(w, http.StatusBadRequest, fmt.Errorf("invalid repository '%s': expected '<owner>/<repo>'", request.Repository))
+			return
+		}
+
+		// the module's team is only a GitHub owner if the module is hosted on GitHub
+		if !isGitHubModule(module) {
+			httputil.RespondWithError(w, http.StatusBadRequest, errors.New("trusted publishing is only supported for modules hosted on GitHub"))
+			return
+		}
+
+		if !strings.EqualFold(tokens[0], module.Team) {
+			httputil.RespondWithError(w, http.StatusBadRequest, fmt.Errorf("repository must be owned by the module's team '%s'", module.Team))
+			return
+		}
+
+		if !isWorkflowFile(request.Workflow) {
+			httputil.RespondWithError(w, http.StatusBadRequest, fmt.Errorf("invalid workflow '%s': expected a workflow file name, e.g. 'release.yml'", request.Workflow))
+			return
+		}
+
+		policy, err := module.AddTrustPolicy(r.db, authUser, request.Repository, request.Workflow)
+		if err != nil {
+			code := http.StatusInternalServerError
+			if errors.Is(err, models.ErrTrustPolicyConflict) {
+				code = http.StatusConflict
+			}
+
+			httputil.RespondWithError(w, code, err)
+			return
+		}
+
+		httputil.RespondWithJSON(w, http.StatusOK, policy)
+	}
+}
+
+// RemoveModuleTrustPolicy implements a request handler removing a workflow
+// trusted to publish a module. Any owner of the module may remove its trust
+// policies.
+//
+// @Summary Remove a workflow trusted to publish a module
+// @Tags modules
+// @Produce  json
+// @Param id path int true "module ID"
+// @Param policyID path int true "trust policy ID"
+// @Success 200 {array} models.ModuleTrustPolicyJSON
+// @Failure 400 {object} httputil.ErrResponse
+// @Failure 401 {object} httputil.ErrResponse
+// @Failure 403 {object} httputil.ErrResponse
+// @Failure 404 {object} httputil.ErrResponse
+// @Failure 500 {object} httputil.ErrResponse
+// @Security APIKeyAuth
+// @Router /modules/{id}/trust-policies/{policyID} [delete]
+func (r *Router) RemoveModuleTrustPolicy() http.HandlerFunc {
 	return func(w http.ResponseWriter, req *http.Request) {
-		params := mux.Vars(req)
+		authUser, module, ok := r.authorizeModuleOwner(w, req, models.TokenScopeOwners)
+		if !ok {
+			return
+		}
 
-		modules, err := models.GetUserModules(r.db, params["name"])
+		policyID, err := strconv.ParseUint(mux.Vars(req)["policyID"], 10, 64)
 		if err != nil {
+			httputil.RespondWithError(w, http.StatusBadRequest, fmt.Errorf("invalid trust policy ID: %w", err))
+			return
+		}
+
+		if err := module.RemoveTrustPolicy(r.db, authUser, uint(policyID)); err != nil {
 			code := http.StatusInternalServerError
 			if errors.Is(err, gorm.ErrRecordNotFound) {
 				code = http.StatusNotFound
@@ -695,12 +2132,105 @@ func (r *Router) GetUserModules() http.HandlerFunc {
 			return
 		}
 
-		httputil.RespondWithJSON(w, http.StatusOK, modules)
+		policies, err := module.GetTrustPolicies(r.db)
+		if err != nil {
+			httputil.RespondWithError(w, http.StatusInternalServerError, err)
+			return
+		}
+
+		httputil.RespondWithJSON(w, http.StatusOK, policies)
+	}
+}
+
+// ExchangeOIDCToken implements a request handler exchanging a signed OIDC
+// token, e.g. a GitHub Actions ID token, for a short-lived API token. The OIDC
+// token is verified against the configured issuer's JWKS and audience, and the
+// workflow run it was issued to must be trusted to publish at least one module.
+// The API token belongs to the owner on whose behalf the workflow is trusted
+// and is only granted publishing the modules trusting the workflow.
+//
+// @Summary Exchange an OIDC token for a short-lived publish token
+// @Tags users
+// @Accept  json
+// @Produce  json
+// @Param token body OIDCTokenExchange true "OIDC token"
+// @Success 200 {object} models.UserTokenJSON
+// @Failure 400 {object} httputil.ErrResponse
+// @Failure 401 {object} httputil.ErrResponse
+// @Failure 403 {object} httputil.ErrResponse
+// @Failure 500 {object} httputil.ErrResponse
+// @Router /oidc/token [put]
+func (r *Router) ExchangeOIDCToken() http.HandlerFunc {
+	return func(w http.ResponseWriter, req *http.Request) {
+		if r.oidcVerifier == nil {
+			httputil.RespondWithError(w, http.StatusNotFound, errors.New("trusted publishing is not enabled"))
+			return
+		}
+
+		var request OIDCTokenExchange
+		if err := json.NewDecoder(req.Body).Decode(&request); err != nil {
+			httputil.RespondWithError(w, http.StatusBadRequest, fmt.Errorf("failed to read request: %w", err))
+			return
+		}
+
+		if err := r.validate.Struct(request); err != nil {
+			httputil.RespondWithError(w, http.StatusBadRequest, fmt.Errorf("invalid request: %w", httputil.TransformValidationError(err)))
+			return
+		}
+
+		claims, err := r.oidcVerifier.Verify(request.Token)
+		if err != nil {
+			code := http.StatusInternalServerError
+			if errors.Is(err, ErrInvalidOIDCToken) {
+				code = http.StatusUnauthorized
+			}
+
+			httputil.RespondWithError(w, code, err)
+			return
+		}
+
+		workflow, err := claims.Workflow()
+		if err != nil {
+			httputil.RespondWithError(w, http.StatusUnauthorized, err)
+			return
+		}
+
+		token, err := models.CreateTrustedPublishToken(r.db, claims.Repository, workflow, time.Now().Add(TrustedPublishTokenTTL(r.cfg)))
+		if err != nil {
+			code := http.StatusInternalServerError
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				code = http.StatusForbidden
+				err = fmt.Errorf("workflow '%s' of repository '%s' is not trusted to publish any module", workflow, claims.Repository)
+			}
+
+			httputil.RespondWithError(w, code, err)
+			return
+		}
+
+		httputil.RespondWithJSON(w, http.StatusOK, token)
+	}
+}
+
+// getTeam returns a team, along with its members, by name. If the team does not
+// exist or the query fails, the corresponding error response is written and
+// false is returned.
+func (r *Router) getTeam(w http.ResponseWriter, name string) (models.Team, bool) {
+	team, err := models.GetTeamByName(r.db, name)
+	if err != nil {
+		code := http.StatusInternalServerError
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			code = http.StatusNotFound
+		}
+
+		httputil.RespondWithError(w, code, err)
+		return models.Team{}, false
 	}
+
+	return team, true
 }
 
 // InviteOwner implements a request handler to invite a user to be an owner of a
-// module.
+// module. The authenticated user must be an owner of the module.
 //
 // @Summary Invite a user to be an owner of a module
 // @Tags users
@@ -710,13 +2240,14 @@ func (r *Router) GetUserModules() http.HandlerFunc {
 // @Success 200 {object} boolean
 // @Failure 400 {object} httputil.ErrResponse
 // @Failure 401 {object} httputil.ErrResponse
+// @Failure 403 {object} httputil.ErrResponse
 // @Failure 404 {object} httputil.ErrResponse
 // @Failure 500 {object} httputil.ErrResponse
 // @Security APIKeyAuth
 // @Router /me/invite [put]
 func (r *Router) InviteOwner() http.HandlerFunc {
 	return func(w http.ResponseWriter, req *http.Request) {
-		authUser, ok, err := r.authorize(req)
+		authUser, ok, err := r.authorize(req, models.TokenScopeInvite)
 		if err != nil || !ok {
 			httputil.RespondWithError(w, http.StatusUnauthorized, err)
 			return
@@ -744,6 +2275,11 @@ func (r *Router) InviteOwner() http.HandlerFunc {
 			return
 		}
 
+		if !module.IsOwner(authUser.ID) {
+			httputil.RespondWithError(w, http.StatusForbidden, fmt.Errorf("'%s' is not an owner of the module", authUser.Name))
+			return
+		}
+
 		// ensure invitee is not already an owner
 		for _, o := range module.Owners {
 			if o.Name == requestBody.User {
@@ -788,7 +2324,8 @@ func (r *Router) InviteOwner() http.HandlerFunc {
 }
 
 // AcceptOwnerInvite implements a request handler for accepting a module owner
-// invitation.
+// invitation. An invitation sent by a user who is no longer an owner of the
+// module can no longer be accepted.
 //
 // @Summary Accept a module owner invitation
 // @Tags users
@@ -798,12 +2335,13 @@ func (r *Router) InviteOwner() http.HandlerFunc {
 // @Failure 400 {object} httputil.ErrResponse
 // @Failure 401 {object} httputil.ErrResponse
 // @Failure 404 {object} httputil.ErrResponse
+// @Failure 410 {object} httputil.ErrResponse
 // @Failure 500 {object} httputil.ErrResponse
 // @Security APIKeyAuth
 // @Router /me/invite/accept/{inviteToken} [put]
 func (r *Router) AcceptOwnerInvite() http.HandlerFunc {
 	return func(w http.ResponseWriter, req *http.Request) {
-		authUser, ok, err := r.authorize(req)
+		authUser, ok, err := r.authorize(req, models.TokenScopeInvite)
 		if err != nil || !ok {
 			httputil.RespondWithError(w, http.StatusUnauthorized, err)
 			return
@@ -817,7 +2355,7 @@ func (r *Router) AcceptOwnerInvite() http.HandlerFunc {
 		}
 
 		// prevent stale invites from being accepted
-		if time.Since(moi.UpdatedAt) > 24*time.Hour {
+		if moi.Expired(InviteTTL(r.cfg)) {
 			httputil.RespondWithError(w, http.StatusBadRequest, errors.New("expired module owner invitation"))
 			return
 		}
@@ -829,37 +2367,292 @@ func (r *Router) AcceptOwnerInvite() http.HandlerFunc {
 				code = http.StatusNotFound
 			}
 
-			httputil.RespondWithError(w, code, err)
-			return
+			httputil.RespondWithError(w, code, err)
+			return
+		}
+
+		if !module.IsOwner(moi.InvitedByUserID) {
+			httputil.RespondWithError(w, http.StatusGone, errors.New("module owner invitation is no longer valid; the inviter is no longer an owner"))
+			return
+		}
+
+		module, err = module.AddOwner(r.db, authUser)
+		if err != nil {
+			httputil.RespondWithError(w, http.StatusInternalServerError, err)
+			return
+		}
+
+		httputil.RespondWithJSON(w, http.StatusOK, module)
+	}
+}
+
+// DeclineOwnerInvite implements a request handler for declining a module owner
+// invitation addressed to the authenticated user.
+//
+// @Summary Decline a module owner invitation
+// @Tags users
+// @Produce  json
+// @Param inviteToken path string true "invite token"
+// @Success 200 {object} boolean
+// @Failure 401 {object} httputil.ErrResponse
+// @Failure 404 {object} httputil.ErrResponse
+// @Failure 500 {object} httputil.ErrResponse
+// @Security APIKeyAuth
+// @Router /me/invite/decline/{inviteToken} [put]
+func (r *Router) DeclineOwnerInvite() http.HandlerFunc {
+	return func(w http.ResponseWriter, req *http.Request) {
+		authUser, ok, err := r.authorize(req, models.TokenScopeInvite)
+		if err != nil || !ok {
+			httputil.RespondWithError(w, http.StatusUnauthorized, err)
+			return
+		}
+
+		inviteToken := mux.Vars(req)["inviteToken"]
+		moi, err := models.QueryModuleOwnerInvite(r.db, map[string]interface{}{"invited_user_id": authUser.ID, "token": inviteToken})
+		if err != nil {
+			httputil.RespondWithError(w, http.StatusNotFound, err)
+			return
+		}
+
+		if err := moi.Decline(r.db); err != nil {
+			httputil.RespondWithError(w, http.StatusInternalServerError, err)
+			return
+		}
+
+		httputil.RespondWithJSON(w, http.StatusOK, true)
+	}
+}
+
+// GetReceivedOwnerInvites implements a request handler returning the pending,
+// i.e. unexpired, module owner invitations addressed to the authenticated user.
+//
+// @Summary Get all pending module owner invitations addressed to the authenticated user
+// @Tags users
+// @Produce  json
+// @Success 200 {array} models.ModuleOwnerInviteJSON
+// @Failure 401 {object} httputil.ErrResponse
+// @Failure 500 {object} httputil.ErrResponse
+// @Security APIKeyAuth
+// @Router /me/invites [get]
+func (r *Router) GetReceivedOwnerInvites() http.HandlerFunc {
+	return func(w http.ResponseWriter, req *http.Request) {
+		authUser, ok, err := r.authorize(req, models.TokenScopeRead)
+		if err != nil || !ok {
+			httputil.RespondWithError(w, http.StatusUnauthorized, err)
+			return
+		}
+
+		ttl := InviteTTL(r.cfg)
+
+		invites, err := models.GetPendingModuleOwnerInvites(r.db, map[string]interface{}{"invited_user_id": authUser.ID}, ttl)
+		if err != nil {
+			httputil.RespondWithError(w, http.StatusInternalServerError, err)
+			return
+		}
+
+		invitesJSON := make([]models.ModuleOwnerInviteJSON, len(invites))
+		for i, moi := range invites {
+			invitesJSON[i] = moi.NewModuleOwnerInviteJSON(ttl)
+		}
+
+		httputil.RespondWithJSON(w, http.StatusOK, invitesJSON)
+	}
+}
+
+// GetSentOwnerInvites implements a request handler returning the pending, i.e.
+// unexpired, module owner invitations sent by the authenticated user. The
+// invitations' tokens are omitted.
+//
+// @Summary Get all pending module owner invitations sent by the authenticated user
+// @Tags users
+// @Produce  json
+// @Success 200 {array} models.ModuleOwnerInviteJSON
+// @Failure 401 {object} httputil.ErrResponse
+// @Failure 500 {object} httputil.ErrResponse
+// @Security APIKeyAuth
+// @Router /me/invites/sent [get]
+func (r *Router) GetSentOwnerInvites() http.HandlerFunc {
+	return func(w http.ResponseWriter, req *http.Request) {
+		authUser, ok, err := r.authorize(req, models.TokenScopeRead)
+		if err != nil || !ok {
+			httputil.RespondWithError(w, http.StatusUnauthorized, err)
+			return
+		}
+
+		ttl := InviteTTL(r.cfg)
+
+		invites, err := models.GetPendingModuleOwnerInvites(r.db, map[string]interface{}{"invited_by_user_id": authUser.ID}, ttl)
+		if err != nil {
+			httputil.RespondWithError(w, http.StatusInternalServerError, err)
+			return
+		}
+
+		invitesJSON := make([]models.ModuleOwnerInviteJSON, len(invites))
+		for i, moi := range invites {
+			invitesJSON[i] = moi.NewModuleOwnerInviteJSON(ttl)
+			invitesJSON[i].Token = nil
+		}
+
+		httputil.RespondWithJSON(w, http.StatusOK, invitesJSON)
+	}
+}
+
+// ResendOwnerInvite implements a request handler to resend a module owner
+// invitation sent by the authenticated user, who must still be an owner of the
+// module. The invitation's token is regenerated and its expiration is reset.
+//
+// @Summary Resend a module owner invitation
+// @Tags modules
+// @Produce  json
+// @Param id path int true "module ID"
+// @Param user path string true "invited user name"
+// @Success 200 {object} boolean
+// @Failure 400 {object} httputil.ErrResponse
+// @Failure 401 {object} httputil.ErrResponse
+// @Failure 403 {object} httputil.ErrResponse
+// @Failure 404 {object} httputil.ErrResponse
+// @Failure 500 {object} httputil.ErrResponse
+// @Security APIKeyAuth
+// @Router /modules/{id}/invites/{user}/resend [put]
+func (r *Router) ResendOwnerInvite() http.HandlerFunc {
+	return func(w http.ResponseWriter, req *http.Request) {
+		authUser, moi, ok := r.authorizeOwnerInviter(w, req)
+		if !ok {
+			return
+		}
+
+		module, err := models.GetModuleByID(r.db, moi.ModuleID)
+		if err != nil {
+			httputil.RespondWithError(w, http.StatusInternalServerError, err)
+			return
+		}
+
+		if !module.IsOwner(authUser.ID) {
+			httputil.RespondWithError(w, http.StatusForbidden, fmt.Errorf("'%s' is not an owner of the module", authUser.Name))
+			return
+		}
+
+		moi, err = moi.Upsert(r.db)
+		if err != nil {
+			httputil.RespondWithError(w, http.StatusInternalServerError, err)
+			return
+		}
+
+		invitee, err := models.GetUserByID(r.db, moi.InvitedUserID)
+		if err != nil {
+			httputil.RespondWithError(w, http.StatusInternalServerError, err)
+			return
+		}
+
+		acceptURL := fmt.Sprintf("%s/accept/%s", r.cfg.String(config.DomainName), moi.Token)
+		if err := r.sendOwnerInvitation(acceptURL, authUser.Name, invitee, module); err != nil {
+			httputil.RespondWithError(w, http.StatusInternalServerError, err)
+			return
+		}
+
+		httputil.RespondWithJSON(w, http.StatusOK, true)
+	}
+}
+
+// RevokeOwnerInvite implements a request handler to revoke a module owner
+// invitation sent by the authenticated user.
+//
+// @Summary Revoke a module owner invitation
+// @Tags modules
+// @Produce  json
+// @Param id path int true "module ID"
+// @Param user path string true "invited user name"
+// @Success 200 {object} boolean
+// @Failure 400 {object} httputil.ErrResponse
+// @Failure 401 {object} httputil.ErrResponse
+// @Failure 404 {object} httputil.ErrResponse
+// @Failure 500 {object} httputil.ErrResponse
+// @Security APIKeyAuth
+// @Router /modules/{id}/invites/{user} [delete]
+func (r *Router) RevokeOwnerInvite() http.HandlerFunc {
+	return func(w http.ResponseWriter, req *http.Request) {
+		_, moi, ok := r.authorizeOwnerInviter(w, req)
+		if !ok {
+			return
+		}
+
+		if err := moi.Revoke(r.db); err != nil {
+			httputil.RespondWithError(w, http.StatusInternalServerError, err)
+			return
+		}
+
+		httputil.RespondWithJSON(w, http.StatusOK, true)
+	}
+}
+
+// authorizeOwnerInviter authorizes the request and returns the module owner
+// invitation, referenced by the module ID and invited user name in the request
+// path, that was sent by the authorized user. Upon failure, an error response
+// is written and false is returned.
+func (r *Router) authorizeOwnerInviter(w http.ResponseWriter, req *http.Request) (models.User, models.ModuleOwnerInvite, bool) {
+	authUser, ok, err := r.authorize(req, models.TokenScopeInvite)
+	if err != nil || !ok {
+		httputil.RespondWithError(w, http.StatusUnauthorized, err)
+		return models.User{}, models.ModuleOwnerInvite{}, false
+	}
+
+	params := mux.Vars(req)
+
+	id, err := strconv.ParseUint(params["id"], 10, 64)
+	if err != nil {
+		httputil.RespondWithError(w, http.StatusBadRequest, fmt.Errorf("invalid module ID: %w", err))
+		return models.User{}, models.ModuleOwnerInvite{}, false
+	}
+
+	invitee, err := models.QueryUser(r.db, map[string]interface{}{"name": params["user"]})
+	if err != nil {
+		code := http.StatusInternalServerError
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			code = http.StatusNotFound
 		}
 
-		module, err = module.AddOwner(r.db, authUser)
-		if err != nil {
-			httputil.RespondWithError(w, http.StatusInternalServerError, err)
-			return
+		httputil.RespondWithError(w, code, err)
+		return models.User{}, models.ModuleOwnerInvite{}, false
+	}
+
+	query := map[string]interface{}{"module_id": id, "invited_user_id": invitee.ID, "invited_by_user_id": authUser.ID}
+	moi, err := models.QueryModuleOwnerInvite(r.db, query)
+	if err != nil {
+		code := http.StatusInternalServerError
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			code = http.StatusNotFound
 		}
 
-		httputil.RespondWithJSON(w, http.StatusOK, module)
+		httputil.RespondWithError(w, code, err)
+		return models.User{}, models.ModuleOwnerInvite{}, false
 	}
+
+	return authUser, moi, true
 }
 
 // CreateUserToken implements a request handler that creates a new API token for
-// the authenticated user.
+// the authenticated user with the requested scopes and optional expiry.
 //
 // @Summary Create a user API token
 // @Tags users
 // @Produce  json
-// @Param token body Token true "token name"
+// @Param token body Token true "token name, scopes and expiry"
 // @Success 200 {object} models.UserTokenJSON
 // @Failure 400 {object} httputil.ErrResponse
 // @Failure 401 {object} httputil.ErrResponse
+// @Failure 403 {object} httputil.ErrResponse
 // @Failure 500 {object} httputil.ErrResponse
 // @Security APIKeyAuth
 // @Router /me/tokens [put]
 func (r *Router) CreateUserToken() http.HandlerFunc {
 	return func(w http.ResponseWriter, req *http.Request) {
-		authUser, ok, err := r.authorize(req)
-		if err != nil || !ok {
+		authUser, authToken, err := r.authenticate(req)
+		if err != nil {
+			httputil.RespondWithError(w, http.StatusUnauthorized, err)
+			return
+		}
+
+		if err := authorizeTokenScope(authToken, models.TokenScopeTokens); err != nil {
 			httputil.RespondWithError(w, http.StatusUnauthorized, err)
 			return
 		}
@@ -875,13 +2668,43 @@ func (r *Router) CreateUserToken() http.HandlerFunc {
 			return
 		}
 
+		var expiresAt sql.NullTime
+		if request.ExpiresAt != nil {
+			if !request.ExpiresAt.After(time.Now()) {
+				httputil.RespondWithError(w, http.StatusBadRequest, errors.New("token expiry must be in the future"))
+				return
+			}
+
+			expiresAt = models.NewNullTime(*request.ExpiresAt)
+		}
+
+		scopes := request.Scopes
+		if len(scopes) == 0 {
+			scopes = models.DefaultTokenScopes
+		}
+
+		// An API token may not create a token granted scopes it is not granted
+		// itself or a token that outlives it.
+		for _, s := range scopes {
+			if err := authorizeTokenScope(authToken, s); err != nil {
+				httputil.RespondWithError(w, http.StatusForbidden, err)
+				return
+			}
+		}
+
+		if authToken != nil && authToken.ExpiresAt.Valid &&
+			(!expiresAt.Valid || expiresAt.Time.After(authToken.ExpiresAt.Time)) {
+			httputil.RespondWithError(w, http.StatusForbidden, errors.New("token expiry must not exceed the expiry of the API token"))
+			return
+		}
+
 		numTokens := authUser.CountTokens(r.db)
 		if numTokens >= MaxTokens {
 			httputil.RespondWithError(w, http.StatusBadRequest, errors.New("maximum number of user API tokens reached"))
 			return
 		}
 
-		token, err := authUser.CreateToken(r.db, request.Name)
+		token, err := authUser.CreateToken(r.db, request.Name, scopes, expiresAt)
 		if err != nil {
 			httputil.RespondWithError(w, http.StatusInternalServerError, err)
 			return
@@ -904,7 +2727,7 @@ func (r *Router) CreateUserToken() http.HandlerFunc {
 // @Router /me/tokens [get]
 func (r *Router) GetUserTokens() http.HandlerFunc {
 	return func(w http.ResponseWriter, req *http.Request) {
-		authUser, ok, err := r.authorize(req)
+		authUser, ok, err := r.authorize(req, models.TokenScopeTokens)
 		if err != nil || !ok {
 			httputil.RespondWithError(w, http.StatusUnauthorized, err)
 			return
@@ -935,7 +2758,7 @@ func (r *Router) GetUserTokens() http.HandlerFunc {
 // @Router /me/tokens/{id} [delete]
 func (r *Router) RevokeUserToken() http.HandlerFunc {
 	return func(w http.ResponseWriter, req *http.Request) {
-		authUser, ok, err := r.authorize(req)
+		authUser, ok, err := r.authorize(req, models.TokenScopeTokens)
 		if err != nil || !ok {
 			httputil.RespondWithError(w, http.StatusUnauthorized, err)
 			return
@@ -971,6 +2794,67 @@ func (r *Router) RevokeUserToken() http.HandlerFunc {
 	}
 }
 
+// GetUserTokenUsage implements a request handler returning a paginated set of
+// the recorded uses of one of the authenticated user's tokens, i.e. the time,
+// client IP, user agent and endpoint of each authenticated request.
+//
+// @Summary Get the usage of a user API token by ID
+// @Tags users
+// @Produce  json
+// @Param id path int true "token ID"
+// @Param page query int true "pagination page"  default(1)
+// @Param limit query int true "pagination limit"  default(100)
+// @Param reverse query string false "pagination reverse"  default(false)
+// @Param order query string false "pagination order by"  default(id)
+// @Success 200 {object} httputil.PaginationResponse
+// @Failure 400 {object} httputil.ErrResponse
+// @Failure 401 {object} httputil.ErrResponse
+// @Failure 404 {object} httputil.ErrResponse
+// @Failure 500 {object} httputil.ErrResponse
+// @Security APIKeyAuth
+// @Router /me/tokens/{id}/usage [get]
+func (r *Router) GetUserTokenUsage() http.HandlerFunc {
+	return func(w http.ResponseWriter, req *http.Request) {
+		authUser, ok, err := r.authorize(req, models.TokenScopeTokens)
+		if err != nil || !ok {
+			httputil.RespondWithError(w, http.StatusUnauthorized, err)
+			return
+		}
+
+		id, err := strconv.ParseUint(mux.Vars(req)["id"], 10, 64)
+		if err != nil {
+			httputil.RespondWithError(w, http.StatusBadRequest, fmt.Errorf("invalid token ID: %w", err))
+			return
+		}
+
+		pQuery, err := httputil.ParsePaginationQueryParams(req)
+		if err != nil {
+			httputil.RespondWithError(w, http.StatusBadRequest, err)
+			return
+		}
+
+		token, err := models.QueryUserToken(r.db, map[string]interface{}{"id": id, "user_id": authUser.ID})
+		if err != nil {
+			code := http.StatusInternalServerError
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				code = http.StatusNotFound
+			}
+
+			httputil.RespondWithError(w, code, err)
+			return
+		}
+
+		usage, paginator, err := token.GetUsage(r.db, pQuery)
+		if err != nil {
+			httputil.RespondWithError(w, http.StatusInternalServerError, err)
+			return
+		}
+
+		paginated := httputil.NewPaginationResponse(pQuery, paginator.PrevPage, paginator.NextPage, paginator.Total, usage)
+		httputil.RespondWithJSON(w, http.StatusOK, paginated)
+	}
+}
+
 // StarModule implements a request handler for adding a favorite by a user to a
 // given module.
 //
@@ -987,7 +2871,7 @@ func (r *Router) RevokeUserToken() http.HandlerFunc {
 // @Router /modules/{id}/star [put]
 func (r *Router) StarModule() http.HandlerFunc {
 	return func(w http.ResponseWriter, req *http.Request) {
-		authUser, ok, err := r.authorize(req)
+		authUser, ok, err := r.authorize(req, models.TokenScopeUser)
 		if err != nil || !ok {
 			httputil.RespondWithError(w, http.StatusUnauthorized, err)
 			return
@@ -1039,7 +2923,7 @@ func (r *Router) StarModule() http.HandlerFunc {
 // @Router /modules/{id}/unstar [put]
 func (r *Router) UnStarModule() http.HandlerFunc {
 	return func(w http.ResponseWriter, req *http.Request) {
-		authUser, ok, err := r.authorize(req)
+		authUser, ok, err := r.authorize(req, models.TokenScopeUser)
 		if err != nil || !ok {
 			httputil.RespondWithError(w, http.StatusUnauthorized, err)
 			return
@@ -1075,6 +2959,158 @@ func (r *Router) UnStarModule() http.HandlerFunc {
 	}
 }
 
+// YankModuleVersion implements a request handler for yanking a module version.
+// A yanked version remains accessible by its exact version but is no longer
+// considered the latest version of the module. Only module owners may yank a
+// version.
+//
+// @Summary Yank a module version
+// @Tags modules
+// @Accept  json
+// @Produce  json
+// @Param id path int true "module ID"
+// @Param version path string true "module version"
+// @Param yank body ModuleVersionYank true "yank reason"
+// @Success 200 {object} models.ModuleVersionJSON
+// @Failure 400 {object} httputil.ErrResponse
+// @Failure 401 {object} httputil.ErrResponse
+// @Failure 403 {object} httputil.ErrResponse
+// @Failure 404 {object} httputil.ErrResponse
+// @Failure 500 {object} httputil.ErrResponse
+// @Security APIKeyAuth
+// @Router /modules/{id}/versions/{version}/yank [put]
+func (r *Router) YankModuleVersion() http.HandlerFunc {
+	return func(w http.ResponseWriter, req *http.Request) {
+		authUser, mv, ok := r.authorizeModuleVersionOwner(w, req)
+		if !ok {
+			return
+		}
+
+		var requestBody ModuleVersionYank
+		if err := json.NewDecoder(req.Body).Decode(&requestBody); err != nil {
+			httputil.RespondWithError(w, http.StatusBadRequest, fmt.Errorf("failed to read request: %w", err))
+			return
+		}
+
+		if err := r.validate.Struct(requestBody); err != nil {
+			httputil.RespondWithError(w, http.StatusBadRequest, fmt.Errorf("invalid request: %w", httputil.TransformValidationError(err)))
+			return
+		}
+
+		mv, err := mv.Yank(r.db, authUser.ID, r.sanitizer.Sanitize(requestBody.Reason))
+		if err != nil {
+			httputil.RespondWithError(w, http.StatusInternalServerError, err)
+			return
+		}
+
+		httputil.RespondWithJSON(w, http.StatusOK, mv)
+	}
+}
+
+// UnYankModuleVersion implements a request handler for reverting a yanked module
+// version. Only module owners may un-yank a version.
+//
+// @Summary Un-yank a module version
+// @Tags modules
+// @Produce  json
+// @Param id path int true "module ID"
+// @Param version path string true "module version"
+// @Success 200 {object} models.ModuleVersionJSON
+// @Failure 400 {object} httputil.ErrResponse
+// @Failure 401 {object} httputil.ErrResponse
+// @Failure 403 {object} httputil.ErrResponse
+// @Failure 404 {object} httputil.ErrResponse
+// @Failure 500 {object} httputil.ErrResponse
+// @Security APIKeyAuth
+// @Router /modules/{id}/versions/{version}/unyank [put]
+func (r *Router) UnYankModuleVersion() http.HandlerFunc {
+	return func(w http.ResponseWriter, req *http.Request) {
+		authUser, mv, ok := r.authorizeModuleVersionOwner(w, req)
+		if !ok {
+			return
+		}
+
+		mv, err := mv.UnYank(r.db, authUser.ID)
+		if err != nil {
+			httputil.RespondWithError(w, http.StatusInternalServerError, err)
+			return
+		}
+
+		httputil.RespondWithJSON(w, http.StatusOK, mv)
+	}
+}
+
+// authorizeModuleVersionOwner authorizes the request and ensures the authorized
+// user is an owner of the module referenced in the request path. It returns the
+// authorized user and the module version referenced in the request path. Upon
+// failure, an error response is written and false is returned.
+func (r *Router) authorizeModuleVersionOwner(w http.ResponseWriter, req *http.Request) (models.User, models.ModuleVersion, bool) {
+	authUser, module, ok := r.authorizeModuleOwner(w, req, models.TokenScopePublish)
+	if !ok {
+		return models.User{}, models.ModuleVersion{}, false
+	}
+
+	mv, err := models.QueryModuleVersion(r.db, map[string]interface{}{"module_id": module.ID, "version": mux.Vars(req)["version"]})
+	if err != nil {
+		code := http.StatusInternalServerError
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			code = http.StatusNotFound
+		}
+
+		httputil.RespondWithError(w, code, err)
+		return models.User{}, models.ModuleVersion{}, false
+	}
+
+	return authUser, mv, true
+}
+
+// authorizeModuleOwner authorizes the request for a given API token scope and
+// ensures the authorized user is an owner of the module referenced in the
+// request path. The publish scope is narrowed to publishing the referenced
+// module, such that a token granted publishing only that module is authorized.
+// It returns the authorized user and the module. Upon failure, an error response
+// is written and false is returned.
+func (r *Router) authorizeModuleOwner(w http.ResponseWriter, req *http.Request, scope string) (models.User, models.Module, bool) {
+	authUser, token, err := r.authenticate(req)
+	if err != nil {
+		httputil.RespondWithError(w, http.StatusUnauthorized, err)
+		return models.User{}, models.Module{}, false
+	}
+
+	id, err := strconv.ParseUint(mux.Vars(req)["id"], 10, 64)
+	if err != nil {
+		httputil.RespondWithError(w, http.StatusBadRequest, fmt.Errorf("invalid module ID: %w", err))
+		return models.User{}, models.Module{}, false
+	}
+
+	if scope == models.TokenScopePublish {
+		scope = models.PublishModuleScope(uint(id))
+	}
+
+	if err := authorizeTokenScope(token, scope); err != nil {
+		httputil.RespondWithError(w, http.StatusUnauthorized, err)
+		return models.User{}, models.Module{}, false
+	}
+
+	module, err := models.GetModuleByID(r.db, uint(id))
+	if err != nil {
+		code := http.StatusInternalServerError
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			code = http.StatusNotFound
+		}
+
+		httputil.RespondWithError(w, code, err)
+		return models.User{}, models.Module{}, false
+	}
+
+	if !module.IsOwner(authUser.ID) {
+		httputil.RespondWithError(w, http.StatusForbidden, fmt.Errorf("'%s' is not an owner of the module", authUser.Name))
+		return models.User{}, models.Module{}, false
+	}
+
+	return authUser, module, true
+}
+
 // GetUser returns the current authenticated user.
 //
 // @Summary Get the current authenticated user
@@ -1086,7 +3122,7 @@ func (r *Router) UnStarModule() http.HandlerFunc {
 // @Router /me [get]
 func (r *Router) GetUser() http.HandlerFunc {
 	return func(w http.ResponseWriter, req *http.Request) {
-		authUser, ok, err := r.authorize(req)
+		authUser, ok, err := r.authorize(req, models.TokenScopeRead)
 		if err != nil || !ok {
 			httputil.RespondWithError(w, http.StatusUnauthorized, err)
 			return
@@ -1110,7 +3146,7 @@ func (r *Router) GetUser() http.HandlerFunc {
 // @Router /me [put]
 func (r *Router) UpdateUser() http.HandlerFunc {
 	return func(w http.ResponseWriter, req *http.Request) {
-		authUser, ok, err := r.authorize(req)
+		authUser, ok, err := r.authorize(req, models.TokenScopeUser)
 		if err != nil || !ok {
 			httputil.RespondWithError(w, http.StatusUnauthorized, err)
 			return
@@ -1176,7 +3212,7 @@ func (r *Router) UpdateUser() http.HandlerFunc {
 // @Router /me/confirm/{emailToken} [put]
 func (r *Router) ConfirmEmail() http.HandlerFunc {
 	return func(w http.ResponseWriter, req *http.Request) {
-		authUser, ok, err := r.authorize(req)
+		authUser, ok, err := r.authorize(req, models.TokenScopeUser)
 		if err != nil || !ok {
 			httputil.RespondWithError(w, http.StatusUnauthorized, err)
 			return
@@ -1279,7 +3315,8 @@ func (r *Router) StartSession() http.Handler {
 }
 
 // SearchNodes implements a request handler to retrieve a set of nodes by search
-// criteria, which can be empty.
+// criteria, which can be empty. Only online nodes are returned unless the
+// 'online' query parameter is 'false', for offline nodes only, or 'all'.
 //
 // @Summary Search for Tendermint crawled nodes by network, moniker, version or location.
 // @Tags nodes
@@ -1290,6 +3327,7 @@ func (r *Router) StartSession() http.Handler {
 // @Param reverse query string false "pagination reverse"  default(false)
 // @Param order query string false "pagination order by"  default(id)
 // @Param q query string false "search criteria"
+// @Param online query string false "filter by online status (true|false|all)"  default(true)
 // @Success 200 {object} httputil.PaginationResponse
 // @Failure 400 {object} httputil.ErrResponse
 // @Failure 500 {object} httputil.ErrResponse
@@ -1302,22 +3340,131 @@ func (r *Router) SearchNodes() http.HandlerFunc {
 			return
 		}
 
+		online, err := parseOnlineQueryParam(req)
+		if err != nil {
+			httputil.RespondWithError(w, http.StatusBadRequest, err)
+			return
+		}
+
 		query := req.URL.Query().Get("q")
 
-		nodes, paginator, err := models.SearchNodes(r.db, query, pQuery)
-		if err != nil {
-			httputil.RespondWithError(w, http.StatusInternalServerError, err)
+		nodes, paginator, err := models.SearchNodes(r.db, query, pQuery, online)
+		if err != nil {
+			httputil.RespondWithError(w, http.StatusInternalServerError, err)
+			return
+		}
+
+		paginated := httputil.NewPaginationResponse(
+			pQuery,
+			paginator.PrevPage,
+			paginator.NextPage,
+			paginator.Total,
+			nodes,
+		)
+		httputil.RespondWithJSON(w, http.StatusOK, paginated)
+	}
+}
+
+// GetNodeUptime implements a request handler to retrieve the uptime of a crawled
+// node over a window of time, e.g. '7d' or '12h', which defaults to seven days.
+//
+// @Summary Get the availability and outage timeline of a Tendermint crawled node
+// @Tags nodes
+// @Accept  json
+// @Produce  json
+// @Param id path int true "node ID"
+// @Param window query string false "uptime window, e.g. 7d or 12h"  default(7d)
+// @Success 200 {object} models.NodeUptime
+// @Failure 400 {object} httputil.ErrResponse
+// @Failure 404 {object} httputil.ErrResponse
+// @Failure 500 {object} httputil.ErrResponse
+// @Router /nodes/{id}/uptime [get]
+func (r *Router) GetNodeUptime() http.HandlerFunc {
+	return func(w http.ResponseWriter, req *http.Request) {
+		params := mux.Vars(req)
+		idStr := params["id"]
+
+		id, err := strconv.ParseUint(idStr, 10, 64)
+		if err != nil {
+			httputil.RespondWithError(w, http.StatusBadRequest, fmt.Errorf("invalid node ID: %w", err))
+			return
+		}
+
+		window, err := parseUptimeWindow(req.URL.Query().Get("window"))
+		if err != nil {
+			httputil.RespondWithError(w, http.StatusBadRequest, err)
+			return
+		}
+
+		node, err := models.QueryNode(r.db, map[string]interface{}{"id": uint(id)})
+		if err != nil {
+			code := http.StatusInternalServerError
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				code = http.StatusNotFound
+			}
+
+			httputil.RespondWithError(w, code, err)
+			return
+		}
+
+		uptime, err := models.GetNodeUptime(r.db, node.ID, time.Now().Add(-window))
+		if err != nil {
+			httputil.RespondWithError(w, http.StatusInternalServerError, err)
+			return
+		}
+
+		httputil.RespondWithJSON(w, http.StatusOK, uptime)
+	}
+}
+
+// GetNetworks implements a request handler to retrieve the summary statistics of
+// all crawled networks.
+//
+// @Summary Get summary statistics of all Tendermint crawled networks
+// @Tags nodes
+// @Accept  json
+// @Produce  json
+// @Success 200 {array} models.Network
+// @Failure 500 {object} httputil.ErrResponse
+// @Router /networks [get]
+func (r *Router) GetNetworks() http.HandlerFunc {
+	return func(w http.ResponseWriter, req *http.Request) {
+		networks, err := models.GetNetworks(r.db)
+		if err != nil {
+			httputil.RespondWithError(w, http.StatusInternalServerError, err)
+			return
+		}
+
+		httputil.RespondWithJSON(w, http.StatusOK, networks)
+	}
+}
+
+// GetNetwork implements a request handler to retrieve the summary statistics of
+// a crawled network by chain-id.
+//
+// @Summary Get summary statistics of a Tendermint crawled network by chain-id
+// @Tags nodes
+// @Accept  json
+// @Produce  json
+// @Param chain_id path string true "network chain-id"
+// @Success 200 {object} models.Network
+// @Failure 404 {object} httputil.ErrResponse
+// @Failure 500 {object} httputil.ErrResponse
+// @Router /networks/{chain_id} [get]
+func (r *Router) GetNetwork() http.HandlerFunc {
+	return func(w http.ResponseWriter, req *http.Request) {
+		network, err := models.GetNetwork(r.db, mux.Vars(req)["chain_id"])
+		if err != nil {
+			code := http.StatusInternalServerError
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				code = http.StatusNotFound
+			}
+
+			httputil.RespondWithError(w, code, err)
 			return
 		}
 
-		paginated := httputil.NewPaginationResponse(
-			pQuery,
-			paginator.PrevPage,
-			paginator.NextPage,
-			paginator.Total,
-			nodes,
-		)
-		httputil.RespondWithJSON(w, http.StatusOK, paginated)
+		httputil.RespondWithJSON(w, http.StatusOK, network)
 	}
 }
 
@@ -1428,19 +3575,41 @@ func (r *Router) LogoutSession() http.HandlerFunc {
 	}
 }
 
-// authorize attempts to authorize the given request against the session cookie
-// store or a bearer authorization header. If the session cookie does not exist,
-// or the session has been deleted, or the supplied bearer authorization header
-// is invalid, we treat the request as unauthorized and return false. Otherwise,
-// we return the user record ID and true with no error to indicate successful
-// authorization.
-func (r *Router) authorize(req *http.Request) (models.User, bool, error) {
+// authorize attempts to authorize the given request for a given API token scope
+// via authenticate. A request authenticated by a session cookie is authorized
+// for every scope, whereas a request authenticated by an API token is only
+// authorized if the token is granted the scope. If the request is unauthorized,
+// we return false. Otherwise, we return the user record and true with no error
+// to indicate successful authorization.
+func (r *Router) authorize(req *http.Request, scope string) (models.User, bool, error) {
+	user, token, err := r.authenticate(req)
+	if err != nil {
+		return models.User{}, false, err
+	}
+
+	if err := authorizeTokenScope(token, scope); err != nil {
+		return models.User{}, false, err
+	}
+
+	return user, true, nil
+}
+
+// authenticate attempts to authenticate the given request against the session
+// cookie store or a bearer authorization header. If the session cookie does not
+// exist, or the session has been deleted, or the supplied bearer authorization
+// header is invalid, revoked or expired, we treat the request as unauthenticated
+// and return an error. Otherwise, we return the user record and, if the request
+// was authenticated by an API token, the token.
+func (r *Router) authenticate(req *http.Request) (models.User, *models.UserToken, error) {
 	session, err := r.sessionStore.Get(req, sessionName)
 	if err != nil {
-		return models.User{}, false, fmt.Errorf("failed to get session: %w", err)
+		return models.User{}, nil, fmt.Errorf("failed to get session: %w", err)
 	}
 
-	var userID uint
+	var (
+		userID uint
+		token  *models.UserToken
+	)
 
 	// check for a valid session cookie or bearer authorization header
 	if v, ok := session.Values[sessionUserID]; ok {
@@ -1450,35 +3619,621 @@ func (r *Router) authorize(req *http.Request) (models.User, bool, error) {
 
 		tokenUUID, err := uuid.FromString(tokenStr)
 		if err != nil {
-			return models.User{}, false, fmt.Errorf("failed to get parse token: %w", err)
+			return models.User{}, nil, fmt.Errorf("failed to get parse token: %w", err)
 		}
 
-		token, err := models.QueryUserToken(r.db, map[string]interface{}{"token": tokenUUID.String(), "revoked": false})
+		ut, err := models.AuthenticateUserToken(r.db, tokenUUID.String())
 		if err != nil {
-			return models.User{}, false, err
+			return models.User{}, nil, err
+		}
+
+		if ut.Expired() {
+			return models.User{}, nil, errors.New("expired API token")
 		}
 
-		token, err = token.IncrCount(r.db)
+		ut, err = ut.RecordUsage(r.db, models.UserTokenUsage{
+			IP:        clientIP(req),
+			UserAgent: req.UserAgent(),
+			Method:    req.Method,
+			Path:      req.URL.Path,
+		})
 		if err != nil {
-			return models.User{}, false, err
+			return models.User{}, nil, err
 		}
 
-		userID = token.UserID
+		userID = ut.UserID
+		token = &ut
 	} else {
-		return models.User{}, false, errors.New("unauthorized")
+		return models.User{}, nil, errors.New("unauthorized")
 	}
 
 	user, err := models.GetUserByID(r.db, userID)
 	if err != nil {
-		return models.User{}, false, err
+		return models.User{}, nil, err
 	}
 
-	return user, true, nil
+	return user, token, nil
+}
+
+// clientIP returns the IP address of the client of a request, i.e. the request's
+// remote address without its port.
+func clientIP(req *http.Request) string {
+	host, _, err := net.SplitHostPort(req.RemoteAddr)
+	if err != nil {
+		return req.RemoteAddr
+	}
+
+	return host
+}
+
+// authorizeTokenScope returns an error if a request was authenticated by an API
+// token which is not granted a given scope. Requests authenticated by a session
+// cookie, i.e. with a nil token, are granted every scope.
+func authorizeTokenScope(token *models.UserToken, scope string) error {
+	if token != nil && !token.HasScope(scope) {
+		return fmt.Errorf("API token is not granted the '%s' scope", scope)
+	}
+
+	return nil
+}
+
+// moduleFromManifest converts a validated manifest into a Module to be published
+// by the given publisher. The module's team is set to the owner of its
+// repository, on behalf of which the publisher must be authorized to publish,
+// where the repository may be hosted on any supported provider. The source trees
+// of the repository's commits are fetched through trees. An error is returned if
+// the repository cannot be fetched or if the publisher is not authorized.
+func (r *Router) moduleFromManifest(authUser models.User, manifest Manifest, trees sourceTrees) (models.Module, error) {
+	module := ModuleFromManifest(manifest, r.sanitizer)
+
+	ghClient, repo, err := r.fetchRepository(authUser, module.Version.Repo)
+	if err != nil {
+		return models.Module{}, err
+	}
+
+	host, err := repositoryHost(module.Version.Repo)
+	if err != nil {
+		return models.Module{}, err
+	}
+
+	// set the module's team as the repository owner
+	module.Team = moduleTeam(host, repo.Owner)
+
+	// set the module's version publisher
+	module.Version.PublishedBy = authUser.ID
+
+	// verify the publisher is authorized to publish on behalf of the repository
+	// owner and record how
+	publishAuth, err := r.authorizePublisher(authUser, module.Version.Repo, repo)
+	if err != nil {
+		return models.Module{}, err
+	}
+
+	module.Version.PublishAuthorization = models.NewNullString(publishAuth)
+
+	// set the avatar URL for each author
+	for i, author := range module.Authors {
+		contributor, ok := repo.Contributors[author.Name]
+		if ok {
+			author.AvatarURL = contributor.AvatarURL
+			module.Authors[i] = author
+		}
+	}
+
+	// verify the version exists as a tag in the repository and record its commit
+	sha, err := getVersionCommit(ghClient, repo, module)
+	if err != nil {
+		return models.Module{}, err
+	}
+
+	module.Version.CommitSHA = models.NewNullString(sha)
+
+	// record the checksum of the module's source tree at the version's commit
+	sourceChecksum, err := trees.checksum(ghClient, module.Version.Repo, repo, sha, module.Version.SourcePath.String)
+	if err != nil {
+		return models.Module{}, err
+	}
+
+	module.Version.SourceChecksum = models.NewNullString(sourceChecksum)
+
+	return module, nil
+}
+
+// authorizePublisher verifies the publisher is authorized to publish a module on
+// behalf of the owner of its repository and returns the publish authorization
+// mode that allowed it. A repository contributor is always authorized, where the
+// publisher's identity on providers other than GitHub must be established by an
+// account linked by the operator, as the publisher's Atlas username is their
+// GitHub login. For GitHub repositories, if enabled, members of the organization
+// that owns the repository or members of a configured team of that organization
+// are also authorized. An error is returned if the publisher is not authorized.
+func (r *Router) authorizePublisher(authUser models.User, repoURL string, repo Repository) (string, error) {
+	notAuthorizedErr := fmt.Errorf("publisher '%s' is not a contributor of this module", authUser.Name)
+
+	// a stale repository's contributors are unknown
+	if repo.Stale {
+		return "", fmt.Errorf("failed to verify contributors of repository %s/%s: provider unavailable", repo.Owner, repo.Repo)
+	}
+
+	host, err := repositoryHost(repoURL)
+	if err != nil {
+		return "", err
+	}
+
+	if !isGitHubHost(host) {
+		logins := r.linkedAccounts(authUser, host)
+		if len(logins) == 0 {
+			return "", fmt.Errorf("publisher '%s' has no linked account on '%s'", authUser.Name, host)
+		}
+
+		for _, login := range logins {
+			if _, ok := repo.Contributors[login]; ok {
+				return models.PublishAuthContributor, nil
+			}
+		}
+
+		return "", notAuthorizedErr
+	}
+
+	if _, ok := repo.Contributors[authUser.Name]; ok {
+		return models.PublishAuthContributor, nil
+	}
+
+	orgClient, ok := r.ghClientCreator(authUser.GithubAccessToken.String).(GitHubOrgClientI)
+	if !ok {
+		return "", notAuthorizedErr
+	}
+
+	if r.cfg.Bool(config.PublishOrgMembers) {
+		isMember, err := orgClient.IsOrgMember(repo.Owner, authUser.Name)
+		if err != nil {
+			return "", err
+		}
+
+		if isMember {
+			return models.PublishAuthOrgMember, nil
+		}
+	}
+
+	// Teams are configured as a comma-delimited list of '<org>/<team-slug>',
+	// where a team only authorizes publishing modules owned by its organization.
+	for _, team := range strings.Split(r.cfg.String(config.PublishTeams), ",") {
+		tokens := strings.Split(strings.TrimSpace(team), "/")
+		if len(tokens) != 2 || !strings.EqualFold(tokens[0], repo.Owner) {
+			continue
+		}
+
+		isMember, err := orgClient.IsTeamMember(tokens[0], tokens[1], authUser.Name)
+		if err != nil {
+			return "", err
+		}
+
+		if isMember {
+			return models.PublishAuthTeamMember, nil
+		}
+	}
+
+	return "", notAuthorizedErr
+}
+
+// fetchRepository fetches the given repository from the provider hosting it,
+// selected by the repository URL's host, and returns the client it was fetched
+// with, which is to be used for all other requests of the repository. GitHub
+// repositories are fetched using the server's GitHub token, if any, such that
+// public repositories are shared by all publishers and refreshed in the
+// background if the router has a repository cache. Only if that fails, e.g. for
+// a private repository, is the repository fetched on behalf of the publisher
+// using their GitHub access token, where it is cached for that token only. An
+// error is returned if no provider is configured for the host or if the
+// repository cannot be fetched.
+func (r *Router) fetchRepository(authUser models.User, repoURL string) (GitHubClientI, Repository, error) {
+	host, err := repositoryHost(repoURL)
+	if err != nil {
+		return nil, Repository{}, err
+	}
+
+	if isGitHubHost(host) {
+		client := r.ghClientCreator(r.cfg.String(config.GHToken))
+		if r.repoCache != nil {
+			client = r.repoCache.Wrap(client)
+		}
+
+		repo, err := client.GetRepository(repoURL)
+		if err == nil {
+			return client, repo, nil
+		}
+
+		token := authUser.GithubAccessToken.String
+
+		client = r.ghClientCreator(token)
+		if r.repoCache != nil {
+			client = r.repoCache.WrapToken(client, token)
+		}
+
+		repo, err = client.GetRepository(repoURL)
+		if err != nil {
+			return nil, Repository{}, err
+		}
+
+		return client, repo, nil
+	}
+
+	for _, rc := range r.repoClients {
+		if rc.Host() == host {
+			var client GitHubClientI = rc
+			if r.repoCache != nil {
+				client = r.repoCache.Wrap(rc)
+			}
+
+			repo, err := client.GetRepository(repoURL)
+			if err != nil {
+				return nil, Repository{}, err
+			}
+
+			return client, repo, nil
+		}
+	}
+
+	return nil, Repository{}, fmt.Errorf("unsupported repository host: %s", host)
+}
+
+// linkedAccounts returns the logins of the accounts on a repository provider's
+// host that the operator linked to a user. Accounts are configured as a
+// comma-delimited list of '<host>/<login>=<user>', where <user> is the Atlas
+// username.
+func (r *Router) linkedAccounts(authUser models.User, host string) []string {
+	var logins []string
+
+	for _, account := range strings.Split(r.cfg.String(config.PublishAccounts), ",") {
+		tokens := strings.Split(strings.TrimSpace(account), "=")
+		if len(tokens) != 2 || !strings.EqualFold(tokens[1], authUser.Name) {
+			continue
+		}
+
+		i := strings.LastIndex(tokens[0], "/")
+		if i < 0 || !strings.EqualFold(tokens[0][:i], host) || tokens[0][i+1:] == "" {
+			continue
+		}
+
+		logins = append(logins, tokens[0][i+1:])
+	}
+
+	return logins
+}
+
+// moduleTeam returns the team of a module owned by the given owner of a
+// repository on the given host. The team of a module hosted on GitHub is the
+// owner, whereas the team of a module hosted on any other provider is
+// namespaced by the host, e.g. 'gitlab.com/cosmos', such that it never refers
+// to the GitHub user or organization of the same name.
+func moduleTeam(host, owner string) string {
+	if isGitHubHost(host) {
+		return owner
+	}
+
+	return host + "/" + owner
+}
+
+// isGitHubModule returns true if the repository of a module's most recently
+// published version is hosted on GitHub. The module's versions must be loaded.
+func isGitHubModule(module models.Module) bool {
+	var latest *models.ModuleVersion
+	for i, mv := range module.Versions {
+		if latest == nil || mv.ID > latest.ID {
+			latest = &module.Versions[i]
+		}
+	}
+
+	if latest == nil {
+		return false
+	}
+
+	host, err := repositoryHost(latest.Repo)
+	return err == nil && isGitHubHost(host)
+}
+
+// isGitHubHost returns true if a repository host refers to GitHub.
+func isGitHubHost(host string) bool {
+	return host == gitHubHost || host == "www."+gitHubHost
+}
+
+// sourceTrees defines the source trees of repository commits fetched while
+// publishing, keyed by repository and commit, such that a repository's archive
+// is fetched at most once per commit, e.g. when publishing multiple modules of a
+// monorepo. Source trees are also retrieved through the repository cache, if
+// any, so that they are shared across requests.
+type sourceTrees map[string]checksum.Tree
+
+// checksum returns the checksum of the source tree of the given subdirectory of
+// a repository at the given commit, where an empty subdirectory denotes the
+// repository root. The repository's archive is fetched unless the commit's
+// source tree was already fetched. An error is returned if the archive cannot
+// be fetched or if the subdirectory contains no files.
+func (st sourceTrees) checksum(ghClient GitHubClientI, repoURL string, repo Repository, sha, subdir string) (string, error) {
+	host, err := repositoryHost(repoURL)
+	if err != nil {
+		return "", err
+	}
+
+	key := fmt.Sprintf("%s/%s/%s@%s", host, repo.Owner, repo.Repo, sha)
+
+	tree, ok := st[key]
+	if !ok {
+		if treeClient, isCached := ghClient.(cachedGitHubClient); isCached {
+			tree, err = treeClient.GetSourceTree(repo, sha)
+		} else {
+			tree, err = fetchSourceTree(ghClient, repo, sha)
+		}
+		if err != nil {
+			return "", err
+		}
+
+		st[key] = tree
+	}
+
+	sum, err := tree.Checksum(subdir)
+	if err != nil {
+		return "", fmt.Errorf("failed to compute source checksum of '%s': %w", path.Join("/", subdir), err)
+	}
+
+	return sum, nil
+}
+
+// fetchSourceTree fetches the archive of a repository at the given commit and
+// returns its source tree.
+func fetchSourceTree(ghClient GitHubClientI, repo Repository, sha string) (checksum.Tree, error) {
+	tarball, err := ghClient.GetTarball(repo, sha)
+	if err != nil {
+		return nil, err
+	}
+
+	defer tarball.Close()
+
+	return checksum.ReadTarGz(tarball)
+}
+
+// getVersionCommit returns the SHA of the commit a module version's tag refers
+// to. The tag may be the version with or without a 'v' prefix, optionally
+// prefixed by the module's name as is common for modules in a monorepo, e.g.
+// 'x/bank/v1.0.0'. An error is returned if no such tag exists.
+func getVersionCommit(ghClient GitHubClientI, repo Repository, module models.Module) (string, error) {
+	version := strings.TrimPrefix(module.Version.Version, "v")
+	tags := []string{"v" + version, version}
+
+	if module.Name != "" {
+		tags = append(tags, path.Join(module.Name, "v"+version), path.Join(module.Name, version))
+	}
+
+	for _, tag := range tags {
+		sha, err := ghClient.GetTagCommit(repo, tag)
+		if err == nil {
+			return sha, nil
+		}
+
+		if !errors.Is(err, ErrTagNotFound) {
+			return "", err
+		}
+	}
+
+	return "", fmt.Errorf("version '%s' does not exist as a tag in repository '%s/%s'", module.Version.Version, repo.Owner, repo.Repo)
+}
+
+// publishModule publishes a module, as returned by moduleFromManifest, using the
+// given database handle, which may be a transaction. The publisher must already
+// be an existing owner or the module must be new. If the request was
+// authenticated by an API token, i.e. the token is non-nil, the token must be
+// granted publishing the module, where publishing a new module requires the
+// unrestricted publish scope. It returns the published module
+// along with its version's dependency impact report. Upon failure, an error is
+// returned along with the corresponding HTTP status code.
+func (r *Router) publishModule(
+	db *gorm.DB, authUser models.User, token *models.UserToken, module models.Module, manifest Manifest,
+) (models.ModulePublishJSON, int, error) {
+	// The publisher must already be an existing owner or must have accepted an
+	// invitation by an existing owner.
+	record, err := models.QueryModule(db, map[string]interface{}{"name": module.Name, "team": module.Team})
+	if err == nil {
+		// the module already exists so we check if the publisher is an owner
+		if !record.IsOwner(authUser.ID) {
+			return models.ModulePublishJSON{}, http.StatusBadRequest, errors.New("publisher must be an owner of the module")
+		}
+
+		if err := authorizeTokenScope(token, models.PublishModuleScope(record.ID)); err != nil {
+			return models.ModulePublishJSON{}, http.StatusForbidden, err
+		}
+
+		module.Owners = record.Owners
+	} else {
+		// Otherwise, the module is new and we automatically assign the publisher
+		// as the first and only owner, which requires the unrestricted publish
+		// scope.
+		if err := authorizeTokenScope(token, models.TokenScopePublish); err != nil {
+			return models.ModulePublishJSON{}, http.StatusForbidden, err
+		}
+
+		module.Owners = []models.User{authUser}
+	}
+
+	// resolve the module version's dependencies against existing modules
+	deps, err := r.resolveDependencies(db, module, manifest.Dependencies)
+	if err != nil {
+		code := http.StatusInternalServerError
+		if errors.Is(err, errInvalidDependency) {
+			code = http.StatusBadRequest
+		}
+
+		return models.ModulePublishJSON{}, code, err
+	}
+
+	module.Version.Dependencies = deps
+
+	module, err = module.Upsert(db)
+	if err != nil {
+		return models.ModulePublishJSON{}, http.StatusInternalServerError, err
+	}
+
+	mv, err := models.QueryModuleVersion(db, map[string]interface{}{"module_id": module.ID, "version": manifest.Version.Version})
+	if err != nil {
+		return models.ModulePublishJSON{}, http.StatusInternalServerError, err
+	}
+
+	impact, err := models.GetDependencyImpactReport(db, mv.ID)
+	if err != nil {
+		return models.ModulePublishJSON{}, http.StatusInternalServerError, err
+	}
+
+	impactJSON := make([]models.DependencyEdgeJSON, len(impact))
+	for i, e := range impact {
+		impactJSON[i] = e.NewDependencyEdgeJSON()
+	}
+
+	return models.ModulePublishJSON{ModuleJSON: module.NewModuleJSON(), DependencyImpact: impactJSON}, http.StatusOK, nil
+}
+
+// resolveDependencies resolves a set of dependency manifests to existing modules.
+// Each dependency must reference an existing module, other than the module
+// itself, with at least one non-yanked version satisfying the dependency's
+// version constraint. An error wrapping errInvalidDependency is returned if any
+// dependency is invalid.
+func (r *Router) resolveDependencies(db *gorm.DB, module models.Module, manifests []DependencyManifest) ([]models.ModuleDependency, error) {
+	deps := make([]models.ModuleDependency, 0, len(manifests))
+	seen := make(map[string]bool, len(manifests))
+
+	for _, dm := range manifests {
+		ref := fmt.Sprintf("%s/%s", dm.Team, dm.Name)
+		if dm.Team == module.Team && dm.Name == module.Name {
+			return nil, fmt.Errorf("%w: module cannot depend on itself", errInvalidDependency)
+		}
+		if seen[ref] {
+			return nil, fmt.Errorf("%w: duplicate dependency '%s'", errInvalidDependency, ref)
+		}
+
+		seen[ref] = true
+
+		constraint, err := semver.ParseConstraint(dm.Version)
+		if err != nil {
+			return nil, fmt.Errorf("%w '%s': %s", errInvalidDependency, ref, err)
+		}
+
+		dep, err := models.QueryModule(db, map[string]interface{}{"team": dm.Team, "name": dm.Name})
+		if err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return nil, fmt.Errorf("%w: module '%s' does not exist", errInvalidDependency, ref)
+			}
+
+			return nil, err
+		}
+
+		var satisfied bool
+		for _, mv := range dep.Versions {
+			if v, err := semver.Parse(mv.Version); err == nil && !mv.Yanked && constraint.Check(v) {
+				satisfied = true
+				break
+			}
+		}
+
+		if !satisfied {
+			return nil, fmt.Errorf(
+				"%w: no published version of '%s' satisfies '%s'", errInvalidDependency, ref, dm.Version,
+			)
+		}
+
+		deps = append(deps, models.ModuleDependency{DependencyID: dep.ID, Constraint: dm.Version})
+	}
+
+	return deps, nil
+}
+
+// parseSDKQueryParam parses the optional 'sdk' query parameter as a Semantic
+// Version. It returns nil if the parameter is not provided.
+func parseSDKQueryParam(req *http.Request) (*semver.Version, error) {
+	sdk := req.URL.Query().Get("sdk")
+	if sdk == "" {
+		return nil, nil
+	}
+
+	v, err := semver.Parse(sdk)
+	if err != nil {
+		return nil, fmt.Errorf("invalid SDK version: %w", err)
+	}
+
+	return &v, nil
+}
+
+// parseOnlineQueryParam parses the optional 'online' query parameter, which
+// filters nodes by whether they are online. It defaults to online nodes only and
+// returns nil if all nodes are requested via 'all'.
+func parseOnlineQueryParam(req *http.Request) (*bool, error) {
+	s := req.URL.Query().Get("online")
+	switch s {
+	case "":
+		online := true
+		return &online, nil
+
+	case "all":
+		return nil, nil
+	}
+
+	online, err := strconv.ParseBool(s)
+	if err != nil {
+		return nil, fmt.Errorf("invalid online filter '%s': expected 'true', 'false' or 'all'", s)
+	}
+
+	return &online, nil
+}
+
+// parseUptimeWindow parses a node uptime window, which is either a number of
+// days, e.g. '7d', or a Go duration, e.g. '12h'. It defaults to seven days if
+// not provided and may not exceed the node observation retention.
+func parseUptimeWindow(s string) (time.Duration, error) {
+	if s == "" {
+		return 7 * 24 * time.Hour, nil
+	}
+
+	var (
+		window time.Duration
+		err    error
+	)
+
+	if strings.HasSuffix(s, "d") {
+		var days uint64
+		days, err = strconv.ParseUint(strings.TrimSuffix(s, "d"), 10, 16)
+		window = time.Duration(days) * 24 * time.Hour
+	} else {
+		window, err = time.ParseDuration(s)
+	}
+
+	switch {
+	case err != nil:
+		return 0, fmt.Errorf("invalid uptime window '%s': %w", s, err)
+
+	case window <= 0 || window > models.NodeObservationRetention:
+		return 0, fmt.Errorf("invalid uptime window '%s': must be positive and at most %s", s, models.NodeObservationRetention)
+	}
+
+	return window, nil
 }
 
+// newSanitizer returns a Sanitizer for plain-text user-provided input, such as
+// descriptions and URLs, which strips all markup.
 func newSanitizer() Sanitizer {
 	return bluemonday.NewPolicy().
 		RequireParseableURLs(true).
 		AllowRelativeURLs(false).
 		AllowURLSchemes("http", "https")
 }
+
+// newMarkdownSanitizer returns a Sanitizer for rendered Markdown, which allows
+// the common formatting elements of user-generated HTML, where links and images
+// may be relative, e.g. to files in a module's repository.
+func newMarkdownSanitizer() Sanitizer {
+	return bluemonday.UGCPolicy().
+		RequireParseableURLs(true).
+		AllowRelativeURLs(true).
+		AllowURLSchemes("http", "https", "mailto")
+}
+
+// renderMarkdown renders Markdown to sanitized HTML.
+func (r *Router) renderMarkdown(md string) string {
+	return r.mdSanitizer.Sanitize(string(blackfriday.Run([]byte(md))))
+}
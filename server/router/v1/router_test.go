@@ -1,17 +1,22 @@
 package v1
 
 import (
+	"archive/tar"
 	"bytes"
+	"compress/gzip"
 	"context"
+	"crypto/sha1"
 	"database/sql"
 	"encoding/json"
 	"fmt"
+	"io"
 	"io/ioutil"
 	"math/rand"
 	"net/http"
 	"net/http/httptest"
 	"net/url"
 	"os"
+	"strings"
 	"testing"
 	"time"
 
@@ -22,10 +27,10 @@ import (
 	migratepg "github.com/golang-migrate/migrate/v4/database/postgres"
 	_ "github.com/golang-migrate/migrate/v4/source/file"
 	gogithub "github.com/google/go-github/github"
-	githubclient "github.com/google/go-github/v32/github"
 	"github.com/gorilla/mux"
 	"github.com/gorilla/sessions"
 	"github.com/knadh/koanf"
+	"github.com/knadh/koanf/providers/confmap"
 	_ "github.com/lib/pq"
 	"github.com/rs/zerolog"
 	"github.com/stretchr/testify/require"
@@ -35,12 +40,22 @@ import (
 	"gorm.io/gorm"
 	gormlogger "gorm.io/gorm/logger"
 
+	"github.com/cosmos/atlas/config"
+	"github.com/cosmos/atlas/server/checksum"
 	"github.com/cosmos/atlas/server/httputil"
 	"github.com/cosmos/atlas/server/models"
 )
 
 type testGitHubClient struct {
 	contributors []string
+
+	// tags defines the commit SHA of each existing tag, where every tag is
+	// considered to exist if nil
+	tags map[string]string
+
+	// memberships defines the organizations, e.g. 'cosmos', and teams, e.g.
+	// 'cosmos/release', the authenticated user is an active member of
+	memberships []string
 }
 
 func (tgc testGitHubClient) GetRepository(repoURL string) (Repository, error) {
@@ -49,15 +64,84 @@ func (tgc testGitHubClient) GetRepository(repoURL string) (Repository, error) {
 		return Repository{}, err
 	}
 
-	contributors := make(map[string]*githubclient.Contributor)
+	contributors := make(map[string]Contributor)
 	for _, c := range tgc.contributors {
-		contributors[c] = nil
+		contributors[c] = Contributor{Login: c}
 	}
 
 	repo.Contributors = contributors
 	return repo, nil
 }
 
+func (tgc testGitHubClient) GetTarball(repo Repository, ref string) (io.ReadCloser, error) {
+	var buf bytes.Buffer
+
+	gzw := gzip.NewWriter(&buf)
+	tw := tar.NewWriter(gzw)
+
+	contents := []byte(fmt.Sprintf("# %s/%s@%s", repo.Owner, repo.Repo, ref))
+	if err := tw.WriteHeader(&tar.Header{
+		Name:     fmt.Sprintf("%s-%s-%s/README.md", repo.Owner, repo.Repo, ref),
+		Typeflag: tar.TypeReg,
+		Mode:     0644,
+		Size:     int64(len(contents)),
+	}); err != nil {
+		return nil, err
+	}
+
+	if _, err := tw.Write(contents); err != nil {
+		return nil, err
+	}
+
+	if err := tw.Close(); err != nil {
+		return nil, err
+	}
+
+	if err := gzw.Close(); err != nil {
+		return nil, err
+	}
+
+	return ioutil.NopCloser(&buf), nil
+}
+
+func (tgc testGitHubClient) IsOrgMember(org, _ string) (bool, error) {
+	for _, m := range tgc.memberships {
+		if m == org {
+			return true, nil
+		}
+	}
+
+	return false, nil
+}
+
+func (tgc testGitHubClient) IsTeamMember(org, team, user string) (bool, error) {
+	return tgc.IsOrgMember(org+"/"+team, user)
+}
+
+func (tgc testGitHubClient) GetTagCommit(repo Repository, tag string) (string, error) {
+	if tgc.tags == nil {
+		return fmt.Sprintf("%x", sha1.Sum([]byte(repo.Owner+"/"+repo.Repo+"@"+tag))), nil
+	}
+
+	sha, ok := tgc.tags[tag]
+	if !ok {
+		return "", fmt.Errorf("%w: %s", ErrTagNotFound, tag)
+	}
+
+	return sha, nil
+}
+
+// testRepositoryClient implements a RepositoryClientI for a non-GitHub provider.
+type testRepositoryClient struct {
+	testGitHubClient
+
+	host string
+}
+
+func (trc testRepositoryClient) Host() string {
+	return trc.host
+}
+
 type RouterTestSuite struct {
 	suite.Suite
 
@@ -112,6 +196,9 @@ func (rts *RouterTestSuite) SetupSuite() {
 		func(_ string) GitHubClientI {
 			return testGitHubClient{contributors: []string{"foo"}}
 		},
+		nil,
+		nil,
+		nil,
 	)
 	rts.Require().NoError(err)
 
@@ -140,6 +227,28 @@ func (rts *RouterTestSuite) executeRequest(req *http.Request) *httptest.Response
 	return rr
 }
 
+// executeJSONRequest executes a request, typically an authorized request, using
+// the given method and path, where the body, if provided, is JSON encoded.
+func (rts *RouterTestSuite) executeJSONRequest(req *http.Request, method, path string, body interface{}) *httptest.ResponseRecorder {
+	reqURL, err := url.Parse(path)
+	rts.Require().NoError(err)
+
+	req.Method = method
+	req.URL = reqURL
+	req.Body = nil
+	req.ContentLength = 0
+
+	if body != nil {
+		bz, err := json.Marshal(body)
+		rts.Require().NoError(err)
+
+		req.Body = ioutil.NopCloser(bytes.NewBuffer(bz))
+		req.ContentLength = int64(len(bz))
+	}
+
+	return rts.executeRequest(req)
+}
+
 func (rts *RouterTestSuite) authorizeRequest(req *http.Request, token, login string, id int64) *http.Request {
 	rr := httptest.NewRecorder()
 
@@ -319,6 +428,11 @@ func (rts *RouterTestSuite) TestGetAllModules() {
 	rts.Require().Empty(pr.NextURI)
 
 	for i := 0; i < 25; i++ {
+		sdkCompat := "v0.39.x"
+		if i%2 == 1 {
+			sdkCompat = ">=0.40"
+		}
+
 		mod := models.Module{
 			Name: fmt.Sprintf("x/bank-%d", i),
 			Team: "cosmonauts",
@@ -329,6 +443,7 @@ func (rts *RouterTestSuite) TestGetAllModules() {
 				Version:       "v1.0.0",
 				Repo:          "https://github.com/cosmos/cosmos-sdk/releases/tag/v0.39.1",
 				Documentation: fmt.Sprintf("https://raw.githubusercontent.com/cosmos/cosmos-sdk/v0.39.1/x/mod-%d/README.md", i),
+				SDKCompat:     models.NewNullString(sdkCompat),
 			},
 			Keywords: []models.Keyword{
 				{Name: "tokens"},
@@ -398,6 +513,29 @@ func (rts *RouterTestSuite) TestGetAllModules() {
 	rts.Require().Equal(int64(10), pr.Limit)
 	rts.Require().Equal("?page=2&limit=10&reverse=false&order=id", pr.PrevURI)
 	rts.Require().Empty(pr.NextURI)
+
+	// filter by compatible SDK version
+	path = "/api/v1/modules?page=1&limit=20&sdk=v0.42.3"
+	req, err = http.NewRequest("GET", path, nil)
+	rts.Require().NoError(err)
+
+	response = rts.executeRequest(req)
+	rts.Require().Equal(http.StatusOK, response.Code, response.Body.String())
+	rts.Require().NoError(json.Unmarshal(response.Body.Bytes(), &pr))
+	rts.Require().Len(pr.Results, 12)
+
+	for _, m := range pr.Results.([]interface{}) {
+		// modules with an odd index, i.e. an even ID, are compatible
+		rts.Require().Equal(0, int(m.(map[string]interface{})["id"].(float64))%2, "unexpected module")
+	}
+
+	// invalid SDK version
+	path = "/api/v1/modules?page=1&limit=10&sdk=stargate"
+	req, err = http.NewRequest("GET", path, nil)
+	rts.Require().NoError(err)
+
+	response = rts.executeRequest(req)
+	rts.Require().Equal(http.StatusBadRequest, response.Code, response.Body.String())
 }
 
 func (rts *RouterTestSuite) TestGetModuleByID() {
@@ -509,6 +647,83 @@ func (rts *RouterTestSuite) TestGetModuleVersions() {
 	})
 }
 
+func (rts *RouterTestSuite) TestGetModuleVersionReadme() {
+	rts.resetDB()
+
+	mod := models.Module{
+		Name: "x/bank",
+		Team: "cosmonauts",
+		Authors: []models.User{
+			{Name: "foo", Email: models.NewNullString("foo@cosmonauts.com")},
+		},
+		Version: models.ModuleVersion{
+			Version: "v1.0.0",
+			Repo:    "https://github.com/cosmos/cosmos-sdk/releases/tag/v0.39.1",
+			Readme:  "# x/bank\n\nSee the [spec](https://docs.cosmos.network).\n\n<script>alert('xss')</script>\n",
+		},
+		BugTracker: models.BugTracker{},
+	}
+
+	mod, err := mod.Upsert(rts.router.db)
+	rts.Require().NoError(err)
+
+	// publish a version without a README
+	mod.Version = models.ModuleVersion{
+		Version: "v1.1.0",
+		Repo:    "https://github.com/cosmos/cosmos-sdk/releases/tag/v0.40.0",
+	}
+
+	mod, err = mod.Upsert(rts.router.db)
+	rts.Require().NoError(err)
+
+	testCases := []struct {
+		name string
+		path string
+		code int
+	}{
+		{
+			"no module exists",
+			fmt.Sprintf("/api/v1/modules/%d/versions/v1.0.0/readme", mod.ID+1),
+			http.StatusNotFound,
+		},
+		{
+			"no README exists",
+			fmt.Sprintf("/api/v1/modules/%d/versions/v1.1.0/readme", mod.ID),
+			http.StatusNotFound,
+		},
+		{
+			"README exists",
+			fmt.Sprintf("/api/v1/modules/%d/versions/v1.0.0/readme", mod.ID),
+			http.StatusOK,
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+
+		rts.Run(tc.name, func() {
+			req, err := http.NewRequest("GET", tc.path, nil)
+			rts.Require().NoError(err)
+
+			response := rts.executeRequest(req)
+			rts.Require().Equal(tc.code, response.Code, response.Body.String())
+
+			var body map[string]interface{}
+			rts.Require().NoError(json.Unmarshal(response.Body.Bytes(), &body))
+
+			if tc.code == http.StatusOK {
+				html := body["html"].(string)
+				rts.Require().Equal("v1.0.0", body["version"])
+				rts.Require().Contains(html, "<h1>x/bank</h1>")
+				rts.Require().Contains(html, `href="https://docs.cosmos.network"`)
+				rts.Require().NotContains(html, "<script>")
+			} else {
+				rts.Require().NotEmpty(body["error"])
+			}
+		})
+	}
+}
+
 func (rts *RouterTestSuite) GetModuleAuthors() {
 	rts.resetDB()
 
@@ -1046,6 +1261,32 @@ func (rts *RouterTestSuite) TestUpsertModule() {
 			},
 			code: http.StatusBadRequest,
 		},
+		{
+			name: "invalid version",
+			body: map[string]interface{}{
+				"module": map[string]interface{}{
+					"name":     "x/bank",
+					"team":     "cosmonauts",
+					"repo":     "https://github.com/cosmos/cosmos-sdk",
+					"keywords": []string{"tokens"},
+				},
+				"authors": []map[string]interface{}{
+					{
+						"name": "foo", "email": "foo@email.com",
+					},
+				},
+				"version": map[string]interface{}{
+					"repo":          "https://github.com/cosmos/cosmos-sdk/releases/tag/v0.39.1",
+					"documentation": "https://raw.githubusercontent.com/cosmos/cosmos-sdk/v0.39.1/x/bank/README.md",
+					"version":       "latest",
+				},
+				"bug_tracker": map[string]interface{}{
+					"url":     "https://cosmonauts.com",
+					"contact": "contact@cosmonauts.com",
+				},
+			},
+			code: http.StatusBadRequest,
+		},
 		{
 			name: "valid module",
 			body: map[string]interface{}{
@@ -1174,737 +1415,2232 @@ func (rts *RouterTestSuite) TestUpsertModule_Unauthorized() {
 	rts.Require().Equal(http.StatusUnauthorized, response.Code)
 }
 
-func (rts *RouterTestSuite) TestCreateModule_InvalidOwner() {
+func (rts *RouterTestSuite) TestUpsertModule_VerifyTag() {
 	rts.resetDB()
 
-	req1, err := http.NewRequest("GET", "/", nil)
-	rts.Require().NoError(err)
+	ghClientCreator := rts.router.ghClientCreator
+	defer func() {
+		rts.router.ghClientCreator = ghClientCreator
+	}()
 
-	req2, err := http.NewRequest("GET", "/", nil)
+	sha := "a9b3d4b6f5c2e1f0d8c7b6a5f4e3d2c1b0a9f8e7"
+	rts.router.ghClientCreator = func(_ string) GitHubClientI {
+		return testGitHubClient{
+			contributors: []string{"foo"},
+			tags:         map[string]string{"x/bank/v1.0.0": sha},
+		}
+	}
+
+	req, err := http.NewRequest("GET", "/", nil)
 	rts.Require().NoError(err)
 
-	req1 = rts.authorizeRequest(req1, "test_token1", "foo", 12345)
-	req2 = rts.authorizeRequest(req2, "test_token2", "test_user2", 67899)
+	req = rts.authorizeRequest(req, "test_token", "foo", 12345)
 
 	upsertURL, err := url.Parse("/api/v1/modules")
 	rts.Require().NoError(err)
 
-	body := map[string]interface{}{
-		"module": map[string]interface{}{
-			"name":     "x/bank",
-			"team":     "cosmonauts",
-			"keywords": []string{"tokens"},
-		},
-		"authors": []map[string]interface{}{
-			{
-				"name": "foo", "email": "foo@email.com",
-			},
-		},
-		"version": map[string]interface{}{
-			"repo":          "https://github.com/cosmos/cosmos-sdk/releases/tag/v0.39.1",
-			"documentation": "https://raw.githubusercontent.com/cosmos/cosmos-sdk/v0.39.1/x/bank/README.md",
-			"version":       "v1.0.0",
-		},
-		"bug_tracker": map[string]interface{}{
-			"url":     "https://cosmonauts.com",
-			"contact": "contact@cosmonauts.com",
-		},
+	testCases := []struct {
+		name    string
+		version string
+		code    int
+	}{
+		{"missing tag", "v1.1.0", http.StatusBadRequest},
+		{"existing tag", "v1.0.0", http.StatusOK},
 	}
 
-	// create module published by test_user1
-	bz, err := json.Marshal(body)
-	rts.Require().NoError(err)
+	for _, tc := range testCases {
+		tc := tc
 
-	req1.Method = httputil.MethodPUT
-	req1.URL = upsertURL
-	req1.Body = ioutil.NopCloser(bytes.NewBuffer(bz))
-	req1.ContentLength = int64(len(bz))
+		rts.Run(tc.name, func() {
+			body := map[string]interface{}{
+				"module": map[string]interface{}{
+					"name": "x/bank",
+				},
+				"authors": []map[string]interface{}{
+					{"name": "foo"},
+				},
+				"version": map[string]interface{}{
+					"repo":    "https://github.com/cosmos/cosmos-sdk",
+					"version": tc.version,
+				},
+			}
 
-	rr := httptest.NewRecorder()
-	rts.mux.ServeHTTP(rr, req1)
-	rts.Require().Equal(http.StatusOK, rr.Code, rr.Body.String())
+			bz, err := json.Marshal(body)
+			rts.Require().NoError(err)
 
-	// attempt to update module published by test_user2
-	req2.Method = httputil.MethodPUT
-	req2.URL = upsertURL
-	req2.Body = ioutil.NopCloser(bytes.NewBuffer(bz))
-	req2.ContentLength = int64(len(bz))
+			req.Method = httputil.MethodPUT
+			req.URL = upsertURL
+			req.Body = ioutil.NopCloser(bytes.NewBuffer(bz))
+			req.ContentLength = int64(len(bz))
 
-	rr = httptest.NewRecorder()
-	rts.mux.ServeHTTP(rr, req2)
-	rts.Require().Equal(http.StatusBadRequest, rr.Code, rr.Body.String())
+			rr := httptest.NewRecorder()
+			rts.mux.ServeHTTP(rr, req)
+			rts.Require().Equal(tc.code, rr.Code, rr.Body.String())
+
+			if tc.code == http.StatusOK {
+				var body map[string]interface{}
+				rts.Require().NoError(json.Unmarshal(rr.Body.Bytes(), &body))
+
+				versions := body["versions"].([]interface{})
+				rts.Require().Len(versions, 1)
+
+				version := versions[0].(map[string]interface{})
+				rts.Require().Equal(sha, version["commit_sha"])
+				rts.Require().True(strings.HasPrefix(version["source_checksum"].(string), checksum.Prefix))
+			}
+		})
+	}
 }
 
-func (rts *RouterTestSuite) TestCreateUserToken() {
+func (rts *RouterTestSuite) TestUpsertModule_RepositoryProviders() {
 	rts.resetDB()
 
-	unAuthReq, err := http.NewRequest(httputil.MethodPUT, "/api/v1/me/tokens", nil)
-	rts.Require().NoError(err)
-
-	// unauthenticated
-	rr := httptest.NewRecorder()
-	rts.mux.ServeHTTP(rr, unAuthReq)
-	rts.Require().Equal(http.StatusUnauthorized, rr.Code, rr.Body.String())
+	cfg := rts.router.cfg
+	repoClients := rts.router.repoClients
+	defer func() {
+		rts.router.cfg = cfg
+		rts.router.repoClients = repoClients
+	}()
+
+	// foo's GitLab account is linked, whereas the Gitea account of the same login
+	// as foo's GitHub login is not
+	k := koanf.New(".")
+	rts.Require().NoError(k.Load(confmap.Provider(map[string]interface{}{
+		config.PublishAccounts: "gitlab.example.com/foo-gl=foo, gitea.example.com/bar=bar",
+	}, "."), nil))
+
+	rts.router.cfg = k
+	rts.router.repoClients = []RepositoryClientI{
+		testRepositoryClient{testGitHubClient: testGitHubClient{contributors: []string{"foo-gl"}}, host: "gitlab.example.com"},
+		testRepositoryClient{testGitHubClient: testGitHubClient{contributors: []string{"foo", "bar"}}, host: "gitea.example.com"},
+	}
 
-	// authenticated
-	req, err := http.NewRequest(httputil.MethodGET, "/", nil)
+	req, err := http.NewRequest("GET", "/", nil)
 	rts.Require().NoError(err)
 
-	req = rts.authorizeRequest(req, "test_token1", "test_user1", 123456)
-	req.Method = httputil.MethodPUT
-	req.URL = unAuthReq.URL
+	req = rts.authorizeRequest(req, "test_token", "foo", 12345)
 
-	// require empty name fails
-	body := Token{Name: ""}
-	bz, err := json.Marshal(body)
+	upsertURL, err := url.Parse("/api/v1/modules")
 	rts.Require().NoError(err)
 
-	req.Body = ioutil.NopCloser(bytes.NewBuffer(bz))
-	req.ContentLength = int64(len(bz))
-
-	rr = httptest.NewRecorder()
-	rts.mux.ServeHTTP(rr, req)
-	rts.Require().Equal(http.StatusBadRequest, rr.Code, rr.Body.String())
+	testCases := []struct {
+		name string
+		repo string
+		code int
+		team string
+	}{
+		{"unsupported host", "https://bitbucket.org/cosmos/cosmos-sdk", http.StatusBadRequest, ""},
+		{"unlinked account", "https://gitea.example.com/cosmos/cosmos-sdk", http.StatusBadRequest, ""},
+		{"linked account contributor", "https://GitLab.example.com/cosmos/cosmos-sdk", http.StatusOK, "gitlab.example.com/cosmos"},
+		{"GitHub owner of the same name", "https://github.com/cosmos/cosmos-sdk", http.StatusOK, "cosmos"},
+	}
 
-	for i := int64(0); i < MaxTokens; i++ {
-		body := Token{Name: fmt.Sprintf("token-%d", i+1)}
-		bz, err := json.Marshal(body)
-		rts.Require().NoError(err)
+	for _, tc := range testCases {
+		tc := tc
 
-		req.Body = ioutil.NopCloser(bytes.NewBuffer(bz))
-		req.ContentLength = int64(len(bz))
+		rts.Run(tc.name, func() {
+			body := map[string]interface{}{
+				"module": map[string]interface{}{
+					"name": "x/bank",
+				},
+				"authors": []map[string]interface{}{
+					{"name": "foo"},
+				},
+				"version": map[string]interface{}{
+					"repo":    tc.repo,
+					"version": "v1.0.0",
+				},
+			}
 
-		rr = httptest.NewRecorder()
-		rts.mux.ServeHTTP(rr, req)
-		rts.Require().Equal(http.StatusOK, rr.Code, rr.Body.String())
+			bz, err := json.Marshal(body)
+			rts.Require().NoError(err)
 
-		var ut map[string]interface{}
-		rts.Require().NoError(json.Unmarshal(rr.Body.Bytes(), &ut), rr.Body.String())
+			req.Method = httputil.MethodPUT
+			req.URL = upsertURL
+			req.Body = ioutil.NopCloser(bytes.NewBuffer(bz))
+			req.ContentLength = int64(len(bz))
+
+			rr := httptest.NewRecorder()
+			rts.mux.ServeHTTP(rr, req)
+			rts.Require().Equal(tc.code, rr.Code, rr.Body.String())
+
+			if tc.code == http.StatusOK {
+				var body map[string]interface{}
+				rts.Require().NoError(json.Unmarshal(rr.Body.Bytes(), &body))
+				rts.Require().Equal(tc.team, body["team"])
+			}
+		})
+	}
+}
+
+func (rts *RouterTestSuite) TestUpsertModule_PublishAuthorization() {
+	rts.resetDB()
+
+	cfg := rts.router.cfg
+	ghClientCreator := rts.router.ghClientCreator
+	defer func() {
+		rts.router.cfg = cfg
+		rts.router.ghClientCreator = ghClientCreator
+	}()
+
+	req, err := http.NewRequest("GET", "/", nil)
+	rts.Require().NoError(err)
+
+	req = rts.authorizeRequest(req, "test_token", "foo", 12345)
+
+	upsertURL, err := url.Parse("/api/v1/modules")
+	rts.Require().NoError(err)
+
+	testCases := []struct {
+		name         string
+		cfg          map[string]interface{}
+		client       testGitHubClient
+		moduleName   string
+		code         int
+		expectedAuth string
+	}{
+		{
+			"contributor",
+			map[string]interface{}{},
+			testGitHubClient{contributors: []string{"foo"}, memberships: []string{"cosmos"}},
+			"x/bank",
+			http.StatusOK,
+			models.PublishAuthContributor,
+		},
+		{
+			"org member with mode disabled",
+			map[string]interface{}{},
+			testGitHubClient{memberships: []string{"cosmos"}},
+			"x/staking",
+			http.StatusBadRequest,
+			"",
+		},
+		{
+			"org member",
+			map[string]interface{}{config.PublishOrgMembers: true},
+			testGitHubClient{memberships: []string{"cosmos"}},
+			"x/staking",
+			http.StatusOK,
+			models.PublishAuthOrgMember,
+		},
+		{
+			"member of another org's team",
+			map[string]interface{}{config.PublishTeams: "tendermint/release"},
+			testGitHubClient{memberships: []string{"tendermint/release"}},
+			"x/gov",
+			http.StatusBadRequest,
+			"",
+		},
+		{
+			"team member",
+			map[string]interface{}{config.PublishTeams: "tendermint/release, cosmos/release"},
+			testGitHubClient{memberships: []string{"cosmos/release"}},
+			"x/gov",
+			http.StatusOK,
+			models.PublishAuthTeamMember,
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+
+		rts.Run(tc.name, func() {
+			k := koanf.New(".")
+			rts.Require().NoError(k.Load(confmap.Provider(tc.cfg, "."), nil))
+
+			rts.router.cfg = k
+			rts.router.ghClientCreator = func(_ string) GitHubClientI {
+				return tc.client
+			}
+
+			body := map[string]interface{}{
+				"module": map[string]interface{}{
+					"name": tc.moduleName,
+				},
+				"authors": []map[string]interface{}{
+					{"name": "foo"},
+				},
+				"version": map[string]interface{}{
+					"repo":    "https://github.com/cosmos/cosmos-sdk",
+					"version": "v1.0.0",
+				},
+			}
+
+			bz, err := json.Marshal(body)
+			rts.Require().NoError(err)
+
+			req.Method = httputil.MethodPUT
+			req.URL = upsertURL
+			req.Body = ioutil.NopCloser(bytes.NewBuffer(bz))
+			req.ContentLength = int64(len(bz))
+
+			rr := httptest.NewRecorder()
+			rts.mux.ServeHTTP(rr, req)
+			rts.Require().Equal(tc.code, rr.Code, rr.Body.String())
+
+			if tc.code == http.StatusOK {
+				var body map[string]interface{}
+				rts.Require().NoError(json.Unmarshal(rr.Body.Bytes(), &body))
+
+				versions := body["versions"].([]interface{})
+				rts.Require().Len(versions, 1)
+				rts.Require().Equal(tc.expectedAuth, versions[0].(map[string]interface{})["publish_authorization"])
+			}
+		})
+	}
+}
+
+func (rts *RouterTestSuite) TestUpsertModule_Dependencies() {
+	rts.resetDB()
+
+	staking := models.Module{
+		Name: "x/staking",
+		Team: "cosmonauts",
+		Authors: []models.User{
+			{Name: "bar", Email: models.NewNullString("bar@cosmonauts.com")},
+		},
+		Version: models.ModuleVersion{
+			Version: "v1.2.0",
+			Repo:    "https://github.com/cosmos/cosmos-sdk",
+		},
+		BugTracker: models.BugTracker{},
+	}
+
+	staking, err := staking.Upsert(rts.router.db)
+	rts.Require().NoError(err)
+
+	req, err := http.NewRequest("GET", "/", nil)
+	rts.Require().NoError(err)
+
+	req = rts.authorizeRequest(req, "test_token", "foo", 12345)
+
+	upsertURL, err := url.Parse("/api/v1/modules")
+	rts.Require().NoError(err)
+
+	newBody := func(deps ...map[string]interface{}) map[string]interface{} {
+		return map[string]interface{}{
+			"module": map[string]interface{}{
+				"name": "x/poa",
+			},
+			"authors": []map[string]interface{}{
+				{"name": "foo"},
+			},
+			"version": map[string]interface{}{
+				"repo":    "https://github.com/cosmonauts/poa",
+				"version": "v1.0.0",
+			},
+			"dependencies": deps,
+		}
+	}
+
+	testCases := []struct {
+		name string
+		body map[string]interface{}
+		code int
+	}{
+		{
+			name: "invalid constraint",
+			body: newBody(map[string]interface{}{"team": "cosmonauts", "name": "x/staking", "version": "latest"}),
+			code: http.StatusBadRequest,
+		},
+		{
+			name: "unknown module",
+			body: newBody(map[string]interface{}{"team": "cosmonauts", "name": "x/unknown", "version": "^1.0.0"}),
+			code: http.StatusBadRequest,
+		},
+		{
+			name: "self dependency",
+			body: newBody(map[string]interface{}{"team": "cosmonauts", "name": "x/poa", "version": "^1.0.0"}),
+			code: http.StatusBadRequest,
+		},
+		{
+			name: "unsatisfiable constraint",
+			body: newBody(map[string]interface{}{"team": "cosmonauts", "name": "x/staking", "version": "^2.0.0"}),
+			code: http.StatusBadRequest,
+		},
+		{
+			name: "duplicate dependency",
+			body: newBody(
+				map[string]interface{}{"team": "cosmonauts", "name": "x/staking", "version": "^1.0.0"},
+				map[string]interface{}{"team": "cosmonauts", "name": "x/staking", "version": "^1.1.0"},
+			),
+			code: http.StatusBadRequest,
+		},
+		{
+			name: "valid dependency",
+			body: newBody(map[string]interface{}{"team": "cosmonauts", "name": "x/staking", "version": "^1.0.0"}),
+			code: http.StatusOK,
+		},
+	}
+
+	var poaID interface{}
+
+	for _, tc := range testCases {
+		tc := tc
+
+		rts.Run(tc.name, func() {
+			bz, err := json.Marshal(tc.body)
+			rts.Require().NoError(err)
+
+			req.Method = httputil.MethodPUT
+			req.URL = upsertURL
+			req.Body = ioutil.NopCloser(bytes.NewBuffer(bz))
+			req.ContentLength = int64(len(bz))
+
+			rr := httptest.NewRecorder()
+			rts.mux.ServeHTTP(rr, req)
+			rts.Require().Equal(tc.code, rr.Code, rr.Body.String())
+
+			if tc.code == http.StatusOK {
+				var body map[string]interface{}
+				rts.Require().NoError(json.Unmarshal(rr.Body.Bytes(), &body))
+
+				poaID = body["id"]
+				rts.Require().Equal([]interface{}{}, body["dependency_impact"])
+			}
+		})
+	}
+
+	rts.Require().NotNil(poaID)
+
+	expected := []interface{}{
+		map[string]interface{}{
+			"dependent": map[string]interface{}{
+				"module_id": poaID,
+				"team":      "cosmonauts",
+				"name":      "x/poa",
+				"version":   "v1.0.0",
+			},
+			"dependency": map[string]interface{}{
+				"module_id": float64(staking.ID),
+				"team":      "cosmonauts",
+				"name":      "x/staking",
+			},
+			"constraint": "^1.0.0",
+		},
+	}
+
+	for _, path := range []string{
+		fmt.Sprintf("/api/v1/modules/%v/dependencies", poaID),
+		fmt.Sprintf("/api/v1/modules/%d/dependents", staking.ID),
+	} {
+		req, err := http.NewRequest("GET", path, nil)
+		rts.Require().NoError(err)
+
+		response := rts.executeRequest(req)
+		rts.Require().Equal(http.StatusOK, response.Code, response.Body.String())
+
+		var body []interface{}
+		rts.Require().NoError(json.Unmarshal(response.Body.Bytes(), &body))
+		rts.Require().Equal(expected, body)
+	}
+
+	// ensure an unknown module results in a 404
+	req, err = http.NewRequest("GET", fmt.Sprintf("/api/v1/modules/%d/dependents", staking.ID+100), nil)
+	rts.Require().NoError(err)
+
+	response := rts.executeRequest(req)
+	rts.Require().Equal(http.StatusNotFound, response.Code, response.Body.String())
+
+	// publish a new major version of the dependency
+	staking.Version = models.ModuleVersion{
+		Version: "v2.0.0",
+		Repo:    "https://github.com/cosmos/cosmos-sdk",
+	}
+
+	_, err = staking.Upsert(rts.router.db)
+	rts.Require().NoError(err)
+
+	impactURL := fmt.Sprintf("/api/v1/modules/%d/versions/%s/impact", staking.ID, "v2.0.0")
+	req, err = http.NewRequest("GET", impactURL, nil)
+	rts.Require().NoError(err)
+
+	response = rts.executeRequest(req)
+	rts.Require().Equal(http.StatusOK, response.Code, response.Body.String())
+
+	expectedImpact := expected[0].(map[string]interface{})
+	expectedImpact["dependency"].(map[string]interface{})["version"] = "v2.0.0"
+
+	var impact []interface{}
+	rts.Require().NoError(json.Unmarshal(response.Body.Bytes(), &impact))
+	rts.Require().Equal([]interface{}{expectedImpact}, impact)
+
+	// ensure an unknown version results in a 404
+	req, err = http.NewRequest("GET", fmt.Sprintf("/api/v1/modules/%d/versions/%s/impact", staking.ID, "v3.0.0"), nil)
+	rts.Require().NoError(err)
+
+	response = rts.executeRequest(req)
+	rts.Require().Equal(http.StatusNotFound, response.Code, response.Body.String())
+}
+
+func (rts *RouterTestSuite) TestUpsertModules() {
+	rts.resetDB()
+
+	req, err := http.NewRequest("GET", "/", nil)
+	rts.Require().NoError(err)
+
+	req = rts.authorizeRequest(req, "test_token", "foo", 12345)
+
+	upsertURL, err := url.Parse("/api/v1/modules/batch")
+	rts.Require().NoError(err)
+
+	newManifest := func(name string, deps ...map[string]interface{}) map[string]interface{} {
+		return map[string]interface{}{
+			"module": map[string]interface{}{
+				"name": name,
+			},
+			"authors": []map[string]interface{}{
+				{"name": "foo"},
+			},
+			"version": map[string]interface{}{
+				"repo":    "https://github.com/cosmonauts/monorepo",
+				"version": "v1.0.0",
+			},
+			"dependencies": deps,
+		}
+	}
+
+	testCases := []struct {
+		name      string
+		manifests []map[string]interface{}
+		code      int
+	}{
+		{
+			name:      "no manifests",
+			manifests: []map[string]interface{}{},
+			code:      http.StatusBadRequest,
+		},
+		{
+			name: "invalid manifest",
+			manifests: []map[string]interface{}{
+				newManifest("x/foo"),
+				newManifest(""),
+			},
+			code: http.StatusBadRequest,
+		},
+		{
+			name: "duplicate module",
+			manifests: []map[string]interface{}{
+				newManifest("x/foo"),
+				newManifest("x/foo"),
+			},
+			code: http.StatusBadRequest,
+		},
+		{
+			name: "invalid dependency",
+			manifests: []map[string]interface{}{
+				newManifest("x/foo"),
+				newManifest("x/bar", map[string]interface{}{"team": "cosmonauts", "name": "x/unknown", "version": "^1.0.0"}),
+			},
+			code: http.StatusBadRequest,
+		},
+		{
+			name: "dependency on a later module",
+			manifests: []map[string]interface{}{
+				newManifest("x/bar", map[string]interface{}{"team": "cosmonauts", "name": "x/foo", "version": "^1.0.0"}),
+				newManifest("x/foo"),
+			},
+			code: http.StatusBadRequest,
+		},
+		{
+			name: "valid manifests",
+			manifests: []map[string]interface{}{
+				newManifest("x/foo"),
+				newManifest("x/bar", map[string]interface{}{"team": "cosmonauts", "name": "x/foo", "version": "^1.0.0"}),
+			},
+			code: http.StatusOK,
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+
+		rts.Run(tc.name, func() {
+			bz, err := json.Marshal(map[string]interface{}{"manifests": tc.manifests})
+			rts.Require().NoError(err)
+
+			req.Method = httputil.MethodPUT
+			req.URL = upsertURL
+			req.Body = ioutil.NopCloser(bytes.NewBuffer(bz))
+			req.ContentLength = int64(len(bz))
+
+			rr := httptest.NewRecorder()
+			rts.mux.ServeHTTP(rr, req)
+			rts.Require().Equal(tc.code, rr.Code, rr.Body.String())
+
+			modules, _, err := models.GetAllModules(rts.router.db, httputil.PaginationQuery{Page: 1, Limit: 10, Order: "id"}, nil)
+			rts.Require().NoError(err)
+
+			if tc.code != http.StatusOK {
+				// ensure no module is published if any of them fails
+				rts.Require().Empty(modules)
+				return
+			}
+
+			var body []map[string]interface{}
+			rts.Require().NoError(json.Unmarshal(rr.Body.Bytes(), &body))
+			rts.Require().Len(body, 2)
+			rts.Require().Equal("x/foo", body[0]["name"])
+			rts.Require().Equal("x/bar", body[1]["name"])
+			rts.Require().Len(modules, 2)
+
+			deps, err := models.GetModuleDependencies(rts.router.db, modules[1].ID)
+			rts.Require().NoError(err)
+			rts.Require().Len(deps, 1)
+			rts.Require().Equal("x/foo", deps[0].DependencyName)
+		})
+	}
+}
+
+func (rts *RouterTestSuite) TestCreateModule_InvalidOwner() {
+	rts.resetDB()
+
+	req1, err := http.NewRequest("GET", "/", nil)
+	rts.Require().NoError(err)
+
+	req2, err := http.NewRequest("GET", "/", nil)
+	rts.Require().NoError(err)
+
+	req1 = rts.authorizeRequest(req1, "test_token1", "foo", 12345)
+	req2 = rts.authorizeRequest(req2, "test_token2", "test_user2", 67899)
+
+	upsertURL, err := url.Parse("/api/v1/modules")
+	rts.Require().NoError(err)
+
+	body := map[string]interface{}{
+		"module": map[string]interface{}{
+			"name":     "x/bank",
+			"team":     "cosmonauts",
+			"keywords": []string{"tokens"},
+		},
+		"authors": []map[string]interface{}{
+			{
+				"name": "foo", "email": "foo@email.com",
+			},
+		},
+		"version": map[string]interface{}{
+			"repo":          "https://github.com/cosmos/cosmos-sdk/releases/tag/v0.39.1",
+			"documentation": "https://raw.githubusercontent.com/cosmos/cosmos-sdk/v0.39.1/x/bank/README.md",
+			"version":       "v1.0.0",
+		},
+		"bug_tracker": map[string]interface{}{
+			"url":     "https://cosmonauts.com",
+			"contact": "contact@cosmonauts.com",
+		},
+	}
+
+	// create module published by test_user1
+	bz, err := json.Marshal(body)
+	rts.Require().NoError(err)
+
+	req1.Method = httputil.MethodPUT
+	req1.URL = upsertURL
+	req1.Body = ioutil.NopCloser(bytes.NewBuffer(bz))
+	req1.ContentLength = int64(len(bz))
+
+	rr := httptest.NewRecorder()
+	rts.mux.ServeHTTP(rr, req1)
+	rts.Require().Equal(http.StatusOK, rr.Code, rr.Body.String())
+
+	// attempt to update module published by test_user2
+	req2.Method = httputil.MethodPUT
+	req2.URL = upsertURL
+	req2.Body = ioutil.NopCloser(bytes.NewBuffer(bz))
+	req2.ContentLength = int64(len(bz))
+
+	rr = httptest.NewRecorder()
+	rts.mux.ServeHTTP(rr, req2)
+	rts.Require().Equal(http.StatusBadRequest, rr.Code, rr.Body.String())
+}
+
+func (rts *RouterTestSuite) TestCreateUserToken() {
+	rts.resetDB()
+
+	unAuthReq, err := http.NewRequest(httputil.MethodPUT, "/api/v1/me/tokens", nil)
+	rts.Require().NoError(err)
+
+	// unauthenticated
+	rr := httptest.NewRecorder()
+	rts.mux.ServeHTTP(rr, unAuthReq)
+	rts.Require().Equal(http.StatusUnauthorized, rr.Code, rr.Body.String())
+
+	// authenticated
+	req, err := http.NewRequest(httputil.MethodGET, "/", nil)
+	rts.Require().NoError(err)
+
+	req = rts.authorizeRequest(req, "test_token1", "test_user1", 123456)
+	req.Method = httputil.MethodPUT
+	req.URL = unAuthReq.URL
+
+	// require empty name fails
+	body := Token{Name: ""}
+	bz, err := json.Marshal(body)
+	rts.Require().NoError(err)
+
+	req.Body = ioutil.NopCloser(bytes.NewBuffer(bz))
+	req.ContentLength = int64(len(bz))
+
+	rr = httptest.NewRecorder()
+	rts.mux.ServeHTTP(rr, req)
+	rts.Require().Equal(http.StatusBadRequest, rr.Code, rr.Body.String())
+
+	for i := int64(0); i < MaxTokens; i++ {
+		body := Token{Name: fmt.Sprintf("token-%d", i+1)}
+		bz, err := json.Marshal(body)
+		rts.Require().NoError(err)
+
+		req.Body = ioutil.NopCloser(bytes.NewBuffer(bz))
+		req.ContentLength = int64(len(bz))
+
+		rr = httptest.NewRecorder()
+		rts.mux.ServeHTTP(rr, req)
+		rts.Require().Equal(http.StatusOK, rr.Code, rr.Body.String())
+
+		var ut map[string]interface{}
+		rts.Require().NoError(json.Unmarshal(rr.Body.Bytes(), &ut), rr.Body.String())
+		rts.Require().NotEmpty(ut["token"])
+		rts.Require().Equal(1, int(ut["user_id"].(float64)))
+	}
+
+	// max tokens reached
+	rr = httptest.NewRecorder()
+	rts.mux.ServeHTTP(rr, req)
+	rts.Require().Equal(http.StatusBadRequest, rr.Code, rr.Body.String())
+}
+
+func (rts *RouterTestSuite) TestGetUserTokens() {
+	rts.resetDB()
+
+	unAuthReq, err := http.NewRequest(httputil.MethodGET, "/api/v1/me/tokens", nil)
+	rts.Require().NoError(err)
+
+	// unauthenticated
+	rr := httptest.NewRecorder()
+	rts.mux.ServeHTTP(rr, unAuthReq)
+	rts.Require().Equal(http.StatusUnauthorized, rr.Code, rr.Body.String())
+
+	// authenticated
+	req, err := http.NewRequest(httputil.MethodGET, "/", nil)
+	rts.Require().NoError(err)
+
+	req = rts.authorizeRequest(req, "test_token1", "foo", 123456)
+	req.Method = httputil.MethodPUT
+	req.URL = unAuthReq.URL
+
+	for i := 0; i < 25; i++ {
+		body := Token{Name: fmt.Sprintf("token-%d", i+1)}
+		bz, err := json.Marshal(body)
+		rts.Require().NoError(err)
+
+		req.Body = ioutil.NopCloser(bytes.NewBuffer(bz))
+		req.ContentLength = int64(len(bz))
+
+		rr = httptest.NewRecorder()
+		rts.mux.ServeHTTP(rr, req)
+		rts.Require().Equal(http.StatusOK, rr.Code, rr.Body.String())
+
+		var ut map[string]interface{}
+		rts.Require().NoError(json.Unmarshal(rr.Body.Bytes(), &ut))
+		rts.Require().NotEmpty(ut["token"])
+		rts.Require().Equal(1, int(ut["user_id"].(float64)))
+	}
+
+	req.Method = httputil.MethodGET
+
+	rr = httptest.NewRecorder()
+	rts.mux.ServeHTTP(rr, req)
+	rts.Require().Equal(http.StatusOK, rr.Code, rr.Body.String())
+
+	var tokens []map[string]interface{}
+	rts.Require().NoError(json.Unmarshal(rr.Body.Bytes(), &tokens))
+	rts.Require().Len(tokens, 25)
+
+	// tokens are only identified by their prefix once created
+	for _, ut := range tokens {
+		rts.Require().NotContains(ut, "token")
+		rts.Require().Len(ut["prefix"], models.UserTokenPrefixLen)
+	}
+}
+
+func (rts *RouterTestSuite) TestUserTokenScopes() {
+	rts.resetDB()
+
+	req, err := http.NewRequest(httputil.MethodGET, "/", nil)
+	rts.Require().NoError(err)
+
+	req = rts.authorizeRequest(req, "test_token1", "foo", 12345)
+
+	modules := make([]models.Module, 2)
+	for i, name := range []string{"x/bank", "x/staking"} {
+		mod := models.Module{
+			Name:    name,
+			Team:    "cosmonauts",
+			Owners:  []models.User{{Name: "foo"}},
+			Authors: []models.User{{Name: "foo"}},
+			Version: models.ModuleVersion{
+				Version:       "v1.0.0",
+				Repo:          "https://github.com/cosmos/cosmos-sdk/releases/tag/v0.39.1",
+				Documentation: "https://raw.githubusercontent.com/cosmos/cosmos-sdk/v0.39.1/x/bank/README.md",
+			},
+		}
+
+		modules[i], err = mod.Upsert(rts.router.db)
+		rts.Require().NoError(err)
+	}
+
+	createToken := func(req *http.Request, body Token) (string, *httptest.ResponseRecorder) {
+		rr := rts.executeJSONRequest(req, httputil.MethodPUT, "/api/v1/me/tokens", body)
+		if rr.Code != http.StatusOK {
+			return "", rr
+		}
+
+		var ut map[string]interface{}
+		rts.Require().NoError(json.Unmarshal(rr.Body.Bytes(), &ut))
+
+		return ut["token"].(string), rr
+	}
+
+	bearerReq := func(token string) *http.Request {
+		req, err := http.NewRequest(httputil.MethodGET, "/", nil)
+		rts.Require().NoError(err)
+
+		req.Header.Set("Authorization", httputil.BearerSchema+token)
+		return req
+	}
+
+	yankPath := func(mod models.Module) string {
+		return fmt.Sprintf("/api/v1/modules/%d/versions/v1.0.0/yank", mod.ID)
+	}
+
+	// invalid scopes and expiries are rejected
+	_, rr := createToken(req, Token{Name: "invalid", Scopes: []string{"admin"}})
+	rts.Require().Equal(http.StatusBadRequest, rr.Code, rr.Body.String())
+
+	past := time.Now().Add(-time.Hour)
+	_, rr = createToken(req, Token{Name: "invalid", ExpiresAt: &past})
+	rts.Require().Equal(http.StatusBadRequest, rr.Code, rr.Body.String())
+
+	// a token granted publishing a single module may only publish that module
+	ciToken, rr := createToken(req, Token{Name: "ci", Scopes: []string{models.PublishModuleScope(modules[0].ID)}})
+	rts.Require().Equal(http.StatusOK, rr.Code, rr.Body.String())
+
+	ciReq := bearerReq(ciToken)
+
+	rr = rts.executeJSONRequest(ciReq, httputil.MethodPUT, yankPath(modules[0]), ModuleVersionYank{Reason: "broken"})
+	rts.Require().Equal(http.StatusOK, rr.Code, rr.Body.String())
+
+	rr = rts.executeJSONRequest(ciReq, httputil.MethodPUT, yankPath(modules[1]), ModuleVersionYank{Reason: "broken"})
+	rts.Require().Equal(http.StatusUnauthorized, rr.Code, rr.Body.String())
+
+	rr = rts.executeJSONRequest(ciReq, httputil.MethodGET, "/api/v1/me", nil)
+	rts.Require().Equal(http.StatusUnauthorized, rr.Code, rr.Body.String())
+
+	rr = rts.executeJSONRequest(ciReq, httputil.MethodGET, "/api/v1/me/tokens", nil)
+	rts.Require().Equal(http.StatusUnauthorized, rr.Code, rr.Body.String())
+
+	_, rr = createToken(ciReq, Token{Name: "escalate"})
+	rts.Require().Equal(http.StatusUnauthorized, rr.Code, rr.Body.String())
+
+	// a token may only create tokens with a subset of its scopes and expiry
+	expiry := time.Now().Add(time.Hour)
+	mgmtToken, rr := createToken(req, Token{
+		Name:      "mgmt",
+		Scopes:    []string{models.TokenScopeRead, models.TokenScopeTokens},
+		ExpiresAt: &expiry,
+	})
+	rts.Require().Equal(http.StatusOK, rr.Code, rr.Body.String())
+
+	mgmtReq := bearerReq(mgmtToken)
+
+	rr = rts.executeJSONRequest(mgmtReq, httputil.MethodGET, "/api/v1/me", nil)
+	rts.Require().Equal(http.StatusOK, rr.Code, rr.Body.String())
+
+	// a token without a publish scope is forbidden from publishing
+	rr = rts.executeJSONRequest(mgmtReq, httputil.MethodPUT, "/api/v1/modules", Manifest{})
+	rts.Require().Equal(http.StatusForbidden, rr.Code, rr.Body.String())
+
+	rr = rts.executeJSONRequest(mgmtReq, httputil.MethodPUT, "/api/v1/modules/batch", BatchManifest{})
+	rts.Require().Equal(http.StatusForbidden, rr.Code, rr.Body.String())
+
+	_, rr = createToken(mgmtReq, Token{Name: "publish", Scopes: []string{models.TokenScopePublish}})
+	rts.Require().Equal(http.StatusForbidden, rr.Code, rr.Body.String())
+
+	_, rr = createToken(mgmtReq, Token{Name: "read", Scopes: []string{models.TokenScopeRead}})
+	rts.Require().Equal(http.StatusForbidden, rr.Code, rr.Body.String())
+
+	sooner := expiry.Add(-time.Minute)
+	_, rr = createToken(mgmtReq, Token{Name: "read", Scopes: []string{models.TokenScopeRead}, ExpiresAt: &sooner})
+	rts.Require().Equal(http.StatusOK, rr.Code, rr.Body.String())
+
+	// an expired token is rejected
+	foo, err := models.QueryUser(rts.router.db, map[string]interface{}{"name": "foo"})
+	rts.Require().NoError(err)
+
+	expired, err := foo.CreateToken(rts.router.db, "expired", nil, models.NewNullTime(past))
+	rts.Require().NoError(err)
+
+	rr = rts.executeJSONRequest(bearerReq(expired.Token.String()), httputil.MethodGET, "/api/v1/me", nil)
+	rts.Require().Equal(http.StatusUnauthorized, rr.Code, rr.Body.String())
+}
+
+func (rts *RouterTestSuite) TestGetUserTokenUsage() {
+	rts.resetDB()
+
+	fooReq, err := http.NewRequest(httputil.MethodGET, "/", nil)
+	rts.Require().NoError(err)
+
+	fooReq = rts.authorizeRequest(fooReq, "test_token1", "foo", 12345)
+
+	barReq, err := http.NewRequest(httputil.MethodGET, "/", nil)
+	rts.Require().NoError(err)
+
+	barReq = rts.authorizeRequest(barReq, "test_token2", "bar", 67890)
+
+	rr := rts.executeJSONRequest(fooReq, httputil.MethodPUT, "/api/v1/me/tokens", Token{Name: "cli"})
+	rts.Require().Equal(http.StatusOK, rr.Code, rr.Body.String())
+
+	var ut map[string]interface{}
+	rts.Require().NoError(json.Unmarshal(rr.Body.Bytes(), &ut))
+	rts.Require().Nil(ut["last_used_at"])
+
+	usagePath := fmt.Sprintf("/api/v1/me/tokens/%d/usage?page=1&limit=10", int(ut["id"].(float64)))
+
+	// use the token twice
+	for i := 0; i < 2; i++ {
+		req, err := http.NewRequest(httputil.MethodGET, "/api/v1/me", nil)
+		rts.Require().NoError(err)
+
+		req.RemoteAddr = "192.0.2.1:54321"
+		req.Header.Set("Authorization", httputil.BearerSchema+ut["token"].(string))
+		req.Header.Set("User-Agent", "atlas-cli")
+
+		rr = rts.executeRequest(req)
+		rts.Require().Equal(http.StatusOK, rr.Code, rr.Body.String())
+	}
+
+	rr = rts.executeJSONRequest(fooReq, httputil.MethodGET, usagePath, nil)
+	rts.Require().Equal(http.StatusOK, rr.Code, rr.Body.String())
+
+	var resp httputil.PaginationResponse
+	rts.Require().NoError(json.Unmarshal(rr.Body.Bytes(), &resp))
+	rts.Require().Equal(int64(2), resp.Total)
+
+	usage := resp.Results.([]interface{})
+	rts.Require().Len(usage, 2)
+
+	for _, u := range usage {
+		u := u.(map[string]interface{})
+		rts.Require().Equal("192.0.2.1", u["ip"])
+		rts.Require().Equal("atlas-cli", u["user_agent"])
+		rts.Require().Equal(httputil.MethodGET, u["method"])
+		rts.Require().Equal("/api/v1/me", u["path"])
+	}
+
+	// the token's last use and count are updated
+	rr = rts.executeJSONRequest(fooReq, httputil.MethodGET, "/api/v1/me/tokens", nil)
+	rts.Require().Equal(http.StatusOK, rr.Code, rr.Body.String())
+
+	var tokens []map[string]interface{}
+	rts.Require().NoError(json.Unmarshal(rr.Body.Bytes(), &tokens))
+	rts.Require().Len(tokens, 1)
+	rts.Require().NotNil(tokens[0]["last_used_at"])
+	rts.Require().Equal(2, int(tokens[0]["count"].(float64)))
+
+	// other users cannot view the token's usage
+	rr = rts.executeJSONRequest(barReq, httputil.MethodGET, usagePath, nil)
+	rts.Require().Equal(http.StatusNotFound, rr.Code, rr.Body.String())
+}
+
+func (rts *RouterTestSuite) TestRevokeUserToken() {
+	rts.resetDB()
+
+	unAuthReq, err := http.NewRequest(httputil.MethodDELETE, "/api/v1/me/tokens/1", nil)
+	rts.Require().NoError(err)
+
+	// unauthenticated
+	rr := httptest.NewRecorder()
+	rts.mux.ServeHTTP(rr, unAuthReq)
+	rts.Require().Equal(http.StatusUnauthorized, rr.Code, rr.Body.String())
+
+	// authenticated
+	req, err := http.NewRequest(httputil.MethodGET, "/", nil)
+	rts.Require().NoError(err)
+
+	createURL, err := url.Parse("/api/v1/me/tokens")
+	rts.Require().NoError(err)
+
+	req = rts.authorizeRequest(req, "test_token1", "test_user1", 123456)
+	req.Method = httputil.MethodPUT
+	req.URL = createURL
+
+	for i := 0; i < 25; i++ {
+		body := Token{Name: fmt.Sprintf("token-%d", i+1)}
+		bz, err := json.Marshal(body)
+		rts.Require().NoError(err)
+
+		req.Body = ioutil.NopCloser(bytes.NewBuffer(bz))
+		req.ContentLength = int64(len(bz))
+
+		rr = httptest.NewRecorder()
+		rts.mux.ServeHTTP(rr, req)
+		rts.Require().Equal(http.StatusOK, rr.Code, rr.Body.String())
+
+		var ut map[string]interface{}
+		rts.Require().NoError(json.Unmarshal(rr.Body.Bytes(), &ut))
 		rts.Require().NotEmpty(ut["token"])
 		rts.Require().Equal(1, int(ut["user_id"].(float64)))
 	}
 
-	// max tokens reached
-	rr = httptest.NewRecorder()
-	rts.mux.ServeHTTP(rr, req)
-	rts.Require().Equal(http.StatusBadRequest, rr.Code, rr.Body.String())
-}
+	req.Method = httputil.MethodDELETE
+	req.URL = unAuthReq.URL
+
+	rr = httptest.NewRecorder()
+	rts.mux.ServeHTTP(rr, req)
+	rts.Require().Equal(http.StatusOK, rr.Code, rr.Body.String())
+
+	var ut map[string]interface{}
+	rts.Require().NoError(json.Unmarshal(rr.Body.Bytes(), &ut))
+	rts.Require().NotContains(ut, "token")
+	rts.Require().NotEmpty(ut["prefix"])
+	rts.Require().True(ut["revoked"].(bool))
+
+	// attempt to revoke an non-existent token
+	revokeURL, err := url.Parse("/api/v1/me/tokens/100")
+	rts.Require().NoError(err)
+
+	req.URL = revokeURL
+
+	rr = httptest.NewRecorder()
+	rts.mux.ServeHTTP(rr, req)
+	rts.Require().Equal(http.StatusNotFound, rr.Code, rr.Body.String())
+}
+
+func (rts *RouterTestSuite) TestGetUserByName() {
+	rts.resetDB()
+
+	req1, err := http.NewRequest("GET", "/", nil)
+	rts.Require().NoError(err)
+
+	req1 = rts.authorizeRequest(req1, "test_token1", "foo", 12345)
+
+	upsertURL, err := url.Parse("/api/v1/modules")
+	rts.Require().NoError(err)
+
+	body := map[string]interface{}{
+		"module": map[string]interface{}{
+			"name":     "x/bank",
+			"team":     "cosmonauts",
+			"keywords": []string{"tokens"},
+		},
+		"authors": []map[string]interface{}{
+			{
+				"name": "foo", "email": "foo@email.com",
+			},
+		},
+		"version": map[string]interface{}{
+			"repo":          "https://github.com/cosmos/cosmos-sdk/releases/tag/v0.39.1",
+			"documentation": "https://raw.githubusercontent.com/cosmos/cosmos-sdk/v0.39.1/x/bank/README.md",
+			"version":       "v1.0.0",
+		},
+		"bug_tracker": map[string]interface{}{
+			"url":     "https://cosmonauts.com",
+			"contact": "contact@cosmonauts.com",
+		},
+	}
+
+	// create module published by test_user1
+	bz, err := json.Marshal(body)
+	rts.Require().NoError(err)
+
+	req1.Method = httputil.MethodPUT
+	req1.URL = upsertURL
+	req1.Body = ioutil.NopCloser(bytes.NewBuffer(bz))
+	req1.ContentLength = int64(len(bz))
+
+	rr := httptest.NewRecorder()
+	rts.mux.ServeHTTP(rr, req1)
+	rts.Require().Equal(http.StatusOK, rr.Code, rr.Body.String())
+
+	// ensure the publishing user exists
+	req2, err := http.NewRequest("GET", "/api/v1/users/foo", nil)
+	rts.Require().NoError(err)
+
+	rr = httptest.NewRecorder()
+	rts.mux.ServeHTTP(rr, req2)
+	rts.Require().Equal(http.StatusOK, rr.Code, rr.Body.String())
+
+	var user map[string]interface{}
+	rts.Require().NoError(json.Unmarshal(rr.Body.Bytes(), &user))
+	rts.Require().Equal(user["name"], "foo")
+
+	// ensure a non-existent user returns an error
+	req3, err := http.NewRequest("GET", "/api/v1/users/bar", nil)
+	rts.Require().NoError(err)
+
+	rr = httptest.NewRecorder()
+	rts.mux.ServeHTTP(rr, req3)
+	rts.Require().Equal(http.StatusNotFound, rr.Code, rr.Body.String())
+}
+
+func (rts *RouterTestSuite) TestGetUser() {
+	rts.resetDB()
+
+	req1, err := http.NewRequest("GET", "/", nil)
+	rts.Require().NoError(err)
+
+	req1 = rts.authorizeRequest(req1, "test_token1", "foo", 12345)
+
+	upsertURL, err := url.Parse("/api/v1/modules")
+	rts.Require().NoError(err)
+
+	body := map[string]interface{}{
+		"module": map[string]interface{}{
+			"name":     "x/bank",
+			"team":     "cosmonauts",
+			"keywords": []string{"tokens"},
+		},
+		"authors": []map[string]interface{}{
+			{
+				"name": "foo", "email": "foo@email.com",
+			},
+		},
+		"version": map[string]interface{}{
+			"repo":          "https://github.com/cosmos/cosmos-sdk/releases/tag/v0.39.1",
+			"documentation": "https://raw.githubusercontent.com/cosmos/cosmos-sdk/v0.39.1/x/bank/README.md",
+			"version":       "v1.0.0",
+		},
+		"bug_tracker": map[string]interface{}{
+			"url":     "https://cosmonauts.com",
+			"contact": "contact@cosmonauts.com",
+		},
+	}
+
+	// create module published by test_user1
+	bz, err := json.Marshal(body)
+	rts.Require().NoError(err)
+
+	req1.Method = httputil.MethodPUT
+	req1.URL = upsertURL
+	req1.Body = ioutil.NopCloser(bytes.NewBuffer(bz))
+	req1.ContentLength = int64(len(bz))
+
+	rr := httptest.NewRecorder()
+	rts.mux.ServeHTTP(rr, req1)
+	rts.Require().Equal(http.StatusOK, rr.Code, rr.Body.String())
+
+	// get the authenticated user
+	getUserURL, err := url.Parse("/api/v1/me")
+	rts.Require().NoError(err)
+
+	req1.Method = httputil.MethodGET
+	req1.URL = getUserURL
+
+	rr = httptest.NewRecorder()
+	rts.mux.ServeHTTP(rr, req1)
+	rts.Require().Equal(http.StatusOK, rr.Code, rr.Body.String())
+
+	// create a new unauthenticated request and ensure the request fails
+	req2, err := http.NewRequest("GET", getUserURL.String(), nil)
+	rts.Require().NoError(err)
+
+	rr = httptest.NewRecorder()
+	rts.mux.ServeHTTP(rr, req2)
+	rts.Require().Equal(http.StatusUnauthorized, rr.Code, rr.Body.String())
+}
+
+func (rts *RouterTestSuite) TestUpdateUser() {
+	rts.resetDB()
+
+	req1, err := http.NewRequest("GET", "/", nil)
+	rts.Require().NoError(err)
+
+	req1 = rts.authorizeRequest(req1, "test_token1", "foo", 12345)
+
+	upsertURL, err := url.Parse("/api/v1/modules")
+	rts.Require().NoError(err)
+
+	body := map[string]interface{}{
+		"module": map[string]interface{}{
+			"name":     "x/bank",
+			"team":     "cosmonauts",
+			"keywords": []string{"tokens"},
+		},
+		"authors": []map[string]interface{}{
+			{
+				"name": "foo", "email": "foo@email.com",
+			},
+		},
+		"version": map[string]interface{}{
+			"repo":          "https://github.com/cosmos/cosmos-sdk/releases/tag/v0.39.1",
+			"documentation": "https://raw.githubusercontent.com/cosmos/cosmos-sdk/v0.39.1/x/bank/README.md",
+			"version":       "v1.0.0",
+		},
+		"bug_tracker": map[string]interface{}{
+			"url":     "https://cosmonauts.com",
+			"contact": "contact@cosmonauts.com",
+		},
+	}
+
+	// create module published by test_user1
+	bz, err := json.Marshal(body)
+	rts.Require().NoError(err)
+
+	req1.Method = httputil.MethodPUT
+	req1.URL = upsertURL
+	req1.Body = ioutil.NopCloser(bytes.NewBuffer(bz))
+	req1.ContentLength = int64(len(bz))
+
+	rr := httptest.NewRecorder()
+	rts.mux.ServeHTTP(rr, req1)
+	rts.Require().Equal(http.StatusOK, rr.Code, rr.Body.String())
+
+	// update the authenticated user
+	updateUserURL, err := url.Parse("/api/v1/me")
+	rts.Require().NoError(err)
+
+	body = map[string]interface{}{
+		"email": "newfoo@email.com",
+	}
+	bz, err = json.Marshal(body)
+	rts.Require().NoError(err)
+
+	req1.Method = httputil.MethodPUT
+	req1.URL = updateUserURL
+	req1.Body = ioutil.NopCloser(bytes.NewBuffer(bz))
+	req1.ContentLength = int64(len(bz))
+
+	rr = httptest.NewRecorder()
+	rts.mux.ServeHTTP(rr, req1)
+	rts.Require().Equal(http.StatusOK, rr.Code, rr.Body.String())
+
+	var result bool
+	rts.Require().NoError(json.Unmarshal(rr.Body.Bytes(), &result))
+	rts.Require().True(result)
+
+	// ensure an invalid request fails
+	body = map[string]interface{}{
+		"email": "newfoo",
+	}
+	bz, err = json.Marshal(body)
+	rts.Require().NoError(err)
+
+	req1.Method = httputil.MethodPUT
+	req1.URL = updateUserURL
+	req1.Body = ioutil.NopCloser(bytes.NewBuffer(bz))
+	req1.ContentLength = int64(len(bz))
+
+	rr = httptest.NewRecorder()
+	rts.mux.ServeHTTP(rr, req1)
+	rts.Require().Equal(http.StatusBadRequest, rr.Code, rr.Body.String())
+
+	// ensure an unauthenticated request fails
+	req2, err := http.NewRequest(httputil.MethodPUT, updateUserURL.String(), ioutil.NopCloser(bytes.NewBuffer(bz)))
+	rts.Require().NoError(err)
+
+	rr = httptest.NewRecorder()
+	rts.mux.ServeHTTP(rr, req2)
+	rts.Require().Equal(http.StatusUnauthorized, rr.Code, rr.Body.String())
+}
+
+func (rts *RouterTestSuite) TestConfirmEmail() {
+	rts.resetDB()
+
+	req1, err := http.NewRequest("GET", "/", nil)
+	rts.Require().NoError(err)
+
+	req1 = rts.authorizeRequest(req1, "test_token1", "foo", 12345)
+
+	upsertURL, err := url.Parse("/api/v1/modules")
+	rts.Require().NoError(err)
+
+	body := map[string]interface{}{
+		"module": map[string]interface{}{
+			"name":     "x/bank",
+			"team":     "cosmonauts",
+			"keywords": []string{"tokens"},
+		},
+		"authors": []map[string]interface{}{
+			{
+				"name": "foo", "email": "foo@email.com",
+			},
+		},
+		"version": map[string]interface{}{
+			"repo":          "https://github.com/cosmos/cosmos-sdk/releases/tag/v0.39.1",
+			"documentation": "https://raw.githubusercontent.com/cosmos/cosmos-sdk/v0.39.1/x/bank/README.md",
+			"version":       "v1.0.0",
+		},
+		"bug_tracker": map[string]interface{}{
+			"url":     "https://cosmonauts.com",
+			"contact": "contact@cosmonauts.com",
+		},
+	}
+
+	// create module published by test_user1
+	bz, err := json.Marshal(body)
+	rts.Require().NoError(err)
+
+	req1.Method = httputil.MethodPUT
+	req1.URL = upsertURL
+	req1.Body = ioutil.NopCloser(bytes.NewBuffer(bz))
+	req1.ContentLength = int64(len(bz))
+
+	rr := httptest.NewRecorder()
+	rts.mux.ServeHTTP(rr, req1)
+	rts.Require().Equal(http.StatusOK, rr.Code, rr.Body.String())
+
+	// update the authenticated user
+	updateUserURL, err := url.Parse("/api/v1/me")
+	rts.Require().NoError(err)
+
+	body = map[string]interface{}{
+		"email": "bar@email.com",
+	}
+	bz, err = json.Marshal(body)
+	rts.Require().NoError(err)
+
+	req1.Method = httputil.MethodPUT
+	req1.URL = updateUserURL
+	req1.Body = ioutil.NopCloser(bytes.NewBuffer(bz))
+	req1.ContentLength = int64(len(bz))
+
+	rr = httptest.NewRecorder()
+	rts.mux.ServeHTTP(rr, req1)
+	rts.Require().Equal(http.StatusOK, rr.Code, rr.Body.String())
+
+	var result bool
+	rts.Require().NoError(json.Unmarshal(rr.Body.Bytes(), &result))
+	rts.Require().True(result)
+
+	// make a request with an invalid token
+	confirmEmailURL, err := url.Parse("/api/v1/me/confirm/badtoken")
+	rts.Require().NoError(err)
+
+	req1.URL = confirmEmailURL
+
+	rr = httptest.NewRecorder()
+	rts.mux.ServeHTTP(rr, req1)
+	rts.Require().Equal(http.StatusNotFound, rr.Code, rr.Body.String())
+
+	// get the valid token
+	uec, err := models.QueryUserEmailConfirmation(rts.router.db, map[string]interface{}{"user_id": 1})
+	rts.Require().NoError(err)
+
+	// make a request with the valid token
+	token := uec.Token
+	confirmEmailURL, err = url.Parse(fmt.Sprintf("/api/v1/me/confirm/%s", token))
+	rts.Require().NoError(err)
+
+	req1.URL = confirmEmailURL
+
+	rr = httptest.NewRecorder()
+	rts.mux.ServeHTTP(rr, req1)
+	rts.Require().Equal(http.StatusOK, rr.Code, rr.Body.String())
+}
+
+func (rts *RouterTestSuite) TestInviteModuleOwner() {
+	rts.resetDB()
+
+	mod := models.Module{
+		Name: "x/bank",
+		Team: "cosmonauts",
+		Owners: []models.User{
+			{Name: "foo", Email: models.NewNullString("foo@email.com")},
+		},
+		Authors: []models.User{
+			{Name: "bar", Email: models.NewNullString("bar@email.com")},
+		},
+		Version: models.ModuleVersion{
+			Version:       "v1.0.0",
+			Repo:          "https://github.com/cosmos/cosmos-sdk/releases/tag/v0.39.1",
+			Documentation: "https://raw.githubusercontent.com/cosmos/cosmos-sdk/v0.39.1/x/bank/README.md",
+		},
+		Keywords: []models.Keyword{
+			{Name: "tokens"}, {Name: "transfer"},
+		},
+		BugTracker: models.BugTracker{
+			URL:     models.NewNullString("cosmonauts.com"),
+			Contact: models.NewNullString("contact@cosmonauts.com"),
+		},
+	}
+
+	mod, err := mod.Upsert(rts.router.db)
+	rts.Require().NoError(err)
+
+	req1, err := http.NewRequest("GET", "/", nil)
+	rts.Require().NoError(err)
+
+	req1 = rts.authorizeRequest(req1, "test_token1", "foo", 12345)
+
+	inviteURL, err := url.Parse("/api/v1/me/invite")
+	rts.Require().NoError(err)
+
+	body := map[string]interface{}{
+		"module_id": mod.ID,
+		"user":      mod.Owners[0].Name,
+	}
+
+	bz, err := json.Marshal(body)
+	rts.Require().NoError(err)
+
+	req1.Method = httputil.MethodPUT
+	req1.URL = inviteURL
+	req1.Body = ioutil.NopCloser(bytes.NewBuffer(bz))
+	req1.ContentLength = int64(len(bz))
+
+	// assert failure due to invitee already being an owner
+	rr := httptest.NewRecorder()
+	rts.mux.ServeHTTP(rr, req1)
+	rts.Require().Equal(http.StatusBadRequest, rr.Code, rr.Body.String())
+
+	body = map[string]interface{}{
+		"module_id": mod.ID,
+		"user":      mod.Authors[0].Name,
+	}
+
+	bz, err = json.Marshal(body)
+	rts.Require().NoError(err)
+
+	req1.Body = ioutil.NopCloser(bytes.NewBuffer(bz))
+	req1.ContentLength = int64(len(bz))
+
+	// assert failure due to invitee unconfirmed email
+	rr = httptest.NewRecorder()
+	rts.mux.ServeHTTP(rr, req1)
+	rts.Require().Equal(http.StatusBadRequest, rr.Code, rr.Body.String())
+
+	// confirm email
+	author, err := models.GetUserByID(rts.router.db, mod.Authors[0].ID)
+	rts.Require().NoError(err)
+
+	author.EmailConfirmed = true
+	author, err = author.Upsert(rts.router.db)
+	rts.Require().NoError(err)
+
+	// assert successful invitation
+	req1.Body = ioutil.NopCloser(bytes.NewBuffer(bz))
+	req1.ContentLength = int64(len(bz))
+
+	rr = httptest.NewRecorder()
+	rts.mux.ServeHTTP(rr, req1)
+	rts.Require().Equal(http.StatusOK, rr.Code, rr.Body.String())
+}
+
+func (rts *RouterTestSuite) TestAcceptOwnerInvite() {
+	rts.resetDB()
+
+	mod := models.Module{
+		Name: "x/bank",
+		Team: "cosmonauts",
+		Owners: []models.User{
+			{Name: "foo", Email: models.NewNullString("foo@email.com")},
+		},
+		Authors: []models.User{
+			{Name: "bar", Email: models.NewNullString("bar@email.com")},
+		},
+		Version: models.ModuleVersion{
+			Version:       "v1.0.0",
+			Repo:          "https://github.com/cosmos/cosmos-sdk/releases/tag/v0.39.1",
+			Documentation: "https://raw.githubusercontent.com/cosmos/cosmos-sdk/v0.39.1/x/bank/README.md",
+		},
+		Keywords: []models.Keyword{
+			{Name: "tokens"}, {Name: "transfer"},
+		},
+		BugTracker: models.BugTracker{
+			URL:     models.NewNullString("cosmonauts.com"),
+			Contact: models.NewNullString("contact@cosmonauts.com"),
+		},
+	}
+
+	mod, err := mod.Upsert(rts.router.db)
+	rts.Require().NoError(err)
 
-func (rts *RouterTestSuite) TestGetUserTokens() {
-	rts.resetDB()
+	// confirm email
+	author, err := models.GetUserByID(rts.router.db, mod.Authors[0].ID)
+	rts.Require().NoError(err)
 
-	unAuthReq, err := http.NewRequest(httputil.MethodGET, "/api/v1/me/tokens", nil)
+	author.EmailConfirmed = true
+	author, err = author.Upsert(rts.router.db)
 	rts.Require().NoError(err)
 
-	// unauthenticated
+	req1, err := http.NewRequest("GET", "/", nil)
+	rts.Require().NoError(err)
+
+	req1 = rts.authorizeRequest(req1, "test_token1", "foo", 1)
+
+	inviteURL, err := url.Parse("/api/v1/me/invite")
+	rts.Require().NoError(err)
+
+	body := map[string]interface{}{
+		"module_id": mod.ID,
+		"user":      mod.Authors[0].Name,
+	}
+
+	bz, err := json.Marshal(body)
+	rts.Require().NoError(err)
+
+	req1.Method = httputil.MethodPUT
+	req1.URL = inviteURL
+	req1.Body = ioutil.NopCloser(bytes.NewBuffer(bz))
+	req1.ContentLength = int64(len(bz))
+
 	rr := httptest.NewRecorder()
-	rts.mux.ServeHTTP(rr, unAuthReq)
-	rts.Require().Equal(http.StatusUnauthorized, rr.Code, rr.Body.String())
+	rts.mux.ServeHTTP(rr, req1)
+	rts.Require().Equal(http.StatusOK, rr.Code, rr.Body.String())
 
-	// authenticated
-	req, err := http.NewRequest(httputil.MethodGET, "/", nil)
+	// make a request with an invalid token
+	acceptInviteURL, err := url.Parse("/api/v1/me/invite/accept/badtoken")
 	rts.Require().NoError(err)
 
-	req = rts.authorizeRequest(req, "test_token1", "foo", 123456)
-	req.Method = httputil.MethodPUT
-	req.URL = unAuthReq.URL
+	req1.URL = acceptInviteURL
 
-	for i := 0; i < 25; i++ {
-		body := Token{Name: fmt.Sprintf("token-%d", i+1)}
-		bz, err := json.Marshal(body)
-		rts.Require().NoError(err)
+	rr = httptest.NewRecorder()
+	rts.mux.ServeHTTP(rr, req1)
+	rts.Require().Equal(http.StatusNotFound, rr.Code, rr.Body.String())
 
-		req.Body = ioutil.NopCloser(bytes.NewBuffer(bz))
-		req.ContentLength = int64(len(bz))
+	// get the valid token
+	moi, err := models.QueryModuleOwnerInvite(rts.router.db, map[string]interface{}{"invited_user_id": mod.Authors[0].ID})
+	rts.Require().NoError(err)
 
-		rr = httptest.NewRecorder()
-		rts.mux.ServeHTTP(rr, req)
-		rts.Require().Equal(http.StatusOK, rr.Code, rr.Body.String())
+	// make a request with the valid token
+	token := moi.Token
+	acceptInviteURL, err = url.Parse(fmt.Sprintf("/api/v1/me/invite/accept/%s", token))
+	rts.Require().NoError(err)
 
-		var ut map[string]interface{}
-		rts.Require().NoError(json.Unmarshal(rr.Body.Bytes(), &ut))
-		rts.Require().NotEmpty(ut["token"])
-		rts.Require().Equal(1, int(ut["user_id"].(float64)))
-	}
+	req2, err := http.NewRequest("GET", "/", nil)
+	rts.Require().NoError(err)
 
-	req.Method = httputil.MethodGET
+	req2 = rts.authorizeRequest(req2, "test_token2", "bar", int64(mod.Authors[0].ID))
+
+	req2.Method = httputil.MethodPUT
+	req2.URL = acceptInviteURL
+	req2.Body = ioutil.NopCloser(bytes.NewBuffer(bz))
+	req2.ContentLength = int64(len(bz))
 
 	rr = httptest.NewRecorder()
-	rts.mux.ServeHTTP(rr, req)
+	rts.mux.ServeHTTP(rr, req2)
 	rts.Require().Equal(http.StatusOK, rr.Code, rr.Body.String())
-
-	var tokens []map[string]interface{}
-	rts.Require().NoError(json.Unmarshal(rr.Body.Bytes(), &tokens))
-	rts.Require().Len(tokens, 25)
 }
 
-func (rts *RouterTestSuite) TestRevokeUserToken() {
+func (rts *RouterTestSuite) TestManageOwnerInvites() {
 	rts.resetDB()
 
-	unAuthReq, err := http.NewRequest(httputil.MethodDELETE, "/api/v1/me/tokens/1", nil)
+	fooReq, err := http.NewRequest("GET", "/", nil)
 	rts.Require().NoError(err)
 
-	// unauthenticated
-	rr := httptest.NewRecorder()
-	rts.mux.ServeHTTP(rr, unAuthReq)
-	rts.Require().Equal(http.StatusUnauthorized, rr.Code, rr.Body.String())
+	fooReq = rts.authorizeRequest(fooReq, "test_token1", "foo", 12345)
 
-	// authenticated
-	req, err := http.NewRequest(httputil.MethodGET, "/", nil)
+	barReq, err := http.NewRequest("GET", "/", nil)
 	rts.Require().NoError(err)
 
-	createURL, err := url.Parse("/api/v1/me/tokens")
+	barReq = rts.authorizeRequest(barReq, "test_token2", "bar", 67890)
+
+	mod := models.Module{
+		Name:    "x/bank",
+		Team:    "cosmonauts",
+		Owners:  []models.User{{Name: "foo"}},
+		Authors: []models.User{{Name: "foo"}},
+		Version: models.ModuleVersion{
+			Version:       "v1.0.0",
+			Repo:          "https://github.com/cosmos/cosmos-sdk/releases/tag/v0.39.1",
+			Documentation: "https://raw.githubusercontent.com/cosmos/cosmos-sdk/v0.39.1/x/bank/README.md",
+		},
+	}
+
+	mod, err = mod.Upsert(rts.router.db)
 	rts.Require().NoError(err)
 
-	req = rts.authorizeRequest(req, "test_token1", "test_user1", 123456)
-	req.Method = httputil.MethodPUT
-	req.URL = createURL
+	bar, err := models.QueryUser(rts.router.db, map[string]interface{}{"name": "bar"})
+	rts.Require().NoError(err)
 
-	for i := 0; i < 25; i++ {
-		body := Token{Name: fmt.Sprintf("token-%d", i+1)}
-		bz, err := json.Marshal(body)
-		rts.Require().NoError(err)
+	bar.EmailConfirmed = true
+	_, err = bar.Upsert(rts.router.db)
+	rts.Require().NoError(err)
 
-		req.Body = ioutil.NopCloser(bytes.NewBuffer(bz))
-		req.ContentLength = int64(len(bz))
+	invite := map[string]interface{}{"module_id": mod.ID, "user": "bar"}
+	invitePath := fmt.Sprintf("/api/v1/modules/%d/invites/bar", mod.ID)
 
-		rr = httptest.NewRecorder()
-		rts.mux.ServeHTTP(rr, req)
+	getInvites := func(req *http.Request, path string) []models.ModuleOwnerInviteJSON {
+		rr := rts.executeJSONRequest(req, httputil.MethodGET, path, nil)
 		rts.Require().Equal(http.StatusOK, rr.Code, rr.Body.String())
 
-		var ut map[string]interface{}
-		rts.Require().NoError(json.Unmarshal(rr.Body.Bytes(), &ut))
-		rts.Require().NotEmpty(ut["token"])
-		rts.Require().Equal(1, int(ut["user_id"].(float64)))
+		var invites []models.ModuleOwnerInviteJSON
+		rts.Require().NoError(json.Unmarshal(rr.Body.Bytes(), &invites))
+
+		return invites
 	}
 
-	req.Method = httputil.MethodDELETE
-	req.URL = unAuthReq.URL
+	rr := rts.executeJSONRequest(fooReq, httputil.MethodPUT, "/api/v1/me/invite", invite)
+	rts.Require().Equal(http.StatusOK, rr.Code, rr.Body.String())
 
-	rr = httptest.NewRecorder()
-	rts.mux.ServeHTTP(rr, req)
+	// the invitee lists the invitation along with its token
+	received := getInvites(barReq, "/api/v1/me/invites")
+	rts.Require().Len(received, 1)
+	rts.Require().Equal("x/bank", received[0].Module)
+	rts.Require().Equal("foo", received[0].InvitedBy)
+	rts.Require().NotNil(received[0].Token)
+
+	// the inviter lists the invitation without its token
+	sent := getInvites(fooReq, "/api/v1/me/invites/sent")
+	rts.Require().Len(sent, 1)
+	rts.Require().Equal("bar", sent[0].InvitedUser)
+	rts.Require().Nil(sent[0].Token)
+
+	rts.Require().Empty(getInvites(barReq, "/api/v1/me/invites/sent"))
+
+	// only the inviter may resend or revoke the invitation
+	rr = rts.executeJSONRequest(barReq, httputil.MethodPUT, invitePath+"/resend", nil)
+	rts.Require().Equal(http.StatusNotFound, rr.Code, rr.Body.String())
+
+	rr = rts.executeJSONRequest(barReq, httputil.MethodDELETE, invitePath, nil)
+	rts.Require().Equal(http.StatusNotFound, rr.Code, rr.Body.String())
+
+	// resending regenerates the token
+	rr = rts.executeJSONRequest(fooReq, httputil.MethodPUT, invitePath+"/resend", nil)
 	rts.Require().Equal(http.StatusOK, rr.Code, rr.Body.String())
 
-	var ut map[string]interface{}
-	rts.Require().NoError(json.Unmarshal(rr.Body.Bytes(), &ut))
-	rts.Require().NotEmpty(ut["token"])
-	rts.Require().True(ut["revoked"].(bool))
+	resent := getInvites(barReq, "/api/v1/me/invites")
+	rts.Require().Len(resent, 1)
+	rts.Require().NotEqual(received[0].Token, resent[0].Token)
 
-	// attempt to revoke an non-existent token
-	revokeURL, err := url.Parse("/api/v1/me/tokens/100")
-	rts.Require().NoError(err)
+	// the invitee declines the invitation
+	rr = rts.executeJSONRequest(barReq, httputil.MethodPUT, fmt.Sprintf("/api/v1/me/invite/decline/%s", received[0].Token), nil)
+	rts.Require().Equal(http.StatusNotFound, rr.Code, rr.Body.String())
 
-	req.URL = revokeURL
+	rr = rts.executeJSONRequest(barReq, httputil.MethodPUT, fmt.Sprintf("/api/v1/me/invite/decline/%s", resent[0].Token), nil)
+	rts.Require().Equal(http.StatusOK, rr.Code, rr.Body.String())
+	rts.Require().Empty(getInvites(barReq, "/api/v1/me/invites"))
 
-	rr = httptest.NewRecorder()
-	rts.mux.ServeHTTP(rr, req)
+	// the inviter revokes the invitation
+	rr = rts.executeJSONRequest(fooReq, httputil.MethodPUT, "/api/v1/me/invite", invite)
+	rts.Require().Equal(http.StatusOK, rr.Code, rr.Body.String())
+
+	rr = rts.executeJSONRequest(fooReq, httputil.MethodDELETE, invitePath, nil)
+	rts.Require().Equal(http.StatusOK, rr.Code, rr.Body.String())
+	rts.Require().Empty(getInvites(fooReq, "/api/v1/me/invites/sent"))
+
+	rr = rts.executeJSONRequest(fooReq, httputil.MethodDELETE, invitePath, nil)
 	rts.Require().Equal(http.StatusNotFound, rr.Code, rr.Body.String())
 }
 
-func (rts *RouterTestSuite) TestGetUserByName() {
+func (rts *RouterTestSuite) TestOwnerInvites_FormerOwner() {
 	rts.resetDB()
 
-	req1, err := http.NewRequest("GET", "/", nil)
+	fooReq, err := http.NewRequest("GET", "/", nil)
 	rts.Require().NoError(err)
 
-	req1 = rts.authorizeRequest(req1, "test_token1", "foo", 12345)
+	fooReq = rts.authorizeRequest(fooReq, "test_token1", "foo", 12345)
 
-	upsertURL, err := url.Parse("/api/v1/modules")
+	barReq, err := http.NewRequest("GET", "/", nil)
 	rts.Require().NoError(err)
 
-	body := map[string]interface{}{
-		"module": map[string]interface{}{
-			"name":     "x/bank",
-			"team":     "cosmonauts",
-			"keywords": []string{"tokens"},
-		},
-		"authors": []map[string]interface{}{
-			{
-				"name": "foo", "email": "foo@email.com",
-			},
-		},
-		"version": map[string]interface{}{
-			"repo":          "https://github.com/cosmos/cosmos-sdk/releases/tag/v0.39.1",
-			"documentation": "https://raw.githubusercontent.com/cosmos/cosmos-sdk/v0.39.1/x/bank/README.md",
-			"version":       "v1.0.0",
-		},
-		"bug_tracker": map[string]interface{}{
-			"url":     "https://cosmonauts.com",
-			"contact": "contact@cosmonauts.com",
+	barReq = rts.authorizeRequest(barReq, "test_token2", "bar", 67890)
+
+	bazReq, err := http.NewRequest("GET", "/", nil)
+	rts.Require().NoError(err)
+
+	bazReq = rts.authorizeRequest(bazReq, "test_token3", "baz", 13579)
+
+	mod := models.Module{
+		Name:    "x/bank",
+		Team:    "cosmonauts",
+		Owners:  []models.User{{Name: "foo"}},
+		Authors: []models.User{{Name: "foo"}},
+		Version: models.ModuleVersion{
+			Version:       "v1.0.0",
+			Repo:          "https://github.com/cosmos/cosmos-sdk/releases/tag/v0.39.1",
+			Documentation: "https://raw.githubusercontent.com/cosmos/cosmos-sdk/v0.39.1/x/bank/README.md",
 		},
 	}
 
-	// create module published by test_user1
-	bz, err := json.Marshal(body)
+	mod, err = mod.Upsert(rts.router.db)
 	rts.Require().NoError(err)
 
-	req1.Method = httputil.MethodPUT
-	req1.URL = upsertURL
-	req1.Body = ioutil.NopCloser(bytes.NewBuffer(bz))
-	req1.ContentLength = int64(len(bz))
+	bar, err := models.QueryUser(rts.router.db, map[string]interface{}{"name": "bar"})
+	rts.Require().NoError(err)
 
-	rr := httptest.NewRecorder()
-	rts.mux.ServeHTTP(rr, req1)
-	rts.Require().Equal(http.StatusOK, rr.Code, rr.Body.String())
+	baz, err := models.QueryUser(rts.router.db, map[string]interface{}{"name": "baz"})
+	rts.Require().NoError(err)
 
-	// ensure the publishing user exists
-	req2, err := http.NewRequest("GET", "/api/v1/users/foo", nil)
+	baz.EmailConfirmed = true
+	baz, err = baz.Upsert(rts.router.db)
 	rts.Require().NoError(err)
 
-	rr = httptest.NewRecorder()
-	rts.mux.ServeHTTP(rr, req2)
+	// an invitation sent by a user who is not an owner can neither be resent nor
+	// accepted
+	moi, err := models.ModuleOwnerInvite{ModuleID: mod.ID, InvitedByUserID: bar.ID, InvitedUserID: baz.ID}.Upsert(rts.router.db)
+	rts.Require().NoError(err)
+
+	rr := rts.executeJSONRequest(barReq, httputil.MethodPUT, fmt.Sprintf("/api/v1/modules/%d/invites/baz/resend", mod.ID), nil)
+	rts.Require().Equal(http.StatusForbidden, rr.Code, rr.Body.String())
+
+	rr = rts.executeJSONRequest(bazReq, httputil.MethodPUT, fmt.Sprintf("/api/v1/me/invite/accept/%s", moi.Token), nil)
+	rts.Require().Equal(http.StatusGone, rr.Code, rr.Body.String())
+
+	mod, err = models.GetModuleByID(rts.router.db, mod.ID)
+	rts.Require().NoError(err)
+	rts.Require().False(mod.IsOwner(baz.ID))
+
+	rr = rts.executeJSONRequest(barReq, httputil.MethodDELETE, fmt.Sprintf("/api/v1/modules/%d/invites/baz", mod.ID), nil)
 	rts.Require().Equal(http.StatusOK, rr.Code, rr.Body.String())
 
-	var user map[string]interface{}
-	rts.Require().NoError(json.Unmarshal(rr.Body.Bytes(), &user))
-	rts.Require().Equal(user["name"], "foo")
+	// only an owner may invite owners
+	invite := map[string]interface{}{"module_id": mod.ID, "user": "baz"}
 
-	// ensure a non-existent user returns an error
-	req3, err := http.NewRequest("GET", "/api/v1/users/bar", nil)
+	rr = rts.executeJSONRequest(barReq, httputil.MethodPUT, "/api/v1/me/invite", invite)
+	rts.Require().Equal(http.StatusForbidden, rr.Code, rr.Body.String())
+
+	rr = rts.executeJSONRequest(fooReq, httputil.MethodPUT, "/api/v1/me/invite", invite)
+	rts.Require().Equal(http.StatusOK, rr.Code, rr.Body.String())
+
+	moi, err = models.QueryModuleOwnerInvite(rts.router.db, map[string]interface{}{"invited_user_id": baz.ID})
 	rts.Require().NoError(err)
 
-	rr = httptest.NewRecorder()
-	rts.mux.ServeHTTP(rr, req3)
-	rts.Require().Equal(http.StatusNotFound, rr.Code, rr.Body.String())
+	rr = rts.executeJSONRequest(bazReq, httputil.MethodPUT, fmt.Sprintf("/api/v1/me/invite/accept/%s", moi.Token), nil)
+	rts.Require().Equal(http.StatusOK, rr.Code, rr.Body.String())
 }
 
-func (rts *RouterTestSuite) TestGetUser() {
+func (rts *RouterTestSuite) TestAcceptOwnerInvite_TTL() {
 	rts.resetDB()
 
-	req1, err := http.NewRequest("GET", "/", nil)
+	k := koanf.New(".")
+	rts.Require().NoError(k.Load(confmap.Provider(map[string]interface{}{config.InviteTTL: "1ms"}, "."), nil))
+
+	cfg := rts.router.cfg
+	rts.router.cfg = k
+	defer func() { rts.router.cfg = cfg }()
+
+	fooReq, err := http.NewRequest("GET", "/", nil)
 	rts.Require().NoError(err)
 
-	req1 = rts.authorizeRequest(req1, "test_token1", "foo", 12345)
+	fooReq = rts.authorizeRequest(fooReq, "test_token1", "foo", 12345)
 
-	upsertURL, err := url.Parse("/api/v1/modules")
+	barReq, err := http.NewRequest("GET", "/", nil)
 	rts.Require().NoError(err)
 
-	body := map[string]interface{}{
-		"module": map[string]interface{}{
-			"name":     "x/bank",
-			"team":     "cosmonauts",
-			"keywords": []string{"tokens"},
-		},
-		"authors": []map[string]interface{}{
-			{
-				"name": "foo", "email": "foo@email.com",
-			},
-		},
-		"version": map[string]interface{}{
-			"repo":          "https://github.com/cosmos/cosmos-sdk/releases/tag/v0.39.1",
-			"documentation": "https://raw.githubusercontent.com/cosmos/cosmos-sdk/v0.39.1/x/bank/README.md",
-			"version":       "v1.0.0",
-		},
-		"bug_tracker": map[string]interface{}{
-			"url":     "https://cosmonauts.com",
-			"contact": "contact@cosmonauts.com",
+	barReq = rts.authorizeRequest(barReq, "test_token2", "bar", 67890)
+
+	mod := models.Module{
+		Name:    "x/bank",
+		Team:    "cosmonauts",
+		Owners:  []models.User{{Name: "foo"}},
+		Authors: []models.User{{Name: "foo"}},
+		Version: models.ModuleVersion{
+			Version:       "v1.0.0",
+			Repo:          "https://github.com/cosmos/cosmos-sdk/releases/tag/v0.39.1",
+			Documentation: "https://raw.githubusercontent.com/cosmos/cosmos-sdk/v0.39.1/x/bank/README.md",
 		},
 	}
 
-	// create module published by test_user1
-	bz, err := json.Marshal(body)
-	rts.Require().NoError(err)
-
-	req1.Method = httputil.MethodPUT
-	req1.URL = upsertURL
-	req1.Body = ioutil.NopCloser(bytes.NewBuffer(bz))
-	req1.ContentLength = int64(len(bz))
+	mod, err = mod.Upsert(rts.router.db)
+	rts.Require().NoError(err)
 
-	rr := httptest.NewRecorder()
-	rts.mux.ServeHTTP(rr, req1)
-	rts.Require().Equal(http.StatusOK, rr.Code, rr.Body.String())
+	bar, err := models.QueryUser(rts.router.db, map[string]interface{}{"name": "bar"})
+	rts.Require().NoError(err)
 
-	// get the authenticated user
-	getUserURL, err := url.Parse("/api/v1/me")
+	moi, err := models.ModuleOwnerInvite{ModuleID: mod.ID, InvitedByUserID: mod.Owners[0].ID, InvitedUserID: bar.ID}.Upsert(rts.router.db)
 	rts.Require().NoError(err)
 
-	req1.Method = httputil.MethodGET
-	req1.URL = getUserURL
+	time.Sleep(10 * time.Millisecond)
 
-	rr = httptest.NewRecorder()
-	rts.mux.ServeHTTP(rr, req1)
+	// the invitation expires after the configured TTL
+	rr := rts.executeJSONRequest(barReq, httputil.MethodGET, "/api/v1/me/invites", nil)
 	rts.Require().Equal(http.StatusOK, rr.Code, rr.Body.String())
+	rts.Require().Equal("[]", strings.TrimSpace(rr.Body.String()))
 
-	// create a new unauthenticated request and ensure the request fails
-	req2, err := http.NewRequest("GET", getUserURL.String(), nil)
-	rts.Require().NoError(err)
-
-	rr = httptest.NewRecorder()
-	rts.mux.ServeHTTP(rr, req2)
-	rts.Require().Equal(http.StatusUnauthorized, rr.Code, rr.Body.String())
+	rr = rts.executeJSONRequest(barReq, httputil.MethodPUT, fmt.Sprintf("/api/v1/me/invite/accept/%s", moi.Token), nil)
+	rts.Require().Equal(http.StatusBadRequest, rr.Code, rr.Body.String())
 }
 
-func (rts *RouterTestSuite) TestUpdateUser() {
+func (rts *RouterTestSuite) TestTeams() {
 	rts.resetDB()
 
-	req1, err := http.NewRequest("GET", "/", nil)
+	fooReq, err := http.NewRequest("GET", "/", nil)
 	rts.Require().NoError(err)
 
-	req1 = rts.authorizeRequest(req1, "test_token1", "foo", 12345)
+	fooReq = rts.authorizeRequest(fooReq, "test_token1", "foo", 12345)
 
-	upsertURL, err := url.Parse("/api/v1/modules")
+	barReq, err := http.NewRequest("GET", "/", nil)
 	rts.Require().NoError(err)
 
-	body := map[string]interface{}{
-		"module": map[string]interface{}{
-			"name":     "x/bank",
-			"team":     "cosmonauts",
-			"keywords": []string{"tokens"},
-		},
-		"authors": []map[string]interface{}{
-			{
-				"name": "foo", "email": "foo@email.com",
+	barReq = rts.authorizeRequest(barReq, "test_token2", "bar", 67890)
+
+	newModule := func(name, team string) models.Module {
+		mod := models.Module{
+			Name:    name,
+			Team:    team,
+			Owners:  []models.User{{Name: "foo"}},
+			Authors: []models.User{{Name: "foo"}},
+			Version: models.ModuleVersion{
+				Version:       "v1.0.0",
+				Repo:          "https://github.com/cosmos/cosmos-sdk/releases/tag/v0.39.1",
+				Documentation: "https://raw.githubusercontent.com/cosmos/cosmos-sdk/v0.39.1/x/bank/README.md",
 			},
-		},
-		"version": map[string]interface{}{
-			"repo":          "https://github.com/cosmos/cosmos-sdk/releases/tag/v0.39.1",
-			"documentation": "https://raw.githubusercontent.com/cosmos/cosmos-sdk/v0.39.1/x/bank/README.md",
-			"version":       "v1.0.0",
-		},
-		"bug_tracker": map[string]interface{}{
-			"url":     "https://cosmonauts.com",
-			"contact": "contact@cosmonauts.com",
-		},
+		}
+
+		mod, err := mod.Upsert(rts.router.db)
+		rts.Require().NoError(err)
+
+		return mod
 	}
 
-	// create module published by test_user1
-	bz, err := json.Marshal(body)
-	rts.Require().NoError(err)
+	bank := newModule("x/bank", "cosmonauts")
+	gov := newModule("x/gov", "astronauts")
 
-	req1.Method = httputil.MethodPUT
-	req1.URL = upsertURL
-	req1.Body = ioutil.NopCloser(bytes.NewBuffer(bz))
-	req1.ContentLength = int64(len(bz))
+	// only an owner of the team's existing modules may create the team
+	rr := rts.executeJSONRequest(barReq, httputil.MethodPUT, "/api/v1/teams", map[string]interface{}{"name": "cosmonauts"})
+	rts.Require().Equal(http.StatusForbidden, rr.Code, rr.Body.String())
 
-	rr := httptest.NewRecorder()
-	rts.mux.ServeHTTP(rr, req1)
+	rr = rts.executeJSONRequest(fooReq, httputil.MethodPUT, "/api/v1/teams", map[string]interface{}{"name": "Cosmonauts"})
 	rts.Require().Equal(http.StatusOK, rr.Code, rr.Body.String())
 
-	// update the authenticated user
-	updateUserURL, err := url.Parse("/api/v1/me")
-	rts.Require().NoError(err)
-
-	body = map[string]interface{}{
-		"email": "newfoo@email.com",
-	}
-	bz, err = json.Marshal(body)
-	rts.Require().NoError(err)
+	rr = rts.executeJSONRequest(fooReq, httputil.MethodPUT, "/api/v1/teams", map[string]interface{}{"name": "cosmonauts"})
+	rts.Require().Equal(http.StatusBadRequest, rr.Code, rr.Body.String())
 
-	req1.Method = httputil.MethodPUT
-	req1.URL = updateUserURL
-	req1.Body = ioutil.NopCloser(bytes.NewBuffer(bz))
-	req1.ContentLength = int64(len(bz))
+	// users join a team by accepting an invitation
+	rr = rts.executeJSONRequest(fooReq, httputil.MethodPUT, "/api/v1/teams/cosmonauts/invites", map[string]interface{}{"user": "bar", "role": "owner"})
+	rts.Require().Equal(http.StatusBadRequest, rr.Code, rr.Body.String())
 
-	rr = httptest.NewRecorder()
-	rts.mux.ServeHTTP(rr, req1)
-	rts.Require().Equal(http.StatusOK, rr.Code, rr.Body.String())
+	rr = rts.executeJSONRequest(fooReq, httputil.MethodPUT, "/api/v1/teams/cosmonauts/invites", map[string]interface{}{"user": "baz", "role": "viewer"})
+	rts.Require().Equal(http.StatusNotFound, rr.Code, rr.Body.String())
 
-	var result bool
-	rts.Require().NoError(json.Unmarshal(rr.Body.Bytes(), &result))
-	rts.Require().True(result)
+	rr = rts.executeJSONRequest(fooReq, httputil.MethodPUT, "/api/v1/teams/astronauts/invites", map[string]interface{}{"user": "bar", "role": "viewer"})
+	rts.Require().Equal(http.StatusNotFound, rr.Code, rr.Body.String())
 
-	// ensure an invalid request fails
-	body = map[string]interface{}{
-		"email": "newfoo",
-	}
-	bz, err = json.Marshal(body)
-	rts.Require().NoError(err)
+	rr = rts.executeJSONRequest(fooReq, httputil.MethodPUT, "/api/v1/teams/cosmonauts/members", map[string]interface{}{"user": "bar", "role": "publisher"})
+	rts.Require().Equal(http.StatusNotFound, rr.Code, rr.Body.String())
 
-	req1.Method = httputil.MethodPUT
-	req1.URL = updateUserURL
-	req1.Body = ioutil.NopCloser(bytes.NewBuffer(bz))
-	req1.ContentLength = int64(len(bz))
+	rr = rts.executeJSONRequest(fooReq, httputil.MethodPUT, "/api/v1/teams/cosmonauts/invites", map[string]interface{}{"user": "foo", "role": "viewer"})
+	rts.Require().Equal(http.StatusBadRequest, rr.Code, rr.Body.String())
 
-	rr = httptest.NewRecorder()
-	rts.mux.ServeHTTP(rr, req1)
+	// the invitee must have a confirmed email
+	rr = rts.executeJSONRequest(fooReq, httputil.MethodPUT, "/api/v1/teams/cosmonauts/invites", map[string]interface{}{"user": "bar", "role": "publisher"})
 	rts.Require().Equal(http.StatusBadRequest, rr.Code, rr.Body.String())
 
-	// ensure an unauthenticated request fails
-	req2, err := http.NewRequest(httputil.MethodPUT, updateUserURL.String(), ioutil.NopCloser(bytes.NewBuffer(bz)))
+	bar, err := models.QueryUser(rts.router.db, map[string]interface{}{"name": "bar"})
 	rts.Require().NoError(err)
 
-	rr = httptest.NewRecorder()
-	rts.mux.ServeHTTP(rr, req2)
-	rts.Require().Equal(http.StatusUnauthorized, rr.Code, rr.Body.String())
-}
+	bar.EmailConfirmed = true
+	bar, err = bar.Upsert(rts.router.db)
+	rts.Require().NoError(err)
 
-func (rts *RouterTestSuite) TestConfirmEmail() {
-	rts.resetDB()
+	rr = rts.executeJSONRequest(barReq, httputil.MethodPUT, "/api/v1/teams/cosmonauts/invites", map[string]interface{}{"user": "bar", "role": "admin"})
+	rts.Require().Equal(http.StatusForbidden, rr.Code, rr.Body.String())
 
-	req1, err := http.NewRequest("GET", "/", nil)
+	rr = rts.executeJSONRequest(fooReq, httputil.MethodPUT, "/api/v1/teams/cosmonauts/invites", map[string]interface{}{"user": "bar", "role": "publisher"})
+	rts.Require().Equal(http.StatusOK, rr.Code, rr.Body.String())
+
+	tmi, err := models.QueryTeamMemberInvite(rts.router.db, map[string]interface{}{"invited_user_id": bar.ID})
 	rts.Require().NoError(err)
+	rts.Require().Equal(models.TeamRolePublisher, tmi.Role)
 
-	req1 = rts.authorizeRequest(req1, "test_token1", "foo", 12345)
+	// only the invitee may accept the invitation
+	rr = rts.executeJSONRequest(fooReq, httputil.MethodPUT, fmt.Sprintf("/api/v1/me/team-invites/accept/%s", tmi.Token), nil)
+	rts.Require().Equal(http.StatusNotFound, rr.Code, rr.Body.String())
 
-	upsertURL, err := url.Parse("/api/v1/modules")
+	team, err := models.GetTeamByName(rts.router.db, "cosmonauts")
 	rts.Require().NoError(err)
 
-	body := map[string]interface{}{
-		"module": map[string]interface{}{
-			"name":     "x/bank",
-			"team":     "cosmonauts",
-			"keywords": []string{"tokens"},
-		},
-		"authors": []map[string]interface{}{
-			{
-				"name": "foo", "email": "foo@email.com",
-			},
-		},
-		"version": map[string]interface{}{
-			"repo":          "https://github.com/cosmos/cosmos-sdk/releases/tag/v0.39.1",
-			"documentation": "https://raw.githubusercontent.com/cosmos/cosmos-sdk/v0.39.1/x/bank/README.md",
-			"version":       "v1.0.0",
-		},
-		"bug_tracker": map[string]interface{}{
-			"url":     "https://cosmonauts.com",
-			"contact": "contact@cosmonauts.com",
-		},
-	}
+	_, ok := team.MemberRole(bar.ID)
+	rts.Require().False(ok)
 
-	// create module published by test_user1
-	bz, err := json.Marshal(body)
-	rts.Require().NoError(err)
+	rr = rts.executeJSONRequest(barReq, httputil.MethodPUT, fmt.Sprintf("/api/v1/me/team-invites/accept/%s", tmi.Token), nil)
+	rts.Require().Equal(http.StatusOK, rr.Code, rr.Body.String())
 
-	req1.Method = httputil.MethodPUT
-	req1.URL = upsertURL
-	req1.Body = ioutil.NopCloser(bytes.NewBuffer(bz))
-	req1.ContentLength = int64(len(bz))
+	rr = rts.executeJSONRequest(barReq, httputil.MethodPUT, fmt.Sprintf("/api/v1/me/team-invites/accept/%s", tmi.Token), nil)
+	rts.Require().Equal(http.StatusNotFound, rr.Code, rr.Body.String())
 
-	rr := httptest.NewRecorder()
-	rts.mux.ServeHTTP(rr, req1)
+	// manage membership
+	rr = rts.executeJSONRequest(fooReq, httputil.MethodPUT, "/api/v1/teams/cosmonauts/members", map[string]interface{}{"user": "bar", "role": "publisher"})
 	rts.Require().Equal(http.StatusOK, rr.Code, rr.Body.String())
 
-	// update the authenticated user
-	updateUserURL, err := url.Parse("/api/v1/me")
-	rts.Require().NoError(err)
+	rr = rts.executeJSONRequest(barReq, httputil.MethodPUT, "/api/v1/teams/cosmonauts/members", map[string]interface{}{"user": "bar", "role": "admin"})
+	rts.Require().Equal(http.StatusForbidden, rr.Code, rr.Body.String())
 
-	body = map[string]interface{}{
-		"email": "bar@email.com",
-	}
-	bz, err = json.Marshal(body)
-	rts.Require().NoError(err)
+	rr = rts.executeJSONRequest(fooReq, httputil.MethodPUT, "/api/v1/teams/cosmonauts/members", map[string]interface{}{"user": "foo", "role": "viewer"})
+	rts.Require().Equal(http.StatusBadRequest, rr.Code, rr.Body.String())
 
-	req1.Method = httputil.MethodPUT
-	req1.URL = updateUserURL
-	req1.Body = ioutil.NopCloser(bytes.NewBuffer(bz))
-	req1.ContentLength = int64(len(bz))
+	// grant ownership of a module to the team
+	modTeamsPath := fmt.Sprintf("/api/v1/modules/%d/teams", gov.ID)
 
-	rr = httptest.NewRecorder()
-	rts.mux.ServeHTTP(rr, req1)
+	rr = rts.executeJSONRequest(barReq, httputil.MethodPUT, modTeamsPath, map[string]interface{}{"team": "cosmonauts"})
+	rts.Require().Equal(http.StatusForbidden, rr.Code, rr.Body.String())
+
+	rr = rts.executeJSONRequest(fooReq, httputil.MethodPUT, modTeamsPath, map[string]interface{}{"team": "cosmonauts"})
 	rts.Require().Equal(http.StatusOK, rr.Code, rr.Body.String())
 
-	var result bool
-	rts.Require().NoError(json.Unmarshal(rr.Body.Bytes(), &result))
-	rts.Require().True(result)
+	var modResp map[string]interface{}
+	rts.Require().NoError(json.Unmarshal(rr.Body.Bytes(), &modResp))
+	rts.Require().Equal([]interface{}{"cosmonauts"}, modResp["owner_teams"])
 
-	// make a request with an invalid token
-	confirmEmailURL, err := url.Parse("/api/v1/me/confirm/badtoken")
+	// publishers of the team are owners of the module
+	gov, err = models.GetModuleByID(rts.router.db, gov.ID)
 	rts.Require().NoError(err)
 
-	req1.URL = confirmEmailURL
+	rts.Require().True(gov.IsOwner(bar.ID))
 
-	rr = httptest.NewRecorder()
-	rts.mux.ServeHTTP(rr, req1)
+	// the team lists its members and modules
+	rr = rts.executeJSONRequest(barReq, httputil.MethodGET, "/api/v1/teams/cosmonauts", nil)
+	rts.Require().Equal(http.StatusOK, rr.Code, rr.Body.String())
+
+	var teamResp models.TeamModulesJSON
+	rts.Require().NoError(json.Unmarshal(rr.Body.Bytes(), &teamResp))
+	rts.Require().Equal("cosmonauts", teamResp.Name)
+	rts.Require().Len(teamResp.Members, 2)
+	rts.Require().Equal("foo", teamResp.Members[0].User.Name)
+	rts.Require().Equal(models.TeamRoleAdmin, teamResp.Members[0].Role)
+	rts.Require().Equal("bar", teamResp.Members[1].User.Name)
+	rts.Require().Equal(models.TeamRolePublisher, teamResp.Members[1].Role)
+
+	// modules whose repository owner matches the team's name do not belong to the
+	// team unless their ownership was granted to it
+	rts.Require().Len(teamResp.Modules, 1)
+	rts.Require().Equal(gov.Name, teamResp.Modules[0].Name)
+	rts.Require().NotEqual(bank.Name, teamResp.Modules[0].Name)
+
+	rr = rts.executeJSONRequest(barReq, httputil.MethodGET, "/api/v1/teams/astronauts", nil)
 	rts.Require().Equal(http.StatusNotFound, rr.Code, rr.Body.String())
 
-	// get the valid token
-	uec, err := models.QueryUserEmailConfirmation(rts.router.db, map[string]interface{}{"user_id": 1})
-	rts.Require().NoError(err)
+	// a team created before modules of the same name are published does not gain
+	// the modules
+	rr = rts.executeJSONRequest(barReq, httputil.MethodPUT, "/api/v1/teams", map[string]interface{}{"name": "gaianauts"})
+	rts.Require().Equal(http.StatusOK, rr.Code, rr.Body.String())
 
-	// make a request with the valid token
-	token := uec.Token
-	confirmEmailURL, err = url.Parse(fmt.Sprintf("/api/v1/me/confirm/%s", token))
-	rts.Require().NoError(err)
+	mint := newModule("x/mint", "gaianauts")
+	rts.Require().False(mint.IsOwner(bar.ID))
 
-	req1.URL = confirmEmailURL
+	rr = rts.executeJSONRequest(barReq, httputil.MethodGET, "/api/v1/teams/gaianauts", nil)
+	rts.Require().Equal(http.StatusOK, rr.Code, rr.Body.String())
 
-	rr = httptest.NewRecorder()
-	rts.mux.ServeHTTP(rr, req1)
+	teamResp = models.TeamModulesJSON{}
+	rts.Require().NoError(json.Unmarshal(rr.Body.Bytes(), &teamResp))
+	rts.Require().Empty(teamResp.Modules)
+
+	// members may leave a team, but the last admin may not
+	rr = rts.executeJSONRequest(barReq, httputil.MethodDELETE, "/api/v1/teams/cosmonauts/members/foo", nil)
+	rts.Require().Equal(http.StatusForbidden, rr.Code, rr.Body.String())
+
+	rr = rts.executeJSONRequest(barReq, httputil.MethodDELETE, "/api/v1/teams/cosmonauts/members/bar", nil)
 	rts.Require().Equal(http.StatusOK, rr.Code, rr.Body.String())
+
+	rr = rts.executeJSONRequest(fooReq, httputil.MethodDELETE, "/api/v1/teams/cosmonauts/members/bar", nil)
+	rts.Require().Equal(http.StatusNotFound, rr.Code, rr.Body.String())
+
+	rr = rts.executeJSONRequest(fooReq, httputil.MethodDELETE, "/api/v1/teams/cosmonauts/members/foo", nil)
+	rts.Require().Equal(http.StatusBadRequest, rr.Code, rr.Body.String())
 }
 
-func (rts *RouterTestSuite) TestInviteModuleOwner() {
+func (rts *RouterTestSuite) TestModuleOwnership() {
 	rts.resetDB()
 
+	fooReq, err := http.NewRequest("GET", "/", nil)
+	rts.Require().NoError(err)
+
+	fooReq = rts.authorizeRequest(fooReq, "test_token1", "foo", 12345)
+
+	barReq, err := http.NewRequest("GET", "/", nil)
+	rts.Require().NoError(err)
+
+	barReq = rts.authorizeRequest(barReq, "test_token2", "bar", 67890)
+
 	mod := models.Module{
-		Name: "x/bank",
-		Team: "cosmonauts",
-		Owners: []models.User{
-			{Name: "foo", Email: models.NewNullString("foo@email.com")},
-		},
-		Authors: []models.User{
-			{Name: "bar", Email: models.NewNullString("bar@email.com")},
-		},
+		Name:    "x/bank",
+		Team:    "cosmonauts",
+		Owners:  []models.User{{Name: "foo"}},
+		Authors: []models.User{{Name: "foo"}},
 		Version: models.ModuleVersion{
 			Version:       "v1.0.0",
 			Repo:          "https://github.com/cosmos/cosmos-sdk/releases/tag/v0.39.1",
 			Documentation: "https://raw.githubusercontent.com/cosmos/cosmos-sdk/v0.39.1/x/bank/README.md",
 		},
-		Keywords: []models.Keyword{
-			{Name: "tokens"}, {Name: "transfer"},
-		},
-		BugTracker: models.BugTracker{
-			URL:     models.NewNullString("cosmonauts.com"),
-			Contact: models.NewNullString("contact@cosmonauts.com"),
-		},
 	}
 
-	mod, err := mod.Upsert(rts.router.db)
+	mod, err = mod.Upsert(rts.router.db)
 	rts.Require().NoError(err)
 
-	req1, err := http.NewRequest("GET", "/", nil)
-	rts.Require().NoError(err)
+	modPath := fmt.Sprintf("/api/v1/modules/%d", mod.ID)
 
-	req1 = rts.authorizeRequest(req1, "test_token1", "foo", 12345)
+	// only owners may remove owners or transfer ownership
+	rr := rts.executeJSONRequest(barReq, httputil.MethodDELETE, modPath+"/owners/foo", nil)
+	rts.Require().Equal(http.StatusForbidden, rr.Code, rr.Body.String())
 
-	inviteURL, err := url.Parse("/api/v1/me/invite")
-	rts.Require().NoError(err)
+	rr = rts.executeJSONRequest(barReq, httputil.MethodPUT, modPath+"/transfer", map[string]interface{}{"user": "bar"})
+	rts.Require().Equal(http.StatusForbidden, rr.Code, rr.Body.String())
 
-	body := map[string]interface{}{
-		"module_id": mod.ID,
-		"user":      mod.Owners[0].Name,
-	}
+	// the last owner cannot be removed
+	rr = rts.executeJSONRequest(fooReq, httputil.MethodDELETE, modPath+"/owners/foo", nil)
+	rts.Require().Equal(http.StatusBadRequest, rr.Code, rr.Body.String())
 
-	bz, err := json.Marshal(body)
-	rts.Require().NoError(err)
+	rr = rts.executeJSONRequest(fooReq, httputil.MethodDELETE, modPath+"/owners/bar", nil)
+	rts.Require().Equal(http.StatusNotFound, rr.Code, rr.Body.String())
 
-	req1.Method = httputil.MethodPUT
-	req1.URL = inviteURL
-	req1.Body = ioutil.NopCloser(bytes.NewBuffer(bz))
-	req1.ContentLength = int64(len(bz))
+	// exactly one of a user or team must be provided
+	rr = rts.executeJSONRequest(fooReq, httputil.MethodPUT, modPath+"/transfer", map[string]interface{}{})
+	rts.Require().Equal(http.StatusBadRequest, rr.Code, rr.Body.String())
 
-	// assert failure due to invitee already being an owner
-	rr := httptest.NewRecorder()
-	rts.mux.ServeHTTP(rr, req1)
+	rr = rts.executeJSONRequest(fooReq, httputil.MethodPUT, modPath+"/transfer", map[string]interface{}{"user": "bar", "team": "cosmonauts"})
 	rts.Require().Equal(http.StatusBadRequest, rr.Code, rr.Body.String())
 
-	body = map[string]interface{}{
-		"module_id": mod.ID,
-		"user":      mod.Authors[0].Name,
-	}
+	// the new owner must have a confirmed email
+	rr = rts.executeJSONRequest(fooReq, httputil.MethodPUT, modPath+"/transfer", map[string]interface{}{"user": "bar"})
+	rts.Require().Equal(http.StatusBadRequest, rr.Code, rr.Body.String())
 
-	bz, err = json.Marshal(body)
+	bar, err := models.QueryUser(rts.router.db, map[string]interface{}{"name": "bar"})
 	rts.Require().NoError(err)
 
-	req1.Body = ioutil.NopCloser(bytes.NewBuffer(bz))
-	req1.ContentLength = int64(len(bz))
+	bar.EmailConfirmed = true
+	bar, err = bar.Upsert(rts.router.db)
+	rts.Require().NoError(err)
 
-	// assert failure due to invitee unconfirmed email
-	rr = httptest.NewRecorder()
-	rts.mux.ServeHTTP(rr, req1)
+	rr = rts.executeJSONRequest(fooReq, httputil.MethodPUT, modPath+"/transfer", map[string]interface{}{"user": "bar"})
+	rts.Require().Equal(http.StatusOK, rr.Code, rr.Body.String())
+
+	var modResp models.ModuleJSON
+	rts.Require().NoError(json.Unmarshal(rr.Body.Bytes(), &modResp))
+	rts.Require().Len(modResp.Owners, 1)
+	rts.Require().Equal("bar", modResp.Owners[0].Name)
+
+	// the previous owner is no longer an owner
+	rr = rts.executeJSONRequest(fooReq, httputil.MethodPUT, modPath+"/transfer", map[string]interface{}{"user": "foo"})
+	rts.Require().Equal(http.StatusForbidden, rr.Code, rr.Body.String())
+
+	// transfer ownership to a team administered by the owner
+	rr = rts.executeJSONRequest(barReq, httputil.MethodPUT, "/api/v1/teams", map[string]interface{}{"name": "cosmonauts"})
+	rts.Require().Equal(http.StatusOK, rr.Code, rr.Body.String())
+
+	rr = rts.executeJSONRequest(barReq, httputil.MethodPUT, modPath+"/transfer", map[string]interface{}{"team": "cosmonauts"})
+	rts.Require().Equal(http.StatusOK, rr.Code, rr.Body.String())
+
+	modResp = models.ModuleJSON{}
+	rts.Require().NoError(json.Unmarshal(rr.Body.Bytes(), &modResp))
+	rts.Require().Empty(modResp.Owners)
+	rts.Require().Equal([]string{"cosmonauts"}, modResp.OwnerTeams)
+
+	// the only owning team cannot be removed
+	rr = rts.executeJSONRequest(barReq, httputil.MethodDELETE, modPath+"/teams/cosmonauts", nil)
 	rts.Require().Equal(http.StatusBadRequest, rr.Code, rr.Body.String())
 
-	// confirm email
-	author, err := models.GetUserByID(rts.router.db, mod.Authors[0].ID)
-	rts.Require().NoError(err)
+	// every ownership change is recorded
+	rr = rts.executeJSONRequest(fooReq, httputil.MethodGET, modPath+"/owners/events", nil)
+	rts.Require().Equal(http.StatusOK, rr.Code, rr.Body.String())
 
-	author.EmailConfirmed = true
-	author, err = author.Upsert(rts.router.db)
-	rts.Require().NoError(err)
+	var events []models.ModuleOwnerEventJSON
+	rts.Require().NoError(json.Unmarshal(rr.Body.Bytes(), &events))
 
-	// assert successful invitation
-	req1.Body = ioutil.NopCloser(bytes.NewBuffer(bz))
-	req1.ContentLength = int64(len(bz))
+	actions := make([]string, len(events))
+	for i, e := range events {
+		actions[i] = e.Action
+	}
 
-	rr = httptest.NewRecorder()
-	rts.mux.ServeHTTP(rr, req1)
-	rts.Require().Equal(http.StatusOK, rr.Code, rr.Body.String())
+	rts.Require().Equal([]string{
+		models.OwnerEventOwnerAdded,
+		models.OwnerEventOwnerRemoved,
+		models.OwnerEventTransferredUser,
+		models.OwnerEventOwnerRemoved,
+		models.OwnerEventTransferredTeam,
+	}, actions)
 }
 
-func (rts *RouterTestSuite) TestAcceptOwnerInvite() {
+func (rts *RouterTestSuite) TestAuditEvents() {
 	rts.resetDB()
 
+	fooReq, err := http.NewRequest("GET", "/", nil)
+	rts.Require().NoError(err)
+
+	fooReq = rts.authorizeRequest(fooReq, "test_token1", "foo", 12345)
+
+	barReq, err := http.NewRequest("GET", "/", nil)
+	rts.Require().NoError(err)
+
+	barReq = rts.authorizeRequest(barReq, "test_token2", "bar", 67890)
+
 	mod := models.Module{
-		Name: "x/bank",
-		Team: "cosmonauts",
-		Owners: []models.User{
-			{Name: "foo", Email: models.NewNullString("foo@email.com")},
-		},
-		Authors: []models.User{
-			{Name: "bar", Email: models.NewNullString("bar@email.com")},
-		},
+		Name:    "x/bank",
+		Team:    "cosmonauts",
+		Owners:  []models.User{{Name: "foo"}},
+		Authors: []models.User{{Name: "foo"}},
 		Version: models.ModuleVersion{
 			Version:       "v1.0.0",
 			Repo:          "https://github.com/cosmos/cosmos-sdk/releases/tag/v0.39.1",
 			Documentation: "https://raw.githubusercontent.com/cosmos/cosmos-sdk/v0.39.1/x/bank/README.md",
 		},
-		Keywords: []models.Keyword{
-			{Name: "tokens"}, {Name: "transfer"},
-		},
-		BugTracker: models.BugTracker{
-			URL:     models.NewNullString("cosmonauts.com"),
-			Contact: models.NewNullString("contact@cosmonauts.com"),
-		},
 	}
 
-	mod, err := mod.Upsert(rts.router.db)
+	mod, err = mod.Upsert(rts.router.db)
 	rts.Require().NoError(err)
 
-	// confirm email
-	author, err := models.GetUserByID(rts.router.db, mod.Authors[0].ID)
-	rts.Require().NoError(err)
+	rr := rts.executeJSONRequest(fooReq, httputil.MethodPUT, "/api/v1/me/tokens", Token{Name: "cli"})
+	rts.Require().Equal(http.StatusOK, rr.Code, rr.Body.String())
 
-	author.EmailConfirmed = true
-	author, err = author.Upsert(rts.router.db)
-	rts.Require().NoError(err)
+	rr = rts.executeJSONRequest(barReq, httputil.MethodPUT, fmt.Sprintf("/api/v1/modules/%d/star", mod.ID), nil)
+	rts.Require().Equal(http.StatusOK, rr.Code, rr.Body.String())
 
-	req1, err := http.NewRequest("GET", "/", nil)
+	getActions := func(req *http.Request, path string) []string {
+		rr := rts.executeJSONRequest(req, httputil.MethodGET, path, nil)
+		rts.Require().Equal(http.StatusOK, rr.Code, rr.Body.String())
+
+		var resp httputil.PaginationResponse
+		rts.Require().NoError(json.Unmarshal(rr.Body.Bytes(), &resp))
+
+		results := resp.Results.([]interface{})
+		actions := make([]string, len(results))
+		for i, e := range results {
+			e := e.(map[string]interface{})
+			rts.Require().NotEmpty(e["hash"])
+			actions[i] = e["action"].(string)
+		}
+
+		return actions
+	}
+
+	rts.Require().Equal(
+		[]string{"module." + models.OwnerEventOwnerAdded, models.AuditActionTokenCreate},
+		getActions(fooReq, "/api/v1/me/audit?page=1&limit=10"),
+	)
+	rts.Require().Equal(
+		[]string{models.AuditActionModuleStar},
+		getActions(barReq, "/api/v1/me/audit?page=1&limit=10"),
+	)
+
+	modulePath := fmt.Sprintf("/api/v1/modules/%d/audit?page=1&limit=10", mod.ID)
+	rts.Require().Equal(
+		[]string{models.AuditActionModulePublish, "module." + models.OwnerEventOwnerAdded, models.AuditActionModuleStar},
+		getActions(fooReq, modulePath),
+	)
+
+	// only owners may view a module's audit events
+	rr = rts.executeJSONRequest(barReq, httputil.MethodGET, modulePath, nil)
+	rts.Require().Equal(http.StatusForbidden, rr.Code, rr.Body.String())
+}
+
+func (rts *RouterTestSuite) TestTrustedPublishing() {
+	rts.resetDB()
+
+	issuer := newTestOIDCIssuer(rts.T())
+	defer issuer.Close()
+
+	rts.router.oidcVerifier = NewOIDCVerifier(GitHubActionsOIDCIssuer, issuer.URL, DefaultOIDCAudience)
+	defer func() { rts.router.oidcVerifier = nil }()
+
+	fooReq, err := http.NewRequest("GET", "/", nil)
 	rts.Require().NoError(err)
 
-	req1 = rts.authorizeRequest(req1, "test_token1", "foo", 1)
+	fooReq = rts.authorizeRequest(fooReq, "test_token1", "foo", 12345)
 
-	inviteURL, err := url.Parse("/api/v1/me/invite")
+	barReq, err := http.NewRequest("GET", "/", nil)
 	rts.Require().NoError(err)
 
-	body := map[string]interface{}{
-		"module_id": mod.ID,
-		"user":      mod.Authors[0].Name,
+	barReq = rts.authorizeRequest(barReq, "test_token2", "bar", 67890)
+
+	modules := make([]models.Module, 2)
+	for i, name := range []string{"x/bank", "x/staking"} {
+		mod := models.Module{
+			Name:    name,
+			Team:    "cosmonauts",
+			Owners:  []models.User{{Name: "foo"}},
+			Authors: []models.User{{Name: "foo"}},
+			Version: models.ModuleVersion{
+				Version:       "v1.0.0",
+				Repo:          "https://github.com/cosmos/cosmos-sdk/releases/tag/v0.39.1",
+				Documentation: "https://raw.githubusercontent.com/cosmos/cosmos-sdk/v0.39.1/x/bank/README.md",
+			},
+		}
+
+		modules[i], err = mod.Upsert(rts.router.db)
+		rts.Require().NoError(err)
 	}
 
-	bz, err := json.Marshal(body)
-	rts.Require().NoError(err)
+	policiesPath := fmt.Sprintf("/api/v1/modules/%d/trust-policies", modules[0].ID)
 
-	req1.Method = httputil.MethodPUT
-	req1.URL = inviteURL
-	req1.Body = ioutil.NopCloser(bytes.NewBuffer(bz))
-	req1.ContentLength = int64(len(bz))
+	// only owners may register trust policies
+	rr := rts.executeJSONRequest(barReq, httputil.MethodPUT, policiesPath, TrustPolicy{Repository: "cosmonauts/bank", Workflow: "release.yml"})
+	rts.Require().Equal(http.StatusForbidden, rr.Code, rr.Body.String())
 
-	rr := httptest.NewRecorder()
-	rts.mux.ServeHTTP(rr, req1)
+	// the repository must be owned by the module's team and the workflow must be
+	// a workflow file
+	rr = rts.executeJSONRequest(fooReq, httputil.MethodPUT, policiesPath, TrustPolicy{Repository: "other/bank", Workflow: "release.yml"})
+	rts.Require().Equal(http.StatusBadRequest, rr.Code, rr.Body.String())
+
+	rr = rts.executeJSONRequest(fooReq, httputil.MethodPUT, policiesPath, TrustPolicy{Repository: "cosmonauts/bank", Workflow: ".github/workflows/release.yml"})
+	rts.Require().Equal(http.StatusBadRequest, rr.Code, rr.Body.String())
+
+	rr = rts.executeJSONRequest(fooReq, httputil.MethodPUT, policiesPath, TrustPolicy{Repository: "Cosmonauts/Bank", Workflow: "release.yml"})
 	rts.Require().Equal(http.StatusOK, rr.Code, rr.Body.String())
 
-	// make a request with an invalid token
-	acceptInviteURL, err := url.Parse("/api/v1/me/invite/accept/badtoken")
-	rts.Require().NoError(err)
+	var policy models.ModuleTrustPolicyJSON
+	rts.Require().NoError(json.Unmarshal(rr.Body.Bytes(), &policy))
+	rts.Require().Equal("cosmonauts/bank", policy.Repository)
 
-	req1.URL = acceptInviteURL
+	rr = rts.executeJSONRequest(fooReq, httputil.MethodGET, policiesPath, nil)
+	rts.Require().Equal(http.StatusOK, rr.Code, rr.Body.String())
 
-	rr = httptest.NewRecorder()
-	rts.mux.ServeHTTP(rr, req1)
-	rts.Require().Equal(http.StatusNotFound, rr.Code, rr.Body.String())
+	var policies []models.ModuleTrustPolicyJSON
+	rts.Require().NoError(json.Unmarshal(rr.Body.Bytes(), &policies))
+	rts.Require().Len(policies, 1)
 
-	// get the valid token
-	moi, err := models.QueryModuleOwnerInvite(rts.router.db, map[string]interface{}{"invited_user_id": mod.Authors[0].ID})
-	rts.Require().NoError(err)
+	exchange := func(claims map[string]interface{}) *httptest.ResponseRecorder {
+		req, err := http.NewRequest("GET", "/", nil)
+		rts.Require().NoError(err)
 
-	// make a request with the valid token
-	token := moi.Token
-	acceptInviteURL, err = url.Parse(fmt.Sprintf("/api/v1/me/invite/accept/%s", token))
+		token := issuer.sign(rts.T(), issuer.key, claims)
+		return rts.executeJSONRequest(req, httputil.MethodPUT, "/api/v1/oidc/token", OIDCTokenExchange{Token: token})
+	}
+
+	// tokens of untrusted workflows or for another audience are rejected
+	rr = exchange(workflowClaims("cosmonauts/bank", "ci.yml"))
+	rts.Require().Equal(http.StatusForbidden, rr.Code, rr.Body.String())
+
+	claims := workflowClaims("cosmonauts/bank", "release.yml")
+	claims["aud"] = "other"
+	rr = exchange(claims)
+	rts.Require().Equal(http.StatusUnauthorized, rr.Code, rr.Body.String())
+
+	// a token of a trusted workflow is exchanged for a short-lived token granted
+	// publishing only the trusting module
+	rr = exchange(workflowClaims("cosmonauts/bank", "release.yml"))
+	rts.Require().Equal(http.StatusOK, rr.Code, rr.Body.String())
+
+	var ut models.UserTokenJSON
+	rts.Require().NoError(json.Unmarshal(rr.Body.Bytes(), &ut))
+	rts.Require().True(ut.TrustedPublishing)
+	rts.Require().Equal([]string{models.PublishModuleScope(modules[0].ID)}, ut.Scopes)
+	rts.Require().NotNil(ut.ExpiresAt)
+
+	ciReq, err := http.NewRequest("GET", "/", nil)
 	rts.Require().NoError(err)
 
-	req2, err := http.NewRequest("GET", "/", nil)
+	ciReq.Header.Set("Authorization", httputil.BearerSchema+ut.Token.(string))
+
+	yankPath := func(mod models.Module) string {
+		return fmt.Sprintf("/api/v1/modules/%d/versions/v1.0.0/yank", mod.ID)
+	}
+
+	rr = rts.executeJSONRequest(ciReq, httputil.MethodPUT, yankPath(modules[0]), ModuleVersionYank{Reason: "broken"})
+	rts.Require().Equal(http.StatusOK, rr.Code, rr.Body.String())
+
+	rr = rts.executeJSONRequest(ciReq, httputil.MethodPUT, yankPath(modules[1]), ModuleVersionYank{Reason: "broken"})
+	rts.Require().Equal(http.StatusUnauthorized, rr.Code, rr.Body.String())
+
+	rr = rts.executeJSONRequest(ciReq, httputil.MethodPUT, policiesPath, TrustPolicy{Repository: "cosmonauts/bank", Workflow: "ci.yml"})
+	rts.Require().Equal(http.StatusUnauthorized, rr.Code, rr.Body.String())
+
+	// trusted publishing tokens do not count towards the maximum number of tokens
+	foo, err := models.QueryUser(rts.router.db, map[string]interface{}{"name": "foo"})
 	rts.Require().NoError(err)
+	rts.Require().Equal(int64(0), foo.CountTokens(rts.router.db))
 
-	req2 = rts.authorizeRequest(req2, "test_token2", "bar", int64(mod.Authors[0].ID))
+	// a workflow may only be trusted on behalf of a single user
+	bar, err := models.QueryUser(rts.router.db, map[string]interface{}{"name": "bar"})
+	rts.Require().NoError(err)
 
-	req2.Method = httputil.MethodPUT
-	req2.URL = acceptInviteURL
-	req2.Body = ioutil.NopCloser(bytes.NewBuffer(bz))
-	req2.ContentLength = int64(len(bz))
+	_, err = modules[1].AddTrustPolicy(rts.router.db, bar, "cosmonauts/bank", "release.yml")
+	rts.Require().ErrorIs(err, models.ErrTrustPolicyConflict)
 
-	rr = httptest.NewRecorder()
-	rts.mux.ServeHTTP(rr, req2)
+	// removing the policy revokes the workflow's trust
+	rr = rts.executeJSONRequest(fooReq, httputil.MethodDELETE, fmt.Sprintf("%s/%d", policiesPath, policy.ID), nil)
 	rts.Require().Equal(http.StatusOK, rr.Code, rr.Body.String())
+
+	rr = exchange(workflowClaims("cosmonauts/bank", "release.yml"))
+	rts.Require().Equal(http.StatusForbidden, rr.Code, rr.Body.String())
 }
 
 func (rts *RouterTestSuite) TestStarModule() {
@@ -1980,6 +3716,292 @@ func (rts *RouterTestSuite) TestStarModule() {
 	rts.Require().Equal(int64(0), int64(resp["stars"].(float64)))
 }
 
+func (rts *RouterTestSuite) TestYankModuleVersion() {
+	rts.resetDB()
+
+	ownerReq, err := http.NewRequest("GET", "/", nil)
+	rts.Require().NoError(err)
+
+	ownerReq = rts.authorizeRequest(ownerReq, "test_token1", "foo", 12345)
+
+	otherReq, err := http.NewRequest("GET", "/", nil)
+	rts.Require().NoError(err)
+
+	otherReq = rts.authorizeRequest(otherReq, "test_token2", "bar", 67890)
+
+	upsertURL, err := url.Parse("/api/v1/modules")
+	rts.Require().NoError(err)
+
+	body := map[string]interface{}{
+		"module": map[string]interface{}{
+			"name":     "x/bank",
+			"team":     "cosmonauts",
+			"keywords": []string{"tokens"},
+		},
+		"authors": []map[string]interface{}{
+			{
+				"name": "foo", "email": "foo@email.com",
+			},
+		},
+		"version": map[string]interface{}{
+			"repo":          "https://github.com/cosmos/cosmos-sdk/releases/tag/v0.39.1",
+			"documentation": "https://raw.githubusercontent.com/cosmos/cosmos-sdk/v0.39.1/x/bank/README.md",
+			"version":       "v1.0.0",
+		},
+		"bug_tracker": map[string]interface{}{
+			"url":     "https://cosmonauts.com",
+			"contact": "contact@cosmonauts.com",
+		},
+	}
+
+	// create module published by foo
+	bz, err := json.Marshal(body)
+	rts.Require().NoError(err)
+
+	ownerReq.Method = httputil.MethodPUT
+	ownerReq.URL = upsertURL
+	ownerReq.Body = ioutil.NopCloser(bytes.NewBuffer(bz))
+	ownerReq.ContentLength = int64(len(bz))
+
+	rr := httptest.NewRecorder()
+	rts.mux.ServeHTTP(rr, ownerReq)
+	rts.Require().Equal(http.StatusOK, rr.Code, rr.Body.String())
+
+	var resp map[string]interface{}
+	rts.Require().NoError(json.Unmarshal(rr.Body.Bytes(), &resp))
+
+	modID := resp["id"]
+
+	testCases := []struct {
+		name         string
+		req          *http.Request
+		action       string
+		version      string
+		body         interface{}
+		expectedCode int
+		expectYanked bool
+	}{
+		{
+			name:         "non-owner",
+			req:          otherReq,
+			action:       "yank",
+			version:      "v1.0.0",
+			body:         ModuleVersionYank{Reason: "broken"},
+			expectedCode: http.StatusForbidden,
+		},
+		{
+			name:         "missing reason",
+			req:          ownerReq,
+			action:       "yank",
+			version:      "v1.0.0",
+			body:         ModuleVersionYank{},
+			expectedCode: http.StatusBadRequest,
+		},
+		{
+			name:         "unknown version",
+			req:          ownerReq,
+			action:       "yank",
+			version:      "v2.0.0",
+			body:         ModuleVersionYank{Reason: "broken"},
+			expectedCode: http.StatusNotFound,
+		},
+		{
+			name:         "yank",
+			req:          ownerReq,
+			action:       "yank",
+			version:      "v1.0.0",
+			body:         ModuleVersionYank{Reason: "broken"},
+			expectedCode: http.StatusOK,
+			expectYanked: true,
+		},
+		{
+			name:         "un-yank",
+			req:          ownerReq,
+			action:       "unyank",
+			version:      "v1.0.0",
+			expectedCode: http.StatusOK,
+			expectYanked: false,
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+
+		rts.Run(tc.name, func() {
+			yankURL, err := url.Parse(fmt.Sprintf("/api/v1/modules/%v/versions/%s/%s", modID, tc.version, tc.action))
+			rts.Require().NoError(err)
+
+			bz, err := json.Marshal(tc.body)
+			rts.Require().NoError(err)
+
+			tc.req.Method = httputil.MethodPUT
+			tc.req.URL = yankURL
+			tc.req.Body = ioutil.NopCloser(bytes.NewBuffer(bz))
+			tc.req.ContentLength = int64(len(bz))
+
+			rr := httptest.NewRecorder()
+			rts.mux.ServeHTTP(rr, tc.req)
+			rts.Require().Equal(tc.expectedCode, rr.Code, rr.Body.String())
+
+			if tc.expectedCode == http.StatusOK {
+				var resp map[string]interface{}
+				rts.Require().NoError(json.Unmarshal(rr.Body.Bytes(), &resp))
+				rts.Require().Equal(tc.expectYanked, resp["yanked"])
+
+				// ensure the version remains accessible by its exact version
+				req, err := http.NewRequest("GET", fmt.Sprintf("/api/v1/modules/%v/versions/%s", modID, tc.version), nil)
+				rts.Require().NoError(err)
+
+				response := rts.executeRequest(req)
+				rts.Require().Equal(http.StatusOK, response.Code, response.Body.String())
+				rts.Require().NoError(json.Unmarshal(response.Body.Bytes(), &resp))
+				rts.Require().Equal(tc.expectYanked, resp["yanked"])
+
+				if tc.expectYanked {
+					rts.Require().Equal("broken", resp["yank_reason"])
+				} else {
+					rts.Require().Nil(resp["yank_reason"])
+				}
+			}
+		})
+	}
+}
+
+func (rts *RouterTestSuite) TestGetNodeUptime() {
+	rts.resetDB()
+
+	req, err := http.NewRequest("GET", "/", nil)
+	rts.Require().NoError(err)
+
+	node := models.Node{
+		Location: models.Location{
+			Country:   "US",
+			Region:    "US",
+			City:      "New York",
+			Latitude:  "40.7128",
+			Longitude: "74.0060",
+		},
+		Address: "127.0.0.1",
+		RPCPort: "26657",
+		P2PPort: "26656",
+		Moniker: "test",
+		NodeID:  "0000FF",
+		Network: "testnet",
+		Version: "1.0.1",
+		TxIndex: "false",
+	}
+
+	record, err := node.Upsert(rts.router.db)
+	rts.Require().NoError(err)
+
+	now := time.Now()
+	for i, ok := range []bool{true, false, true, true} {
+		_, err := record.RecordObservation(rts.router.db, models.NodeObservation{
+			CreatedAt: now.Add(time.Duration(i-4) * time.Hour),
+			Reachable: ok,
+		})
+		rts.Require().NoError(err)
+	}
+
+	// an observation outside of the window
+	_, err = record.RecordObservation(rts.router.db, models.NodeObservation{
+		CreatedAt: now.Add(-8 * 24 * time.Hour),
+		Reachable: false,
+	})
+	rts.Require().NoError(err)
+
+	rr := rts.executeJSONRequest(req, httputil.MethodGET, fmt.Sprintf("/api/v1/nodes/%d/uptime", record.ID), nil)
+	rts.Require().Equal(http.StatusOK, rr.Code, rr.Body.String())
+
+	var uptime models.NodeUptime
+	rts.Require().NoError(json.Unmarshal(rr.Body.Bytes(), &uptime))
+	rts.Require().Equal(record.ID, uptime.NodeID)
+	rts.Require().Equal(4, uptime.Observations)
+	rts.Require().Equal(75.0, uptime.Availability)
+	rts.Require().Len(uptime.Outages, 1)
+	rts.Require().NotNil(uptime.Outages[0].End)
+
+	rr = rts.executeJSONRequest(req, httputil.MethodGET, fmt.Sprintf("/api/v1/nodes/%d/uptime?window=9d", record.ID), nil)
+	rts.Require().Equal(http.StatusOK, rr.Code, rr.Body.String())
+	rts.Require().NoError(json.Unmarshal(rr.Body.Bytes(), &uptime))
+	rts.Require().Equal(5, uptime.Observations)
+	rts.Require().Equal(60.0, uptime.Availability)
+	rts.Require().Len(uptime.Outages, 2)
+
+	rr = rts.executeJSONRequest(req, httputil.MethodGET, fmt.Sprintf("/api/v1/nodes/%d/uptime?window=150m", record.ID), nil)
+	rts.Require().Equal(http.StatusOK, rr.Code, rr.Body.String())
+	rts.Require().NoError(json.Unmarshal(rr.Body.Bytes(), &uptime))
+	rts.Require().Equal(2, uptime.Observations)
+	rts.Require().Equal(100.0, uptime.Availability)
+	rts.Require().Empty(uptime.Outages)
+
+	for _, window := range []string{"0d", "-1h", "31d", "week"} {
+		rr = rts.executeJSONRequest(req, httputil.MethodGET, fmt.Sprintf("/api/v1/nodes/%d/uptime?window=%s", record.ID, window), nil)
+		rts.Require().Equal(http.StatusBadRequest, rr.Code, rr.Body.String())
+	}
+
+	rr = rts.executeJSONRequest(req, httputil.MethodGET, fmt.Sprintf("/api/v1/nodes/%d/uptime", record.ID+1), nil)
+	rts.Require().Equal(http.StatusNotFound, rr.Code, rr.Body.String())
+}
+
+func (rts *RouterTestSuite) TestGetNetworks() {
+	rts.resetDB()
+
+	req, err := http.NewRequest("GET", "/", nil)
+	rts.Require().NoError(err)
+
+	rr := rts.executeJSONRequest(req, httputil.MethodGET, "/api/v1/networks", nil)
+	rts.Require().Equal(http.StatusOK, rr.Code, rr.Body.String())
+	rts.Require().JSONEq("[]", rr.Body.String())
+
+	for i, network := range []string{"testnet", "testnet", "cosmoshub-4"} {
+		node := models.Node{
+			Location: models.Location{
+				Country:   "US",
+				Region:    "US",
+				City:      "New York",
+				Latitude:  "40.7128",
+				Longitude: "74.0060",
+			},
+			Address:           fmt.Sprintf("127.0.0.%d", i+1),
+			RPCPort:           "26657",
+			P2PPort:           "26656",
+			Network:           network,
+			Version:           "v0.34.9",
+			TxIndex:           "on",
+			LatestBlockHeight: int64(100 + i),
+		}
+
+		_, err := node.Upsert(rts.router.db)
+		rts.Require().NoError(err)
+	}
+
+	rr = rts.executeJSONRequest(req, httputil.MethodGET, "/api/v1/networks", nil)
+	rts.Require().Equal(http.StatusOK, rr.Code, rr.Body.String())
+
+	var networks []models.Network
+	rts.Require().NoError(json.Unmarshal(rr.Body.Bytes(), &networks))
+	rts.Require().Len(networks, 2)
+	rts.Require().Equal("cosmoshub-4", networks[0].ChainID)
+	rts.Require().Equal("testnet", networks[1].ChainID)
+
+	rr = rts.executeJSONRequest(req, httputil.MethodGET, "/api/v1/networks/testnet", nil)
+	rts.Require().Equal(http.StatusOK, rr.Code, rr.Body.String())
+
+	var network models.Network
+	rts.Require().NoError(json.Unmarshal(rr.Body.Bytes(), &network))
+	rts.Require().Equal("testnet", network.ChainID)
+	rts.Require().Equal(int64(2), network.Nodes)
+	rts.Require().Equal(int64(2), network.OnlineNodes)
+	rts.Require().Equal(map[string]int64{"v0.34.9": 2}, network.Versions)
+	rts.Require().Equal(map[string]int64{"US": 2}, network.Countries)
+	rts.Require().Equal(100.0, network.TxIndexShare)
+	rts.Require().Equal(int64(100), network.MedianBlockHeight)
+
+	rr = rts.executeJSONRequest(req, httputil.MethodGET, "/api/v1/networks/unknown", nil)
+	rts.Require().Equal(http.StatusNotFound, rr.Code, rr.Body.String())
+}
+
 func (rts *RouterTestSuite) resetDB() {
 	rts.T().Helper()
 
@@ -2,6 +2,7 @@ package v1
 
 import (
 	"fmt"
+	"html"
 	"net/http"
 
 	"github.com/sendgrid/sendgrid-go"
@@ -42,6 +43,21 @@ func (r *Router) sendOwnerInvitation(acceptURL, invitedBy string, invitee models
 	return r.sendEmail(m)
 }
 
+func (r *Router) sendTeamInvitation(acceptURL, invitedBy string, invitee models.User, team models.Team, role string) error {
+	invitation := fmt.Sprintf("%s has invited you to join the team '%s' on Atlas as a %s.", invitedBy, team.Name, role)
+
+	m := mail.NewSingleEmail(
+		mail.NewEmail("Atlas", "contact@atlas.cosmos.network"),
+		fmt.Sprintf("Invitation to join the team '%s' on Atlas", team.Name),
+		mail.NewEmail(invitee.Name, invitee.Email.String),
+		fmt.Sprintf("%s\n\nAccept the invitation: %s\n", invitation, acceptURL),
+		fmt.Sprintf(`<p>%s</p><p><a href="%s">Accept the invitation</a></p>`, html.EscapeString(invitation), html.EscapeString(acceptURL)),
+	)
+
+	r.logger.Debug().Msg("sending team member invitation")
+	return r.sendEmail(m)
+}
+
 func (r *Router) sendEmail(msg *mail.SGMailV3) error {
 	apiKey := r.cfg.String(config.SendGridAPIKey)
 	if apiKey == "" {
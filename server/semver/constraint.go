@@ -0,0 +1,337 @@
+package semver
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// ErrInvalidConstraint defines a sentinel error when a constraint cannot be
+// parsed or can never be satisfied.
+var ErrInvalidConstraint = errors.New("invalid semantic version constraint")
+
+type (
+	// Range defines a half-open range of versions [Min, Max). A nil bound is
+	// unbounded.
+	Range struct {
+		Min *Version
+		Max *Version
+	}
+
+	// Constraint defines a parsed version constraint expression, e.g.
+	// ">=0.40, <0.43 || ^1.0.0". Comparators separated by a comma or whitespace
+	// must all be satisfied, where "||" separates alternatives. The supported
+	// operators are =, !=, >, >=, <, <=, ~ and ^. Versions may be partial, e.g.
+	// "0.40", and may contain wildcards, e.g. "v0.40.x" or "*".
+	//
+	// Every constraint reduces to a union of version ranges such that it can be
+	// evaluated by comparing sort keys, e.g. in a database query. Partial upper
+	// bounds exclude pre-releases, e.g. "<0.43" does not match "v0.43.0-rc.1".
+	// Otherwise, versions are compared by Semantic Versioning precedence.
+	Constraint struct {
+		raw    string
+		ranges []Range
+	}
+
+	// partialVersion defines a version where trailing components may be omitted
+	// or wildcards. The number of specified numeric components is given by n.
+	partialVersion struct {
+		Version
+		n int
+	}
+)
+
+// ParseConstraint parses a version constraint expression. An error is returned
+// if the expression is invalid or can never be satisfied.
+func ParseConstraint(s string) (Constraint, error) {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return Constraint{}, fmt.Errorf("%w: empty constraint", ErrInvalidConstraint)
+	}
+
+	var ranges []Range
+	for _, alt := range strings.Split(s, "||") {
+		altRanges, err := parseConjunction(alt)
+		if err != nil {
+			return Constraint{}, fmt.Errorf("%w '%s': %s", ErrInvalidConstraint, s, err)
+		}
+
+		ranges = append(ranges, altRanges...)
+	}
+
+	if len(ranges) == 0 {
+		return Constraint{}, fmt.Errorf("%w '%s': no version can satisfy the constraint", ErrInvalidConstraint, s)
+	}
+
+	return Constraint{raw: s, ranges: ranges}, nil
+}
+
+// String returns the original constraint expression.
+func (c Constraint) String() string {
+	return c.raw
+}
+
+// Ranges returns the set of version ranges the constraint reduces to. A version
+// satisfies the constraint if it is contained in any of the ranges.
+func (c Constraint) Ranges() []Range {
+	return c.ranges
+}
+
+// Check returns true if the given version satisfies the constraint.
+func (c Constraint) Check(v Version) bool {
+	for _, r := range c.ranges {
+		if r.Contains(v) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Contains returns true if the given version is contained in the Range.
+func (r Range) Contains(v Version) bool {
+	if r.Min != nil && v.Compare(*r.Min) < 0 {
+		return false
+	}
+	if r.Max != nil && v.Compare(*r.Max) >= 0 {
+		return false
+	}
+
+	return true
+}
+
+// intersect returns the intersection of two ranges and false if it is empty.
+func (r Range) intersect(other Range) (Range, bool) {
+	res := r
+
+	if other.Min != nil && (res.Min == nil || other.Min.Compare(*res.Min) > 0) {
+		res.Min = other.Min
+	}
+	if other.Max != nil && (res.Max == nil || other.Max.Compare(*res.Max) < 0) {
+		res.Max = other.Max
+	}
+
+	if res.Min != nil && res.Max != nil && res.Min.Compare(*res.Max) >= 0 {
+		return Range{}, false
+	}
+
+	return res, true
+}
+
+// parseConjunction parses a set of comparators that must all be satisfied and
+// returns the resulting set of ranges, which is empty if the comparators can
+// never be satisfied.
+func parseConjunction(s string) ([]Range, error) {
+	tokens := strings.FieldsFunc(s, func(r rune) bool {
+		return r == ',' || r == ' ' || r == '\t'
+	})
+	if len(tokens) == 0 {
+		return nil, errors.New("empty comparator set")
+	}
+
+	// merge operators separated from their version by whitespace, e.g. ">= 0.40"
+	var comparators []string
+	for i := 0; i < len(tokens); i++ {
+		tok := tokens[i]
+		if strings.TrimLeft(tok, "=!<>~^") == "" && i+1 < len(tokens) {
+			tok += tokens[i+1]
+			i++
+		}
+
+		comparators = append(comparators, tok)
+	}
+
+	result := []Range{{}}
+	for _, comp := range comparators {
+		compRanges, err := parseComparator(comp)
+		if err != nil {
+			return nil, err
+		}
+
+		var next []Range
+		for _, a := range result {
+			for _, b := range compRanges {
+				if r, ok := a.intersect(b); ok {
+					next = append(next, r)
+				}
+			}
+		}
+
+		result = next
+	}
+
+	return result, nil
+}
+
+// parseComparator parses a single comparator, e.g. ">=0.40", into a set of
+// ranges.
+func parseComparator(s string) ([]Range, error) {
+	op := s[:len(s)-len(strings.TrimLeft(s, "=!<>~^"))]
+	pv, err := parsePartial(s[len(op):])
+	if err != nil {
+		return nil, err
+	}
+
+	switch op {
+	case "", "=", "==":
+		return []Range{pv.exact()}, nil
+
+	case "!=":
+		exact := pv.exact()
+
+		var ranges []Range
+		if exact.Min != nil {
+			ranges = append(ranges, Range{Max: exact.Min})
+		}
+		if exact.Max != nil {
+			ranges = append(ranges, Range{Min: exact.Max})
+		}
+
+		return ranges, nil
+
+	case ">=":
+		return []Range{{Min: pv.exact().Min}}, nil
+
+	case ">":
+		return []Range{{Min: pv.exact().Max}}, nil
+
+	case "<":
+		r := Range{Max: pv.exact().Min}
+		if pv.n > 0 && pv.n < 3 {
+			r.Max = upperBound(pv.Major, pv.Minor, 0)
+		}
+
+		return []Range{r}, nil
+
+	case "<=":
+		return []Range{{Max: pv.exact().Max}}, nil
+
+	case "~":
+		// allow patch-level changes if a minor version is specified, otherwise
+		// allow minor-level changes
+		r := pv.exact()
+		if pv.n >= 2 {
+			r.Max = upperBound(pv.Major, pv.Minor+1, 0)
+		}
+
+		return []Range{r}, nil
+
+	case "^":
+		// allow changes that do not modify the left-most non-zero component
+		r := pv.exact()
+		switch {
+		case pv.n == 0:
+		case pv.Major > 0 || pv.n == 1:
+			r.Max = upperBound(pv.Major+1, 0, 0)
+		case pv.Minor > 0 || pv.n == 2:
+			r.Max = upperBound(0, pv.Minor+1, 0)
+		default:
+			r.Max = upperBound(0, 0, pv.Patch+1)
+		}
+
+		return []Range{r}, nil
+
+	default:
+		return nil, fmt.Errorf("invalid operator '%s'", op)
+	}
+}
+
+// parsePartial parses a possibly partial version, e.g. "v0.40", "0.40.x" or "*".
+func parsePartial(s string) (partialVersion, error) {
+	if s == "" {
+		return partialVersion{}, errors.New("missing version")
+	}
+
+	orig := s
+	s = strings.TrimPrefix(s, "v")
+
+	core := s
+	if i := strings.IndexAny(s, "-+"); i >= 0 {
+		core = s[:i]
+	}
+
+	parts := strings.Split(core, ".")
+	if len(parts) > 3 {
+		return partialVersion{}, fmt.Errorf("invalid version '%s'", orig)
+	}
+
+	var pv partialVersion
+	nums := []*uint64{&pv.Major, &pv.Minor, &pv.Patch}
+
+	for i, p := range parts {
+		if p == "x" || p == "X" || p == "*" {
+			// all subsequent components must be wildcards as well
+			for _, rest := range parts[i+1:] {
+				if rest != "x" && rest != "X" && rest != "*" {
+					return partialVersion{}, fmt.Errorf("invalid version '%s'", orig)
+				}
+			}
+
+			break
+		}
+
+		n, err := parseNumeric(p)
+		if err != nil {
+			return partialVersion{}, fmt.Errorf("invalid version '%s': %s", orig, err)
+		}
+
+		*nums[i] = n
+		pv.n++
+	}
+
+	// pre-release and build metadata are only valid on a complete version
+	if core != s {
+		if pv.n != 3 {
+			return partialVersion{}, fmt.Errorf("invalid version '%s'", orig)
+		}
+
+		v, err := Parse(s)
+		if err != nil {
+			return partialVersion{}, err
+		}
+
+		pv.Version = v
+	}
+
+	return pv, nil
+}
+
+// exact returns the range of versions matched by the partial version, e.g.
+// "0.40" matches [v0.40.0, v0.41.0-0) and "v1.2.3" matches [v1.2.3, v1.2.4-0).
+func (pv partialVersion) exact() Range {
+	lower := pv.Version
+
+	switch pv.n {
+	case 0:
+		return Range{}
+
+	case 1:
+		return Range{Min: &lower, Max: upperBound(pv.Major+1, 0, 0)}
+
+	case 2:
+		return Range{Min: &lower, Max: upperBound(pv.Major, pv.Minor+1, 0)}
+
+	default:
+		upper := lower.next()
+		return Range{Min: &lower, Max: &upper}
+	}
+}
+
+// next returns the version with the lowest precedence that is higher than v,
+// where build metadata is ignored.
+func (v Version) next() Version {
+	if len(v.Prerelease) > 0 {
+		pre := make([]string, len(v.Prerelease), len(v.Prerelease)+1)
+		copy(pre, v.Prerelease)
+
+		return Version{Major: v.Major, Minor: v.Minor, Patch: v.Patch, Prerelease: append(pre, "0")}
+	}
+
+	return Version{Major: v.Major, Minor: v.Minor, Patch: v.Patch + 1, Prerelease: []string{"0"}}
+}
+
+// upperBound returns the lowest pre-release of the given version, which is an
+// exclusive upper bound that excludes all of its pre-releases.
+func upperBound(major, minor, patch uint64) *Version {
+	return &Version{Major: major, Minor: minor, Patch: patch, Prerelease: []string{"0"}}
+}
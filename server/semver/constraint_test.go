@@ -0,0 +1,110 @@
+package semver_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/cosmos/atlas/server/semver"
+)
+
+func TestParseConstraint(t *testing.T) {
+	testCases := []struct {
+		input     string
+		expectErr bool
+	}{
+		{"v0.40.x", false},
+		{"0.40", false},
+		{"*", false},
+		{">=0.40, <0.43", false},
+		{">= 0.40 < 0.43", false},
+		{"^1.2.3 || ~0.39.2", false},
+		{"!=v0.42.1", false},
+		{"=v1.0.0-rc.1", false},
+		{"", true},
+		{"latest", true},
+		{">=", true},
+		{"=>0.40", true},
+		{"0.40.x.1", true},
+		{"0.x.1", true},
+		{"0.40.x-rc.1", true},
+		{">=0.43, <0.40", true},
+		{"0.40 ||", true},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+
+		t.Run(tc.input, func(t *testing.T) {
+			c, err := semver.ParseConstraint(tc.input)
+			if tc.expectErr {
+				require.Error(t, err)
+				require.ErrorIs(t, err, semver.ErrInvalidConstraint)
+			} else {
+				require.NoError(t, err)
+				require.Equal(t, tc.input, c.String())
+			}
+		})
+	}
+}
+
+func TestConstraint_Check(t *testing.T) {
+	testCases := []struct {
+		constraint string
+		version    string
+		expected   bool
+	}{
+		{"v0.40.x", "v0.40.0", true},
+		{"v0.40.x", "v0.40.9", true},
+		{"v0.40.x", "v0.41.0", false},
+		{"v0.40.x", "v0.40.0-rc.1", false},
+		{"*", "v0.1.0", true},
+		{">=0.40, <0.43", "v0.42.3", true},
+		{">=0.40, <0.43", "v0.40.0", true},
+		{">=0.40, <0.43", "v0.39.9", false},
+		{">=0.40, <0.43", "v0.43.0", false},
+		{">=0.40, <0.43", "v0.43.0-rc.1", false},
+		{">0.40.1", "v0.40.1", false},
+		{">0.40.1", "v0.40.2", true},
+		{"<=0.40", "v0.40.7", true},
+		{"<=0.40.1", "v0.40.2", false},
+		{"!=0.42.1", "v0.42.1", false},
+		{"!=0.42.1", "v0.42.2", true},
+		{"~0.39.2", "v0.39.9", true},
+		{"~0.39.2", "v0.40.0", false},
+		{"^1.2.3", "v1.9.0", true},
+		{"^1.2.3", "v2.0.0", false},
+		{"^0.40.1", "v0.40.5", true},
+		{"^0.40.1", "v0.41.0", false},
+		{"^0.0.3", "v0.0.4", false},
+		{"^1.2.3 || ~0.39.2", "v0.39.3", true},
+		{"^1.2.3 || ~0.39.2", "v0.40.0", false},
+		{"=v1.0.0-rc.1", "v1.0.0-rc.1", true},
+		{"=v1.0.0-rc.1", "v1.0.0", false},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+
+		t.Run(tc.constraint+"/"+tc.version, func(t *testing.T) {
+			c, err := semver.ParseConstraint(tc.constraint)
+			require.NoError(t, err)
+			require.Equal(t, tc.expected, c.Check(semver.MustParse(tc.version)))
+		})
+	}
+}
+
+func TestConstraint_RangesSortKey(t *testing.T) {
+	c, err := semver.ParseConstraint(">=0.40, <0.43")
+	require.NoError(t, err)
+	require.Len(t, c.Ranges(), 1)
+
+	r := c.Ranges()[0]
+
+	// a version is contained in a range iff min <= key < max
+	for _, v := range []string{"v0.39.9", "v0.40.0", "v0.42.3", "v0.43.0-rc.1", "v0.43.0"} {
+		key := semver.MustParse(v).SortKey()
+		inRange := r.Min.SortKey() <= key && key < r.Max.SortKey()
+		require.Equal(t, c.Check(semver.MustParse(v)), inRange, v)
+	}
+}
@@ -0,0 +1,258 @@
+// Package semver implements parsing and precedence rules for Semantic Versions
+// as defined by https://semver.org. An optional leading "v", as commonly used by
+// Go modules and Git tags, is accepted.
+package semver
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+const (
+	// sortKeyNumWidth defines the zero-padded width of every numeric component
+	// in a sort key, which is enough to hold any uint64.
+	sortKeyNumWidth = 20
+
+	// The following delimiters are chosen such that a sort key compared byte-wise
+	// yields Semantic Versioning precedence. A release sorts after any of its
+	// pre-releases ('~' > '-') and the pre-release identifier separator sorts
+	// before any valid identifier character.
+	sortKeyRelease    = "~"
+	sortKeyPrerelease = "-"
+	sortKeyIDSep      = "!"
+	sortKeyNumericID  = "0"
+	sortKeyAlnumID    = "1"
+)
+
+// ErrInvalidVersion defines a sentinel error when a version cannot be parsed.
+var ErrInvalidVersion = errors.New("invalid semantic version")
+
+// Version defines a parsed Semantic Version.
+type Version struct {
+	Major      uint64
+	Minor      uint64
+	Patch      uint64
+	Prerelease []string
+	Build      []string
+}
+
+// Parse parses a Semantic Version, e.g. v1.2.3-rc.1+build.5. All three numeric
+// components are required. An error is returned if the version is invalid.
+func Parse(s string) (Version, error) {
+	orig := s
+	s = strings.TrimPrefix(s, "v")
+
+	var v Version
+
+	if i := strings.IndexByte(s, '+'); i >= 0 {
+		build, err := parseIdentifiers(s[i+1:], false)
+		if err != nil {
+			return Version{}, fmt.Errorf("%w '%s': build metadata: %s", ErrInvalidVersion, orig, err)
+		}
+
+		v.Build = build
+		s = s[:i]
+	}
+
+	if i := strings.IndexByte(s, '-'); i >= 0 {
+		pre, err := parseIdentifiers(s[i+1:], true)
+		if err != nil {
+			return Version{}, fmt.Errorf("%w '%s': pre-release: %s", ErrInvalidVersion, orig, err)
+		}
+
+		v.Prerelease = pre
+		s = s[:i]
+	}
+
+	parts := strings.Split(s, ".")
+	if len(parts) != 3 {
+		return Version{}, fmt.Errorf("%w '%s': expected major.minor.patch", ErrInvalidVersion, orig)
+	}
+
+	nums := make([]uint64, len(parts))
+	for i, p := range parts {
+		n, err := parseNumeric(p)
+		if err != nil {
+			return Version{}, fmt.Errorf("%w '%s': %s", ErrInvalidVersion, orig, err)
+		}
+
+		nums[i] = n
+	}
+
+	v.Major, v.Minor, v.Patch = nums[0], nums[1], nums[2]
+	return v, nil
+}
+
+// MustParse behaves like Parse but panics upon failure.
+func MustParse(s string) Version {
+	v, err := Parse(s)
+	if err != nil {
+		panic(err)
+	}
+
+	return v
+}
+
+// String returns the canonical string representation of a Version, which is
+// always prefixed with a "v".
+func (v Version) String() string {
+	s := fmt.Sprintf("v%d.%d.%d", v.Major, v.Minor, v.Patch)
+	if len(v.Prerelease) > 0 {
+		s += "-" + strings.Join(v.Prerelease, ".")
+	}
+	if len(v.Build) > 0 {
+		s += "+" + strings.Join(v.Build, ".")
+	}
+
+	return s
+}
+
+// IsPrerelease returns true if the Version has pre-release identifiers.
+func (v Version) IsPrerelease() bool {
+	return len(v.Prerelease) > 0
+}
+
+// Compare returns -1, 0 or 1 if v has lower, equal or higher precedence than
+// other respectively. Build metadata is ignored.
+func (v Version) Compare(other Version) int {
+	if c := compareUint(v.Major, other.Major); c != 0 {
+		return c
+	}
+	if c := compareUint(v.Minor, other.Minor); c != 0 {
+		return c
+	}
+	if c := compareUint(v.Patch, other.Patch); c != 0 {
+		return c
+	}
+
+	// a release has higher precedence than any of its pre-releases
+	switch {
+	case len(v.Prerelease) == 0 && len(other.Prerelease) == 0:
+		return 0
+	case len(v.Prerelease) == 0:
+		return 1
+	case len(other.Prerelease) == 0:
+		return -1
+	}
+
+	for i := 0; i < len(v.Prerelease) && i < len(other.Prerelease); i++ {
+		if c := compareIdentifier(v.Prerelease[i], other.Prerelease[i]); c != 0 {
+			return c
+		}
+	}
+
+	return compareUint(uint64(len(v.Prerelease)), uint64(len(other.Prerelease)))
+}
+
+// SortKey returns a string encoding of the Version such that comparing the keys
+// of two versions byte-wise (e.g. using a "C" collation) yields the same result
+// as Compare. Build metadata is ignored.
+func (v Version) SortKey() string {
+	var sb strings.Builder
+
+	fmt.Fprintf(&sb, "%0*d.%0*d.%0*d", sortKeyNumWidth, v.Major, sortKeyNumWidth, v.Minor, sortKeyNumWidth, v.Patch)
+
+	if len(v.Prerelease) == 0 {
+		sb.WriteString(sortKeyRelease)
+		return sb.String()
+	}
+
+	sb.WriteString(sortKeyPrerelease)
+	for i, id := range v.Prerelease {
+		if i > 0 {
+			sb.WriteString(sortKeyIDSep)
+		}
+
+		if n, ok := numericIdentifier(id); ok {
+			fmt.Fprintf(&sb, "%s%0*d", sortKeyNumericID, sortKeyNumWidth, n)
+		} else {
+			sb.WriteString(sortKeyAlnumID + id)
+		}
+	}
+
+	return sb.String()
+}
+
+func parseIdentifiers(s string, prerelease bool) ([]string, error) {
+	ids := strings.Split(s, ".")
+	for _, id := range ids {
+		if id == "" {
+			return nil, errors.New("empty identifier")
+		}
+
+		for _, r := range id {
+			if !(r == '-' || (r >= '0' && r <= '9') || (r >= 'a' && r <= 'z') || (r >= 'A' && r <= 'Z')) {
+				return nil, fmt.Errorf("invalid character in identifier '%s'", id)
+			}
+		}
+
+		if prerelease && isDigits(id) && len(id) > 1 && id[0] == '0' {
+			return nil, fmt.Errorf("numeric identifier '%s' must not contain leading zeros", id)
+		}
+	}
+
+	return ids, nil
+}
+
+func parseNumeric(s string) (uint64, error) {
+	if s == "" || !isDigits(s) {
+		return 0, fmt.Errorf("invalid numeric component '%s'", s)
+	}
+	if len(s) > 1 && s[0] == '0' {
+		return 0, fmt.Errorf("numeric component '%s' must not contain leading zeros", s)
+	}
+
+	return strconv.ParseUint(s, 10, 64)
+}
+
+func numericIdentifier(id string) (uint64, bool) {
+	if !isDigits(id) {
+		return 0, false
+	}
+
+	n, err := strconv.ParseUint(id, 10, 64)
+	return n, err == nil
+}
+
+func compareIdentifier(a, b string) int {
+	an, aNum := numericIdentifier(a)
+	bn, bNum := numericIdentifier(b)
+
+	switch {
+	case aNum && bNum:
+		return compareUint(an, bn)
+
+	case aNum:
+		// numeric identifiers have lower precedence than alphanumeric ones
+		return -1
+
+	case bNum:
+		return 1
+
+	default:
+		return strings.Compare(a, b)
+	}
+}
+
+func compareUint(a, b uint64) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	default:
+		return 0
+	}
+}
+
+func isDigits(s string) bool {
+	for _, r := range s {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+
+	return len(s) > 0
+}
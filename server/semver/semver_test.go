@@ -0,0 +1,126 @@
+package semver_test
+
+import (
+	"sort"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/cosmos/atlas/server/semver"
+)
+
+func TestParse(t *testing.T) {
+	testCases := []struct {
+		input     string
+		expected  string
+		expectErr bool
+	}{
+		{"v1.2.3", "v1.2.3", false},
+		{"1.2.3", "v1.2.3", false},
+		{"v0.0.0", "v0.0.0", false},
+		{"v1.0.0-alpha", "v1.0.0-alpha", false},
+		{"v1.0.0-alpha.1", "v1.0.0-alpha.1", false},
+		{"v1.0.0-0.3.7", "v1.0.0-0.3.7", false},
+		{"v1.0.0-x-y-z.--", "v1.0.0-x-y-z.--", false},
+		{"v1.0.0+20130313144700", "v1.0.0+20130313144700", false},
+		{"v1.0.0-beta+exp.sha.5114f85", "v1.0.0-beta+exp.sha.5114f85", false},
+		{"", "", true},
+		{"v", "", true},
+		{"version", "", true},
+		{"v1", "", true},
+		{"v1.0", "", true},
+		{"v1.0.x", "", true},
+		{"v1.0.0.0", "", true},
+		{"v01.0.0", "", true},
+		{"v1.0.0-", "", true},
+		{"v1.0.0-alpha..1", "", true},
+		{"v1.0.0-01", "", true},
+		{"v1.0.0-alpha_1", "", true},
+		{"v1.0.0+", "", true},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+
+		t.Run(tc.input, func(t *testing.T) {
+			v, err := semver.Parse(tc.input)
+			if tc.expectErr {
+				require.Error(t, err)
+				require.ErrorIs(t, err, semver.ErrInvalidVersion)
+			} else {
+				require.NoError(t, err)
+				require.Equal(t, tc.expected, v.String())
+			}
+		})
+	}
+}
+
+func TestVersion_Compare(t *testing.T) {
+	// ordered by increasing precedence as defined by the specification
+	ordered := []string{
+		"v0.9.3",
+		"v1.0.0-alpha",
+		"v1.0.0-alpha.1",
+		"v1.0.0-alpha.beta",
+		"v1.0.0-beta",
+		"v1.0.0-beta.2",
+		"v1.0.0-beta.11",
+		"v1.0.0-rc.1",
+		"v1.0.0",
+		"v1.0.1",
+		"v1.2.0",
+		"v1.10.0",
+		"v2.0.0",
+	}
+
+	for i := 0; i < len(ordered); i++ {
+		for j := 0; j < len(ordered); j++ {
+			a := semver.MustParse(ordered[i])
+			b := semver.MustParse(ordered[j])
+
+			var expected int
+			switch {
+			case i < j:
+				expected = -1
+			case i > j:
+				expected = 1
+			}
+
+			require.Equal(t, expected, a.Compare(b), "%s <=> %s", ordered[i], ordered[j])
+		}
+	}
+
+	// build metadata does not affect precedence
+	require.Equal(t, 0, semver.MustParse("v1.0.0+build.1").Compare(semver.MustParse("v1.0.0+build.2")))
+}
+
+func TestVersion_SortKey(t *testing.T) {
+	versions := []string{
+		"v1.10.0",
+		"v1.0.0",
+		"v1.0.0-rc.1",
+		"v0.9.3",
+		"v1.0.0-alpha.beta",
+		"v1.0.0-beta.11",
+		"v1.0.0-alpha",
+		"v1.0.0-beta.2",
+		"v2.0.0",
+		"v1.0.0-alpha.1",
+		"v1.0.0-alpha-1",
+		"v1.0.0-beta",
+	}
+
+	byKey := make([]string, len(versions))
+	copy(byKey, versions)
+	sort.Slice(byKey, func(i, j int) bool {
+		return semver.MustParse(byKey[i]).SortKey() < semver.MustParse(byKey[j]).SortKey()
+	})
+
+	byPrecedence := make([]string, len(versions))
+	copy(byPrecedence, versions)
+	sort.Slice(byPrecedence, func(i, j int) bool {
+		return semver.MustParse(byPrecedence[i]).Compare(semver.MustParse(byPrecedence[j])) < 0
+	})
+
+	require.Equal(t, byPrecedence, byKey)
+}
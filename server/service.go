@@ -21,6 +21,7 @@ import (
 
 	"github.com/cosmos/atlas/config"
 	"github.com/cosmos/atlas/docs/api"
+	"github.com/cosmos/atlas/server/models"
 	v1 "github.com/cosmos/atlas/server/router/v1"
 )
 
@@ -39,10 +40,16 @@ type Service struct {
 	cookieCfg    gologin.CookieConfig
 	sessionStore *sessions.CookieStore
 	oauth2Cfg    *oauth2.Config
+	repoCache    *v1.RepositoryCache
 	router       *mux.Router
 	server       *http.Server
+	doneCh       chan struct{}
 }
 
+// invitePurgeInterval defines the interval at which expired module owner
+// invitations are purged.
+const invitePurgeInterval = time.Hour
+
 func NewService(logger zerolog.Logger, cfg config.Config) (*Service, error) {
 	dbLogger := NewDBLogger(logger).LogMode(gormlogger.Silent)
 
@@ -75,11 +82,23 @@ func NewService(logger zerolog.Logger, cfg config.Config) (*Service, error) {
 		cookieCfg:    cookieCfg,
 		sessionStore: sessionStore,
 		router:       mux.NewRouter(),
+		doneCh:       make(chan struct{}),
 		oauth2Cfg: &oauth2.Config{
 			ClientID:     cfg.String(config.GHClientID),
 			ClientSecret: cfg.String(config.GHClientSecret),
 			Endpoint:     githuboauth2.Endpoint,
+			Scopes:       oauth2Scopes(cfg),
 		},
+		repoCache: v1.NewRepositoryCache(
+			logger,
+			cfg.Duration(config.RepoCacheTTL),
+			cfg.Duration(config.RepoCacheMaxStale),
+		),
+	}
+
+	repoClients, err := newRepositoryClients(cfg)
+	if err != nil {
+		return nil, err
 	}
 
 	v1Router, err := v1.NewRouter(
@@ -91,6 +110,13 @@ func NewService(logger zerolog.Logger, cfg config.Config) (*Service, error) {
 		func(token string) v1.GitHubClientI {
 			return v1.NewGitHubClient(token)
 		},
+		repoClients,
+		service.repoCache,
+		v1.NewOIDCVerifier(
+			cfg.String(config.OIDCIssuer),
+			cfg.String(config.OIDCJWKSURL),
+			cfg.String(config.OIDCAudience),
+		),
 	)
 	if err != nil {
 		return nil, err
@@ -105,6 +131,46 @@ func NewService(logger zerolog.Logger, cfg config.Config) (*Service, error) {
 	return service, nil
 }
 
+// oauth2Scopes returns the GitHub OAuth scopes requested when a user logs in.
+// Verifying organization and team memberships, when publishing on behalf of an
+// organization is enabled, requires the read:org scope.
+func oauth2Scopes(cfg config.Config) []string {
+	if cfg.Bool(config.PublishOrgMembers) || cfg.String(config.PublishTeams) != "" {
+		return []string{"read:org"}
+	}
+
+	return nil
+}
+
+// newRepositoryClients returns the clients of all configured repository
+// providers other than GitHub. GitLab is always supported and defaults to the
+// public GitLab instance, whereas Gitea is supported only if an instance URL is
+// configured.
+func newRepositoryClients(cfg config.Config) ([]v1.RepositoryClientI, error) {
+	gitLabURL := cfg.String(config.GitLabURL)
+	if gitLabURL == "" {
+		gitLabURL = v1.GitLabURL
+	}
+
+	gitLabClient, err := v1.NewGitLabClient(gitLabURL, cfg.String(config.GitLabToken))
+	if err != nil {
+		return nil, err
+	}
+
+	repoClients := []v1.RepositoryClientI{gitLabClient}
+
+	if giteaURL := cfg.String(config.GiteaURL); giteaURL != "" {
+		giteaClient, err := v1.NewGiteaClient(giteaURL, cfg.String(config.GiteaToken))
+		if err != nil {
+			return nil, err
+		}
+
+		repoClients = append(repoClients, giteaClient)
+	}
+
+	return repoClients, nil
+}
+
 // Start starts the atlas service as a blocking process.
 func (s *Service) Start() error {
 	s.server = &http.Server{
@@ -114,14 +180,24 @@ func (s *Service) Start() error {
 		ReadTimeout:  s.cfg.Duration(config.HTTPWriteTimeout),
 	}
 
+	// start the repository cache refresher in a separate goroutine
+	go s.repoCache.Start()
+
+	// start purging expired module owner invitations in a separate goroutine
+	go s.purgeExpiredInvites()
+
 	s.logger.Info().Str("address", s.server.Addr).Msg("starting atlas server...")
 	return s.server.ListenAndServe()
 }
 
 // Cleanup performs server cleanup. If the internal HTTP server is non-nil, the
-// server will be shutdown after a grace period deadline.
+// repository cache refresher and the invitation purger are stopped and the server
+// will be shutdown after a grace period deadline.
 func (s *Service) Cleanup() {
 	if s.server != nil {
+		s.repoCache.Stop()
+		close(s.doneCh)
+
 		// create a deadline to wait for all existing requests to finish
 		ctx, cancel := context.WithTimeout(context.Background(), 15*time.Second)
 		defer cancel()
@@ -132,6 +208,29 @@ func (s *Service) Cleanup() {
 	}
 }
 
+// purgeExpiredInvites starts a blocking process which periodically deletes all
+// expired module owner invitations. It continues until the service is cleaned up.
+func (s *Service) purgeExpiredInvites() {
+	ticker := time.NewTicker(invitePurgeInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			n, err := models.PurgeModuleOwnerInvites(s.db, v1.InviteTTL(s.cfg))
+			if err != nil {
+				s.logger.Error().Err(err).Msg("failed to purge expired module owner invitations")
+				continue
+			}
+
+			s.logger.Info().Int64("purged", n).Msg("purged expired module owner invitations")
+
+		case <-s.doneCh:
+			return
+		}
+	}
+}
+
 // GetDB returns the underlying database.
 //
 // FIXME: We should consider creating the database outside of the server package
@@ -44,6 +44,10 @@ const APIClient = {
     return this.perform("get", `/modules/${id}`);
   },
 
+  getModuleVersionReadme(id, version) {
+    return this.perform("get", `/modules/${id}/versions/${version}/readme`);
+  },
+
   getModules(pageURI) {
     return this.perform("get", `/modules${pageURI}`);
   },
@@ -75,8 +79,12 @@ const APIClient = {
     return this.perform("put", `/me/invite/accept/${token}`);
   },
 
-  getNodes(pageURI) {
-    return this.perform("get", `/nodes/search${pageURI}`);
+  acceptTeamInvite(token) {
+    return this.perform("put", `/me/team-invites/accept/${token}`);
+  },
+
+  getNodes(pageURI, online) {
+    return this.perform("get", `/nodes/search${pageURI}&online=${online}`);
   },
 
   async perform(method, resource, data) {
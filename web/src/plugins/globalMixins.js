@@ -82,12 +82,9 @@ const GlobalMixins = {
         },
 
         latestVersion(versions) {
-          return versions.reduce((a, b) => {
-            let aCreated = new Date(a.created_at);
-            let bCreated = new Date(b.created_at);
-
-            return aCreated > bCreated ? a : b;
-          });
+          // versions are ordered by semantic version precedence (highest first)
+          // where yanked versions are never considered the latest
+          return versions.find(v => !v.yanked) || versions[0];
         }
       }
     });
@@ -8,6 +8,7 @@ import Components from "./views/Components.vue";
 import Error from "./views/Error.vue";
 import ConfirmEmailPage from "./views/ConfirmEmailPage.vue";
 import AcceptOwnerInvitePage from "./views/AcceptOwnerInvitePage.vue";
+import AcceptTeamInvitePage from "./views/AcceptTeamInvitePage.vue";
 import ProfilePage from "./views/ProfilePage.vue";
 import ModulePage from "./views/Module.vue";
 import ModulesPage from "./views/Modules.vue";
@@ -114,6 +115,16 @@ export default new Router({
       },
       props: { header: { showSearch: false } }
     },
+    {
+      path: "/teams/accept/:token",
+      name: "acceptTeamInvite",
+      components: {
+        header: AppHeader,
+        default: AcceptTeamInvitePage,
+        footer: AppFooter
+      },
+      props: { header: { showSearch: false } }
+    },
     {
       path: "*",
       name: "error",